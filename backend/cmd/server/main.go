@@ -10,27 +10,20 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	_ "github.com/lib/pq"
 	_ "github.com/rgomids/bckoffice/docs"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
 	"github.com/jmoiron/sqlx"
-	httpSwagger "github.com/swaggo/http-swagger"
 
-	"github.com/rgomids/bckoffice/internal/audit"
-	"github.com/rgomids/bckoffice/internal/auditquery"
-	"github.com/rgomids/bckoffice/internal/auth"
 	"github.com/rgomids/bckoffice/internal/contract"
-	"github.com/rgomids/bckoffice/internal/customer"
-	"github.com/rgomids/bckoffice/internal/finance"
-	"github.com/rgomids/bckoffice/internal/lead"
-	"github.com/rgomids/bckoffice/internal/promoter"
-	"github.com/rgomids/bckoffice/internal/service"
+	"github.com/rgomids/bckoffice/internal/importer"
+	"github.com/rgomids/bckoffice/internal/permission"
 )
 
 func main() {
@@ -42,57 +35,20 @@ func main() {
 	}
 	defer db.Close()
 
-	customerRepo := customer.NewPostgresRepository(db)
-	serviceRepo := service.NewPostgresRepository(db)
-	promoterRepo := promoter.NewPostgresRepository(db)
-	leadRepo := lead.NewPostgresRepository(db)
-	contractRepo := contract.NewPostgresRepository(db)
-	financeRepo := finance.NewPostgresRepository(db)
-	authRepo := auth.NewPostgresRepository(db)
-	auditRepo := audit.NewPostgresRepository(db)
-	auditQueryRepo := auditquery.NewPostgresRepository(db)
-	geoSvc := audit.NewHttpGeoService(os.Getenv("GEO_PROVIDER_URL"))
-
-	r := chi.NewRouter()
-	corsMw := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Location"},
-		AllowCredentials: true,
-	})
-	r.Use(corsMw.Handler)
-	r.Get("/docs/*", httpSwagger.WrapHandler)
-
-	// rota publica de login
-	auth.RegisterRoutes(r, authRepo)
-
-	// rotas protegidas
-	r.Group(func(pr chi.Router) {
-		pr.Use(auth.AuthMiddleware)
-		pr.Use(audit.NewAuditMiddleware(auditRepo, geoSvc))
-
-		pr.Group(func(r chi.Router) {
-			r.Use(auth.RequireRole("admin", "finance"))
-			customer.RegisterRoutes(r, customerRepo)
-		})
+	// job de vencimento de contratos
+	expiryMonitor := contract.NewExpiryMonitor(db, contract.NoticeDaysFromEnv(), contract.ExpiryIntervalFromEnv())
+	go expiryMonitor.Run(context.Background())
+	if os.Getenv("CONTRACT_READJUSTMENT_AUTO_APPLY") == "true" {
+		go contract.NewReadjustmentJob(contract.NewPostgresRepository(db), 24*time.Hour).Run(context.Background())
+	}
 
-		service.RegisterRoutes(pr, serviceRepo)
-		promoter.RegisterRoutes(pr, promoterRepo)
-		lead.RegisterRoutes(pr, leadRepo)
-		contract.RegisterRoutes(pr, contractRepo)
-		finance.RegisterRoutes(pr, financeRepo)
-		auditquery.RegisterRoutes(pr, auditQueryRepo)
-	})
+	// fila de importacoes em lote
+	go importer.NewRunner(importer.NewPostgresRepository(db), importer.IntervalFromEnv(), importTargets(db)...).Run(context.Background())
 
-	// rota simples de health-check
-	// @Summary      Health check
-	// @Tags         status
-	// @Success      200  {string}  string  "ok"
-	// @Router       /healthz [get]
-	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) {
-		w.Write([]byte("ok"))
-	})
+	r := newRouter(db)
+	if err := permission.Check(r, permission.Routes); err != nil {
+		log.Fatal(err)
+	}
 
 	log.Println("▶️  backend rodando em http://localhost:8080")
 	if err := http.ListenAndServe(":8080", r); err != nil {
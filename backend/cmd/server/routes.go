@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+	"github.com/jmoiron/sqlx"
+	httpSwagger "github.com/swaggo/http-swagger"
+
+	"github.com/rgomids/bckoffice/internal/audit"
+	"github.com/rgomids/bckoffice/internal/auditquery"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/contract"
+	"github.com/rgomids/bckoffice/internal/contractdoc"
+	"github.com/rgomids/bckoffice/internal/customer"
+	"github.com/rgomids/bckoffice/internal/dashboard"
+	"github.com/rgomids/bckoffice/internal/finance"
+	"github.com/rgomids/bckoffice/internal/importer"
+	"github.com/rgomids/bckoffice/internal/lead"
+	"github.com/rgomids/bckoffice/internal/note"
+	"github.com/rgomids/bckoffice/internal/notification"
+	"github.com/rgomids/bckoffice/internal/permission"
+	"github.com/rgomids/bckoffice/internal/priceindex"
+	"github.com/rgomids/bckoffice/internal/privacy"
+	"github.com/rgomids/bckoffice/internal/promoter"
+	"github.com/rgomids/bckoffice/internal/service"
+	"github.com/rgomids/bckoffice/internal/signature"
+	"github.com/rgomids/bckoffice/internal/tag"
+	"github.com/rgomids/bckoffice/internal/trash"
+)
+
+// newRouter monta o roteador da API. As permissoes de cada rota vem de
+// permission.Routes, aplicada no grupo protegido.
+func newRouter(db *sqlx.DB) *chi.Mux {
+	customerRepo := customer.NewPostgresRepository(db)
+	serviceRepo := service.NewPostgresRepository(db)
+	promoterRepo := promoter.NewPostgresRepository(db)
+	leadRepo := lead.NewPostgresRepository(db)
+	contractRepo := contract.NewPostgresRepository(db)
+	financeRepo := finance.NewPostgresRepository(db)
+	paymentGateway := finance.GatewayFromEnv()
+	authRepo := auth.NewPostgresRepository(db)
+	auditRepo := audit.NewPostgresRepository(db)
+	auditQueryRepo := auditquery.NewPostgresRepository(db)
+	tagRepo := tag.NewPostgresRepository(db)
+	noteRepo := note.NewPostgresRepository(db)
+	notificationRepo := notification.NewPostgresRepository(db)
+	trashRepo := trash.NewPostgresRepository(db)
+	contractDocs := contractdoc.StorageFromEnv()
+	privacyRepo := privacy.NewPostgresRepository(db, contractDocs)
+	priceIndexRepo := priceindex.NewPostgresRepository(db)
+	contractDocRepo := contractdoc.NewPostgresRepository(db)
+	signatureRepo := signature.NewPostgresRepository(db)
+	dashboardRepo := dashboard.NewCachedRepository(dashboard.NewPostgresRepository(db), dashboard.CacheTTLFromEnv())
+	importRepo := importer.NewPostgresRepository(db)
+	geoSvc := audit.NewHttpGeoService(os.Getenv("GEO_PROVIDER_URL"))
+	cepSvc := customer.NewHttpCEPService(os.Getenv("CEP_PROVIDER_URL"))
+
+	r := chi.NewRouter()
+	corsMw := cors.New(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"Location"},
+		AllowCredentials: true,
+	})
+	r.Use(corsMw.Handler)
+	r.Get("/docs/*", httpSwagger.WrapHandler)
+
+	// rota publica de login
+	auth.RegisterRoutes(r, authRepo)
+	// links de assinatura (autenticados pelo token do link)
+	signature.RegisterPublicRoutes(r, signatureRepo)
+	// webhook do gateway de pagamento (autenticado pela assinatura)
+	finance.RegisterPublicRoutes(r, financeRepo, paymentGateway)
+
+	// rotas protegidas
+	r.Group(func(pr chi.Router) {
+		pr.Use(auth.AuthMiddleware)
+		pr.Use(permission.Routes.Middleware)
+		pr.Use(audit.NewAuditMiddleware(auditRepo, geoSvc))
+
+		customer.RegisterRoutes(pr, customerRepo, cepSvc)
+
+		service.RegisterRoutes(pr, serviceRepo)
+		promoter.RegisterRoutes(pr, promoterRepo)
+		lead.RegisterRoutes(pr, leadRepo)
+		contract.RegisterRoutes(pr, contractRepo, serviceRepo)
+		finance.RegisterRoutes(pr, financeRepo, paymentGateway)
+		tag.RegisterRoutes(pr, tagRepo)
+		note.RegisterRoutes(pr, noteRepo)
+		notification.RegisterRoutes(pr, notificationRepo)
+		auditquery.RegisterRoutes(pr, auditQueryRepo)
+		trash.RegisterRoutes(pr, trashRepo, trash.RetentionFromEnv())
+		privacy.RegisterRoutes(pr, privacyRepo)
+		priceindex.RegisterRoutes(pr, priceIndexRepo)
+		contractdoc.RegisterRoutes(pr, contractDocRepo, contractDocs)
+		signature.RegisterRoutes(pr, signatureRepo, contractDocRepo, signature.LocalProviderFromEnv())
+		dashboard.RegisterRoutes(pr, dashboardRepo)
+		importer.RegisterRoutes(pr, importRepo, importTargets(db)...)
+	})
+
+	// rota simples de health-check
+	// @Summary      Health check
+	// @Tags         status
+	// @Success      200  {string}  string  "ok"
+	// @Router       /healthz [get]
+	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	return r
+}
+
+// importTargets lista os cadastros importaveis por CSV, usados tanto nas
+// rotas de upload quanto no job que processa a fila.
+func importTargets(db *sqlx.DB) []importer.Target {
+	return []importer.Target{
+		customer.ImportTarget(customer.NewPostgresRepository(db), customer.NewHttpCEPService(os.Getenv("CEP_PROVIDER_URL"))),
+		service.ImportTarget(service.NewPostgresRepository(db)),
+		promoter.ImportTarget(promoter.NewPostgresRepository(db)),
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+func TestEveryRouteHasPermissionPolicy(t *testing.T) {
+	r := newRouter(sqlx.NewDb(nil, "postgres"))
+	if err := permission.Check(r, permission.Routes); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,16 @@
+package auditquery
+
+import (
+	"github.com/rgomids/bckoffice/internal/audit"
+	"github.com/rgomids/bckoffice/pkg/export"
+)
+
+// exportTable define as colunas da exportacao dos audit logs.
+var exportTable = export.Table{
+	Sheet:   "Audit logs",
+	Columns: []string{"ID", "Data", "Usuario", "Entidade", "ID da entidade", "Acao", "IP", "User agent", "Alteracoes"},
+}
+
+func exportRow(l audit.AuditLog) []any {
+	return []any{l.ID, l.CreatedAt, l.UserID, l.EntityName, l.EntityID, l.Action, l.IPAddress, l.UserAgent, string(l.Diff)}
+}
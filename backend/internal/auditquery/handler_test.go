@@ -3,9 +3,11 @@ package auditquery
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/rgomids/bckoffice/internal/audit"
 	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
 )
 
 type fakeRepo struct {
@@ -36,6 +39,16 @@ func (f *fakeRepo) List(ctx context.Context, fl AuditFilter) ([]audit.AuditLog,
 	return out, nil
 }
 
+func (f *fakeRepo) Each(ctx context.Context, fl AuditFilter, fn func(audit.AuditLog) error) error {
+	list, _ := f.List(ctx, fl)
+	for _, l := range list {
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func setupRouter(repo Repository, role string) (*chi.Mux, string) {
 	os.Setenv("JWT_SECRET", "testsecret")
 	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
@@ -43,6 +56,7 @@ func setupRouter(repo Repository, role string) (*chi.Mux, string) {
 
 	r := chi.NewRouter()
 	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
 	RegisterRoutes(r, repo)
 	return r, token
 }
@@ -75,3 +89,27 @@ func TestListAuditLogsFilter(t *testing.T) {
 		t.Fatalf("unexpected result: %+v", out)
 	}
 }
+
+func TestExportAuditLogsCSV(t *testing.T) {
+	repo := &fakeRepo{logs: []audit.AuditLog{
+		{ID: "1", EntityName: "customers", Action: "update", Diff: []byte(`{"email":"a@b.c"}`)},
+		{ID: "2", EntityName: "services", Action: "insert"},
+	}}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/audit-logs?entity=customers", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "text/csv")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /audit-logs error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `customers;;update;;;"{""email"":""a@b.c""}"`) {
+		t.Fatalf("unexpected csv:\n%s", body)
+	}
+}
@@ -25,16 +25,8 @@ func toNull(s string) interface{} {
 	return s
 }
 
-// List retorna os logs conforme filtros informados.
-func (r *PostgresRepository) List(ctx context.Context, f AuditFilter) ([]audit.AuditLog, error) {
-	logs := []audit.AuditLog{}
-	const q = `SELECT * FROM audit_logs
-        WHERE (entity_name=$1 OR $1 IS NULL)
-          AND (user_id=$2 OR $2 IS NULL)
-          AND (action=$3 OR $3 IS NULL)
-          AND created_at BETWEEN $4 AND $5
-        ORDER BY created_at DESC
-        LIMIT $6`
+// filterArgs resolve os padroes do periodo e ordena os argumentos de listQuery.
+func filterArgs(f AuditFilter) []interface{} {
 	start := f.StartDate
 	if start.IsZero() {
 		start = time.Unix(0, 0)
@@ -43,15 +35,53 @@ func (r *PostgresRepository) List(ctx context.Context, f AuditFilter) ([]audit.A
 	if end.IsZero() {
 		end = time.Now()
 	}
+	var limit interface{}
+	if f.Limit > 0 {
+		limit = f.Limit
+	}
+	return []interface{}{toNull(f.EntityName), toNull(f.UserID), toNull(f.Action), start, end, limit}
+}
+
+// listQuery filtra os logs; LIMIT NULL nao limita o resultado.
+const listQuery = `SELECT * FROM audit_logs
+        WHERE (entity_name=$1 OR $1 IS NULL)
+          AND (user_id=$2 OR $2 IS NULL)
+          AND (action=$3 OR $3 IS NULL)
+          AND created_at BETWEEN $4 AND $5
+        ORDER BY created_at DESC
+        LIMIT $6`
+
+// List retorna os logs conforme filtros informados, ate 100 sem limite
+// explicito.
+func (r *PostgresRepository) List(ctx context.Context, f AuditFilter) ([]audit.AuditLog, error) {
+	logs := []audit.AuditLog{}
 	if f.Limit == 0 {
 		f.Limit = 100
 	}
-	if err := r.db.SelectContext(ctx, &logs, q,
-		toNull(f.EntityName), toNull(f.UserID), toNull(f.Action),
-		start, end, f.Limit); err != nil {
+	if err := r.db.SelectContext(ctx, &logs, listQuery, filterArgs(f)...); err != nil {
 		return nil, err
 	}
 	return logs, nil
 }
 
+// Each percorre os logs filtrados direto do cursor; sem limite explicito
+// percorre todos.
+func (r *PostgresRepository) Each(ctx context.Context, f AuditFilter, fn func(audit.AuditLog) error) error {
+	rows, err := r.db.QueryxContext(ctx, listQuery, filterArgs(f)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l audit.AuditLog
+		if err := rows.StructScan(&l); err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 var _ Repository = (*PostgresRepository)(nil)
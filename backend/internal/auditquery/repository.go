@@ -20,4 +20,5 @@ type AuditFilter struct {
 // Repository define operacoes de consulta aos logs de auditoria.
 type Repository interface {
 	List(ctx context.Context, filter AuditFilter) ([]audit.AuditLog, error)
+	Each(ctx context.Context, filter AuditFilter, fn func(audit.AuditLog) error) error
 }
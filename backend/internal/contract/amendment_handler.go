@@ -0,0 +1,190 @@
+package contract
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+)
+
+// AmendmentInput define o payload de um aditivo contratual. Sem value_total
+// o valor vigente eh mantido.
+type AmendmentInput struct {
+	ValueTotal    *float64 `json:"value_total" validate:"omitempty,gte=0"`
+	StartDate     string   `json:"start_date" validate:"required"`
+	EndDate       string   `json:"end_date"`
+	Reason        string   `json:"reason" validate:"required"`
+	EffectiveDate string   `json:"effective_date" validate:"required"`
+}
+
+// RenewalInput define o payload de renovacao. Campos omitidos herdam do original.
+type RenewalInput struct {
+	ValueTotal *float64 `json:"value_total" validate:"omitempty,gte=0"`
+	StartDate  string   `json:"start_date"`
+	EndDate    string   `json:"end_date" validate:"required"`
+	Reason     string   `json:"reason"`
+}
+
+// parseDate converte datas opcionais no formato AAAA-MM-DD.
+func parseDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", s)
+	}
+	return &t, nil
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// @Summary      Registra aditivo contratual
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      201  {object}  Version
+// @Router       /contracts/{id}/amendments [post]
+func (h handler) amend(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in AmendmentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	in.Reason = strings.TrimSpace(in.Reason)
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	start, err := parseDate(in.StartDate)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	end, err := parseDate(in.EndDate)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	effective, err := parseDate(in.EffectiveDate)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if end != nil && start.After(*end) {
+		writeBadRequest(w, errors.New("start_date must be before end_date"))
+		return
+	}
+
+	v, err := h.repo.Amend(r.Context(), id, Amendment{
+		ValueTotal:    in.ValueTotal,
+		StartDate:     *start,
+		EndDate:       end,
+		Reason:        in.Reason,
+		EffectiveDate: *effective,
+		ActorID:       auth.UserIDFromContext(r.Context()),
+	})
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", id))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// @Summary      Lista versoes do contrato
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      200  {array}  Version
+// @Router       /contracts/{id}/amendments [get]
+func (h handler) versions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	versions, err := h.repo.Versions(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(versions)
+}
+
+// @Summary      Renova contrato
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      201  {object}  Contract
+// @Router       /contracts/{id}/renewals [post]
+func (h handler) renew(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in RenewalInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	rn := Renewal{
+		ValueTotal: in.ValueTotal,
+		Reason:     strings.TrimSpace(in.Reason),
+		ActorID:    auth.UserIDFromContext(r.Context()),
+	}
+	start, err := parseDate(in.StartDate)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if start != nil {
+		rn.StartDate = *start
+	}
+	if rn.EndDate, err = parseDate(in.EndDate); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	c, err := h.repo.Renew(r.Context(), id, rn)
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/contracts/"+c.ID)
+	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", c.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// writeLifecycleError traduz erros de aditivos e renovacoes em respostas HTTP.
+func writeLifecycleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, ErrServiceUnavailable):
+		writeBadRequest(w, err)
+	case errors.Is(err, ErrNotAmendable), errors.Is(err, ErrNotRenewable), errors.Is(err, ErrRenewed), errors.Is(err, ErrNotEditable),
+		errors.Is(err, ErrPendingCharge):
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
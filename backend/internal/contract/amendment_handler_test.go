@@ -0,0 +1,144 @@
+package contract
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s error: %v", url, err)
+	}
+	return resp
+}
+
+func TestAmendContractCreatesVersion(t *testing.T) {
+	repo := &fakeRepository{contracts: []Contract{
+		{ID: "k1", Status: StatusActive, ValueTotal: 1000, CurrentVersion: 1},
+		{ID: "k2", Status: StatusCancelled, ValueTotal: 1000, CurrentVersion: 1},
+	}}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	body := `{"value_total":1200,"start_date":"2026-01-01","end_date":"2026-12-31","reason":"inclusao de modulo","effective_date":"2026-11-01"}`
+	resp := post(t, server.URL+"/contracts/k1/amendments", token, body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var v Version
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if v.Version != 2 || v.ValueTotal != 1200 || repo.contracts[0].ValueTotal != 1200 {
+		t.Fatalf("unexpected version: %+v / %+v", v, repo.contracts[0])
+	}
+
+	resp2 := post(t, server.URL+"/contracts/k1/amendments", token, `{"value_total":1,"start_date":"2026-01-01","effective_date":"2026-11-01"}`)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without reason, got %d", resp2.StatusCode)
+	}
+
+	resp3 := post(t, server.URL+"/contracts/k2/amendments", token, body)
+	resp3.Body.Close()
+	if resp3.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409 for cancelled contract, got %d", resp3.StatusCode)
+	}
+}
+
+func TestAmendContractKeepsValueWhenOmitted(t *testing.T) {
+	repo := &fakeRepository{
+		contracts:   []Contract{{ID: "k1", Status: StatusActive, ValueTotal: 1200, CurrentVersion: 1}},
+		receivables: map[string][]float64{"k1": {100, 100}},
+	}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	body := `{"start_date":"2026-01-01","end_date":"2027-06-30","reason":"prorrogacao","effective_date":"2026-11-01"}`
+	resp := post(t, server.URL+"/contracts/k1/amendments", token, body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var v Version
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got := repo.receivables["k1"]
+	if v.ValueTotal != 1200 || repo.contracts[0].ValueTotal != 1200 || got[0] != 100 || got[1] != 100 {
+		t.Fatalf("value or receivables changed: %+v / %+v / %v", v, repo.contracts[0], got)
+	}
+	if end := repo.contracts[0].EndDate; end == nil || end.Format("2006-01-02") != "2027-06-30" {
+		t.Fatalf("end_date not amended: %+v", repo.contracts[0])
+	}
+}
+
+func TestAmendContractWithPendingCharge(t *testing.T) {
+	repo := &fakeRepository{
+		contracts:      []Contract{{ID: "k1", Status: StatusActive, ValueTotal: 1200, CurrentVersion: 1}},
+		receivables:    map[string][]float64{"k1": {100, 100}},
+		pendingCharges: map[string]bool{"k1": true},
+	}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	body := `{"start_date":"2026-01-01","value_total":2400,"reason":"upgrade","effective_date":"2026-11-01"}`
+	resp := post(t, server.URL+"/contracts/k1/amendments", token, body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", resp.StatusCode)
+	}
+	if got := repo.receivables["k1"]; repo.contracts[0].ValueTotal != 1200 || got[0] != 100 {
+		t.Fatalf("contract or receivables changed: %+v / %v", repo.contracts[0], got)
+	}
+}
+
+func TestRenewContract(t *testing.T) {
+	end := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	repo := &fakeRepository{contracts: []Contract{
+		{ID: "k1", CustomerID: "c1", ServiceID: "s1", Status: StatusActive, ValueTotal: 1000, EndDate: &end},
+		{ID: "k2", Status: StatusActive, ValueTotal: 1000},
+	}}
+	r, token := setupAuthRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := post(t, server.URL+"/contracts/k1/renewals", token, `{"end_date":"2027-12-31","value_total":1100}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var succ Contract
+	if err := json.NewDecoder(resp.Body).Decode(&succ); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if succ.RenewedFromID == nil || *succ.RenewedFromID != "k1" || succ.ValueTotal != 1100 ||
+		succ.StartDate.Format("2006-01-02") != "2027-01-01" || repo.contracts[0].Status != StatusClosed {
+		t.Fatalf("unexpected renewal: %+v", succ)
+	}
+
+	resp2 := post(t, server.URL+"/contracts/k2/renewals", token, `{"end_date":"2027-12-31"}`)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409 without end_date, got %d", resp2.StatusCode)
+	}
+
+	resp3 := post(t, server.URL+"/contracts/k1/renewals", token, `{"end_date":"2028-12-31"}`)
+	resp3.Body.Close()
+	if resp3.StatusCode != http.StatusConflict || len(repo.contracts) != 3 {
+		t.Fatalf("expected status 409 for a second renewal, got %d", resp3.StatusCode)
+	}
+}
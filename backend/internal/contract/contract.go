@@ -1,11 +1,26 @@
 package contract
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository define operações para persistência de contratos.
 type Repository interface {
-	FindAll(ctx context.Context) ([]Contract, error)
+	FindAll(ctx context.Context, tags []string) ([]Contract, error)
+	Each(ctx context.Context, tags []string, status string, fn func(Contract) error) error
 	Create(ctx context.Context, c *Contract) error
 	Update(ctx context.Context, c *Contract) error
 	SoftDelete(ctx context.Context, id string) error
+	ChangeStatus(ctx context.Context, id string, change StatusChange) (StatusResult, error)
+	History(ctx context.Context, id string) ([]StatusHistory, error)
+	Amend(ctx context.Context, id string, a Amendment) (Version, error)
+	Versions(ctx context.Context, id string) ([]Version, error)
+	Renew(ctx context.Context, id string, rn Renewal) (Contract, error)
+	Expiring(ctx context.Context, days int) ([]Contract, error)
+	SetReadjustmentClause(ctx context.Context, id string, clause *ReadjustmentClause) error
+	PreviewReadjustments(ctx context.Context, ref time.Time) ([]ReadjustmentPreview, error)
+	ApplyReadjustments(ctx context.Context, ref time.Time, ids []string, actorID string) ([]Readjustment, error)
+	SetLateFees(ctx context.Context, id string, fees LateFees) error
+	Items(ctx context.Context, id string) ([]Item, error)
 }
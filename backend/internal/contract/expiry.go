@@ -0,0 +1,241 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rgomids/bckoffice/internal/notification"
+)
+
+// DefaultNoticeDays sao as antecedencias padrao dos avisos de vencimento.
+const DefaultNoticeDays = "60,30,7"
+
+// ParseNoticeDays le uma lista de dias separados por virgula, ex.: "60,30,7".
+func ParseNoticeDays(s string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := strconv.Atoi(part)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid notice day %q", part)
+		}
+		days = append(days, d)
+	}
+	sort.Ints(days)
+	return days, nil
+}
+
+// NoticeDaysFromEnv le CONTRACT_EXPIRY_NOTICE_DAYS, usando DefaultNoticeDays como padrao.
+func NoticeDaysFromEnv() []int {
+	if v := os.Getenv("CONTRACT_EXPIRY_NOTICE_DAYS"); v != "" {
+		if days, err := ParseNoticeDays(v); err == nil {
+			return days
+		}
+	}
+	days, _ := ParseNoticeDays(DefaultNoticeDays)
+	return days
+}
+
+// ExpiryIntervalFromEnv le CONTRACT_EXPIRY_INTERVAL_MINUTES, usando uma hora como padrao.
+func ExpiryIntervalFromEnv() time.Duration {
+	if v := os.Getenv("CONTRACT_EXPIRY_INTERVAL_MINUTES"); v != "" {
+		if m, err := strconv.Atoi(v); err == nil && m > 0 {
+			return time.Duration(m) * time.Minute
+		}
+	}
+	return time.Hour
+}
+
+// noticeThreshold retorna a menor antecedencia que cobre os dias restantes.
+// Quando o monitor encontra o contrato pela primeira vez perto do vencimento,
+// apenas o aviso mais proximo eh enviado.
+func noticeThreshold(remaining int, days []int) (int, bool) {
+	for _, d := range days {
+		if remaining <= d {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// ExpiryReport resume uma execucao do monitor.
+type ExpiryReport struct {
+	Closed   []string
+	Notified int
+}
+
+// ExpiryMonitor encerra contratos ativos cujo end_date, somado ao prazo de
+// carencia do servico, ja passou e avisa o promotor e os admins sobre
+// vencimentos proximos.
+type ExpiryMonitor struct {
+	db         *sqlx.DB
+	noticeDays []int
+	interval   time.Duration
+	now        func() time.Time
+}
+
+// NewExpiryMonitor cria um monitor que executa a cada interval.
+func NewExpiryMonitor(db *sqlx.DB, noticeDays []int, interval time.Duration) *ExpiryMonitor {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &ExpiryMonitor{db: db, noticeDays: noticeDays, interval: interval, now: time.Now}
+}
+
+// Run executa o monitor imediatamente e depois a cada intervalo ate ctx ser cancelado.
+func (m *ExpiryMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if rep, err := m.RunOnce(ctx); err != nil {
+			log.Printf("contract expiry monitor: %v", err)
+		} else if len(rep.Closed) > 0 || rep.Notified > 0 {
+			log.Printf("contract expiry monitor: %d closed, %d notified", len(rep.Closed), rep.Notified)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce encerra os contratos vencidos e envia os avisos pendentes.
+func (m *ExpiryMonitor) RunOnce(ctx context.Context) (ExpiryReport, error) {
+	today := m.now().Format("2006-01-02")
+	var rep ExpiryReport
+	var err error
+	if rep.Closed, err = m.closeExpired(ctx, today); err != nil {
+		return rep, err
+	}
+	if rep.Notified, err = m.notifyExpiring(ctx, today); err != nil {
+		return rep, err
+	}
+	return rep, nil
+}
+
+func (m *ExpiryMonitor) closeExpired(ctx context.Context, today string) ([]string, error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var ids []string
+	const q = `SELECT c.id FROM contracts c JOIN services s ON s.id = c.service_id
+        WHERE c.status='active' AND c.deleted_at IS NULL AND c.end_date IS NOT NULL
+          AND c.end_date + s.auto_close_grace_days < $1::date
+        ORDER BY c.id
+        FOR UPDATE OF c SKIP LOCKED`
+	if err = tx.SelectContext(ctx, &ids, q, today); err != nil {
+		return nil, err
+	}
+	from := StatusActive
+	for _, id := range ids {
+		if _, err = tx.ExecContext(ctx, `UPDATE contracts SET status=$2, updated_at=now() WHERE id=$1`, id, StatusClosed); err != nil {
+			return nil, err
+		}
+		if _, err = insertHistory(ctx, tx, id, &from, StatusClosed, "encerrado automaticamente apos o termino", ""); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (m *ExpiryMonitor) notifyExpiring(ctx context.Context, today string) (int, error) {
+	if len(m.noticeDays) == 0 {
+		return 0, nil
+	}
+	var rows []struct {
+		ID         string    `db:"id"`
+		EndDate    time.Time `db:"end_date"`
+		PromoterID *string   `db:"promoter_id"`
+		Remaining  int       `db:"remaining"`
+	}
+	const q = `SELECT id, end_date, promoter_id, (end_date - $1::date) AS remaining FROM contracts
+        WHERE status='active' AND deleted_at IS NULL
+          AND end_date BETWEEN $1::date AND $1::date + $2::int
+        ORDER BY end_date, id`
+	maxDays := m.noticeDays[len(m.noticeDays)-1]
+	if err := m.db.SelectContext(ctx, &rows, q, today, maxDays); err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, c := range rows {
+		threshold, ok := noticeThreshold(c.Remaining, m.noticeDays)
+		if !ok {
+			continue
+		}
+		n, err := m.notifyContract(ctx, c.ID, c.PromoterID, c.EndDate, threshold, c.Remaining)
+		if err != nil {
+			return notified, err
+		}
+		notified += n
+	}
+	return notified, nil
+}
+
+// notifyContract registra o aviso e notifica os destinatarios uma unica vez
+// por contrato, antecedencia e end_date.
+func (m *ExpiryMonitor) notifyContract(ctx context.Context, id string, promoterID *string, endDate time.Time, threshold, remaining int) (int, error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const qn = `INSERT INTO contract_expiry_notices (contract_id, days_before, end_date) VALUES ($1, $2, $3)
+        ON CONFLICT DO NOTHING`
+	res, err := tx.ExecContext(ctx, qn, id, threshold, endDate)
+	if err != nil {
+		return 0, err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return 0, nil
+	}
+
+	var recipients []string
+	const qr = `SELECT u.id FROM users u
+        WHERE u.deleted_at IS NULL AND (
+              u.id IN (SELECT ur.user_id FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE r.name='admin')
+           OR u.id = $1
+           OR lower(u.email) = (SELECT lower(p.email) FROM promoters p WHERE p.id = $1))
+        ORDER BY u.id`
+	if err = tx.SelectContext(ctx, &recipients, qr, promoterID); err != nil {
+		return 0, err
+	}
+	entity := "contracts"
+	msg := fmt.Sprintf("Contrato %s vence em %d dia(s), em %s", id, remaining, endDate.Format("02/01/2006"))
+	for _, userID := range recipients {
+		contractID := id
+		err = notification.Insert(ctx, tx, &notification.Notification{
+			UserID:     userID,
+			Kind:       notification.KindContractExpiring,
+			EntityName: &entity,
+			EntityID:   &contractID,
+			Message:    msg,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(recipients), nil
+}
@@ -0,0 +1,41 @@
+package contract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNoticeDays(t *testing.T) {
+	days, err := ParseNoticeDays(" 7, 60 ,30,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(days, []int{7, 30, 60}) {
+		t.Fatalf("expected sorted days, got %v", days)
+	}
+	if _, err := ParseNoticeDays("30,x"); err == nil {
+		t.Fatal("expected error for invalid day")
+	}
+}
+
+func TestNoticeThreshold(t *testing.T) {
+	days := []int{7, 30, 60}
+	cases := []struct {
+		remaining int
+		want      int
+		ok        bool
+	}{
+		{61, 0, false},
+		{60, 60, true},
+		{45, 60, true},
+		{30, 30, true},
+		{8, 30, true},
+		{0, 7, true},
+	}
+	for _, c := range cases {
+		got, ok := noticeThreshold(c.remaining, days)
+		if got != c.want || ok != c.ok {
+			t.Errorf("noticeThreshold(%d) = %d, %v; want %d, %v", c.remaining, got, ok, c.want, c.ok)
+		}
+	}
+}
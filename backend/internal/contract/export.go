@@ -0,0 +1,13 @@
+package contract
+
+import "github.com/rgomids/bckoffice/pkg/export"
+
+// exportTable define as colunas da exportacao da listagem de contratos.
+var exportTable = export.Table{
+	Sheet:   "Contratos",
+	Columns: []string{"ID", "Cliente", "Servico", "Promotor", "Valor total", "Inicio", "Fim", "Status", "Versao", "Criado em"},
+}
+
+func exportRow(c Contract) []any {
+	return []any{c.ID, c.CustomerID, c.ServiceID, c.PromoterID, c.ValueTotal, c.StartDate, c.EndDate, c.Status, c.CurrentVersion, c.CreatedAt}
+}
@@ -1,58 +1,138 @@
 package contract
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/service"
+	"github.com/rgomids/bckoffice/internal/tag"
+	"github.com/rgomids/bckoffice/pkg/export"
 )
 
-// RegisterRoutes adiciona as rotas do modulo Contract.
-func RegisterRoutes(r chi.Router, repo Repository) {
-	h := handler{repo: repo, validate: validator.New()}
+// PriceResolver resolve o preco de tabela de um servico na data e quantidade.
+type PriceResolver interface {
+	ResolvePrice(ctx context.Context, serviceID string, date time.Time, quantity float64) (service.ResolvedPrice, error)
+}
+
+// RegisterRoutes adiciona as rotas do modulo Contract. Itens sem unit_price
+// recebem o preco de tabela vigente no inicio do contrato via prices.
+func RegisterRoutes(r chi.Router, repo Repository, prices PriceResolver) {
+	h := handler{repo: repo, prices: prices, validate: validator.New()}
 	r.Get("/contracts", h.list)
+	r.Get("/contracts/expiring", h.expiring)
 	r.Post("/contracts", h.create)
 	r.Put("/contracts/{id}", h.update)
 	r.Delete("/contracts/{id}", h.remove)
+	r.Get("/contracts/{id}/items", h.items)
+	r.Put("/contracts/{id}/status", h.changeStatus)
+	r.Get("/contracts/{id}/history", h.history)
+	r.Post("/contracts/{id}/amendments", h.amend)
+	r.Get("/contracts/{id}/amendments", h.versions)
+	r.Post("/contracts/{id}/renewals", h.renew)
+	r.Put("/contracts/{id}/readjustment", h.setReadjustmentClause)
+	r.Get("/contracts/readjustments/preview", h.previewReadjustments)
+	r.Post("/contracts/readjustments", h.applyReadjustments)
+	r.Put("/contracts/{id}/late-fees", h.setLateFees)
 }
 
 type handler struct {
 	repo     Repository
+	prices   PriceResolver
 	validate *validator.Validate
 }
 
+// priceItems preenche o preco unitario dos itens que nao o informaram.
+func (h handler) priceItems(ctx context.Context, items []ItemInput, date time.Time) error {
+	for i, it := range items {
+		if it.UnitPrice != nil {
+			continue
+		}
+		if h.prices == nil {
+			return ErrMissingUnitPrice
+		}
+		p, err := h.prices.ResolvePrice(ctx, it.ServiceID, date, it.Quantity)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrServiceUnavailable
+			}
+			if errors.Is(err, service.ErrNoPrice) {
+				return ErrMissingUnitPrice
+			}
+			return err
+		}
+		items[i].UnitPrice = &p.UnitPrice
+	}
+	return nil
+}
+
+// createContractInput aceita um servico unico (service_id e value_total) ou
+// uma lista de itens; com itens, o valor total eh calculado a partir deles.
 type createContractInput struct {
-	CustomerID string  `json:"customer_id" validate:"required"`
-	ServiceID  string  `json:"service_id" validate:"required"`
-	PromoterID string  `json:"promoter_id"`
-	ValueTotal float64 `json:"value_total" validate:"required,gte=0"`
-	StartDate  string  `json:"start_date" validate:"required"`
-	EndDate    string  `json:"end_date"`
-	Status     string  `json:"status"`
+	CustomerID       string      `json:"customer_id" validate:"required"`
+	ServiceID        string      `json:"service_id" validate:"required_without=Items"`
+	PromoterID       string      `json:"promoter_id"`
+	ValueTotal       float64     `json:"value_total" validate:"required_without=Items,gte=0"`
+	Items            []ItemInput `json:"items" validate:"omitempty,dive"`
+	Installments     int         `json:"installments" validate:"gte=0,lte=120"`
+	StartDate        string      `json:"start_date" validate:"required"`
+	EndDate          string      `json:"end_date"`
+	RequireSignature bool        `json:"require_signature"`
 }
 
 // UpdateContractInput define o payload para atualizacao de contratos.
+// Itens informados substituem as linhas atuais e definem o valor total.
 type UpdateContractInput struct {
-	ValueTotal float64 `json:"value_total" validate:"required,gte=0"`
-	StartDate  string  `json:"start_date" validate:"required"`
-	EndDate    string  `json:"end_date"`
-	Status     string  `json:"status" validate:"required,oneof=active suspended closed cancelled"`
+	ValueTotal float64     `json:"value_total" validate:"required_without=Items,gte=0"`
+	Items      []ItemInput `json:"items" validate:"omitempty,dive"`
+	StartDate  string      `json:"start_date" validate:"required"`
+	EndDate    string      `json:"end_date"`
+}
+
+// ChangeStatusInput define o payload para mudanca de status do contrato.
+type ChangeStatusInput struct {
+	Status string `json:"status" validate:"required,oneof=active suspended closed cancelled"`
+	Reason string `json:"reason"`
 }
 
 // @Summary      Lista contratos
 // @Tags         contracts
 // @Security     BearerAuth
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        tags    query  string  false  "Tags separadas por virgula (todas obrigatorias)"
+// @Param        status  query  string  false  "Filtra pelo status"
+// @Param        format  query  string  false  "json|csv|xlsx (ou header Accept)"
 // @Success      200  {array}  Contract
 // @Router       /contracts [get]
 func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	format, err := export.Negotiate(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if format != export.JSON {
+		export.Stream(w, format, "contratos", exportTable, func(emit func([]any) error) error {
+			return h.repo.Each(r.Context(), tag.ParseFilter(r.URL.Query()), r.URL.Query().Get("status"), func(c Contract) error {
+				return emit(exportRow(c))
+			})
+		})
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	contracts, err := h.repo.FindAll(r.Context())
+	contracts, err := h.repo.FindAll(r.Context(), tag.ParseFilter(r.URL.Query()))
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -121,17 +201,36 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 		c.PromoterID = &in.PromoterID
 	}
 	c.ValueTotal = in.ValueTotal
+	c.Items = []Item{singleItem(in.ServiceID, in.ValueTotal)}
+	if len(in.Items) > 0 {
+		if err = h.priceItems(r.Context(), in.Items, startDate); err != nil {
+			writePricingError(w, err)
+			return
+		}
+		if c.Items, c.ValueTotal, err = ComputeItems(in.Items); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		// service_id passa a indicar o servico principal (primeira linha)
+		c.ServiceID = c.Items[0].ServiceID
+	}
+	c.Installments = in.Installments
 	c.StartDate = startDate
 	c.EndDate = endDatePtr
-	if in.Status != "" {
-		c.Status = in.Status
-	} else {
-		c.Status = "active"
+	// o contrato nasce ativo ou aguardando assinatura; mudancas passam por
+	// /contracts/{id}/status
+	c.Status = StatusActive
+	if in.RequireSignature {
+		c.Status = StatusPendingSignature
 	}
 	c.CreatedAt = time.Now()
 	c.UpdatedAt = time.Now()
 
 	if err := h.repo.Create(r.Context(), &c); err != nil {
+		if errors.Is(err, ErrServiceUnavailable) {
+			writeBadRequest(w, err)
+			return
+		}
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
@@ -147,6 +246,8 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary      Atualiza contrato
+// @Description  Somente contratos pendentes de assinatura; depois disso os termos mudam por aditivo (409).
+// @Description  Solicitacoes de assinatura pendentes sao canceladas e precisam ser reenviadas.
 // @Tags         contracts
 // @Security     BearerAuth
 // @Success      204  {null}  nil
@@ -192,11 +293,45 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 		ValueTotal: in.ValueTotal,
 		StartDate:  startDate,
 		EndDate:    endDatePtr,
-		Status:     in.Status,
 		UpdatedAt:  time.Now(),
 	}
+	if len(in.Items) > 0 {
+		if err = h.priceItems(r.Context(), in.Items, startDate); err != nil {
+			writePricingError(w, err)
+			return
+		}
+		if c.Items, c.ValueTotal, err = ComputeItems(in.Items); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+	}
 
 	if err := h.repo.Update(r.Context(), &c); err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writePricingError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrMissingUnitPrice) || errors.Is(err, ErrServiceUnavailable) {
+		writeBadRequest(w, err)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// @Summary      Lista itens do contrato
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      200  {array}  Item
+// @Router       /contracts/{id}/items [get]
+func (h handler) items(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	items, err := h.repo.Items(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 			return
@@ -204,9 +339,7 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
-
-	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", id))
-	w.WriteHeader(http.StatusNoContent)
+	_ = json.NewEncoder(w).Encode(items)
 }
 
 // @Summary      Remove contrato
@@ -227,3 +360,100 @@ func (h handler) remove(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", id))
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// @Summary      Altera status do contrato
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      200  {object}  StatusResult
+// @Router       /contracts/{id}/status [put]
+func (h handler) changeStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in ChangeStatusInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	in.Reason = strings.TrimSpace(in.Reason)
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if RequiresReason(in.Status) && in.Reason == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": ErrReasonRequired.Error()})
+		return
+	}
+
+	res, err := h.repo.ChangeStatus(r.Context(), id, StatusChange{
+		To:      in.Status,
+		Reason:  in.Reason,
+		ActorID: auth.UserIDFromContext(r.Context()),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrContractExpired), errors.Is(err, ErrPendingCharge):
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		case errors.Is(err, ErrReasonRequired):
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", id))
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// @Summary      Historico de status do contrato
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      200  {array}  StatusHistory
+// @Router       /contracts/{id}/history [get]
+func (h handler) history(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	history, err := h.repo.History(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(history)
+}
+
+// @Summary      Lista contratos proximos do vencimento
+// @Tags         contracts
+// @Security     BearerAuth
+// @Param        days  query  int  false  "Janela em dias (padrao 30)"
+// @Success      200  {array}  Contract
+// @Router       /contracts/expiring [get]
+func (h handler) expiring(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 3650 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "days must be between 0 and 3650"})
+			return
+		}
+		days = n
+	}
+	contracts, err := h.repo.Expiring(r.Context(), days)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(contracts)
+}
@@ -1,23 +1,43 @@
 package contract
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
 )
 
 type fakeRepository struct {
 	contracts []Contract
+	history   []StatusHistory
+	versions  []Version
+	lastDays  int
+	inactive  map[string]bool
+	// rates guarda as variacoes por indice e mes (AAAA-MM)
+	rates map[string]map[string]float64
+	// receivables guarda os valores das parcelas em aberto por contrato
+	receivables map[string][]float64
+	// pendingCharges marca os contratos com parcela de cobranca pendente
+	pendingCharges map[string]bool
+	// pendingSignatures marca os contratos com solicitacao de assinatura pendente
+	pendingSignatures map[string]bool
 }
 
-func (f *fakeRepository) FindAll(ctx context.Context) ([]Contract, error) {
+func (f *fakeRepository) FindAll(ctx context.Context, tags []string) ([]Contract, error) {
 	out := make([]Contract, 0, len(f.contracts))
 	for _, c := range f.contracts {
 		if c.DeletedAt == nil {
@@ -27,7 +47,25 @@ func (f *fakeRepository) FindAll(ctx context.Context) ([]Contract, error) {
 	return out, nil
 }
 
+func (f *fakeRepository) Each(ctx context.Context, tags []string, status string, fn func(Contract) error) error {
+	list, _ := f.FindAll(ctx, tags)
+	for _, c := range list {
+		if status != "" && c.Status != status {
+			continue
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *fakeRepository) Create(ctx context.Context, c *Contract) error {
+	for _, it := range c.Items {
+		if f.inactive[it.ServiceID] {
+			return ErrServiceUnavailable
+		}
+	}
 	f.contracts = append(f.contracts, *c)
 	return nil
 }
@@ -35,10 +73,18 @@ func (f *fakeRepository) Create(ctx context.Context, c *Contract) error {
 func (f *fakeRepository) Update(ctx context.Context, c *Contract) error {
 	for i, ct := range f.contracts {
 		if ct.ID == c.ID {
+			if ct.Status != StatusPendingSignature {
+				return ErrNotEditable
+			}
+			delete(f.pendingSignatures, c.ID)
 			ct.ValueTotal = c.ValueTotal
+			if len(c.Items) > 0 {
+				ct.Items = c.Items
+			} else {
+				ct.Items = ScaleItems(ct.Items, c.ValueTotal)
+			}
 			ct.StartDate = c.StartDate
 			ct.EndDate = c.EndDate
-			ct.Status = c.Status
 			ct.UpdatedAt = c.UpdatedAt
 			f.contracts[i] = ct
 			return nil
@@ -62,10 +108,183 @@ func (f *fakeRepository) SoftDelete(ctx context.Context, id string) error {
 	return sql.ErrNoRows
 }
 
+func (f *fakeRepository) ChangeStatus(ctx context.Context, id string, change StatusChange) (StatusResult, error) {
+	for i, ct := range f.contracts {
+		if ct.ID != id {
+			continue
+		}
+		if err := ValidateTransition(ct.Status, change.To, change.Reason, ct.EndDate, time.Now()); err != nil {
+			return StatusResult{}, err
+		}
+		if change.To == StatusCancelled && f.pendingCharges[id] {
+			return StatusResult{}, ErrPendingCharge
+		}
+		from := ct.Status
+		f.contracts[i].Status = change.To
+		h := StatusHistory{ID: "h", ContractID: id, FromStatus: &from, ToStatus: change.To, Reason: change.Reason}
+		if change.ActorID != "" {
+			h.ChangedBy = &change.ActorID
+		}
+		f.history = append(f.history, h)
+		return StatusResult{History: h}, nil
+	}
+	return StatusResult{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) History(ctx context.Context, id string) ([]StatusHistory, error) {
+	out := []StatusHistory{}
+	for _, h := range f.history {
+		if h.ContractID == id {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) Expiring(ctx context.Context, days int) ([]Contract, error) {
+	f.lastDays = days
+	limit := time.Now().AddDate(0, 0, days)
+	out := []Contract{}
+	for _, c := range f.contracts {
+		if c.Status == StatusActive && c.DeletedAt == nil && c.EndDate != nil && !c.EndDate.After(limit) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) SetReadjustmentClause(ctx context.Context, id string, clause *ReadjustmentClause) error {
+	for i, c := range f.contracts {
+		if c.ID == id {
+			f.contracts[i].ReadjustmentIndex, f.contracts[i].AnniversaryMonth = nil, nil
+			if clause != nil {
+				f.contracts[i].ReadjustmentIndex = &clause.Index
+				f.contracts[i].AnniversaryMonth = &clause.AnniversaryMonth
+			}
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) SetLateFees(ctx context.Context, id string, fees LateFees) error {
+	for i, c := range f.contracts {
+		if c.ID == id {
+			f.contracts[i].LateFinePercent = fees.FinePercent
+			f.contracts[i].MonthlyInterestPercent = fees.MonthlyInterestPercent
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) PreviewReadjustments(ctx context.Context, ref time.Time) ([]ReadjustmentPreview, error) {
+	out := []ReadjustmentPreview{}
+	for _, c := range f.contracts {
+		if c.Status == StatusActive && c.AnniversaryMonth != nil && *c.AnniversaryMonth == int(ref.Month()) {
+			p := buildReadjustment(c, ref, f.rates[*c.ReadjustmentIndex])
+			p.PendingCharge = f.pendingCharges[c.ID]
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) ApplyReadjustments(ctx context.Context, ref time.Time, ids []string, actorID string) ([]Readjustment, error) {
+	previews, _ := f.PreviewReadjustments(ctx, ref)
+	applied := []Readjustment{}
+	for _, p := range previews {
+		if len(p.MissingMonths) > 0 || p.PendingCharge {
+			continue
+		}
+		for i := range f.contracts {
+			if f.contracts[i].ID == p.ContractID {
+				f.contracts[i].ValueTotal = p.NewValue
+			}
+		}
+		applied = append(applied, Readjustment{ContractID: p.ContractID, Factor: p.Factor, PreviousValue: p.PreviousValue, NewValue: p.NewValue})
+	}
+	return applied, nil
+}
+
+func (f *fakeRepository) Amend(ctx context.Context, id string, a Amendment) (Version, error) {
+	for i, ct := range f.contracts {
+		if ct.ID != id {
+			continue
+		}
+		if ct.Status != StatusActive && ct.Status != StatusSuspended {
+			return Version{}, ErrNotAmendable
+		}
+		value := ct.ValueTotal
+		if a.ValueTotal != nil {
+			value = *a.ValueTotal
+		}
+		if ct.ValueTotal > 0 && value != ct.ValueTotal {
+			if f.pendingCharges[id] {
+				return Version{}, ErrPendingCharge
+			}
+			for j, amount := range f.receivables[id] {
+				f.receivables[id][j] = math.Round(amount*value/ct.ValueTotal*100) / 100
+			}
+		}
+		ct.ValueTotal, ct.StartDate, ct.EndDate = value, a.StartDate, a.EndDate
+		ct.CurrentVersion++
+		f.contracts[i] = ct
+		v := Version{ContractID: id, Version: ct.CurrentVersion, ValueTotal: value, Reason: a.Reason, EffectiveDate: a.EffectiveDate}
+		f.versions = append(f.versions, v)
+		return v, nil
+	}
+	return Version{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) Items(ctx context.Context, id string) ([]Item, error) {
+	for _, c := range f.contracts {
+		if c.ID == id {
+			return c.Items, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepository) Versions(ctx context.Context, id string) ([]Version, error) {
+	out := []Version{}
+	for _, v := range f.versions {
+		if v.ContractID == id {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) Renew(ctx context.Context, id string, rn Renewal) (Contract, error) {
+	for i, ct := range f.contracts {
+		if ct.ID != id {
+			continue
+		}
+		if (ct.Status != StatusActive && ct.Status != StatusClosed) || ct.EndDate == nil {
+			return Contract{}, ErrNotRenewable
+		}
+		for _, other := range f.contracts {
+			if other.RenewedFromID != nil && *other.RenewedFromID == id && other.Status != StatusCancelled {
+				return Contract{}, ErrRenewed
+			}
+		}
+		succ := Contract{ID: "renewed", CustomerID: ct.CustomerID, ServiceID: ct.ServiceID, ValueTotal: ct.ValueTotal,
+			StartDate: ct.EndDate.AddDate(0, 0, 1), EndDate: rn.EndDate, Status: StatusActive, CurrentVersion: 1, RenewedFromID: &ct.ID}
+		if rn.ValueTotal != nil {
+			succ.ValueTotal = *rn.ValueTotal
+		}
+		f.contracts[i].Status = StatusClosed
+		f.contracts = append(f.contracts, succ)
+		return succ, nil
+	}
+	return Contract{}, sql.ErrNoRows
+}
+
 func setupRouter() *chi.Mux {
 	r := chi.NewRouter()
 	repo := &fakeRepository{}
-	RegisterRoutes(r, repo)
+	RegisterRoutes(r, repo, nil)
 	return r
 }
 
@@ -126,7 +345,7 @@ func TestUpdateContract(t *testing.T) {
 	server := httptest.NewServer(setupRouter())
 	defer server.Close()
 
-	body := strings.NewReader(`{"customer_id":"c1","service_id":"s1","value_total":1000,"start_date":"2025-07-01"}`)
+	body := strings.NewReader(`{"customer_id":"c1","service_id":"s1","value_total":1000,"start_date":"2025-07-01","require_signature":true}`)
 	resp, err := http.Post(server.URL+"/contracts", "application/json", body)
 	if err != nil {
 		t.Fatalf("POST /contracts error: %v", err)
@@ -174,6 +393,19 @@ func TestUpdateContract(t *testing.T) {
 	}
 }
 
+func TestUpdateSignedContractRequiresAmendment(t *testing.T) {
+	repo := &fakeRepository{contracts: []Contract{{ID: "k1", Status: StatusActive, ValueTotal: 1000}}}
+	r, token := setupAuthRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := putStatus(t, server.URL+"/contracts/k1", token, `{"value_total":2000,"start_date":"2025-07-01"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict || repo.contracts[0].ValueTotal != 1000 {
+		t.Fatalf("expected status 409 keeping the value, got %d / %+v", resp.StatusCode, repo.contracts[0])
+	}
+}
+
 func TestDeleteContract(t *testing.T) {
 	server := httptest.NewServer(setupRouter())
 	defer server.Close()
@@ -219,3 +451,234 @@ func TestDeleteContract(t *testing.T) {
 		t.Fatalf("expected 0 contracts, got %d", len(list))
 	}
 }
+
+func setupAuthRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo, nil)
+	return r, token
+}
+
+func putStatus(t *testing.T, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT %s error: %v", url, err)
+	}
+	return resp
+}
+
+func TestContractStatusLifecycle(t *testing.T) {
+	repo := &fakeRepository{contracts: []Contract{{ID: "k1", Status: StatusActive}}}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+	url := server.URL + "/contracts/k1/status"
+
+	steps := []struct {
+		body string
+		want int
+	}{
+		{`{"status":"suspended"}`, http.StatusBadRequest},
+		{`{"status":"suspended","reason":"inadimplencia"}`, http.StatusOK},
+		{`{"status":"closed"}`, http.StatusConflict},
+		{`{"status":"active"}`, http.StatusOK},
+		{`{"status":"cancelled","reason":"pedido do cliente"}`, http.StatusOK},
+		{`{"status":"active"}`, http.StatusConflict},
+	}
+	for _, st := range steps {
+		resp := putStatus(t, url, token, st.body)
+		resp.Body.Close()
+		if resp.StatusCode != st.want {
+			t.Fatalf("%s: expected status %d, got %d", st.body, st.want, resp.StatusCode)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/contracts/k1/history", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET history error: %v", err)
+	}
+	defer resp.Body.Close()
+	var history []StatusHistory
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(history) != 3 || history[2].ToStatus != StatusCancelled || history[2].Reason != "pedido do cliente" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+}
+
+func TestUpdateContractCancelsPendingSignature(t *testing.T) {
+	repo := &fakeRepository{
+		contracts:         []Contract{{ID: "k1", Status: StatusPendingSignature, ValueTotal: 1000}},
+		pendingSignatures: map[string]bool{"k1": true},
+	}
+	r, token := setupAuthRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/contracts/k1", token, `{"value_total":2000,"start_date":"2026-11-01"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp.StatusCode)
+	}
+	if repo.pendingSignatures["k1"] || repo.contracts[0].ValueTotal != 2000 {
+		t.Fatalf("pending signature request must be cancelled: %+v", repo.pendingSignatures)
+	}
+}
+
+func TestCancelContractWithPendingCharge(t *testing.T) {
+	repo := &fakeRepository{
+		contracts:      []Contract{{ID: "k1", Status: StatusActive}},
+		pendingCharges: map[string]bool{"k1": true},
+	}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := putStatus(t, server.URL+"/contracts/k1/status", token, `{"status":"cancelled","reason":"pedido do cliente"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", resp.StatusCode)
+	}
+	if repo.contracts[0].Status != StatusActive {
+		t.Fatalf("contract must stay active: %+v", repo.contracts[0])
+	}
+}
+
+func TestContractStatusRequiresFinance(t *testing.T) {
+	repo := &fakeRepository{contracts: []Contract{{ID: "k1", Status: StatusActive}}}
+	r, token := setupAuthRouter(repo, "sales")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := putStatus(t, server.URL+"/contracts/k1/status", token, `{"status":"closed"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestValidateTransitionExpiredReactivation(t *testing.T) {
+	today := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	past := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	if err := ValidateTransition(StatusSuspended, StatusActive, "", &past, today); err != ErrContractExpired {
+		t.Fatalf("expected ErrContractExpired, got %v", err)
+	}
+	end := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if err := ValidateTransition(StatusSuspended, StatusActive, "", &end, today); err != nil {
+		t.Fatalf("expected reactivation on last day to be allowed, got %v", err)
+	}
+}
+
+func TestGetExpiringContracts(t *testing.T) {
+	soon := time.Now().AddDate(0, 0, 10)
+	later := time.Now().AddDate(0, 0, 90)
+	repo := &fakeRepository{contracts: []Contract{
+		{ID: "k1", Status: StatusActive, EndDate: &soon},
+		{ID: "k2", Status: StatusActive, EndDate: &later},
+		{ID: "k3", Status: StatusClosed, EndDate: &soon},
+	}}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, nil)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/contracts/expiring")
+	if err != nil {
+		t.Fatalf("GET /contracts/expiring error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var out []Contract
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if repo.lastDays != 30 || len(out) != 1 || out[0].ID != "k1" {
+		t.Fatalf("expected k1 within default 30 days, got days=%d %+v", repo.lastDays, out)
+	}
+
+	bad, err := http.Get(server.URL + "/contracts/expiring?days=abc")
+	if err != nil {
+		t.Fatalf("GET /contracts/expiring error: %v", err)
+	}
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", bad.StatusCode)
+	}
+}
+
+func TestCreateContractPendingSignature(t *testing.T) {
+	repo := &fakeRepository{}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, nil)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	body := strings.NewReader(`{"customer_id":"c1","service_id":"s1","value_total":1000,"start_date":"2025-07-01","require_signature":true}`)
+	resp, err := http.Post(server.URL+"/contracts", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /contracts error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || repo.contracts[0].Status != StatusPendingSignature {
+		t.Fatalf("expected pending_signature contract, got %d %+v", resp.StatusCode, repo.contracts)
+	}
+
+	if err := ValidateTransition(StatusPendingSignature, StatusActive, "", nil, time.Now()); err != ErrInvalidTransition {
+		t.Fatalf("expected manual activation to be rejected, got %v", err)
+	}
+	if err := ValidateTransition(StatusPendingSignature, StatusCancelled, "desistencia", nil, time.Now()); err != nil {
+		t.Fatalf("expected cancellation to be allowed, got %v", err)
+	}
+}
+
+func TestExportContractsXLSXFiltersStatus(t *testing.T) {
+	repo := &fakeRepository{contracts: []Contract{
+		{ID: "k1", CustomerID: "c1", ServiceID: "s1", ValueTotal: 1200, StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Status: "active"},
+		{ID: "k2", CustomerID: "c2", ServiceID: "s1", ValueTotal: 800, StartDate: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Status: "cancelled"},
+	}}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, nil)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/contracts?format=xlsx&status=active")
+	if err != nil {
+		t.Fatalf("GET /contracts error: %v", err)
+	}
+	defer resp.Body.Close()
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), `filename="contratos.xlsx"`) {
+		t.Fatalf("unexpected disposition %s", resp.Header.Get("Content-Disposition"))
+	}
+	body, _ := io.ReadAll(resp.Body)
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("expected xlsx: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		sheet, _ := io.ReadAll(rc)
+		rc.Close()
+		if !strings.Contains(string(sheet), ">k1<") || strings.Contains(string(sheet), ">k2<") || strings.Contains(string(sheet), `r="3"`) {
+			t.Fatalf("unexpected sheet:\n%s", sheet)
+		}
+		return
+	}
+	t.Fatal("missing worksheet")
+}
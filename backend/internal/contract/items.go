@@ -0,0 +1,147 @@
+package contract
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidItem        = errors.New("line total must not be negative")
+	ErrServiceUnavailable = errors.New("service not found or inactive")
+	ErrMissingUnitPrice   = errors.New("unit_price is required when the service has no price for the start date")
+)
+
+// Item eh uma linha do contrato. O valor total do contrato eh a soma de
+// LineTotal (quantidade x preco unitario - desconto).
+type Item struct {
+	ID         string    `db:"id" json:"id"`
+	ContractID string    `db:"contract_id" json:"contract_id"`
+	ServiceID  string    `db:"service_id" json:"service_id"`
+	Quantity   float64   `db:"quantity" json:"quantity"`
+	UnitPrice  float64   `db:"unit_price" json:"unit_price"`
+	Discount   float64   `db:"discount" json:"discount"`
+	LineTotal  float64   `db:"line_total" json:"line_total"`
+	Position   int       `db:"position" json:"position"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ItemInput define o payload de uma linha do contrato. Sem unit_price vale
+// o preco de tabela do servico.
+type ItemInput struct {
+	ServiceID string   `json:"service_id" validate:"required"`
+	Quantity  float64  `json:"quantity" validate:"gt=0"`
+	UnitPrice *float64 `json:"unit_price" validate:"omitempty,gte=0"`
+	Discount  float64  `json:"discount" validate:"gte=0"`
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func cents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+// ComputeItems calcula o total de cada linha e o total do contrato.
+func ComputeItems(in []ItemInput) ([]Item, float64, error) {
+	items := make([]Item, 0, len(in))
+	var total int64
+	for i, it := range in {
+		if it.UnitPrice == nil {
+			return nil, 0, ErrMissingUnitPrice
+		}
+		unit := *it.UnitPrice
+		line := round2(it.Quantity*unit - it.Discount)
+		if line < 0 {
+			return nil, 0, ErrInvalidItem
+		}
+		items = append(items, Item{
+			ServiceID: it.ServiceID,
+			Quantity:  it.Quantity,
+			UnitPrice: unit,
+			Discount:  it.Discount,
+			LineTotal: line,
+			Position:  i + 1,
+		})
+		total += cents(line)
+	}
+	return items, float64(total) / 100, nil
+}
+
+// singleItem representa um contrato de servico unico como uma linha com
+// quantidade 1, mantendo a compatibilidade com contratos sem itens.
+func singleItem(serviceID string, value float64) Item {
+	return Item{ServiceID: serviceID, Quantity: 1, UnitPrice: value, LineTotal: round2(value), Position: 1}
+}
+
+// ScaleItems distribui um novo valor total entre as linhas na proporcao dos
+// totais atuais. A diferenca de arredondamento fica na maior linha, de modo
+// que a soma coincide com total; o preco unitario eh recalculado.
+func ScaleItems(items []Item, total float64) []Item {
+	if len(items) == 0 {
+		return items
+	}
+	out := make([]Item, len(items))
+	copy(out, items)
+
+	var current int64
+	largest := 0
+	for i, it := range out {
+		current += cents(it.LineTotal)
+		if it.LineTotal > out[largest].LineTotal {
+			largest = i
+		}
+	}
+	target := cents(total)
+	var sum int64
+	for i := range out {
+		var c int64
+		if current > 0 {
+			c = int64(math.Round(float64(cents(out[i].LineTotal)) * float64(target) / float64(current)))
+		} else if i == largest {
+			c = target
+		}
+		out[i].LineTotal = float64(c) / 100
+		sum += c
+	}
+	out[largest].LineTotal = float64(cents(out[largest].LineTotal)+target-sum) / 100
+	for i := range out {
+		if out[i].Quantity > 0 {
+			out[i].UnitPrice = math.Round((out[i].LineTotal+out[i].Discount)/out[i].Quantity*10000) / 10000
+		}
+	}
+	return out
+}
+
+// SplitInstallments divide o total de cada linha em n parcelas; o resto do
+// arredondamento de cada linha vai para a ultima parcela.
+func SplitInstallments(items []Item, n int) []float64 {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]int64, n)
+	for _, it := range items {
+		c := cents(it.LineTotal)
+		per := c / int64(n)
+		for i := 0; i < n-1; i++ {
+			parts[i] += per
+		}
+		parts[n-1] += c - per*int64(n-1)
+	}
+	out := make([]float64, n)
+	for i, p := range parts {
+		out[i] = float64(p) / 100
+	}
+	return out
+}
+
+// CommissionAmount soma a comissao de cada linha com o percentual informado.
+func CommissionAmount(items []Item, percentage float64) float64 {
+	var total int64
+	for _, it := range items {
+		total += cents(it.LineTotal * percentage / 100)
+	}
+	return float64(total) / 100
+}
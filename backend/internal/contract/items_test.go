@@ -0,0 +1,184 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rgomids/bckoffice/internal/service"
+)
+
+func price(v float64) *float64 { return &v }
+
+type fakePrices map[string]float64
+
+func (f fakePrices) ResolvePrice(ctx context.Context, serviceID string, date time.Time, quantity float64) (service.ResolvedPrice, error) {
+	p, ok := f[serviceID]
+	if !ok {
+		return service.ResolvedPrice{}, service.ErrNoPrice
+	}
+	if quantity >= 10 {
+		p *= 0.9
+	}
+	return service.ResolvedPrice{ServiceID: serviceID, Date: date, Quantity: quantity, UnitPrice: p}, nil
+}
+
+func TestComputeItems(t *testing.T) {
+	items, total, err := ComputeItems([]ItemInput{
+		{ServiceID: "s1", Quantity: 3, UnitPrice: price(100), Discount: 50},
+		{ServiceID: "s2", Quantity: 1.5, UnitPrice: price(33.33)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if items[0].LineTotal != 250 || items[1].LineTotal != 50 || total != 300 || items[1].Position != 2 {
+		t.Fatalf("unexpected items: %+v total %v", items, total)
+	}
+	if _, _, err := ComputeItems([]ItemInput{{ServiceID: "s1", Quantity: 1, UnitPrice: price(10), Discount: 20}}); err != ErrInvalidItem {
+		t.Fatalf("expected ErrInvalidItem, got %v", err)
+	}
+}
+
+func TestScaleItemsKeepsTotal(t *testing.T) {
+	items := []Item{
+		{ServiceID: "s1", Quantity: 1, UnitPrice: 100, LineTotal: 100},
+		{ServiceID: "s2", Quantity: 2, UnitPrice: 100, LineTotal: 200},
+		{ServiceID: "s3", Quantity: 1, UnitPrice: 100, LineTotal: 100},
+	}
+	out := ScaleItems(items, 1000.01)
+	var sum int64
+	for _, it := range out {
+		sum += cents(it.LineTotal)
+	}
+	if sum != 100001 {
+		t.Fatalf("expected sum 1000.01, got %v (%+v)", float64(sum)/100, out)
+	}
+	if out[1].LineTotal != 500.01 || out[1].UnitPrice != 250.005 {
+		t.Fatalf("expected remainder on largest line, got %+v", out[1])
+	}
+	if items[0].LineTotal != 100 {
+		t.Fatalf("input must not be modified")
+	}
+}
+
+func TestSplitInstallmentsAndCommission(t *testing.T) {
+	items := []Item{{LineTotal: 100}, {LineTotal: 50}}
+	parts := SplitInstallments(items, 3)
+	if len(parts) != 3 || parts[0] != 49.99 || parts[1] != 49.99 || parts[2] != 50.02 {
+		t.Fatalf("unexpected installments: %v", parts)
+	}
+	if got := CommissionAmount(items, 7.5); got != 11.25 {
+		t.Fatalf("expected commission 11.25, got %v", got)
+	}
+}
+
+func TestCreateContractWithItems(t *testing.T) {
+	repo := &fakeRepository{inactive: map[string]bool{"off": true}}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, nil)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	body := `{"customer_id":"c1","start_date":"2026-01-01","installments":12,"items":[
+        {"service_id":"s1","quantity":2,"unit_price":500,"discount":100},
+        {"service_id":"s2","quantity":1,"unit_price":300}]}`
+	resp, err := http.Post(server.URL+"/contracts", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /contracts error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var c Contract
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if c.ValueTotal != 1200 || c.ServiceID != "s1" || len(c.Items) != 2 || repo.contracts[0].Installments != 12 {
+		t.Fatalf("unexpected contract: %+v", c)
+	}
+
+	resp2, err := http.Get(server.URL + "/contracts/" + c.ID + "/items")
+	if err != nil {
+		t.Fatalf("GET items error: %v", err)
+	}
+	defer resp2.Body.Close()
+	var items []Item
+	if err := json.NewDecoder(resp2.Body).Decode(&items); err != nil || len(items) != 2 {
+		t.Fatalf("unexpected items: %v %+v", err, items)
+	}
+
+	for name, b := range map[string]string{
+		"inactive service": `{"customer_id":"c1","start_date":"2026-01-01","items":[{"service_id":"off","quantity":1,"unit_price":10}]}`,
+		"zero quantity":    `{"customer_id":"c1","start_date":"2026-01-01","items":[{"service_id":"s1","quantity":0,"unit_price":10}]}`,
+		"negative line":    `{"customer_id":"c1","start_date":"2026-01-01","items":[{"service_id":"s1","quantity":1,"unit_price":10,"discount":11}]}`,
+		"no service":       `{"customer_id":"c1","start_date":"2026-01-01","value_total":10}`,
+	} {
+		resp, err := http.Post(server.URL+"/contracts", "application/json", strings.NewReader(b))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", name, resp.StatusCode)
+		}
+	}
+}
+
+func TestCreateSingleServiceContractHasImplicitItem(t *testing.T) {
+	repo := &fakeRepository{}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, nil)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/contracts", "application/json",
+		strings.NewReader(`{"customer_id":"c1","service_id":"s1","value_total":900,"start_date":"2026-01-01"}`))
+	if err != nil {
+		t.Fatalf("POST /contracts error: %v", err)
+	}
+	resp.Body.Close()
+	items := repo.contracts[0].Items
+	if len(items) != 1 || items[0].ServiceID != "s1" || items[0].Quantity != 1 || items[0].LineTotal != 900 {
+		t.Fatalf("unexpected implicit item: %+v", items)
+	}
+}
+
+func TestCreateContractResolvesListPrice(t *testing.T) {
+	repo := &fakeRepository{}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, fakePrices{"s1": 100})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	body := `{"customer_id":"c1","start_date":"2026-01-01","items":[
+        {"service_id":"s1","quantity":10},
+        {"service_id":"s1","quantity":1,"unit_price":80}]}`
+	resp, err := http.Post(server.URL+"/contracts", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /contracts error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	if c := repo.contracts[0]; c.ValueTotal != 980 || c.Items[0].UnitPrice != 90 {
+		t.Fatalf("unexpected priced contract: %+v", c)
+	}
+
+	resp2, err := http.Post(server.URL+"/contracts", "application/json",
+		strings.NewReader(`{"customer_id":"c1","start_date":"2026-01-01","items":[{"service_id":"s9","quantity":1}]}`))
+	if err != nil {
+		t.Fatalf("POST /contracts error: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without price, got %d", resp2.StatusCode)
+	}
+}
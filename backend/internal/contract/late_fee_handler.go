@@ -0,0 +1,53 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// LateFees define a multa e os juros ao mes cobrados sobre parcelas em
+// atraso. Percentuais nulos usam o padrao do servico do contrato.
+type LateFees struct {
+	FinePercent            *float64
+	MonthlyInterestPercent *float64
+}
+
+// LateFeesInput define os encargos por atraso negociados no contrato.
+type LateFeesInput struct {
+	LateFinePercent        *float64 `json:"late_fine_percent" validate:"omitempty,gte=0,lte=100"`
+	MonthlyInterestPercent *float64 `json:"monthly_interest_percent" validate:"omitempty,gte=0,lte=100"`
+}
+
+// @Summary      Define os encargos por atraso do contrato
+// @Description  Multa (percentual sobre a parcela) e juros ao mes, cobrados pro rata die. Percentual nulo volta a usar o padrao do servico.
+// @Tags         contracts
+// @Security     BearerAuth
+// @Param        input  body  LateFeesInput  true  "Encargos por atraso"
+// @Success      204  {null}  nil
+// @Router       /contracts/{id}/late-fees [put]
+func (h handler) setLateFees(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in LateFeesInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	fees := LateFees{FinePercent: in.LateFinePercent, MonthlyInterestPercent: in.MonthlyInterestPercent}
+	if err := h.repo.SetLateFees(r.Context(), id, fees); err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", id))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,47 @@
+package contract
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetLateFees(t *testing.T) {
+	repo := &fakeRepository{contracts: []Contract{{ID: "k1", Status: StatusActive}}}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/contracts/k1/late-fees", token, `{"late_fine_percent":10,"monthly_interest_percent":0.5}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp.StatusCode)
+	}
+	c := repo.contracts[0]
+	if c.LateFinePercent == nil || *c.LateFinePercent != 10 || c.MonthlyInterestPercent == nil || *c.MonthlyInterestPercent != 0.5 {
+		t.Fatalf("unexpected late fees: %+v", c)
+	}
+
+	// sem percentuais o contrato volta ao padrao do servico
+	resp = do(t, http.MethodPut, server.URL+"/contracts/k1/late-fees", token, `{}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || repo.contracts[0].LateFinePercent != nil {
+		t.Fatalf("expected late fees cleared, got %d %+v", resp.StatusCode, repo.contracts[0])
+	}
+
+	cases := []struct {
+		id, body string
+		want     int
+	}{
+		{"k1", `{"late_fine_percent":-1}`, http.StatusBadRequest},
+		{"k1", `{"monthly_interest_percent":101}`, http.StatusBadRequest},
+		{"missing", `{}`, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		resp = do(t, http.MethodPut, server.URL+"/contracts/"+c.id+"/late-fees", token, c.body)
+		resp.Body.Close()
+		if resp.StatusCode != c.want {
+			t.Fatalf("%s %s: expected %d, got %d", c.id, c.body, c.want, resp.StatusCode)
+		}
+	}
+}
@@ -4,15 +4,24 @@ import "time"
 
 // Contract representa um contrato entre cliente e serviço.
 type Contract struct {
-	ID         string     `db:"id" json:"id"`
-	CustomerID string     `db:"customer_id" json:"customer_id"`
-	ServiceID  string     `db:"service_id" json:"service_id"`
-	PromoterID *string    `db:"promoter_id" json:"promoter_id,omitempty"`
-	ValueTotal float64    `db:"value_total" json:"value_total"`
-	StartDate  time.Time  `db:"start_date" json:"start_date"`
-	EndDate    *time.Time `db:"end_date" json:"end_date,omitempty"`
-	Status     string     `db:"status" json:"status"`
-	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
-	DeletedAt  *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	ID                string     `db:"id" json:"id"`
+	CustomerID        string     `db:"customer_id" json:"customer_id"`
+	ServiceID         string     `db:"service_id" json:"service_id"`
+	PromoterID        *string    `db:"promoter_id" json:"promoter_id,omitempty"`
+	ValueTotal        float64    `db:"value_total" json:"value_total"`
+	StartDate         time.Time  `db:"start_date" json:"start_date"`
+	EndDate           *time.Time `db:"end_date" json:"end_date,omitempty"`
+	Status            string     `db:"status" json:"status"`
+	CurrentVersion    int        `db:"current_version" json:"current_version"`
+	RenewedFromID     *string    `db:"renewed_from_id" json:"renewed_from_id,omitempty"`
+	ReadjustmentIndex *string    `db:"readjustment_index" json:"readjustment_index,omitempty"`
+	AnniversaryMonth  *int       `db:"anniversary_month" json:"anniversary_month,omitempty"`
+	// Encargos por atraso negociados; nulos usam o padrao do servico.
+	LateFinePercent        *float64   `db:"late_fine_percent" json:"late_fine_percent,omitempty"`
+	MonthlyInterestPercent *float64   `db:"monthly_interest_percent" json:"monthly_interest_percent,omitempty"`
+	CreatedAt              time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt              time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt              *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Items                  []Item     `db:"-" json:"items,omitempty"`
+	Installments           int        `db:"installments" json:"installments,omitempty"`
 }
@@ -4,8 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/priceindex"
+	"github.com/rgomids/bckoffice/internal/tag"
 )
 
 // PostgresRepository implementa Repository usando PostgreSQL.
@@ -18,19 +25,60 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-// FindAll retorna todos os contratos nao excluidos.
-func (r *PostgresRepository) FindAll(ctx context.Context) ([]Contract, error) {
+// FindAll retorna todos os contratos nao excluidos,
+// opcionalmente filtrados por tags (todas devem estar presentes).
+func (r *PostgresRepository) FindAll(ctx context.Context, tags []string) ([]Contract, error) {
 	contracts := []Contract{}
-	const q = `SELECT * FROM contracts WHERE deleted_at IS NULL ORDER BY start_date DESC`
-	if err := r.db.SelectContext(ctx, &contracts, q); err != nil {
+	q := `SELECT * FROM contracts WHERE deleted_at IS NULL`
+	args := []interface{}{}
+	if len(tags) > 0 {
+		q += ` AND ` + tag.FilterClause("contracts", "id", "$1")
+		args = append(args, pq.Array(tags))
+	}
+	q += ` ORDER BY start_date DESC`
+	if err := r.db.SelectContext(ctx, &contracts, q, args...); err != nil {
 		return nil, err
 	}
 	return contracts, nil
 }
 
-// Create insere um novo contrato.
+// Each percorre os contratos de FindAll, opcionalmente filtrados por
+// status, direto do cursor, sem carregar a lista em memoria.
+func (r *PostgresRepository) Each(ctx context.Context, tags []string, status string, fn func(Contract) error) error {
+	q := `SELECT * FROM contracts WHERE deleted_at IS NULL`
+	args := []interface{}{}
+	if len(tags) > 0 {
+		args = append(args, pq.Array(tags))
+		q += ` AND ` + tag.FilterClause("contracts", "id", "$1")
+	}
+	if status != "" {
+		args = append(args, status)
+		q += fmt.Sprintf(` AND status=$%d`, len(args))
+	}
+	q += ` ORDER BY start_date DESC, id`
+	rows, err := r.db.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c Contract
+		if err := rows.StructScan(&c); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Create insere um novo contrato com seus itens e registra o status inicial
+// no historico. Sem itens, o contrato vira uma linha unica com o servico e o
+// valor informados. Contratos que ja nascem ativos geram a cobranca
+// (GenerateBilling); os pendentes de assinatura, so quando assinados.
 func (r *PostgresRepository) Create(ctx context.Context, c *Contract) error {
-	// valida existencia de customer e service
+	// valida existencia de customer
 	var exists int
 	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM customers WHERE id=$1 AND deleted_at IS NULL`, c.CustomerID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -38,22 +86,106 @@ func (r *PostgresRepository) Create(ctx context.Context, c *Contract) error {
 		}
 		return err
 	}
-	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM services WHERE id=$1 AND deleted_at IS NULL`, c.ServiceID); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return sql.ErrNoRows
-		}
+	if len(c.Items) == 0 {
+		c.Items = []Item{singleItem(c.ServiceID, c.ValueTotal)}
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
 		return err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	const q = `INSERT INTO contracts (id, customer_id, service_id, promoter_id, value_total, start_date, end_date, status) VALUES (:id, :customer_id, :service_id, :promoter_id, :value_total, :start_date, :end_date, :status)`
-	_, err := r.db.NamedExecContext(ctx, q, c)
-	return err
+	if err = checkServices(ctx, tx, c.Items); err != nil {
+		return err
+	}
+	const q = `INSERT INTO contracts (id, customer_id, service_id, promoter_id, value_total, start_date, end_date, status, installments) VALUES (:id, :customer_id, :service_id, :promoter_id, :value_total, :start_date, :end_date, :status, :installments)`
+	if _, err = tx.NamedExecContext(ctx, q, c); err != nil {
+		return err
+	}
+	if err = insertItems(ctx, tx, c.ID, c.Items); err != nil {
+		return err
+	}
+	if _, err = insertHistory(ctx, tx, c.ID, nil, c.Status, "", ""); err != nil {
+		return err
+	}
+	c.CurrentVersion = 1
+	if _, err = insertVersion(ctx, tx, c, "", c.StartDate, ""); err != nil {
+		return err
+	}
+	if c.Status == StatusActive {
+		if err = generateBilling(ctx, tx, c); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-// Update altera dados de um contrato existente.
+// Update altera os termos de um contrato ainda pendente de assinatura. Como
+// nada foi acordado, a versao vigente eh reescrita em vez de gerar uma nova;
+// depois da assinatura os termos so mudam por aditivo (Amend). Com itens, as
+// linhas sao substituidas e o valor eh a soma delas; sem itens, as linhas
+// atuais sao reescaladas para o novo valor. O status so muda via ChangeStatus.
 func (r *PostgresRepository) Update(ctx context.Context, c *Contract) error {
-	const q = `UPDATE contracts SET value_total=:value_total, start_date=:start_date, end_date=:end_date, status=:status, updated_at=now() WHERE id=:id AND deleted_at IS NULL`
-	res, err := r.db.NamedExecContext(ctx, q, c)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var cur Contract
+	if err = tx.GetContext(ctx, &cur, `SELECT * FROM contracts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, c.ID); err != nil {
+		return err
+	}
+	if cur.Status != StatusPendingSignature {
+		return ErrNotEditable
+	}
+	// o documento enviado para assinatura nao reflete mais os termos; uma
+	// nova solicitacao precisa ser criada
+	const qs = `UPDATE signature_requests SET status='cancelled' WHERE contract_id=$1 AND status='pending'`
+	if _, err = tx.ExecContext(ctx, qs, c.ID); err != nil {
+		return err
+	}
+	if len(c.Items) > 0 {
+		if err = checkServices(ctx, tx, c.Items); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM contract_items WHERE contract_id=$1`, c.ID); err != nil {
+			return err
+		}
+		if err = insertItems(ctx, tx, c.ID, c.Items); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `UPDATE contracts SET service_id=$2 WHERE id=$1`, c.ID, c.Items[0].ServiceID); err != nil {
+			return err
+		}
+	}
+	const qc = `UPDATE contracts SET value_total=$2, start_date=$3, end_date=$4, updated_at=now() WHERE id=$1`
+	if _, err = tx.ExecContext(ctx, qc, c.ID, c.ValueTotal, c.StartDate, c.EndDate); err != nil {
+		return err
+	}
+	const qv = `UPDATE contract_versions SET value_total=$3, start_date=$4, end_date=$5, effective_date=$4
+        WHERE contract_id=$1 AND version=$2`
+	if _, err = tx.ExecContext(ctx, qv, c.ID, cur.CurrentVersion, c.ValueTotal, c.StartDate, c.EndDate); err != nil {
+		return err
+	}
+	if len(c.Items) == 0 {
+		if err = rescaleItems(ctx, tx, c.ID, c.ValueTotal); err != nil {
+			return err
+		}
+	}
+	// a cobranca so eh gerada na ativacao; parcelas de contratos pendentes
+	// criados antes disso acompanham o novo valor
+	if err = scaleReceivables(ctx, tx, c.ID, cur.ValueTotal, c.ValueTotal, cur.StartDate); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SoftDelete marca um contrato como removido.
+func (r *PostgresRepository) SoftDelete(ctx context.Context, id string) error {
+	const q = `UPDATE contracts SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, id)
 	if err != nil {
 		return err
 	}
@@ -67,10 +199,120 @@ func (r *PostgresRepository) Update(ctx context.Context, c *Contract) error {
 	return nil
 }
 
-// SoftDelete marca um contrato como removido.
-func (r *PostgresRepository) SoftDelete(ctx context.Context, id string) error {
-	const q = `UPDATE contracts SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`
-	res, err := r.db.ExecContext(ctx, q, id)
+// ChangeStatus aplica uma transicao valida e seus efeitos. No cancelamento,
+// as contas a receber em aberto com vencimento futuro sao canceladas e as
+// comissoes ainda nao aprovadas sao removidas logicamente; parcelas com
+// cobranca pendente impedem o cancelamento (ErrPendingCharge).
+func (r *PostgresRepository) ChangeStatus(ctx context.Context, id string, change StatusChange) (StatusResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return StatusResult{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var cur struct {
+		Status  string     `db:"status"`
+		EndDate *time.Time `db:"end_date"`
+	}
+	const qs = `SELECT status, end_date FROM contracts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	if err = tx.GetContext(ctx, &cur, qs, id); err != nil {
+		return StatusResult{}, err
+	}
+	if err = ValidateTransition(cur.Status, change.To, change.Reason, cur.EndDate, time.Now()); err != nil {
+		return StatusResult{}, err
+	}
+	if change.To == StatusCancelled {
+		pending, err := hasPendingCharge(ctx, tx, id, time.Now())
+		if err != nil {
+			return StatusResult{}, err
+		}
+		if pending {
+			return StatusResult{}, ErrPendingCharge
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE contracts SET status=$2, updated_at=now() WHERE id=$1`, id, change.To); err != nil {
+		return StatusResult{}, err
+	}
+
+	var res StatusResult
+	if change.To == StatusCancelled {
+		if res.ReceivablesCancelled, err = execCount(ctx, tx, `UPDATE accounts_receivable SET status='cancelled', updated_at=now()
+            WHERE contract_id=$1 AND status='open' AND due_date >= current_date AND deleted_at IS NULL`, id); err != nil {
+			return StatusResult{}, err
+		}
+		if res.CommissionsCancelled, err = execCount(ctx, tx, `UPDATE commissions SET deleted_at=now(), updated_at=now()
+            WHERE contract_id=$1 AND approved=false AND deleted_at IS NULL`, id); err != nil {
+			return StatusResult{}, err
+		}
+	}
+
+	from := cur.Status
+	if res.History, err = insertHistory(ctx, tx, id, &from, change.To, change.Reason, change.ActorID); err != nil {
+		return StatusResult{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return StatusResult{}, err
+	}
+	return res, nil
+}
+
+// History retorna as transicoes de status do contrato em ordem cronologica.
+func (r *PostgresRepository) History(ctx context.Context, id string) ([]StatusHistory, error) {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM contracts WHERE id=$1`, id); err != nil {
+		return nil, err
+	}
+	history := []StatusHistory{}
+	const q = `SELECT * FROM contract_status_history WHERE contract_id=$1 ORDER BY created_at, id`
+	if err := r.db.SelectContext(ctx, &history, q, id); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// Expiring retorna os contratos ativos que vencem nos proximos days dias.
+func (r *PostgresRepository) Expiring(ctx context.Context, days int) ([]Contract, error) {
+	contracts := []Contract{}
+	const q = `SELECT * FROM contracts
+        WHERE status='active' AND deleted_at IS NULL
+          AND end_date BETWEEN current_date AND current_date + $1::int
+        ORDER BY end_date, id`
+	if err := r.db.SelectContext(ctx, &contracts, q, days); err != nil {
+		return nil, err
+	}
+	return contracts, nil
+}
+
+// SetLateFees define os encargos por atraso do contrato; percentuais nulos
+// voltam a usar o padrao do servico.
+func (r *PostgresRepository) SetLateFees(ctx context.Context, id string, fees LateFees) error {
+	const q = `UPDATE contracts SET late_fine_percent=$2, monthly_interest_percent=$3, updated_at=now()
+        WHERE id=$1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, id, fees.FinePercent, fees.MonthlyInterestPercent)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetReadjustmentClause define ou remove (clause nil) a clausula de reajuste.
+func (r *PostgresRepository) SetReadjustmentClause(ctx context.Context, id string, clause *ReadjustmentClause) error {
+	var index *string
+	var month *int
+	if clause != nil {
+		index, month = &clause.Index, &clause.AnniversaryMonth
+	}
+	const q = `UPDATE contracts SET readjustment_index=$2, anniversary_month=$3, updated_at=now()
+        WHERE id=$1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, id, index, month)
 	if err != nil {
 		return err
 	}
@@ -83,3 +325,508 @@ func (r *PostgresRepository) SoftDelete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// eligibleReadjustments seleciona os contratos ativos com aniversario no mes
+// de referencia ($2), com pelo menos um ano de vigencia e ainda nao reajustados.
+const eligibleReadjustments = `SELECT c.* FROM contracts c
+    WHERE c.status='active' AND c.deleted_at IS NULL
+      AND c.readjustment_index IS NOT NULL AND c.anniversary_month=$1
+      AND date_trunc('month', c.start_date) <= $2::date - interval '12 months'
+      AND (c.end_date IS NULL OR c.end_date >= $2::date)
+      AND NOT EXISTS (SELECT 1 FROM contract_readjustments cr
+                       WHERE cr.contract_id=c.id AND cr.reference_month=$2::date)
+    ORDER BY c.id`
+
+// loadRates retorna as variacoes do periodo de reajuste por indice e mes (AAAA-MM).
+func loadRates(ctx context.Context, q sqlx.QueryerContext, ref time.Time) (map[string]map[string]float64, error) {
+	from, to := ReadjustmentPeriod(ref)
+	var values []priceindex.Value
+	const qv = `SELECT index_code, month, rate FROM price_index_values WHERE month BETWEEN $1 AND $2`
+	if err := sqlx.SelectContext(ctx, q, &values, qv, from, to); err != nil {
+		return nil, err
+	}
+	rates := map[string]map[string]float64{}
+	for _, v := range values {
+		if rates[v.IndexCode] == nil {
+			rates[v.IndexCode] = map[string]float64{}
+		}
+		rates[v.IndexCode][v.Month.Format("2006-01")] = v.Rate
+	}
+	return rates, nil
+}
+
+// PreviewReadjustments calcula, sem gravar, os reajustes do mes de referencia.
+func (r *PostgresRepository) PreviewReadjustments(ctx context.Context, ref time.Time) ([]ReadjustmentPreview, error) {
+	ref = monthStart(ref)
+	var contracts []Contract
+	if err := r.db.SelectContext(ctx, &contracts, eligibleReadjustments, int(ref.Month()), ref); err != nil {
+		return nil, err
+	}
+	rates, err := loadRates(ctx, r.db, ref)
+	if err != nil {
+		return nil, err
+	}
+	out := []ReadjustmentPreview{}
+	for _, c := range contracts {
+		p := buildReadjustment(c, ref, rates[*c.ReadjustmentIndex])
+		if p.PendingCharge, err = hasPendingCharge(ctx, r.db, c.ID, ref); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// ApplyReadjustments aplica os reajustes do mes de referencia como aditivos:
+// cada contrato ganha uma nova versao e as contas a receber em aberto com
+// vencimento a partir da referencia acompanham o novo valor. Com ids vazio
+// todos os contratos elegiveis sao reajustados; contratos sem todos os
+// meses do indice ou com cobranca pendente sao ignorados.
+func (r *PostgresRepository) ApplyReadjustments(ctx context.Context, ref time.Time, ids []string, actorID string) ([]Readjustment, error) {
+	ref = monthStart(ref)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var contracts []Contract
+	if err = tx.SelectContext(ctx, &contracts, eligibleReadjustments+` FOR UPDATE OF c`, int(ref.Month()), ref); err != nil {
+		return nil, err
+	}
+	rates, err := loadRates(ctx, tx, ref)
+	if err != nil {
+		return nil, err
+	}
+	selected := map[string]bool{}
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	applied := []Readjustment{}
+	for _, c := range contracts {
+		if len(ids) > 0 && !selected[c.ID] {
+			continue
+		}
+		p := buildReadjustment(c, ref, rates[*c.ReadjustmentIndex])
+		if len(p.MissingMonths) > 0 {
+			continue
+		}
+		if p.PendingCharge, err = hasPendingCharge(ctx, tx, c.ID, ref); err != nil {
+			return nil, err
+		}
+		if p.PendingCharge {
+			continue
+		}
+		v, err := applyVersion(ctx, tx, &c, p.NewValue, c.StartDate, c.EndDate, readjustmentReason(p), ref, actorID)
+		if err != nil {
+			return nil, err
+		}
+		if err = scaleReceivables(ctx, tx, c.ID, p.PreviousValue, p.NewValue, ref); err != nil {
+			return nil, err
+		}
+		if err = rescaleItems(ctx, tx, c.ID, p.NewValue); err != nil {
+			return nil, err
+		}
+		ra := Readjustment{
+			ID:             ulid.Make().String(),
+			ContractID:     c.ID,
+			IndexCode:      p.Index,
+			ReferenceMonth: ref,
+			PeriodStart:    p.PeriodStart,
+			PeriodEnd:      p.PeriodEnd,
+			Factor:         p.Factor,
+			PreviousValue:  p.PreviousValue,
+			NewValue:       p.NewValue,
+			Version:        v.Version,
+			CreatedBy:      v.CreatedBy,
+		}
+		const qi = `INSERT INTO contract_readjustments (id, contract_id, index_code, reference_month, period_start,
+            period_end, factor, previous_value, new_value, version, created_by)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING created_at`
+		if err = tx.GetContext(ctx, &ra.CreatedAt, qi, ra.ID, ra.ContractID, ra.IndexCode, ra.ReferenceMonth,
+			ra.PeriodStart, ra.PeriodEnd, ra.Factor, ra.PreviousValue, ra.NewValue, ra.Version, ra.CreatedBy); err != nil {
+			return nil, err
+		}
+		applied = append(applied, ra)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func insertHistory(ctx context.Context, tx *sqlx.Tx, contractID string, from *string, to, reason, actorID string) (StatusHistory, error) {
+	h := StatusHistory{
+		ID:         ulid.Make().String(),
+		ContractID: contractID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+	}
+	if actorID != "" {
+		h.ChangedBy = &actorID
+	}
+	const q = `INSERT INTO contract_status_history (id, contract_id, from_status, to_status, reason, changed_by)
+        VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+	err := tx.GetContext(ctx, &h.CreatedAt, q, h.ID, h.ContractID, h.FromStatus, h.ToStatus, h.Reason, h.ChangedBy)
+	return h, err
+}
+
+func execCount(ctx context.Context, tx *sqlx.Tx, q string, args ...interface{}) (int64, error) {
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Amend registra um aditivo como nova versao do contrato. Contas a receber
+// em aberto com vencimento a partir da data de vigencia sao reajustadas na
+// proporcao entre o novo valor e o anterior.
+func (r *PostgresRepository) Amend(ctx context.Context, id string, a Amendment) (Version, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Version{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var cur Contract
+	if err = tx.GetContext(ctx, &cur, `SELECT * FROM contracts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, id); err != nil {
+		return Version{}, err
+	}
+	if cur.Status != StatusActive && cur.Status != StatusSuspended {
+		return Version{}, ErrNotAmendable
+	}
+	previous := cur.ValueTotal
+	value := previous
+	if a.ValueTotal != nil {
+		value = *a.ValueTotal
+	}
+	v, err := applyVersion(ctx, tx, &cur, value, a.StartDate, a.EndDate, a.Reason, a.EffectiveDate, a.ActorID)
+	if err != nil {
+		return Version{}, err
+	}
+	if value != previous {
+		if err = scaleReceivables(ctx, tx, id, previous, value, a.EffectiveDate); err != nil {
+			return Version{}, err
+		}
+		if err = rescaleItems(ctx, tx, id, value); err != nil {
+			return Version{}, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Versions retorna todas as versoes do contrato, da original a vigente.
+func (r *PostgresRepository) Versions(ctx context.Context, id string) ([]Version, error) {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM contracts WHERE id=$1`, id); err != nil {
+		return nil, err
+	}
+	versions := []Version{}
+	const q = `SELECT * FROM contract_versions WHERE contract_id=$1 ORDER BY version`
+	if err := r.db.SelectContext(ctx, &versions, q, id); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Renew cria o contrato sucessor vinculado ao original, com os mesmos itens
+// reescalados para o novo valor, encerra o original se ainda ativo e transfere para o sucessor as contas a receber em aberto
+// que vencem a partir do novo inicio, reajustadas pelo novo valor. Um
+// contrato tem no maximo um sucessor nao cancelado.
+func (r *PostgresRepository) Renew(ctx context.Context, id string, rn Renewal) (Contract, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Contract{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var orig Contract
+	if err = tx.GetContext(ctx, &orig, `SELECT * FROM contracts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, id); err != nil {
+		return Contract{}, err
+	}
+	if (orig.Status != StatusActive && orig.Status != StatusClosed) || orig.EndDate == nil {
+		return Contract{}, ErrNotRenewable
+	}
+	var renewed bool
+	const qr = `SELECT EXISTS (SELECT 1 FROM contracts
+        WHERE renewed_from_id=$1 AND status <> 'cancelled' AND deleted_at IS NULL)`
+	if err = tx.GetContext(ctx, &renewed, qr, orig.ID); err != nil {
+		return Contract{}, err
+	}
+	if renewed {
+		return Contract{}, ErrRenewed
+	}
+
+	succ := Contract{
+		ID:             ulid.Make().String(),
+		CustomerID:     orig.CustomerID,
+		ServiceID:      orig.ServiceID,
+		PromoterID:     orig.PromoterID,
+		ValueTotal:     orig.ValueTotal,
+		StartDate:      rn.StartDate,
+		EndDate:        rn.EndDate,
+		Status:         StatusActive,
+		CurrentVersion: 1,
+		RenewedFromID:  &orig.ID,
+	}
+	if rn.ValueTotal != nil {
+		succ.ValueTotal = *rn.ValueTotal
+	}
+	if succ.StartDate.IsZero() {
+		succ.StartDate = orig.EndDate.AddDate(0, 0, 1)
+	}
+	if succ.EndDate != nil && succ.StartDate.After(*succ.EndDate) {
+		return Contract{}, ErrNotRenewable
+	}
+
+	const qi = `INSERT INTO contracts (id, customer_id, service_id, promoter_id, value_total, start_date, end_date, status, renewed_from_id)
+        VALUES (:id, :customer_id, :service_id, :promoter_id, :value_total, :start_date, :end_date, :status, :renewed_from_id)`
+	if _, err = tx.NamedExecContext(ctx, qi, &succ); err != nil {
+		return Contract{}, err
+	}
+	succ.CreatedAt = time.Now()
+	succ.UpdatedAt = succ.CreatedAt
+	items, err := loadItems(ctx, tx, orig.ID)
+	if err != nil {
+		return Contract{}, err
+	}
+	// a renovacao eh um novo contrato: os servicos precisam estar ativos
+	if err = checkServices(ctx, tx, items); err != nil {
+		return Contract{}, err
+	}
+	succ.Items = ScaleItems(items, succ.ValueTotal)
+	if err = insertItems(ctx, tx, succ.ID, succ.Items); err != nil {
+		return Contract{}, err
+	}
+
+	reason := rn.Reason
+	if reason == "" {
+		reason = "renovacao de " + orig.ID
+	}
+	if _, err = insertHistory(ctx, tx, succ.ID, nil, StatusActive, reason, rn.ActorID); err != nil {
+		return Contract{}, err
+	}
+	if _, err = insertVersion(ctx, tx, &succ, reason, succ.StartDate, rn.ActorID); err != nil {
+		return Contract{}, err
+	}
+
+	if orig.Status == StatusActive {
+		if _, err = tx.ExecContext(ctx, `UPDATE contracts SET status=$2, updated_at=now() WHERE id=$1`, orig.ID, StatusClosed); err != nil {
+			return Contract{}, err
+		}
+		from := orig.Status
+		if _, err = insertHistory(ctx, tx, orig.ID, &from, StatusClosed, "renovado por "+succ.ID, rn.ActorID); err != nil {
+			return Contract{}, err
+		}
+	}
+
+	ratio := 1.0
+	if orig.ValueTotal > 0 {
+		ratio = succ.ValueTotal / orig.ValueTotal
+	}
+	if ratio != 1 {
+		pending, err := hasPendingCharge(ctx, tx, orig.ID, succ.StartDate)
+		if err != nil {
+			return Contract{}, err
+		}
+		if pending {
+			return Contract{}, ErrPendingCharge
+		}
+	}
+	const qm = `UPDATE accounts_receivable SET contract_id=$2, amount=round(amount * $3, 2), updated_at=now()
+        WHERE contract_id=$1 AND status='open' AND due_date >= $4 AND deleted_at IS NULL`
+	if _, err = tx.ExecContext(ctx, qm, orig.ID, succ.ID, ratio, succ.StartDate); err != nil {
+		return Contract{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Contract{}, err
+	}
+	return succ, nil
+}
+
+// Items retorna as linhas do contrato na ordem de cadastro.
+func (r *PostgresRepository) Items(ctx context.Context, id string) ([]Item, error) {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM contracts WHERE id=$1 AND deleted_at IS NULL`, id); err != nil {
+		return nil, err
+	}
+	return loadItems(ctx, r.db, id)
+}
+
+func loadItems(ctx context.Context, q sqlx.QueryerContext, contractID string) ([]Item, error) {
+	items := []Item{}
+	if err := sqlx.SelectContext(ctx, q, &items, `SELECT * FROM contract_items WHERE contract_id=$1 ORDER BY position`, contractID); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// checkServices garante que todos os servicos dos itens existem e estao ativos.
+func checkServices(ctx context.Context, tx *sqlx.Tx, items []Item) error {
+	ids := make([]string, 0, len(items))
+	seen := map[string]bool{}
+	for _, it := range items {
+		if !seen[it.ServiceID] {
+			seen[it.ServiceID] = true
+			ids = append(ids, it.ServiceID)
+		}
+	}
+	var active int
+	const q = `SELECT count(*) FROM services WHERE id = ANY($1) AND is_active AND deleted_at IS NULL`
+	if err := tx.GetContext(ctx, &active, q, pq.Array(ids)); err != nil {
+		return err
+	}
+	if active != len(ids) {
+		return ErrServiceUnavailable
+	}
+	return nil
+}
+
+func insertItems(ctx context.Context, tx *sqlx.Tx, contractID string, items []Item) error {
+	const q = `INSERT INTO contract_items (id, contract_id, service_id, quantity, unit_price, discount, line_total, position)
+        VALUES (:id, :contract_id, :service_id, :quantity, :unit_price, :discount, :line_total, :position)`
+	for i := range items {
+		items[i].ID = ulid.Make().String()
+		items[i].ContractID = contractID
+		items[i].Position = i + 1
+		if _, err := tx.NamedExecContext(ctx, q, &items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rescaleItems ajusta as linhas do contrato para que somem o novo valor.
+func rescaleItems(ctx context.Context, tx *sqlx.Tx, contractID string, value float64) error {
+	items, err := loadItems(ctx, tx, contractID)
+	if err != nil {
+		return err
+	}
+	const q = `UPDATE contract_items SET unit_price=$2, line_total=$3, updated_at=now() WHERE id=$1`
+	for _, it := range ScaleItems(items, value) {
+		if _, err = tx.ExecContext(ctx, q, it.ID, it.UnitPrice, it.LineTotal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateBilling gera a cobranca de um contrato que acabou de ser ativado,
+// dentro da transacao de quem o ativou (ex.: conclusao da assinatura).
+func GenerateBilling(ctx context.Context, tx *sqlx.Tx, contractID string) error {
+	var c Contract
+	if err := tx.GetContext(ctx, &c, `SELECT * FROM contracts WHERE id=$1`, contractID); err != nil {
+		return err
+	}
+	if c.Installments <= 0 {
+		return nil
+	}
+	items, err := loadItems(ctx, tx, contractID)
+	if err != nil {
+		return err
+	}
+	c.Items = items
+	return generateBilling(ctx, tx, &c)
+}
+
+// generateBilling cria as parcelas mensais a partir de start_date, somando a
+// fracao de cada linha, e a comissao do promotor pelo percentual vigente no
+// inicio do contrato.
+func generateBilling(ctx context.Context, tx *sqlx.Tx, c *Contract) error {
+	if c.Installments <= 0 {
+		return nil
+	}
+	const qr = `INSERT INTO accounts_receivable (id, contract_id, due_date, amount) VALUES ($1, $2, $3, $4)`
+	for i, amount := range SplitInstallments(c.Items, c.Installments) {
+		if _, err := tx.ExecContext(ctx, qr, ulid.Make().String(), c.ID, c.StartDate.AddDate(0, i, 0), amount); err != nil {
+			return err
+		}
+	}
+	if c.PromoterID == nil {
+		return nil
+	}
+	var pct float64
+	const qp = `SELECT percentage FROM commission_contracts
+        WHERE promoter_id=$1 AND deleted_at IS NULL AND starts_at <= $2 AND (ends_at IS NULL OR ends_at >= $2)
+        ORDER BY starts_at DESC LIMIT 1`
+	if err := tx.GetContext(ctx, &pct, qp, *c.PromoterID, c.StartDate); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	const qc = `INSERT INTO commissions (id, contract_id, promoter_id, amount) VALUES ($1, $2, $3, $4)`
+	_, err := tx.ExecContext(ctx, qc, ulid.Make().String(), c.ID, *c.PromoterID, CommissionAmount(c.Items, pct))
+	return err
+}
+
+// scaleReceivables reajusta as contas a receber em aberto que vencem a
+// partir de from na proporcao entre o novo valor e o anterior. Parcelas com
+// cobranca pendente bloqueiam o reajuste (ErrPendingCharge).
+func scaleReceivables(ctx context.Context, tx *sqlx.Tx, contractID string, previous, value float64, from time.Time) error {
+	if previous <= 0 || previous == value {
+		return nil
+	}
+	pending, err := hasPendingCharge(ctx, tx, contractID, from)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return ErrPendingCharge
+	}
+	const q = `UPDATE accounts_receivable SET amount=round(amount * $2 / $3, 2), updated_at=now()
+        WHERE contract_id=$1 AND status='open' AND due_date >= $4 AND deleted_at IS NULL`
+	_, err = tx.ExecContext(ctx, q, contractID, value, previous, from)
+	return err
+}
+
+// hasPendingCharge informa se alguma parcela em aberto do contrato com
+// vencimento a partir de from tem cobranca pendente no gateway.
+func hasPendingCharge(ctx context.Context, q sqlx.QueryerContext, contractID string, from time.Time) (bool, error) {
+	var pending bool
+	const qp = `SELECT EXISTS (SELECT 1 FROM accounts_receivable ar
+        JOIN charges ch ON ch.receivable_id = ar.id AND ch.status = 'pending'
+        WHERE ar.contract_id=$1 AND ar.status='open' AND ar.due_date >= $2::date AND ar.deleted_at IS NULL)`
+	err := sqlx.GetContext(ctx, q, &pending, qp, contractID, from)
+	return pending, err
+}
+
+// applyVersion grava os novos termos no contrato e registra a versao seguinte.
+func applyVersion(ctx context.Context, tx *sqlx.Tx, c *Contract, value float64, start time.Time, end *time.Time, reason string, effective time.Time, actorID string) (Version, error) {
+	c.ValueTotal = value
+	c.StartDate = start
+	c.EndDate = end
+	c.CurrentVersion++
+	const q = `UPDATE contracts SET value_total=$2, start_date=$3, end_date=$4, current_version=$5, updated_at=now() WHERE id=$1`
+	if _, err := tx.ExecContext(ctx, q, c.ID, c.ValueTotal, c.StartDate, c.EndDate, c.CurrentVersion); err != nil {
+		return Version{}, err
+	}
+	return insertVersion(ctx, tx, c, reason, effective, actorID)
+}
+
+func insertVersion(ctx context.Context, tx *sqlx.Tx, c *Contract, reason string, effective time.Time, actorID string) (Version, error) {
+	v := Version{
+		ID:            ulid.Make().String(),
+		ContractID:    c.ID,
+		Version:       c.CurrentVersion,
+		ValueTotal:    c.ValueTotal,
+		StartDate:     c.StartDate,
+		EndDate:       c.EndDate,
+		Reason:        reason,
+		EffectiveDate: effective,
+	}
+	if actorID != "" {
+		v.CreatedBy = &actorID
+	}
+	const q = `INSERT INTO contract_versions (id, contract_id, version, value_total, start_date, end_date, reason, effective_date, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING created_at`
+	err := tx.GetContext(ctx, &v.CreatedAt, q, v.ID, v.ContractID, v.Version, v.ValueTotal, v.StartDate, v.EndDate, v.Reason, v.EffectiveDate, v.CreatedBy)
+	return v, err
+}
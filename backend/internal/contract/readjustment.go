@@ -0,0 +1,151 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/rgomids/bckoffice/internal/priceindex"
+)
+
+// ReadjustmentClause define o indice e o mes de aniversario usados no
+// reajuste anual do contrato.
+type ReadjustmentClause struct {
+	Index            string
+	AnniversaryMonth int
+}
+
+// ReadjustmentPreview mostra o calculo do reajuste antes de aplica-lo.
+// Contratos com meses do indice ainda nao importados listam MissingMonths
+// e nao sao reajustados.
+type ReadjustmentPreview struct {
+	ContractID     string    `json:"contract_id"`
+	Index          string    `json:"index"`
+	ReferenceMonth time.Time `json:"reference_month"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	Factor         float64   `json:"factor"`
+	PreviousValue  float64   `json:"previous_value"`
+	NewValue       float64   `json:"new_value"`
+	MissingMonths  []string  `json:"missing_months,omitempty"`
+	// PendingCharge indica parcela com cobranca pendente: o reajuste so eh
+	// aplicado depois que ela for cancelada.
+	PendingCharge bool `json:"pending_charge,omitempty"`
+}
+
+// Readjustment registra um reajuste aplicado; cada contrato eh reajustado
+// no maximo uma vez por mes de referencia.
+type Readjustment struct {
+	ID             string    `db:"id" json:"id"`
+	ContractID     string    `db:"contract_id" json:"contract_id"`
+	IndexCode      string    `db:"index_code" json:"index"`
+	ReferenceMonth time.Time `db:"reference_month" json:"reference_month"`
+	PeriodStart    time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd      time.Time `db:"period_end" json:"period_end"`
+	Factor         float64   `db:"factor" json:"factor"`
+	PreviousValue  float64   `db:"previous_value" json:"previous_value"`
+	NewValue       float64   `db:"new_value" json:"new_value"`
+	Version        int       `db:"version" json:"version"`
+	CreatedBy      *string   `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// monthStart retorna o primeiro dia do mes de t.
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ReadjustmentPeriod retorna os 12 meses acumulados no reajuste do mes de
+// referencia: do mesmo mes do ano anterior ate o mes anterior a referencia.
+func ReadjustmentPeriod(ref time.Time) (from, to time.Time) {
+	ref = monthStart(ref)
+	return ref.AddDate(-1, 0, 0), ref.AddDate(0, -1, 0)
+}
+
+// buildReadjustment calcula o reajuste de c com as variacoes mensais do
+// indice (chave AAAA-MM). Variacao acumulada negativa nao reduz o valor.
+func buildReadjustment(c Contract, ref time.Time, rates map[string]float64) ReadjustmentPreview {
+	from, to := ReadjustmentPeriod(ref)
+	p := ReadjustmentPreview{
+		ContractID:     c.ID,
+		ReferenceMonth: monthStart(ref),
+		PeriodStart:    from,
+		PeriodEnd:      to,
+		PreviousValue:  c.ValueTotal,
+		NewValue:       c.ValueTotal,
+		Factor:         1,
+	}
+	if c.ReadjustmentIndex != nil {
+		p.Index = *c.ReadjustmentIndex
+	}
+	var monthly []float64
+	for m := from; !m.After(to); m = m.AddDate(0, 1, 0) {
+		key := m.Format("2006-01")
+		rate, ok := rates[key]
+		if !ok {
+			p.MissingMonths = append(p.MissingMonths, key)
+			continue
+		}
+		monthly = append(monthly, rate)
+	}
+	if len(p.MissingMonths) > 0 {
+		return p
+	}
+	if f := priceindex.Factor(monthly); f > 1 {
+		p.Factor = f
+	}
+	p.NewValue = math.Round(c.ValueTotal*p.Factor*100) / 100
+	return p
+}
+
+// readjustmentReason descreve o reajuste na versao gerada do contrato.
+func readjustmentReason(p ReadjustmentPreview) string {
+	name := p.Index
+	if idx, ok := priceindex.Lookup(p.Index); ok {
+		name = idx.Name
+	}
+	return fmt.Sprintf("reajuste anual %s %s a %s: %.4f%%", name,
+		p.PeriodStart.Format("01/2006"), p.PeriodEnd.Format("01/2006"), (p.Factor-1)*100)
+}
+
+// ReadjustmentJob aplica periodicamente os reajustes do mes corrente.
+// Reajustes ja aplicados nao se repetem, entao executar varias vezes no
+// mesmo mes eh seguro.
+type ReadjustmentJob struct {
+	repo     Repository
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewReadjustmentJob cria um job que executa a cada interval.
+func NewReadjustmentJob(repo Repository, interval time.Duration) *ReadjustmentJob {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &ReadjustmentJob{repo: repo, interval: interval, now: time.Now}
+}
+
+// Run executa o job imediatamente e depois a cada intervalo ate ctx ser cancelado.
+func (j *ReadjustmentJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		if applied, err := j.RunOnce(ctx); err != nil {
+			log.Printf("contract readjustment job: %v", err)
+		} else if len(applied) > 0 {
+			log.Printf("contract readjustment job: %d contracts readjusted", len(applied))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce aplica os reajustes pendentes do mes corrente.
+func (j *ReadjustmentJob) RunOnce(ctx context.Context) ([]Readjustment, error) {
+	return j.repo.ApplyReadjustments(ctx, monthStart(j.now()), nil, "")
+}
@@ -0,0 +1,124 @@
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/priceindex"
+)
+
+// ReadjustmentClauseInput define a clausula de reajuste; index vazio remove a clausula.
+type ReadjustmentClauseInput struct {
+	Index            string `json:"index"`
+	AnniversaryMonth int    `json:"anniversary_month" validate:"omitempty,min=1,max=12"`
+}
+
+// ApplyReadjustmentsInput define o mes de referencia e, opcionalmente, os
+// contratos a reajustar.
+type ApplyReadjustmentsInput struct {
+	Month       string   `json:"month"`
+	ContractIDs []string `json:"contract_ids"`
+}
+
+// parseReferenceMonth le um mes AAAA-MM; vazio significa o mes corrente.
+func parseReferenceMonth(s string) (time.Time, error) {
+	if s == "" {
+		return monthStart(time.Now()), nil
+	}
+	return priceindex.ParseMonth(s)
+}
+
+// @Summary      Define a clausula de reajuste do contrato
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /contracts/{id}/readjustment [put]
+func (h handler) setReadjustmentClause(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in ReadjustmentClauseInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	var clause *ReadjustmentClause
+	if in.Index != "" {
+		idx, ok := priceindex.Lookup(in.Index)
+		if !ok {
+			writeBadRequest(w, priceindex.ErrUnknownIndex)
+			return
+		}
+		if in.AnniversaryMonth == 0 {
+			writeBadRequest(w, errors.New("anniversary_month is required"))
+			return
+		}
+		clause = &ReadjustmentClause{Index: idx.Code, AnniversaryMonth: in.AnniversaryMonth}
+	}
+
+	if err := h.repo.SetReadjustmentClause(r.Context(), id, clause); err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("contracts:%s", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Simula os reajustes anuais do mes
+// @Tags         contracts
+// @Security     BearerAuth
+// @Param        month  query  string  false  "Mes de referencia AAAA-MM (padrao: mes corrente)"
+// @Success      200  {array}  ReadjustmentPreview
+// @Router       /contracts/readjustments/preview [get]
+func (h handler) previewReadjustments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ref, err := parseReferenceMonth(r.URL.Query().Get("month"))
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	previews, err := h.repo.PreviewReadjustments(r.Context(), ref)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(previews)
+}
+
+// @Summary      Aplica os reajustes anuais do mes
+// @Tags         contracts
+// @Security     BearerAuth
+// @Success      200  {array}  Readjustment
+// @Router       /contracts/readjustments [post]
+func (h handler) applyReadjustments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var in ApplyReadjustmentsInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	ref, err := parseReferenceMonth(in.Month)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	applied, err := h.repo.ApplyReadjustments(r.Context(), ref, in.ContractIDs, auth.UserIDFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(applied)
+}
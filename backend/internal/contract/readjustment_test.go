@@ -0,0 +1,145 @@
+package contract
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestReadjustmentPeriod(t *testing.T) {
+	from, to := ReadjustmentPeriod(time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC))
+	if from.Format("2006-01-02") != "2025-03-01" || to.Format("2006-01-02") != "2026-02-01" {
+		t.Fatalf("unexpected period %s - %s", from, to)
+	}
+}
+
+func monthlyRates(from time.Time, n int, rate float64) map[string]float64 {
+	rates := map[string]float64{}
+	for i := 0; i < n; i++ {
+		rates[from.AddDate(0, i, 0).Format("2006-01")] = rate
+	}
+	return rates
+}
+
+func TestBuildReadjustment(t *testing.T) {
+	ipca := "ipca"
+	c := Contract{ID: "k1", ValueTotal: 1000, ReadjustmentIndex: &ipca}
+	ref := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	from, _ := ReadjustmentPeriod(ref)
+
+	p := buildReadjustment(c, ref, monthlyRates(from, 12, 0.5))
+	if len(p.MissingMonths) != 0 || p.NewValue != 1061.68 {
+		t.Fatalf("expected 1061.68 with no missing months, got %+v", p)
+	}
+
+	p = buildReadjustment(c, ref, monthlyRates(from, 11, 0.5))
+	if len(p.MissingMonths) != 1 || p.MissingMonths[0] != "2026-09" || p.NewValue != 1000 {
+		t.Fatalf("expected missing 2026-09 and unchanged value, got %+v", p)
+	}
+
+	p = buildReadjustment(c, ref, monthlyRates(from, 12, -0.2))
+	if p.Factor != 1 || p.NewValue != 1000 {
+		t.Fatalf("expected negative index to keep value, got %+v", p)
+	}
+}
+
+func TestReadjustmentPreviewAndApply(t *testing.T) {
+	ref := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	from, _ := ReadjustmentPeriod(ref)
+	repo := &fakeRepository{
+		contracts: []Contract{{ID: "k1", Status: StatusActive, ValueTotal: 1000}},
+		rates:     map[string]map[string]float64{"ipca": monthlyRates(from, 12, 0.5)},
+	}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/contracts/k1/readjustment", token, `{"index":"IPCA","anniversary_month":10}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp.StatusCode)
+	}
+
+	resp = do(t, http.MethodGet, server.URL+"/contracts/readjustments/preview?month=2026-10", token, "")
+	var previews []ReadjustmentPreview
+	if err := json.NewDecoder(resp.Body).Decode(&previews); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	resp.Body.Close()
+	if len(previews) != 1 || previews[0].NewValue != 1061.68 {
+		t.Fatalf("unexpected preview: %+v", previews)
+	}
+	if repo.contracts[0].ValueTotal != 1000 {
+		t.Fatal("preview must not change the contract")
+	}
+
+	resp = do(t, http.MethodPost, server.URL+"/contracts/readjustments", token, `{"month":"2026-10"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if repo.contracts[0].ValueTotal != 1061.68 {
+		t.Fatalf("expected readjusted value, got %v", repo.contracts[0].ValueTotal)
+	}
+}
+
+func TestReadjustmentSkipsPendingCharge(t *testing.T) {
+	ref := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	from, _ := ReadjustmentPeriod(ref)
+	index, month := "ipca", 10
+	repo := &fakeRepository{
+		contracts:      []Contract{{ID: "k1", Status: StatusActive, ValueTotal: 1000, ReadjustmentIndex: &index, AnniversaryMonth: &month}},
+		rates:          map[string]map[string]float64{"ipca": monthlyRates(from, 12, 0.5)},
+		pendingCharges: map[string]bool{"k1": true},
+	}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodGet, server.URL+"/contracts/readjustments/preview?month=2026-10", token, "")
+	var previews []ReadjustmentPreview
+	if err := json.NewDecoder(resp.Body).Decode(&previews); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	resp.Body.Close()
+	if len(previews) != 1 || !previews[0].PendingCharge {
+		t.Fatalf("expected preview flagged with pending charge: %+v", previews)
+	}
+
+	resp = do(t, http.MethodPost, server.URL+"/contracts/readjustments", token, `{"month":"2026-10"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if repo.contracts[0].ValueTotal != 1000 {
+		t.Fatalf("contract with pending charge must not be readjusted, got %v", repo.contracts[0].ValueTotal)
+	}
+}
+
+func TestReadjustmentClauseRejectsUnknownIndex(t *testing.T) {
+	repo := &fakeRepository{contracts: []Contract{{ID: "k1", Status: StatusActive}}}
+	r, token := setupAuthRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/contracts/k1/readjustment", token, `{"index":"selic","anniversary_month":1}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+}
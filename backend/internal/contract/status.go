@@ -0,0 +1,84 @@
+package contract
+
+import (
+	"errors"
+	"time"
+)
+
+// Status de contrato
+const (
+	StatusPendingSignature = "pending_signature"
+	StatusActive           = "active"
+	StatusSuspended        = "suspended"
+	StatusClosed           = "closed"
+	StatusCancelled        = "cancelled"
+)
+
+// Errors especificos
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrReasonRequired    = errors.New("reason is required for this status")
+	ErrContractExpired   = errors.New("contract end_date has passed and cannot be reactivated")
+)
+
+// transitions define os destinos permitidos a partir de cada status.
+// closed e cancelled sao finais. pending_signature so passa a active pela
+// conclusao da assinatura (modulo signature).
+var transitions = map[string][]string{
+	StatusPendingSignature: {StatusCancelled},
+	StatusActive:           {StatusSuspended, StatusClosed, StatusCancelled},
+	StatusSuspended:        {StatusActive, StatusCancelled},
+}
+
+// RequiresReason indica se a mudanca para o status exige justificativa.
+func RequiresReason(to string) bool {
+	return to == StatusSuspended || to == StatusCancelled
+}
+
+// ValidateTransition verifica se o contrato pode passar de from para to.
+// A reativacao so eh permitida enquanto end_date nao tiver passado.
+func ValidateTransition(from, to, reason string, endDate *time.Time, today time.Time) error {
+	allowed := false
+	for _, s := range transitions[from] {
+		if s == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrInvalidTransition
+	}
+	if RequiresReason(to) && reason == "" {
+		return ErrReasonRequired
+	}
+	if to == StatusActive && endDate != nil && endDate.Format("2006-01-02") < today.Format("2006-01-02") {
+		return ErrContractExpired
+	}
+	return nil
+}
+
+// StatusChange descreve uma mudanca de status solicitada.
+type StatusChange struct {
+	To      string
+	Reason  string
+	ActorID string
+}
+
+// StatusResult resume a mudanca aplicada e seus efeitos colaterais.
+type StatusResult struct {
+	History              StatusHistory `json:"history"`
+	ReceivablesCancelled int64         `json:"receivablesCancelled"`
+	CommissionsCancelled int64         `json:"commissionsCancelled"`
+}
+
+// StatusHistory registra uma transicao de status do contrato.
+type StatusHistory struct {
+	ID         string    `db:"id" json:"id"`
+	ContractID string    `db:"contract_id" json:"contract_id"`
+	FromStatus *string   `db:"from_status" json:"from_status,omitempty"`
+	ToStatus   string    `db:"to_status" json:"to_status"`
+	Reason     string    `db:"reason" json:"reason,omitempty"`
+	ChangedBy  *string   `db:"changed_by" json:"changed_by,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package contract
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors especificos
+
+var (
+	ErrNotAmendable = errors.New("only active or suspended contracts can be amended")
+	ErrNotRenewable = errors.New("only active or closed contracts with end_date can be renewed")
+	ErrRenewed      = errors.New("contract already has a renewal")
+	ErrNotEditable  = errors.New("only contracts pending signature can be edited; use an amendment")
+	// ErrPendingCharge impede alterar parcelas com boleto/PIX ainda pagavel
+	// no gateway; a cobranca precisa ser cancelada antes.
+	ErrPendingCharge = errors.New("receivable has a pending charge; cancel the charge first")
+)
+
+// Version guarda os termos acordados em cada versao do contrato. A versao 1
+// corresponde aos termos originais; cada aditivo gera uma nova versao.
+type Version struct {
+	ID            string     `db:"id" json:"id"`
+	ContractID    string     `db:"contract_id" json:"contract_id"`
+	Version       int        `db:"version" json:"version"`
+	ValueTotal    float64    `db:"value_total" json:"value_total"`
+	StartDate     time.Time  `db:"start_date" json:"start_date"`
+	EndDate       *time.Time `db:"end_date" json:"end_date,omitempty"`
+	Reason        string     `db:"reason" json:"reason,omitempty"`
+	EffectiveDate time.Time  `db:"effective_date" json:"effective_date"`
+	CreatedBy     *string    `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Amendment descreve os novos termos de um aditivo. Contas a receber em
+// aberto com vencimento a partir de EffectiveDate acompanham o novo valor;
+// ValueTotal nulo mantem o valor vigente.
+type Amendment struct {
+	ValueTotal    *float64
+	StartDate     time.Time
+	EndDate       *time.Time
+	Reason        string
+	EffectiveDate time.Time
+	ActorID       string
+}
+
+// Renewal descreve o contrato sucessor criado na renovacao.
+// Campos vazios herdam do contrato original: o valor eh mantido e o inicio
+// eh o dia seguinte ao termino do original.
+type Renewal struct {
+	ValueTotal *float64
+	StartDate  time.Time
+	EndDate    *time.Time
+	Reason     string
+	ActorID    string
+}
@@ -0,0 +1,189 @@
+package contractdoc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rgomids/bckoffice/pkg/document"
+	"github.com/rgomids/bckoffice/pkg/pdf"
+)
+
+// Repository define operacoes sobre modelos e documentos de contrato.
+type Repository interface {
+	TemplateFor(ctx context.Context, serviceID string) (Template, error)
+	SaveTemplate(ctx context.Context, t *Template) error
+	DeleteTemplate(ctx context.Context, serviceID string) error
+	LoadData(ctx context.Context, contractID string) (Data, error)
+	AddAttachment(ctx context.Context, a *Attachment) error
+}
+
+// ErrRender indica falha ao executar o modelo com os dados do contrato.
+var ErrRender = errors.New("contract template could not be rendered")
+
+// DefaultTemplate eh usado pelos servicos sem modelo proprio.
+const DefaultTemplate = `# CONTRATO DE PRESTAÇÃO DE SERVIÇOS
+
+Contrato n. {{.Contract.ID}} (versão {{.Contract.Version}})
+
+# PARTES
+
+CONTRATANTE: {{.Customer.LegalName}}{{with .Customer.DocumentID}}, inscrito(a) sob o documento {{doc .}}{{end}}{{with .Address}}, com endereço em {{.Street}}{{with .Number}}, {{.}}{{end}}{{with .Complement}} - {{.}}{{end}}{{with .District}}, {{.}}{{end}}, {{.City}}/{{.State}}{{with .PostalCode}}, CEP {{.}}{{end}}{{end}}.
+
+# OBJETO
+
+Prestação do serviço {{.Service.Name}}.{{with .Service.Description}}
+{{.}}{{end}}
+
+# VALOR E VIGÊNCIA
+
+Valor total de {{money .Contract.ValueTotal}}, com vigência a partir de {{date .Contract.StartDate}}{{with .Contract.EndDate}} até {{date .}}{{end}}.
+{{if .Installments}}
+# PARCELAS
+{{range .Installments}}
+{{.Number}}. {{date .DueDate}} - {{money .Amount}}{{end}}
+{{end}}
+{{with .Promoter}}Promotor responsável: {{.FullName}}.
+{{end}}
+Documento gerado em {{date .GeneratedAt}}.
+`
+
+var funcs = template.FuncMap{
+	"money": Money,
+	"date":  formatDate,
+	"doc":   formatDocument,
+	"upper": strings.ToUpper,
+}
+
+// Parse compila o corpo de um modelo com as funcoes disponiveis:
+// money, date, doc e upper.
+func Parse(body string) (*template.Template, error) {
+	return template.New("contract").Funcs(funcs).Option("missingkey=error").Parse(body)
+}
+
+// Validate compila o modelo e o executa com dados de exemplo, rejeitando
+// placeholders inexistentes.
+func Validate(body string) error {
+	_, err := Render(body, SampleData())
+	return err
+}
+
+// Render executa o modelo e converte o texto em PDF. Linhas iniciadas por
+// "# " viram titulos e linhas em branco separam paragrafos.
+func Render(body string, data Data) ([]byte, error) {
+	t, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := t.Execute(&out, data); err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			doc.Paragraph(strings.Join(para, "\n"))
+			para = nil
+		}
+	}
+	for _, l := range strings.Split(out.String(), "\n") {
+		switch {
+		case strings.HasPrefix(l, "# "):
+			flush()
+			doc.Heading(strings.TrimPrefix(l, "# "))
+		case strings.TrimSpace(l) == "":
+			flush()
+		default:
+			para = append(para, l)
+		}
+	}
+	flush()
+	return doc.Bytes(), nil
+}
+
+// Generate carrega os dados do contrato e renderiza o modelo do servico.
+func Generate(ctx context.Context, repo Repository, contractID string, now time.Time) ([]byte, Data, error) {
+	data, err := repo.LoadData(ctx, contractID)
+	if err != nil {
+		return nil, Data{}, err
+	}
+	t, err := repo.TemplateFor(ctx, data.Contract.ServiceID)
+	if err != nil {
+		return nil, Data{}, err
+	}
+	data.GeneratedAt = now
+	out, err := Render(t.Body, data)
+	if err != nil {
+		return nil, Data{}, fmt.Errorf("%w: %v", ErrRender, err)
+	}
+	return out, data, nil
+}
+
+// SampleData retorna dados ficticios usados na validacao de modelos.
+func SampleData() Data {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, -1)
+	return Data{
+		Contract: ContractData{ID: "01HZEXEMPLO", ValueTotal: 12000, StartDate: start, EndDate: &end, Status: "active", Version: 1},
+		Customer: CustomerData{LegalName: "Cliente Exemplo Ltda", DocumentID: "11222333000181", Email: "contato@exemplo.com"},
+		Address:  &AddressData{Street: "Rua Exemplo", Number: "100", District: "Centro", City: "Sao Paulo", State: "SP", PostalCode: "01001000"},
+		Service:  ServiceData{Name: "Servico Exemplo", BasePrice: 1000},
+		Promoter: &PromoterData{FullName: "Promotor Exemplo"},
+		Installments: []Installment{
+			{Number: 1, DueDate: start, Amount: 6000, Status: "open"},
+			{Number: 2, DueDate: start.AddDate(0, 6, 0), Amount: 6000, Status: "open"},
+		},
+		GeneratedAt: start,
+	}
+}
+
+// Money formata valores no padrao brasileiro, ex.: R$ 1.234,56.
+func Money(v float64) string {
+	neg := v < 0
+	cents := int64(math.Round(math.Abs(v) * 100))
+	intPart := fmt.Sprintf("%d", cents/100)
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	s := fmt.Sprintf("R$ %s,%02d", b.String(), cents%100)
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+func formatDate(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format("02/01/2006")
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.Format("02/01/2006")
+	}
+	return fmt.Sprint(v)
+}
+
+// formatDocument aplica a mascara de CPF ou CNPJ.
+func formatDocument(s string) string {
+	d := document.Normalize(s)
+	switch len(d) {
+	case 11:
+		return d[0:3] + "." + d[3:6] + "." + d[6:9] + "-" + d[9:]
+	case 14:
+		return d[0:2] + "." + d[2:5] + "." + d[5:8] + "/" + d[8:12] + "-" + d[12:]
+	}
+	return s
+}
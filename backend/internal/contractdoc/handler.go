@@ -0,0 +1,166 @@
+package contractdoc
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/oklog/ulid/v2"
+)
+
+// RegisterRoutes adiciona as rotas de modelos e documentos de contrato.
+func RegisterRoutes(r chi.Router, repo Repository, storage Storage) {
+	h := handler{repo: repo, storage: storage, validate: validator.New(), now: time.Now}
+	r.Get("/services/{id}/contract-template", h.getTemplate)
+	r.Put("/services/{id}/contract-template", h.saveTemplate)
+	r.Delete("/services/{id}/contract-template", h.deleteTemplate)
+	r.Get("/contracts/{id}/document.pdf", h.document)
+}
+
+type handler struct {
+	repo     Repository
+	storage  Storage
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+// TemplateInput define o payload de um modelo de contrato.
+type TemplateInput struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Body string `json:"body" validate:"required"`
+}
+
+// @Summary      Retorna o modelo de contrato do servico
+// @Tags         contract-templates
+// @Security     BearerAuth
+// @Success      200  {object}  Template
+// @Router       /services/{id}/contract-template [get]
+func (h handler) getTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	t, err := h.repo.TemplateFor(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// @Summary      Cria ou substitui o modelo de contrato do servico
+// @Tags         contract-templates
+// @Security     BearerAuth
+// @Success      200  {object}  Template
+// @Router       /services/{id}/contract-template [put]
+func (h handler) saveTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	serviceID := chi.URLParam(r, "id")
+
+	var in TemplateInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	in.Name = strings.TrimSpace(in.Name)
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if err := Validate(in.Body); err != nil {
+		writeBadRequest(w, fmt.Errorf("invalid template: %w", err))
+		return
+	}
+
+	t := Template{ID: ulid.Make().String(), ServiceID: serviceID, Name: in.Name, Body: in.Body}
+	if err := h.repo.SaveTemplate(r.Context(), &t); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("contract_templates:%s", t.ID))
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// @Summary      Remove o modelo de contrato do servico
+// @Tags         contract-templates
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /services/{id}/contract-template [delete]
+func (h handler) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	serviceID := chi.URLParam(r, "id")
+	if err := h.repo.DeleteTemplate(r.Context(), serviceID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("services:%s", serviceID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Gera o documento PDF do contrato
+// @Description  Com store=true o arquivo gerado tambem eh salvo como anexo do contrato.
+// @Tags         contracts
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        store  query  bool  false  "Armazena o PDF em contract_attachments"
+// @Success      200  {file}  file
+// @Router       /contracts/{id}/document.pdf [get]
+func (h handler) document(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	store, _ := strconv.ParseBool(r.URL.Query().Get("store"))
+
+	out, data, err := Generate(r.Context(), h.repo, id, h.now())
+	if err != nil {
+		if errors.Is(err, ErrRender) {
+			// o modelo foi validado ao salvar; falhas aqui sao dados inesperados
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		writeError(w, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("contrato-%s-v%d.pdf", id, data.Contract.Version)
+	if store {
+		a := Attachment{
+			ID:         ulid.Make().String(),
+			ContractID: id,
+			FileName:   fileName,
+			MimeType:   "application/pdf",
+			SizeBytes:  int64(len(out)),
+		}
+		if a.StorageURL, err = h.storage.Put(r.Context(), "contracts/"+id+"/"+a.ID+".pdf", out); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if err = h.repo.AddAttachment(r.Context(), &a); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Attachment-ID", a.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, fileName))
+	w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	_, _ = w.Write(out)
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
@@ -0,0 +1,183 @@
+package contractdoc
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepository struct {
+	templates   map[string]Template
+	data        map[string]Data
+	attachments []Attachment
+}
+
+func (f *fakeRepository) TemplateFor(ctx context.Context, serviceID string) (Template, error) {
+	if t, ok := f.templates[serviceID]; ok {
+		return t, nil
+	}
+	return Template{ServiceID: serviceID, Body: DefaultTemplate, Default: true}, nil
+}
+
+func (f *fakeRepository) SaveTemplate(ctx context.Context, t *Template) error {
+	f.templates[t.ServiceID] = *t
+	return nil
+}
+
+func (f *fakeRepository) DeleteTemplate(ctx context.Context, serviceID string) error {
+	if _, ok := f.templates[serviceID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.templates, serviceID)
+	return nil
+}
+
+func (f *fakeRepository) LoadData(ctx context.Context, contractID string) (Data, error) {
+	d, ok := f.data[contractID]
+	if !ok {
+		return Data{}, sql.ErrNoRows
+	}
+	return d, nil
+}
+
+func (f *fakeRepository) AddAttachment(ctx context.Context, a *Attachment) error {
+	f.attachments = append(f.attachments, *a)
+	return nil
+}
+
+type memoryStorage map[string][]byte
+
+func (m memoryStorage) Put(_ context.Context, key string, data []byte) (string, error) {
+	m[key] = data
+	return "mem://" + key, nil
+}
+
+func newFake() *fakeRepository {
+	d := SampleData()
+	d.Contract.ServiceID = "s1"
+	return &fakeRepository{templates: map[string]Template{}, data: map[string]Data{"k1": d}}
+}
+
+func setupRouter(repo Repository, storage Storage, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo, storage)
+	return r, token
+}
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestContractDocumentPDF(t *testing.T) {
+	repo := newFake()
+	storage := memoryStorage{}
+	r, token := setupRouter(repo, storage, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodGet, server.URL+"/contracts/k1/document.pdf", token, "")
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("expected PDF, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !bytes.HasPrefix(body, []byte("%PDF-")) {
+		t.Fatal("response is not a PDF")
+	}
+	if len(repo.attachments) != 0 {
+		t.Fatal("document must not be stored without store=true")
+	}
+
+	resp = do(t, http.MethodGet, server.URL+"/contracts/k1/document.pdf?store=true", token, "")
+	resp.Body.Close()
+	if len(repo.attachments) != 1 || len(storage) != 1 {
+		t.Fatalf("expected stored attachment, got %d attachments", len(repo.attachments))
+	}
+	if resp.Header.Get("X-Attachment-ID") != repo.attachments[0].ID {
+		t.Fatal("expected X-Attachment-ID header")
+	}
+
+	resp = do(t, http.MethodGet, server.URL+"/contracts/missing/document.pdf", token, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestSaveTemplateValidatesPlaceholders(t *testing.T) {
+	repo := newFake()
+	r, token := setupRouter(repo, memoryStorage{}, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/services/s1/contract-template", token, `{"name":"Basico","body":"{{.Customer.Unknown}}"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+
+	resp = do(t, http.MethodPut, server.URL+"/services/s1/contract-template", token,
+		`{"name":"Basico","body":"# {{upper .Service.Name}}\n{{.Customer.LegalName}} - {{money .Contract.ValueTotal}}"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	resp = do(t, http.MethodGet, server.URL+"/services/s1/contract-template", token, "")
+	var tpl Template
+	if err := json.NewDecoder(resp.Body).Decode(&tpl); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	resp.Body.Close()
+	if tpl.Default || tpl.Name != "Basico" {
+		t.Fatalf("expected saved template, got %+v", tpl)
+	}
+}
+
+func TestSaveTemplateRequiresAdmin(t *testing.T) {
+	r, token := setupRouter(newFake(), memoryStorage{}, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/services/s1/contract-template", token, `{"name":"x","body":"x"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestMoneyAndDocumentFormat(t *testing.T) {
+	if got := Money(1234567.891); got != "R$ 1.234.567,89" {
+		t.Fatalf("unexpected money format %q", got)
+	}
+	if got := formatDocument("11222333000181"); got != "11.222.333/0001-81" {
+		t.Fatalf("unexpected document format %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+package contractdoc
+
+import "time"
+
+// Template guarda o modelo de contrato de um servico, escrito na sintaxe
+// de text/template.
+type Template struct {
+	ID        string     `db:"id" json:"id,omitempty"`
+	ServiceID string     `db:"service_id" json:"serviceID"`
+	Name      string     `db:"name" json:"name"`
+	Body      string     `db:"body" json:"body"`
+	Default   bool       `db:"-" json:"default"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+}
+
+// Attachment representa um arquivo anexado ao contrato.
+type Attachment struct {
+	ID         string    `db:"id" json:"id"`
+	ContractID string    `db:"contract_id" json:"contractID"`
+	FileName   string    `db:"file_name" json:"fileName"`
+	StorageURL string    `db:"storage_url" json:"storageURL"`
+	MimeType   string    `db:"mime_type" json:"mimeType"`
+	SizeBytes  int64     `db:"size_bytes" json:"sizeBytes"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+}
+
+// Data contem os valores disponiveis nos placeholders do modelo.
+type Data struct {
+	Contract     ContractData
+	Customer     CustomerData
+	Address      *AddressData
+	Service      ServiceData
+	Promoter     *PromoterData
+	Installments []Installment
+	GeneratedAt  time.Time
+}
+
+// ContractData resume o contrato.
+type ContractData struct {
+	ID         string     `db:"id"`
+	CustomerID string     `db:"customer_id"`
+	ServiceID  string     `db:"service_id"`
+	PromoterID *string    `db:"promoter_id"`
+	ValueTotal float64    `db:"value_total"`
+	StartDate  time.Time  `db:"start_date"`
+	EndDate    *time.Time `db:"end_date"`
+	Status     string     `db:"status"`
+	Version    int        `db:"current_version"`
+}
+
+// CustomerData resume o cliente contratante.
+type CustomerData struct {
+	LegalName  string `db:"legal_name"`
+	TradeName  string `db:"trade_name"`
+	DocumentID string `db:"document_id"`
+	Email      string `db:"email"`
+	Phone      string `db:"phone"`
+}
+
+// AddressData eh o endereco principal de cobranca do cliente.
+type AddressData struct {
+	Street     string `db:"street"`
+	Number     string `db:"number"`
+	Complement string `db:"complement"`
+	District   string `db:"district"`
+	City       string `db:"city"`
+	State      string `db:"state"`
+	PostalCode string `db:"postal_code"`
+}
+
+// ServiceData resume o servico contratado.
+type ServiceData struct {
+	Name        string  `db:"name"`
+	Description string  `db:"description"`
+	BasePrice   float64 `db:"base_price"`
+}
+
+// PromoterData identifica o promotor responsavel.
+type PromoterData struct {
+	FullName string `db:"full_name"`
+}
+
+// Installment eh uma parcela do contrato em contas a receber.
+type Installment struct {
+	Number  int       `db:"-"`
+	DueDate time.Time `db:"due_date"`
+	Amount  float64   `db:"amount"`
+	Status  string    `db:"status"`
+}
@@ -0,0 +1,128 @@
+package contractdoc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// TemplateFor retorna o modelo do servico ou DefaultTemplate quando o
+// servico nao possui modelo proprio.
+func (r *PostgresRepository) TemplateFor(ctx context.Context, serviceID string) (Template, error) {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM services WHERE id=$1 AND deleted_at IS NULL`, serviceID); err != nil {
+		return Template{}, err
+	}
+	var t Template
+	const q = `SELECT * FROM contract_templates WHERE service_id=$1 AND deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &t, q, serviceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Template{ServiceID: serviceID, Name: "Modelo padrao", Body: DefaultTemplate, Default: true}, nil
+	}
+	return t, err
+}
+
+// SaveTemplate cria ou substitui o modelo do servico.
+func (r *PostgresRepository) SaveTemplate(ctx context.Context, t *Template) error {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM services WHERE id=$1 AND deleted_at IS NULL`, t.ServiceID); err != nil {
+		return err
+	}
+	const q = `INSERT INTO contract_templates (id, service_id, name, body) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (service_id) WHERE deleted_at IS NULL
+        DO UPDATE SET name=EXCLUDED.name, body=EXCLUDED.body, updated_at=now()
+        RETURNING id, created_at, updated_at`
+	return r.db.QueryRowxContext(ctx, q, t.ID, t.ServiceID, t.Name, t.Body).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// DeleteTemplate remove logicamente o modelo; o servico volta a usar o padrao.
+func (r *PostgresRepository) DeleteTemplate(ctx context.Context, serviceID string) error {
+	const q = `UPDATE contract_templates SET deleted_at=now() WHERE service_id=$1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, serviceID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// LoadData reune os dados do contrato usados nos placeholders.
+func (r *PostgresRepository) LoadData(ctx context.Context, contractID string) (Data, error) {
+	var d Data
+	const qc = `SELECT id, customer_id, service_id, promoter_id, value_total, start_date, end_date, status, current_version
+        FROM contracts WHERE id=$1 AND deleted_at IS NULL`
+	if err := r.db.GetContext(ctx, &d.Contract, qc, contractID); err != nil {
+		return Data{}, err
+	}
+
+	const qcu = `SELECT legal_name, COALESCE(trade_name,'') AS trade_name, COALESCE(document_id,'') AS document_id,
+        COALESCE(email,'') AS email, COALESCE(phone,'') AS phone FROM customers WHERE id=$1`
+	if err := r.db.GetContext(ctx, &d.Customer, qcu, d.Contract.CustomerID); err != nil {
+		return Data{}, err
+	}
+
+	var addr AddressData
+	const qa = `SELECT street, COALESCE(number,'') AS number, COALESCE(complement,'') AS complement,
+        COALESCE(district,'') AS district, city, state, COALESCE(postal_code,'') AS postal_code
+        FROM addresses WHERE customer_id=$1 AND deleted_at IS NULL
+        ORDER BY (address_type='billing') DESC, is_primary DESC, created_at LIMIT 1`
+	err := r.db.GetContext(ctx, &addr, qa, d.Contract.CustomerID)
+	switch {
+	case err == nil:
+		d.Address = &addr
+	case !errors.Is(err, sql.ErrNoRows):
+		return Data{}, err
+	}
+
+	const qs = `SELECT name, COALESCE(description,'') AS description, base_price FROM services WHERE id=$1`
+	if err := r.db.GetContext(ctx, &d.Service, qs, d.Contract.ServiceID); err != nil {
+		return Data{}, err
+	}
+
+	if d.Contract.PromoterID != nil {
+		var p PromoterData
+		if err := r.db.GetContext(ctx, &p, `SELECT full_name FROM promoters WHERE id=$1`, *d.Contract.PromoterID); err == nil {
+			d.Promoter = &p
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return Data{}, err
+		}
+	}
+
+	d.Installments = []Installment{}
+	const qi = `SELECT due_date, amount, status FROM accounts_receivable
+        WHERE contract_id=$1 AND deleted_at IS NULL AND status <> 'cancelled' ORDER BY due_date, id`
+	if err := r.db.SelectContext(ctx, &d.Installments, qi, contractID); err != nil {
+		return Data{}, err
+	}
+	for i := range d.Installments {
+		d.Installments[i].Number = i + 1
+	}
+	return d, nil
+}
+
+// AddAttachment registra um arquivo gerado como anexo do contrato.
+func (r *PostgresRepository) AddAttachment(ctx context.Context, a *Attachment) error {
+	const q = `INSERT INTO contract_attachments (id, contract_id, file_name, storage_url, mime_type, size_bytes)
+        VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+	return r.db.GetContext(ctx, &a.CreatedAt, q, a.ID, a.ContractID, a.FileName, a.StorageURL, a.MimeType, a.SizeBytes)
+}
+
+var _ Repository = (*PostgresRepository)(nil)
@@ -0,0 +1,79 @@
+package contractdoc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage grava arquivos gerados e retorna o endereco armazenado em
+// contract_attachments.storage_url.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte) (string, error)
+}
+
+// LocalStorage grava os arquivos em um diretorio local.
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage cria um LocalStorage no diretorio informado.
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+// StorageFromEnv le CONTRACT_DOCUMENTS_DIR, usando data/contract-documents como padrao.
+func StorageFromEnv() *LocalStorage {
+	dir := os.Getenv("CONTRACT_DOCUMENTS_DIR")
+	if dir == "" {
+		dir = "data/contract-documents"
+	}
+	return NewLocalStorage(dir)
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, data []byte) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return "", err
+	}
+	return "file://" + filepath.ToSlash(path), nil
+}
+
+// Get le um arquivo gravado por Put a partir do endereco armazenado.
+func (s *LocalStorage) Get(_ context.Context, url string) ([]byte, error) {
+	path, err := s.path(url)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// Delete remove um arquivo gravado por Put; arquivo inexistente nao eh erro.
+func (s *LocalStorage) Delete(_ context.Context, url string) error {
+	path, err := s.path(url)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// ErrOutsideStorage eh retornado para enderecos fora do diretorio do storage.
+var ErrOutsideStorage = errors.New("file outside the storage directory")
+
+// path converte o endereco em caminho local, recusando arquivos fora de dir.
+func (s *LocalStorage) path(url string) (string, error) {
+	path := filepath.FromSlash(strings.TrimPrefix(url, "file://"))
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideStorage
+	}
+	return path, nil
+}
@@ -0,0 +1,33 @@
+package contractdoc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocalStorage(t.TempDir())
+
+	url, err := s.Put(ctx, "contracts/k1/a1.pdf", []byte("%PDF"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if data, err := s.Get(ctx, url); err != nil || string(data) != "%PDF" {
+		t.Fatalf("get: %q %v", data, err)
+	}
+	if err := s.Delete(ctx, url); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := s.Get(ctx, url); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected removed file, got %v", err)
+	}
+	if err := s.Delete(ctx, url); err != nil {
+		t.Fatalf("deleting a missing file must not fail: %v", err)
+	}
+	if err := s.Delete(ctx, "file:///etc/passwd"); !errors.Is(err, ErrOutsideStorage) {
+		t.Fatalf("expected ErrOutsideStorage, got %v", err)
+	}
+}
@@ -15,6 +15,7 @@ type Address struct {
 	State       string     `db:"state" json:"state"`
 	PostalCode  string     `db:"postal_code" json:"postalCode"`
 	Country     string     `db:"country" json:"country"`
+	IsPrimary   bool       `db:"is_primary" json:"isPrimary"`
 	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updatedAt"`
 	DeletedAt   *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
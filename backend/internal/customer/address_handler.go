@@ -0,0 +1,217 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+// errIncompleteAddress indica endereco sem street, city ou state mesmo apos a consulta de CEP.
+var errIncompleteAddress = errors.New("street, city and state are required")
+
+// newAddress converte o payload recebido em um Address do cliente informado.
+func newAddress(customerID string, a AddressInput) Address {
+	return Address{
+		CustomerID:  customerID,
+		AddressType: a.AddressType,
+		Street:      a.Street,
+		Number:      a.Number,
+		Complement:  a.Complement,
+		District:    a.District,
+		City:        a.City,
+		State:       a.State,
+		PostalCode:  a.PostalCode,
+		Country:     a.Country,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// completeAddress preenche o endereco pelo CEP e valida os campos obrigatorios.
+func (h handler) completeAddress(ctx context.Context, a *Address) error {
+	if err := fillFromCEP(ctx, h.cep, a); err != nil && !errors.Is(err, ErrCEPNotFound) {
+		if a.Street == "" || a.City == "" || a.State == "" {
+			return err
+		}
+	}
+	if a.Street == "" || a.City == "" || a.State == "" {
+		return errIncompleteAddress
+	}
+	return nil
+}
+
+// writeAddressError traduz falhas de completeAddress em respostas HTTP.
+func writeAddressError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errIncompleteAddress) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+}
+
+// @Summary      Lista enderecos do cliente
+// @Tags         customers
+// @Security     BearerAuth
+// @Success      200  {array}  Address
+// @Router       /customers/{id}/addresses [get]
+func (h handler) listAddresses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	addresses, err := h.repo.ListAddresses(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(addresses)
+}
+
+// @Summary      Adiciona endereco ao cliente
+// @Tags         customers
+// @Security     BearerAuth
+// @Success      201  {object}  Address
+// @Router       /customers/{id}/addresses [post]
+func (h handler) addAddress(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in AddressInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	a := newAddress(id, in)
+	a.ID = ulid.Make().String()
+	if err := h.completeAddress(r.Context(), &a); err != nil {
+		writeAddressError(w, err)
+		return
+	}
+
+	if err := h.repo.AddAddress(r.Context(), &a); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/customers/"+id+"/addresses/"+a.ID)
+	w.Header().Set("X-Entity", fmt.Sprintf("addresses:%s", a.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(a)
+}
+
+// @Summary      Atualiza endereco do cliente
+// @Tags         customers
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /customers/{id}/addresses/{addressID} [put]
+func (h handler) updateAddress(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+	addressID := chi.URLParam(r, "addressID")
+
+	var in AddressInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	a := newAddress(id, in)
+	a.ID = addressID
+	if err := h.completeAddress(r.Context(), &a); err != nil {
+		writeAddressError(w, err)
+		return
+	}
+
+	if err := h.repo.UpdateAddress(r.Context(), &a); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("addresses:%s", addressID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Remove endereco do cliente
+// @Tags         customers
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /customers/{id}/addresses/{addressID} [delete]
+func (h handler) removeAddress(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	addressID := chi.URLParam(r, "addressID")
+	if err := h.repo.SoftDeleteAddress(r.Context(), id, addressID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("addresses:%s", addressID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Define endereco principal do tipo
+// @Tags         customers
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /customers/{id}/addresses/{addressID}/primary [put]
+func (h handler) setPrimaryAddress(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	addressID := chi.URLParam(r, "addressID")
+	if err := h.repo.SetPrimaryAddress(r.Context(), id, addressID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("addresses:%s", addressID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Consulta endereco pelo CEP
+// @Tags         customers
+// @Security     BearerAuth
+// @Success      200  {object}  CEPInfo
+// @Router       /cep/{cep} [get]
+func (h handler) lookupCEP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	info, err := h.cep.Lookup(r.Context(), chi.URLParam(r, "cep"))
+	if err != nil {
+		if errors.Is(err, ErrCEPNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(info)
+}
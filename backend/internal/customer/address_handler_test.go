@@ -0,0 +1,154 @@
+package customer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func createTestCustomer(t *testing.T, serverURL string) Customer {
+	t.Helper()
+	body := strings.NewReader(`{"legal_name":"ACME","document_id":"123.456.789-09","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
+	resp, err := http.Post(serverURL+"/customers", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /customers error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var c Customer
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		t.Fatalf("decode created: %v", err)
+	}
+	return c
+}
+
+func TestAddAddressFillsFromCEP(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	c := createTestCustomer(t, server.URL)
+
+	body := strings.NewReader(`{"address_type":"shipping","number":"1000","postal_code":"01310-100"}`)
+	resp, err := http.Post(server.URL+"/customers/"+c.ID+"/addresses", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /customers/{id}/addresses error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	var a Address
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if a.Street != "Avenida Paulista" || a.City != "Sao Paulo" || a.State != "SP" || a.PostalCode != "01310100" {
+		t.Fatalf("address not filled from cep: %+v", a)
+	}
+}
+
+func TestAddAddressUnknownCEP(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	c := createTestCustomer(t, server.URL)
+
+	body := strings.NewReader(`{"address_type":"shipping","postal_code":"99999-999"}`)
+	resp, err := http.Post(server.URL+"/customers/"+c.ID+"/addresses", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /customers/{id}/addresses error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestAddressLifecycle(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	c := createTestCustomer(t, server.URL)
+
+	body := strings.NewReader(`{"address_type":"billing","street":"B","city":"X","state":"Y"}`)
+	resp, err := http.Post(server.URL+"/customers/"+c.ID+"/addresses", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /customers/{id}/addresses error: %v", err)
+	}
+	defer resp.Body.Close()
+	var added Address
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		t.Fatalf("decode added: %v", err)
+	}
+
+	base := server.URL + "/customers/" + c.ID + "/addresses/" + added.ID
+
+	upd := strings.NewReader(`{"address_type":"billing","street":"C","city":"X","state":"Y"}`)
+	req, _ := http.NewRequest(http.MethodPut, base, upd)
+	req.Header.Set("Content-Type", "application/json")
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT address error: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp2.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodPut, base+"/primary", nil)
+	resp3, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT primary error: %v", err)
+	}
+	resp3.Body.Close()
+	if resp3.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp3.StatusCode)
+	}
+
+	resp4, err := http.Get(server.URL + "/customers/" + c.ID + "/addresses")
+	if err != nil {
+		t.Fatalf("GET addresses error: %v", err)
+	}
+	defer resp4.Body.Close()
+	var list []Address
+	if err := json.NewDecoder(resp4.Body).Decode(&list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 addresses, got %d", len(list))
+	}
+	for _, a := range list {
+		if a.ID == added.ID && (!a.IsPrimary || a.Street != "C") {
+			t.Fatalf("address not updated: %+v", a)
+		}
+		if a.ID != added.ID && a.IsPrimary {
+			t.Fatalf("previous primary kept: %+v", a)
+		}
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, base, nil)
+	resp5, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE address error: %v", err)
+	}
+	resp5.Body.Close()
+	if resp5.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp5.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, base, nil)
+	resp6, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE address error: %v", err)
+	}
+	resp6.Body.Close()
+	if resp6.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp6.StatusCode)
+	}
+}
@@ -0,0 +1,145 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrCEPNotFound eh retornado quando o CEP consultado nao existe.
+var ErrCEPNotFound = errors.New("cep not found")
+
+// CEPInfo contem os dados de logradouro retornados pela consulta de CEP.
+type CEPInfo struct {
+	PostalCode string `json:"postalCode"`
+	Street     string `json:"street"`
+	Complement string `json:"complement"`
+	District   string `json:"district"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+}
+
+// CEPService consulta dados de endereco a partir de um CEP.
+type CEPService interface {
+	Lookup(ctx context.Context, cep string) (CEPInfo, error)
+}
+
+// NormalizeCEP mantem apenas os digitos do CEP.
+func NormalizeCEP(cep string) string {
+	var b strings.Builder
+	for _, r := range cep {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// HttpCEPService implementa CEPService usando uma API no formato do ViaCEP.
+type HttpCEPService struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewHttpCEPService cria um HttpCEPService com timeout configurado.
+// Se baseURL for vazio, usa https://viacep.com.br/ws como provedor padrao.
+func NewHttpCEPService(baseURL string) *HttpCEPService {
+	timeout := 5 * time.Second
+	if v := os.Getenv("CEP_TIMEOUT_MS"); v != "" {
+		if ms, err := time.ParseDuration(v + "ms"); err == nil {
+			timeout = ms
+		}
+	}
+	if baseURL == "" {
+		baseURL = "https://viacep.com.br/ws"
+	}
+	return &HttpCEPService{client: &http.Client{Timeout: timeout}, baseURL: baseURL}
+}
+
+func (h *HttpCEPService) Lookup(ctx context.Context, cep string) (CEPInfo, error) {
+	cep = NormalizeCEP(cep)
+	if len(cep) != 8 {
+		return CEPInfo{}, ErrCEPNotFound
+	}
+	url := h.baseURL + "/" + cep + "/json/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return CEPInfo{}, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return CEPInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+		return CEPInfo{}, ErrCEPNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return CEPInfo{}, fmt.Errorf("cep lookup: status %d", resp.StatusCode)
+	}
+	var out struct {
+		CEP         string      `json:"cep"`
+		Logradouro  string      `json:"logradouro"`
+		Complemento string      `json:"complemento"`
+		Bairro      string      `json:"bairro"`
+		Localidade  string      `json:"localidade"`
+		UF          string      `json:"uf"`
+		Erro        interface{} `json:"erro"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return CEPInfo{}, err
+	}
+	// o ViaCEP responde 200 com {"erro": true} para CEPs inexistentes
+	if out.Erro != nil {
+		return CEPInfo{}, ErrCEPNotFound
+	}
+	return CEPInfo{
+		PostalCode: NormalizeCEP(out.CEP),
+		Street:     out.Logradouro,
+		Complement: out.Complemento,
+		District:   out.Bairro,
+		City:       out.Localidade,
+		State:      out.UF,
+	}, nil
+}
+
+// StaticCEPService implementa CEPService a partir de um mapa fixo, util em testes.
+type StaticCEPService map[string]CEPInfo
+
+func (s StaticCEPService) Lookup(_ context.Context, cep string) (CEPInfo, error) {
+	info, ok := s[NormalizeCEP(cep)]
+	if !ok {
+		return CEPInfo{}, ErrCEPNotFound
+	}
+	return info, nil
+}
+
+// fillFromCEP completa os campos vazios do endereco com os dados do CEP.
+func fillFromCEP(ctx context.Context, svc CEPService, a *Address) error {
+	a.PostalCode = NormalizeCEP(a.PostalCode)
+	if a.PostalCode == "" || (a.Street != "" && a.District != "" && a.City != "" && a.State != "") {
+		return nil
+	}
+	info, err := svc.Lookup(ctx, a.PostalCode)
+	if err != nil {
+		return err
+	}
+	if a.Street == "" {
+		a.Street = info.Street
+	}
+	if a.District == "" {
+		a.District = info.District
+	}
+	if a.City == "" {
+		a.City = info.City
+	}
+	if a.State == "" {
+		a.State = info.State
+	}
+	return nil
+}
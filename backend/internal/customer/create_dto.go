@@ -1,14 +1,18 @@
 package customer
 
-// AddressInput representa os dados de endereco recebidos na criacao.
+// AddressInput representa os dados de endereco recebidos.
+// Street, city e state podem ser omitidos quando postal_code for informado,
+// sendo preenchidos pela consulta de CEP. ID identifica um endereco existente
+// na atualizacao do cliente.
 type AddressInput struct {
+	ID          string `json:"id"`
 	AddressType string `json:"address_type" validate:"required"`
-	Street      string `json:"street" validate:"required"`
+	Street      string `json:"street" validate:"required_without=PostalCode"`
 	Number      string `json:"number"`
 	Complement  string `json:"complement"`
 	District    string `json:"district"`
-	City        string `json:"city" validate:"required"`
-	State       string `json:"state" validate:"required"`
+	City        string `json:"city" validate:"required_without=PostalCode"`
+	State       string `json:"state" validate:"required_without=PostalCode"`
 	PostalCode  string `json:"postal_code"`
 	Country     string `json:"country"`
 }
@@ -17,7 +21,7 @@ type AddressInput struct {
 type CreateCustomerInput struct {
 	LegalName  string         `json:"legal_name" validate:"required"`
 	TradeName  string         `json:"trade_name"`
-	DocumentID string         `json:"document_id" validate:"required"`
+	DocumentID string         `json:"document_id" validate:"required,cpfcnpj"`
 	Email      string         `json:"email" validate:"omitempty,email"`
 	Phone      string         `json:"phone"`
 	Addresses  []AddressInput `json:"addresses" validate:"required,min=1,dive"`
@@ -8,27 +8,41 @@ import (
 
 // Customer representa um cliente da aplicacao.
 type Customer struct {
-	ID         string     `db:"id" json:"id"`
-	LegalName  string     `db:"legal_name" json:"legalName"`
-	TradeName  string     `db:"trade_name" json:"tradeName"`
-	DocumentID string     `db:"document_id" json:"documentID"`
-	Email      string     `db:"email" json:"email"`
-	Phone      string     `db:"phone" json:"phone"`
-	PromoterID *string    `db:"promoter_id" json:"promoterID,omitempty"`
-	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt  time.Time  `db:"updated_at" json:"updatedAt"`
-	DeletedAt  *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+	ID           string     `db:"id" json:"id"`
+	LegalName    string     `db:"legal_name" json:"legalName"`
+	TradeName    string     `db:"trade_name" json:"tradeName"`
+	DocumentID   string     `db:"document_id" json:"documentID"`
+	PersonType   *string    `db:"person_type" json:"personType,omitempty"`
+	Email        string     `db:"email" json:"email"`
+	Phone        string     `db:"phone" json:"phone"`
+	PromoterID   *string    `db:"promoter_id" json:"promoterID,omitempty"`
+	MergedIntoID *string    `db:"merged_into_id" json:"mergedIntoID,omitempty"`
+	AnonymizedAt *time.Time `db:"anonymized_at" json:"anonymizedAt,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt    *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
 }
 
-// Repository define operacoes de acesso ao armazenamento de clientes.
 // ErrDuplicateDocumentID eh retornado quando ja existe um cliente com o mesmo
 // document_id no banco de dados.
 var ErrDuplicateDocumentID = errors.New("duplicate document_id")
 
+// Repository define operacoes de acesso ao armazenamento de clientes.
+// Em Update, enderecos com ID sao atualizados, sem ID sao inseridos e os
+// ausentes da lista sao removidos logicamente.
 type Repository interface {
-	FindAll(ctx context.Context) ([]Customer, error)
+	FindAll(ctx context.Context, tags []string) ([]Customer, error)
+	Each(ctx context.Context, tags []string, fn func(Customer) error) error
 	FindByID(ctx context.Context, id string) (Customer, error)
+	Detail(ctx context.Context, id string, opts DetailOptions) (CustomerDetail, error)
 	Create(ctx context.Context, c *Customer, addresses []Address) error
 	Update(ctx context.Context, c *Customer, addresses []Address) error
 	SoftDelete(ctx context.Context, id string) error
+	Merge(ctx context.Context, targetID, sourceID, actorID string, dryRun bool) (MergeResult, error)
+
+	ListAddresses(ctx context.Context, customerID string) ([]Address, error)
+	AddAddress(ctx context.Context, a *Address) error
+	UpdateAddress(ctx context.Context, a *Address) error
+	SoftDeleteAddress(ctx context.Context, customerID, addressID string) error
+	SetPrimaryAddress(ctx context.Context, customerID, addressID string) error
 }
@@ -0,0 +1,140 @@
+package customer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DetailOptions define quais secoes compoem a visao 360 do cliente.
+type DetailOptions struct {
+	Addresses   bool
+	Tags        bool
+	Notes       bool
+	Leads       bool
+	Contracts   bool
+	Receivables bool
+	Promoter    bool
+}
+
+// AllDetailSections retorna opcoes com todas as secoes habilitadas.
+func AllDetailSections() DetailOptions {
+	return DetailOptions{
+		Addresses:   true,
+		Tags:        true,
+		Notes:       true,
+		Leads:       true,
+		Contracts:   true,
+		Receivables: true,
+		Promoter:    true,
+	}
+}
+
+func (o *DetailOptions) set(section string, v bool) error {
+	switch section {
+	case "addresses":
+		o.Addresses = v
+	case "tags":
+		o.Tags = v
+	case "notes":
+		o.Notes = v
+	case "leads":
+		o.Leads = v
+	case "contracts":
+		o.Contracts = v
+	case "receivables":
+		o.Receivables = v
+	case "promoter":
+		o.Promoter = v
+	default:
+		return fmt.Errorf("unknown section %q", section)
+	}
+	return nil
+}
+
+// ParseDetailOptions le os parametros include e exclude (listas separadas
+// por virgula). Sem include todas as secoes sao retornadas.
+func ParseDetailOptions(q url.Values) (DetailOptions, error) {
+	opts := AllDetailSections()
+	if inc := q.Get("include"); inc != "" {
+		opts = DetailOptions{}
+		for _, s := range strings.Split(inc, ",") {
+			if err := opts.set(strings.TrimSpace(s), true); err != nil {
+				return DetailOptions{}, err
+			}
+		}
+	}
+	if exc := q.Get("exclude"); exc != "" {
+		for _, s := range strings.Split(exc, ",") {
+			if err := opts.set(strings.TrimSpace(s), false); err != nil {
+				return DetailOptions{}, err
+			}
+		}
+	}
+	return opts, nil
+}
+
+// CustomerDetail representa a visao 360 do cliente.
+type CustomerDetail struct {
+	Customer
+	Addresses   []Address           `json:"addresses,omitempty"`
+	Tags        []TagSummary        `json:"tags,omitempty"`
+	Notes       []NoteSummary       `json:"notes,omitempty"`
+	Leads       []LeadSummary       `json:"leads,omitempty"`
+	Contracts   []ContractSummary   `json:"contracts,omitempty"`
+	Receivables *ReceivablesSummary `json:"receivables,omitempty"`
+	Promoter    *PromoterSummary    `json:"promoter,omitempty"`
+}
+
+// TagSummary resume uma tag associada ao cliente.
+type TagSummary struct {
+	ID   string `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+// NoteSummary resume uma anotacao interna do cliente.
+type NoteSummary struct {
+	ID         string     `db:"id" json:"id"`
+	AuthorID   *string    `db:"author_id" json:"authorID,omitempty"`
+	AuthorName string     `db:"author_name" json:"authorName"`
+	Text       string     `db:"text" json:"text"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	EditedAt   *time.Time `db:"edited_at" json:"editedAt,omitempty"`
+}
+
+// LeadSummary resume um lead do cliente.
+type LeadSummary struct {
+	ID          string    `db:"id" json:"id"`
+	ServiceID   string    `db:"service_id" json:"serviceID"`
+	ServiceName string    `db:"service_name" json:"serviceName"`
+	Status      string    `db:"status" json:"status"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// ContractSummary resume um contrato do cliente.
+type ContractSummary struct {
+	ID          string     `db:"id" json:"id"`
+	ServiceID   string     `db:"service_id" json:"serviceID"`
+	ServiceName string     `db:"service_name" json:"serviceName"`
+	ValueTotal  float64    `db:"value_total" json:"valueTotal"`
+	StartDate   time.Time  `db:"start_date" json:"startDate"`
+	EndDate     *time.Time `db:"end_date" json:"endDate,omitempty"`
+	Status      string     `db:"status" json:"status"`
+}
+
+// ReceivablesSummary totaliza as contas a receber em aberto e vencidas.
+type ReceivablesSummary struct {
+	OpenCount     int     `db:"open_count" json:"openCount"`
+	OpenAmount    float64 `db:"open_amount" json:"openAmount"`
+	OverdueCount  int     `db:"overdue_count" json:"overdueCount"`
+	OverdueAmount float64 `db:"overdue_amount" json:"overdueAmount"`
+}
+
+// PromoterSummary resume o promotor responsavel pelo cliente.
+type PromoterSummary struct {
+	ID       string `db:"id" json:"id"`
+	FullName string `db:"full_name" json:"fullName"`
+	Email    string `db:"email" json:"email,omitempty"`
+	Phone    string `db:"phone" json:"phone,omitempty"`
+}
@@ -0,0 +1,13 @@
+package customer
+
+import "github.com/rgomids/bckoffice/pkg/export"
+
+// exportTable define as colunas da exportacao da listagem de clientes.
+var exportTable = export.Table{
+	Sheet:   "Clientes",
+	Columns: []string{"ID", "Razao social", "Nome fantasia", "Documento", "Tipo", "E-mail", "Telefone", "Promotor", "Criado em"},
+}
+
+func exportRow(c Customer) []any {
+	return []any{c.ID, c.LegalName, c.TradeName, c.DocumentID, c.PersonType, c.Email, c.Phone, c.PromoterID, c.CreatedAt}
+}
@@ -1,6 +1,7 @@
 package customer
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -11,30 +12,65 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/tag"
+	"github.com/rgomids/bckoffice/pkg/document"
+	"github.com/rgomids/bckoffice/pkg/export"
 )
 
 // RegisterRoutes adiciona as rotas do módulo Customer.
-func RegisterRoutes(r chi.Router, repo Repository) {
-	h := handler{repo: repo, validate: validator.New()}
+func RegisterRoutes(r chi.Router, repo Repository, cep CEPService) {
+	v := validator.New()
+	_ = document.RegisterValidation(v)
+	h := handler{repo: repo, cep: cep, validate: v}
 	r.Get("/customers", h.list)
 	r.Post("/customers", h.create)
+	r.Get("/customers/{id}", h.get)
 	r.Put("/customers/{id}", h.update)
 	r.Delete("/customers/{id}", h.remove)
+	r.Post("/customers/{id}/merge", h.merge)
+
+	r.Get("/customers/{id}/addresses", h.listAddresses)
+	r.Post("/customers/{id}/addresses", h.addAddress)
+	r.Put("/customers/{id}/addresses/{addressID}", h.updateAddress)
+	r.Delete("/customers/{id}/addresses/{addressID}", h.removeAddress)
+	r.Put("/customers/{id}/addresses/{addressID}/primary", h.setPrimaryAddress)
+	r.Get("/cep/{cep}", h.lookupCEP)
 }
 
 type handler struct {
 	repo     Repository
+	cep      CEPService
 	validate *validator.Validate
 }
 
 // @Summary      Lista clientes
 // @Tags         customers
 // @Security     BearerAuth
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        tags    query  string  false  "Tags separadas por virgula (todas obrigatorias)"
+// @Param        format  query  string  false  "json|csv|xlsx (ou header Accept)"
 // @Success      200  {array}  Customer
 // @Router       /customers [get]
 func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	format, err := export.Negotiate(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if format != export.JSON {
+		export.Stream(w, format, "clientes", exportTable, func(emit func([]any) error) error {
+			return h.repo.Each(r.Context(), tag.ParseFilter(r.URL.Query()), func(c Customer) error {
+				return emit(exportRow(c))
+			})
+		})
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	customers, err := h.repo.FindAll(r.Context())
+	customers, err := h.repo.FindAll(r.Context(), tag.ParseFilter(r.URL.Query()))
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -42,6 +78,35 @@ func (h handler) list(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(customers)
 }
 
+// @Summary      Detalha cliente (visao 360)
+// @Tags         customers
+// @Security     BearerAuth
+// @Param        include  query  string  false  "Secoes: addresses,tags,notes,leads,contracts,receivables,promoter"
+// @Param        exclude  query  string  false  "Secoes a omitir"
+// @Success      200  {object}  CustomerDetail
+// @Router       /customers/{id} [get]
+func (h handler) get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	opts, err := ParseDetailOptions(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	d, err := h.repo.Detail(r.Context(), chi.URLParam(r, "id"), opts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(d)
+}
+
 // @Summary      Cria cliente
 // @Tags         customers
 // @Security     BearerAuth
@@ -61,34 +126,10 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c := Customer{
-		ID:         ulid.Make().String(),
-		LegalName:  in.LegalName,
-		TradeName:  in.TradeName,
-		DocumentID: in.DocumentID,
-		Email:      in.Email,
-		Phone:      in.Phone,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
-
-	addresses := make([]Address, len(in.Addresses))
-	for i, a := range in.Addresses {
-		addresses[i] = Address{
-			ID:          ulid.Make().String(),
-			CustomerID:  c.ID,
-			AddressType: a.AddressType,
-			Street:      a.Street,
-			Number:      a.Number,
-			Complement:  a.Complement,
-			District:    a.District,
-			City:        a.City,
-			State:       a.State,
-			PostalCode:  a.PostalCode,
-			Country:     a.Country,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
+	c, addresses, err := h.newCustomer(r.Context(), in)
+	if err != nil {
+		writeAddressError(w, err)
+		return
 	}
 
 	if err := h.repo.Create(r.Context(), &c, addresses); err != nil {
@@ -106,6 +147,42 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(c)
 }
 
+// personType classifica o documento em PF ou PJ; documentos invalidos ou
+// ausentes ficam sem tipo.
+func personType(documentID string) *string {
+	t := string(document.Type(documentID))
+	if t == "" {
+		return nil
+	}
+	return &t
+}
+
+// newCustomer monta o cliente e os enderecos (completados pelo CEP) a partir
+// de um payload ja validado.
+func (h handler) newCustomer(ctx context.Context, in CreateCustomerInput) (Customer, []Address, error) {
+	c := Customer{
+		ID:         ulid.Make().String(),
+		LegalName:  in.LegalName,
+		TradeName:  in.TradeName,
+		DocumentID: document.Normalize(in.DocumentID),
+		PersonType: personType(in.DocumentID),
+		Email:      in.Email,
+		Phone:      in.Phone,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	addresses := make([]Address, len(in.Addresses))
+	for i, a := range in.Addresses {
+		addresses[i] = newAddress(c.ID, a)
+		addresses[i].ID = ulid.Make().String()
+		if err := h.completeAddress(ctx, &addresses[i]); err != nil {
+			return Customer{}, nil, err
+		}
+	}
+	return c, addresses, nil
+}
+
 // @Summary      Atualiza cliente
 // @Tags         customers
 // @Security     BearerAuth
@@ -131,7 +208,8 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 		ID:         id,
 		LegalName:  in.LegalName,
 		TradeName:  in.TradeName,
-		DocumentID: in.DocumentID,
+		DocumentID: document.Normalize(in.DocumentID),
+		PersonType: personType(in.DocumentID),
 		Email:      in.Email,
 		Phone:      in.Phone,
 		UpdatedAt:  time.Now(),
@@ -141,20 +219,11 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 	if in.Addresses != nil {
 		addresses = make([]Address, len(in.Addresses))
 		for i, a := range in.Addresses {
-			addresses[i] = Address{
-				ID:          ulid.Make().String(),
-				CustomerID:  id,
-				AddressType: a.AddressType,
-				Street:      a.Street,
-				Number:      a.Number,
-				Complement:  a.Complement,
-				District:    a.District,
-				City:        a.City,
-				State:       a.State,
-				PostalCode:  a.PostalCode,
-				Country:     a.Country,
-				CreatedAt:   time.Now(),
-				UpdatedAt:   time.Now(),
+			addresses[i] = newAddress(id, a)
+			addresses[i].ID = a.ID
+			if err := h.completeAddress(r.Context(), &addresses[i]); err != nil {
+				writeAddressError(w, err)
+				return
 			}
 		}
 	}
@@ -194,3 +263,45 @@ func (h handler) remove(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Entity", fmt.Sprintf("customers:%s", id))
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// @Summary      Mescla cliente duplicado no cliente informado
+// @Description  Move leads, contratos, enderecos, notas e tags de source_id para {id}. Com dry_run apenas lista o que seria movido.
+// @Tags         customers
+// @Security     BearerAuth
+// @Param        input  body      MergeInput  true  "Cliente de origem"
+// @Success      200  {object}  MergeResult
+// @Router       /customers/{id}/merge [post]
+func (h handler) merge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in MergeInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	res, err := h.repo.Merge(r.Context(), id, in.SourceID, auth.UserIDFromContext(r.Context()), in.DryRun)
+	if err != nil {
+		if errors.Is(err, ErrMergeSameCustomer) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("customers:%s", id))
+	_ = json.NewEncoder(w).Encode(res)
+}
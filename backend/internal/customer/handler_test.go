@@ -4,20 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
 )
 
 type fakeRepository struct {
 	customers []Customer
+	addresses []Address
+	lastTags  []string
 }
 
-func (f *fakeRepository) FindAll(ctx context.Context) ([]Customer, error) {
+func (f *fakeRepository) FindAll(ctx context.Context, tags []string) ([]Customer, error) {
+	f.lastTags = tags
 	out := make([]Customer, 0, len(f.customers))
 	for _, c := range f.customers {
 		if c.DeletedAt == nil {
@@ -27,12 +35,43 @@ func (f *fakeRepository) FindAll(ctx context.Context) ([]Customer, error) {
 	return out, nil
 }
 
+func (f *fakeRepository) Each(ctx context.Context, tags []string, fn func(Customer) error) error {
+	list, _ := f.FindAll(ctx, tags)
+	for _, c := range list {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *fakeRepository) FindByID(ctx context.Context, id string) (Customer, error) {
-	return Customer{}, nil
+	for _, c := range f.customers {
+		if c.ID == id && c.DeletedAt == nil {
+			return c, nil
+		}
+	}
+	return Customer{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) Detail(ctx context.Context, id string, opts DetailOptions) (CustomerDetail, error) {
+	c, err := f.FindByID(ctx, id)
+	if err != nil {
+		return CustomerDetail{}, err
+	}
+	d := CustomerDetail{Customer: c}
+	if opts.Addresses {
+		d.Addresses, _ = f.ListAddresses(ctx, id)
+	}
+	if opts.Receivables {
+		d.Receivables = &ReceivablesSummary{}
+	}
+	return d, nil
 }
 
 func (f *fakeRepository) Create(ctx context.Context, c *Customer, addresses []Address) error {
 	f.customers = append(f.customers, *c)
+	f.addresses = append(f.addresses, addresses...)
 	return nil
 }
 
@@ -67,13 +106,122 @@ func (f *fakeRepository) SoftDelete(ctx context.Context, id string) error {
 	return sql.ErrNoRows
 }
 
+func (f *fakeRepository) ListAddresses(ctx context.Context, customerID string) ([]Address, error) {
+	out := make([]Address, 0)
+	for _, a := range f.addresses {
+		if a.CustomerID == customerID && a.DeletedAt == nil {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) AddAddress(ctx context.Context, a *Address) error {
+	for _, c := range f.customers {
+		if c.ID == a.CustomerID && c.DeletedAt == nil {
+			f.addresses = append(f.addresses, *a)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) UpdateAddress(ctx context.Context, a *Address) error {
+	for i, addr := range f.addresses {
+		if addr.ID == a.ID && addr.CustomerID == a.CustomerID && addr.DeletedAt == nil {
+			a.IsPrimary = addr.IsPrimary
+			f.addresses[i] = *a
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) SoftDeleteAddress(ctx context.Context, customerID, addressID string) error {
+	for i, addr := range f.addresses {
+		if addr.ID == addressID && addr.CustomerID == customerID && addr.DeletedAt == nil {
+			now := time.Now()
+			addr.DeletedAt = &now
+			f.addresses[i] = addr
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) SetPrimaryAddress(ctx context.Context, customerID, addressID string) error {
+	var target *Address
+	for i := range f.addresses {
+		if f.addresses[i].ID == addressID && f.addresses[i].CustomerID == customerID && f.addresses[i].DeletedAt == nil {
+			target = &f.addresses[i]
+		}
+	}
+	if target == nil {
+		return sql.ErrNoRows
+	}
+	for i := range f.addresses {
+		if f.addresses[i].CustomerID == customerID && f.addresses[i].AddressType == target.AddressType {
+			f.addresses[i].IsPrimary = false
+		}
+	}
+	target.IsPrimary = true
+	return nil
+}
+
+func (f *fakeRepository) Merge(ctx context.Context, targetID, sourceID, actorID string, dryRun bool) (MergeResult, error) {
+	if targetID == sourceID {
+		return MergeResult{}, ErrMergeSameCustomer
+	}
+	if _, err := f.FindByID(ctx, targetID); err != nil {
+		return MergeResult{}, err
+	}
+	if _, err := f.FindByID(ctx, sourceID); err != nil {
+		return MergeResult{}, err
+	}
+	res := MergeResult{TargetID: targetID, SourceID: sourceID, DryRun: dryRun, Addresses: []string{}}
+	for i, a := range f.addresses {
+		if a.CustomerID == sourceID && a.DeletedAt == nil {
+			res.Addresses = append(res.Addresses, a.ID)
+			if !dryRun {
+				f.addresses[i].CustomerID = targetID
+			}
+		}
+	}
+	if !dryRun {
+		for i, c := range f.customers {
+			if c.ID == sourceID {
+				now := time.Now()
+				f.customers[i].DeletedAt = &now
+				f.customers[i].MergedIntoID = &targetID
+			}
+		}
+	}
+	return res, nil
+}
+
+var testCEP = StaticCEPService{
+	"01310100": {PostalCode: "01310100", Street: "Avenida Paulista", District: "Bela Vista", City: "Sao Paulo", State: "SP"},
+}
+
 func setupRouter() *chi.Mux {
 	r := chi.NewRouter()
 	repo := &fakeRepository{}
-	RegisterRoutes(r, repo)
+	RegisterRoutes(r, repo, testCEP)
 	return r
 }
 
+func setupAuthRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	tokenStr, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo, testCEP)
+	return r, tokenStr
+}
+
 func TestGetCustomersEmpty(t *testing.T) {
 	server := httptest.NewServer(setupRouter())
 	defer server.Close()
@@ -98,11 +246,29 @@ func TestGetCustomersEmpty(t *testing.T) {
 	}
 }
 
+func TestGetCustomersTagFilter(t *testing.T) {
+	repo := &fakeRepository{}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, testCEP)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/customers?tags=vip,%20sp")
+	if err != nil {
+		t.Fatalf("GET /customers error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(repo.lastTags) != 2 || repo.lastTags[0] != "vip" || repo.lastTags[1] != "sp" {
+		t.Fatalf("unexpected tag filter: %v", repo.lastTags)
+	}
+}
+
 func TestPostCustomersAndGet(t *testing.T) {
 	server := httptest.NewServer(setupRouter())
 	defer server.Close()
 
-	body := strings.NewReader(`{"legal_name":"ACME","document_id":"1","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
+	body := strings.NewReader(`{"legal_name":"ACME","document_id":"123.456.789-09","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
 	resp, err := http.Post(server.URL+"/customers", "application/json", body)
 	if err != nil {
 		t.Fatalf("POST /customers error: %v", err)
@@ -132,7 +298,7 @@ func TestPostCustomersAndGet(t *testing.T) {
 		t.Fatalf("expected 1 customer, got %d", len(out))
 	}
 
-	if out[0].LegalName != "ACME" || out[0].DocumentID != "1" {
+	if out[0].LegalName != "ACME" || out[0].DocumentID != "12345678909" {
 		t.Fatalf("unexpected customer data: %+v", out[0])
 	}
 }
@@ -141,7 +307,7 @@ func TestUpdateCustomer(t *testing.T) {
 	server := httptest.NewServer(setupRouter())
 	defer server.Close()
 
-	body := strings.NewReader(`{"legal_name":"ACME","document_id":"1","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
+	body := strings.NewReader(`{"legal_name":"ACME","document_id":"123.456.789-09","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
 	resp, err := http.Post(server.URL+"/customers", "application/json", body)
 	if err != nil {
 		t.Fatalf("POST /customers error: %v", err)
@@ -157,7 +323,7 @@ func TestUpdateCustomer(t *testing.T) {
 		t.Fatalf("decode created: %v", err)
 	}
 
-	updBody := strings.NewReader(`{"legal_name":"ACME Updated","document_id":"1"}`)
+	updBody := strings.NewReader(`{"legal_name":"ACME Updated","document_id":"123.456.789-09"}`)
 	req, err := http.NewRequest(http.MethodPut, server.URL+"/customers/"+created.ID, updBody)
 	if err != nil {
 		t.Fatalf("new request: %v", err)
@@ -201,7 +367,7 @@ func TestDeleteCustomer(t *testing.T) {
 	server := httptest.NewServer(setupRouter())
 	defer server.Close()
 
-	body := strings.NewReader(`{"legal_name":"ACME","document_id":"1","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
+	body := strings.NewReader(`{"legal_name":"ACME","document_id":"123.456.789-09","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
 	resp, err := http.Post(server.URL+"/customers", "application/json", body)
 	if err != nil {
 		t.Fatalf("POST /customers error: %v", err)
@@ -250,3 +416,201 @@ func TestDeleteCustomer(t *testing.T) {
 		t.Fatalf("expected 0 customers, got %d", len(out))
 	}
 }
+
+func TestPostCustomerInvalidDocument(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	body := strings.NewReader(`{"legal_name":"ACME","document_id":"123.456.789-00","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
+	resp, err := http.Post(server.URL+"/customers", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /customers error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestPostCustomerPersonType(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	body := strings.NewReader(`{"legal_name":"ACME","document_id":"11.222.333/0001-81","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
+	resp, err := http.Post(server.URL+"/customers", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /customers error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	var c Customer
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if c.DocumentID != "11222333000181" || c.PersonType == nil || *c.PersonType != "PJ" {
+		t.Fatalf("unexpected document data: %+v", c)
+	}
+}
+
+func TestGetCustomerDetail(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	body := strings.NewReader(`{"legal_name":"ACME","document_id":"123.456.789-09","addresses":[{"address_type":"billing","street":"A","city":"X","state":"Y"}]}`)
+	resp, err := http.Post(server.URL+"/customers", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /customers error: %v", err)
+	}
+	defer resp.Body.Close()
+	var created Customer
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode created: %v", err)
+	}
+
+	resp2, err := http.Get(server.URL + "/customers/" + created.ID + "?exclude=receivables")
+	if err != nil {
+		t.Fatalf("GET /customers/{id} error: %v", err)
+	}
+	defer resp2.Body.Close()
+
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp2.StatusCode)
+	}
+
+	var d CustomerDetail
+	if err := json.NewDecoder(resp2.Body).Decode(&d); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if d.ID != created.ID || len(d.Addresses) != 1 {
+		t.Fatalf("unexpected detail: %+v", d)
+	}
+	if d.Receivables != nil {
+		t.Fatalf("receivables should be excluded: %+v", d.Receivables)
+	}
+}
+
+func TestGetCustomerDetailNotFound(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/customers/missing")
+	if err != nil {
+		t.Fatalf("GET /customers/{id} error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+
+	resp2, err := http.Get(server.URL + "/customers/missing?include=unknown")
+	if err != nil {
+		t.Fatalf("GET /customers/{id} error: %v", err)
+	}
+	defer resp2.Body.Close()
+
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp2.StatusCode)
+	}
+}
+
+func TestMergeCustomers(t *testing.T) {
+	repo := &fakeRepository{
+		customers: []Customer{{ID: "target"}, {ID: "source"}},
+		addresses: []Address{{ID: "a1", CustomerID: "source"}},
+	}
+	router, token := setupAuthRouter(repo, "admin")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	merge := func(body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/customers/target/merge", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /customers/{id}/merge error: %v", err)
+		}
+		return resp
+	}
+
+	resp := merge(`{"source_id":"source","dry_run":true}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var preview MergeResult
+	if err := json.NewDecoder(resp.Body).Decode(&preview); err != nil {
+		t.Fatalf("decode preview: %v", err)
+	}
+	if !preview.DryRun || len(preview.Addresses) != 1 || repo.addresses[0].CustomerID != "source" {
+		t.Fatalf("dry run should not move records: %+v", preview)
+	}
+
+	resp2 := merge(`{"source_id":"source"}`)
+	defer resp2.Body.Close()
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp2.StatusCode)
+	}
+	if repo.addresses[0].CustomerID != "target" {
+		t.Fatalf("address not moved: %+v", repo.addresses[0])
+	}
+	if repo.customers[1].DeletedAt == nil || repo.customers[1].MergedIntoID == nil {
+		t.Fatalf("source not soft deleted: %+v", repo.customers[1])
+	}
+
+	resp3 := merge(`{"source_id":"target"}`)
+	defer resp3.Body.Close()
+	if resp3.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp3.StatusCode)
+	}
+}
+
+func TestMergeCustomersRequiresAdmin(t *testing.T) {
+	repo := &fakeRepository{customers: []Customer{{ID: "target"}, {ID: "source"}}}
+	router, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/customers/target/merge", strings.NewReader(`{"source_id":"source"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /customers/{id}/merge error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestExportCustomersCSV(t *testing.T) {
+	pj := "PJ"
+	repo := &fakeRepository{customers: []Customer{
+		{ID: "c1", LegalName: "Padaria Pão; Cia", DocumentID: "12345678000190", PersonType: &pj, CreatedAt: time.Date(2026, 1, 2, 13, 4, 0, 0, time.UTC)},
+	}}
+	r := chi.NewRouter()
+	RegisterRoutes(r, repo, testCEP)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/customers?format=csv")
+	if err != nil {
+		t.Fatalf("GET /customers error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected content type %s", resp.Header.Get("Content-Type"))
+	}
+	want := `c1;"Padaria Pão; Cia";;12345678000190;PJ;;;;02/01/2026 13:04`
+	if !strings.Contains(string(body), want) {
+		t.Fatalf("unexpected csv:\n%s", body)
+	}
+}
@@ -0,0 +1,79 @@
+package customer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/rgomids/bckoffice/internal/importer"
+	"github.com/rgomids/bckoffice/pkg/document"
+)
+
+// importColumns sao as colunas aceitas no CSV de clientes, com um endereco
+// por linha.
+var importColumns = []string{
+	"legal_name", "trade_name", "document_id", "email", "phone",
+	"address_type", "street", "number", "complement", "district", "city", "state", "postal_code", "country",
+}
+
+// ImportTarget descreve a importacao de clientes em lote. Cada linha passa
+// pelas mesmas regras de CreateCustomerInput e o documento normalizado eh a
+// chave de idempotencia.
+func ImportTarget(repo Repository, cep CEPService) importer.Target {
+	v := validator.New()
+	_ = document.RegisterValidation(v)
+	h := handler{repo: repo, cep: cep, validate: v}
+	return importer.Target{
+		Entity:    "customers",
+		Table:     "customers",
+		KeyColumn: "document_id",
+		Columns:   importColumns,
+		Key: func(row importer.Row) string {
+			return document.Normalize(row.Get("document_id"))
+		},
+		Validate: func(row importer.Row) error {
+			return v.Struct(importInput(row))
+		},
+		Create: h.importRow,
+	}
+}
+
+// importInput converte a linha no payload de criacao. O tipo de endereco
+// vazio assume billing, o padrao da tabela.
+func importInput(row importer.Row) CreateCustomerInput {
+	addressType := row.Get("address_type")
+	if addressType == "" {
+		addressType = "billing"
+	}
+	return CreateCustomerInput{
+		LegalName:  row.Get("legal_name"),
+		TradeName:  row.Get("trade_name"),
+		DocumentID: row.Get("document_id"),
+		Email:      row.Get("email"),
+		Phone:      row.Get("phone"),
+		Addresses: []AddressInput{{
+			AddressType: addressType,
+			Street:      row.Get("street"),
+			Number:      row.Get("number"),
+			Complement:  row.Get("complement"),
+			District:    row.Get("district"),
+			City:        row.Get("city"),
+			State:       row.Get("state"),
+			PostalCode:  row.Get("postal_code"),
+			Country:     row.Get("country"),
+		}},
+	}
+}
+
+func (h handler) importRow(ctx context.Context, row importer.Row) error {
+	c, addresses, err := h.newCustomer(ctx, importInput(row))
+	if err != nil {
+		return err
+	}
+	err = h.repo.Create(ctx, &c, addresses)
+	if errors.Is(err, ErrDuplicateDocumentID) {
+		return importer.ErrDuplicate
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rgomids/bckoffice/internal/importer"
+)
+
+// uniqueRepository rejeita documentos repetidos como o indice unico do banco.
+type uniqueRepository struct {
+	fakeRepository
+}
+
+func (u *uniqueRepository) Create(ctx context.Context, c *Customer, addresses []Address) error {
+	for _, cur := range u.customers {
+		if cur.DocumentID == c.DocumentID {
+			return ErrDuplicateDocumentID
+		}
+	}
+	return u.fakeRepository.Create(ctx, c, addresses)
+}
+
+func TestImportTarget(t *testing.T) {
+	repo := &uniqueRepository{}
+	target := ImportTarget(repo, testCEP)
+
+	row := importer.Row{"legal_name": "ACME", "document_id": "123.456.789-09", "postal_code": "01310-100"}
+	if err := target.Validate(row); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if target.Key(row) != "12345678909" {
+		t.Fatalf("unexpected key %s", target.Key(row))
+	}
+	if err := target.Create(context.Background(), row); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	a := repo.addresses[0]
+	if repo.customers[0].PersonType == nil || *repo.customers[0].PersonType != "PF" || a.AddressType != "billing" || a.City != "Sao Paulo" {
+		t.Fatalf("unexpected customer %+v %+v", repo.customers[0], a)
+	}
+	if err := target.Create(context.Background(), row); !errors.Is(err, importer.ErrDuplicate) {
+		t.Fatalf("expected duplicate, got %v", err)
+	}
+
+	err := target.Validate(importer.Row{"legal_name": "ACME", "document_id": "123", "email": "x"})
+	msg := importer.Message(err)
+	for _, want := range []string{"DocumentID: cpfcnpj", "Email: email", "Addresses[0].Street: required_without"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in %q", want, msg)
+		}
+	}
+}
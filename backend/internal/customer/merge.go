@@ -0,0 +1,25 @@
+package customer
+
+import "errors"
+
+// ErrMergeSameCustomer eh retornado quando origem e destino do merge coincidem.
+var ErrMergeSameCustomer = errors.New("source and target must be different customers")
+
+// MergeInput define o payload do merge de clientes.
+type MergeInput struct {
+	SourceID string `json:"source_id" validate:"required"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+// MergeResult lista os registros movidos (ou que seriam movidos, em dry-run)
+// do cliente de origem para o cliente de destino.
+type MergeResult struct {
+	TargetID  string   `json:"targetID"`
+	SourceID  string   `json:"sourceID"`
+	DryRun    bool     `json:"dryRun"`
+	Leads     []string `json:"leads"`
+	Contracts []string `json:"contracts"`
+	Addresses []string `json:"addresses"`
+	Notes     []string `json:"notes"`
+	Tags      []string `json:"tags"`
+}
@@ -3,13 +3,26 @@ package customer
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/tag"
 )
 
+const insertAddressQuery = `INSERT INTO addresses (id, customer_id, address_type, street, number, complement, district, city, state, postal_code, country)
+        VALUES (:id, :customer_id, :address_type, :street, :number, :complement, :district, :city, :state, :postal_code, :country)`
+
+// updateAddressQuery perde a marcacao de principal quando o tipo muda.
+const updateAddressQuery = `UPDATE addresses SET is_primary=(is_primary AND address_type=:address_type),
+        address_type=:address_type, street=:street, number=:number, complement=:complement,
+        district=:district, city=:city, state=:state, postal_code=:postal_code, country=:country, updated_at=now()
+        WHERE id=:id AND customer_id=:customer_id AND deleted_at IS NULL`
+
 // PostgresRepository implementa Repository usando PostgreSQL.
 type PostgresRepository struct {
 	db *sqlx.DB
@@ -20,29 +33,146 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-// FindAll retorna todos os clientes nao excluidos.
-func (r *PostgresRepository) FindAll(ctx context.Context) ([]Customer, error) {
+// FindAll retorna todos os clientes nao excluidos,
+// opcionalmente filtrados por tags (todas devem estar presentes).
+func (r *PostgresRepository) FindAll(ctx context.Context, tags []string) ([]Customer, error) {
 	customers := []Customer{}
-	const q = `SELECT * FROM customers WHERE deleted_at IS NULL`
-	if err := r.db.SelectContext(ctx, &customers, q); err != nil {
+	q := `SELECT * FROM customers WHERE deleted_at IS NULL`
+	args := []interface{}{}
+	if len(tags) > 0 {
+		q += ` AND ` + tag.FilterClause("customers", "id", "$1")
+		args = append(args, pq.Array(tags))
+	}
+	if err := r.db.SelectContext(ctx, &customers, q, args...); err != nil {
 		return nil, err
 	}
 	return customers, nil
 }
 
+// Each percorre os clientes de FindAll direto do cursor, sem carregar a
+// lista em memoria.
+func (r *PostgresRepository) Each(ctx context.Context, tags []string, fn func(Customer) error) error {
+	q := `SELECT * FROM customers WHERE deleted_at IS NULL`
+	args := []interface{}{}
+	if len(tags) > 0 {
+		q += ` AND ` + tag.FilterClause("customers", "id", "$1")
+		args = append(args, pq.Array(tags))
+	}
+	q += ` ORDER BY legal_name, id`
+	rows, err := r.db.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c Customer
+		if err := rows.StructScan(&c); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // FindByID retorna um cliente pelo ID.
 func (r *PostgresRepository) FindByID(ctx context.Context, id string) (Customer, error) {
 	var c Customer
 	const q = `SELECT * FROM customers WHERE id = $1 AND deleted_at IS NULL`
 	if err := r.db.GetContext(ctx, &c, q, id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return Customer{}, nil
+			return Customer{}, sql.ErrNoRows
 		}
 		return Customer{}, err
 	}
 	return c, nil
 }
 
+// Detail monta a visao 360 do cliente com as secoes solicitadas.
+func (r *PostgresRepository) Detail(ctx context.Context, id string, opts DetailOptions) (CustomerDetail, error) {
+	c, err := r.FindByID(ctx, id)
+	if err != nil {
+		return CustomerDetail{}, err
+	}
+	d := CustomerDetail{Customer: c}
+
+	if opts.Addresses {
+		if d.Addresses, err = r.ListAddresses(ctx, id); err != nil {
+			return CustomerDetail{}, err
+		}
+	}
+	if opts.Tags {
+		d.Tags = []TagSummary{}
+		const q = `SELECT t.id, t.name FROM entity_tags et
+            JOIN tags t ON t.id = et.tag_id AND t.deleted_at IS NULL
+            WHERE et.entity_name='customers' AND et.entity_id=$1 AND et.deleted_at IS NULL
+            ORDER BY t.name`
+		if err = r.db.SelectContext(ctx, &d.Tags, q, id); err != nil {
+			return CustomerDetail{}, err
+		}
+	}
+	if opts.Notes {
+		d.Notes = []NoteSummary{}
+		const q = `SELECT n.id, n.author_id, COALESCE(u.full_name,'') AS author_name, n.text, n.created_at, n.edited_at
+            FROM notes n LEFT JOIN users u ON u.id = n.author_id
+            WHERE n.entity_name='customers' AND n.entity_id=$1
+            ORDER BY n.created_at DESC`
+		if err = r.db.SelectContext(ctx, &d.Notes, q, id); err != nil {
+			return CustomerDetail{}, err
+		}
+	}
+	if opts.Leads {
+		d.Leads = []LeadSummary{}
+		const q = `SELECT l.id, COALESCE(l.service_id,'') AS service_id, COALESCE(s.name,'') AS service_name,
+            l.status, l.created_at
+            FROM leads l LEFT JOIN services s ON s.id = l.service_id
+            WHERE l.customer_id=$1 AND l.deleted_at IS NULL
+            ORDER BY l.created_at DESC`
+		if err = r.db.SelectContext(ctx, &d.Leads, q, id); err != nil {
+			return CustomerDetail{}, err
+		}
+	}
+	if opts.Contracts {
+		d.Contracts = []ContractSummary{}
+		const q = `SELECT ct.id, COALESCE(ct.service_id,'') AS service_id, COALESCE(s.name,'') AS service_name,
+            ct.value_total, ct.start_date, ct.end_date, ct.status
+            FROM contracts ct LEFT JOIN services s ON s.id = ct.service_id
+            WHERE ct.customer_id=$1 AND ct.deleted_at IS NULL
+            ORDER BY ct.start_date DESC`
+		if err = r.db.SelectContext(ctx, &d.Contracts, q, id); err != nil {
+			return CustomerDetail{}, err
+		}
+	}
+	if opts.Receivables {
+		var rs ReceivablesSummary
+		const q = `SELECT
+            COUNT(*) FILTER (WHERE ar.status='open' AND ar.due_date >= current_date) AS open_count,
+            COALESCE(SUM(ar.amount) FILTER (WHERE ar.status='open' AND ar.due_date >= current_date), 0) AS open_amount,
+            COUNT(*) FILTER (WHERE ar.status='overdue' OR (ar.status='open' AND ar.due_date < current_date)) AS overdue_count,
+            COALESCE(SUM(ar.amount) FILTER (WHERE ar.status='overdue' OR (ar.status='open' AND ar.due_date < current_date)), 0) AS overdue_amount
+            FROM accounts_receivable ar
+            JOIN contracts ct ON ct.id = ar.contract_id
+            WHERE ct.customer_id=$1 AND ar.deleted_at IS NULL AND ct.deleted_at IS NULL`
+		if err = r.db.GetContext(ctx, &rs, q, id); err != nil {
+			return CustomerDetail{}, err
+		}
+		d.Receivables = &rs
+	}
+	if opts.Promoter && c.PromoterID != nil {
+		var p PromoterSummary
+		const q = `SELECT id, full_name, COALESCE(email,'') AS email, COALESCE(phone,'') AS phone
+            FROM promoters WHERE id=$1 AND deleted_at IS NULL`
+		if err = r.db.GetContext(ctx, &p, q, *c.PromoterID); err == nil {
+			d.Promoter = &p
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return CustomerDetail{}, err
+		}
+	}
+
+	return d, nil
+}
+
 // Create insere um novo cliente.
 func (r *PostgresRepository) Create(ctx context.Context, c *Customer, addresses []Address) error {
 	tx, err := r.db.BeginTxx(ctx, nil)
@@ -50,8 +180,8 @@ func (r *PostgresRepository) Create(ctx context.Context, c *Customer, addresses
 		return err
 	}
 
-	const qc = `INSERT INTO customers (id, legal_name, trade_name, document_id, email, phone, promoter_id)
-                VALUES (:id, :legal_name, :trade_name, :document_id, :email, :phone, :promoter_id)`
+	const qc = `INSERT INTO customers (id, legal_name, trade_name, document_id, person_type, email, phone, promoter_id)
+                VALUES (:id, :legal_name, :trade_name, :document_id, :person_type, :email, :phone, :promoter_id)`
 	if _, err = tx.NamedExecContext(ctx, qc, c); err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
 			_ = tx.Rollback()
@@ -61,14 +191,16 @@ func (r *PostgresRepository) Create(ctx context.Context, c *Customer, addresses
 		return err
 	}
 
-	const qa = `INSERT INTO addresses (id, customer_id, address_type, street, number, complement, district, city, state, postal_code, country)
-                VALUES (:id, :customer_id, :address_type, :street, :number, :complement, :district, :city, :state, :postal_code, :country)`
 	for _, a := range addresses {
-		if _, err = tx.NamedExecContext(ctx, qa, a); err != nil {
+		if _, err = tx.NamedExecContext(ctx, insertAddressQuery, a); err != nil {
 			_ = tx.Rollback()
 			return fmt.Errorf("insert address: %w", err)
 		}
 	}
+	if err = ensurePrimaryAddresses(ctx, tx, c.ID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 
 	return tx.Commit()
 }
@@ -81,7 +213,7 @@ func (r *PostgresRepository) Update(ctx context.Context, c *Customer, addrs []Ad
 	}
 
 	const qc = `UPDATE customers SET legal_name=:legal_name, trade_name=:trade_name, document_id=:document_id,
-                email=:email, phone=:phone, promoter_id=:promoter_id, updated_at=now()
+                person_type=:person_type, email=:email, phone=:phone, promoter_id=:promoter_id, updated_at=now()
                 WHERE id=:id AND deleted_at IS NULL`
 	res, err := tx.NamedExecContext(ctx, qc, c)
 	if err != nil {
@@ -104,22 +236,52 @@ func (r *PostgresRepository) Update(ctx context.Context, c *Customer, addrs []Ad
 	}
 
 	if addrs != nil {
-		if _, err = tx.ExecContext(ctx, `DELETE FROM addresses WHERE customer_id=$1`, c.ID); err != nil {
+		if err = syncAddresses(ctx, tx, c.ID, addrs); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
+	}
+
+	return tx.Commit()
+}
 
-		const qa = `INSERT INTO addresses (id, customer_id, address_type, street, number, complement, district, city, state, postal_code, country)
-                        VALUES (:id, :customer_id, :address_type, :street, :number, :complement, :district, :city, :state, :postal_code, :country)`
-		for _, a := range addrs {
-			if _, err = tx.NamedExecContext(ctx, qa, a); err != nil {
-				_ = tx.Rollback()
+// syncAddresses preserva a identidade dos enderecos: atualiza os que possuem
+// ID, insere os novos e remove logicamente os ausentes da lista.
+func syncAddresses(ctx context.Context, tx *sqlx.Tx, customerID string, addrs []Address) error {
+	keep := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		if a.ID != "" {
+			keep = append(keep, a.ID)
+		}
+	}
+	const qd = `UPDATE addresses SET deleted_at=now(), is_primary=false, updated_at=now()
+                WHERE customer_id=$1 AND deleted_at IS NULL AND NOT (id = ANY($2))`
+	if _, err := tx.ExecContext(ctx, qd, customerID, pq.Array(keep)); err != nil {
+		return err
+	}
+
+	for i := range addrs {
+		a := &addrs[i]
+		a.CustomerID = customerID
+		if a.ID == "" {
+			a.ID = ulid.Make().String()
+			if _, err := tx.NamedExecContext(ctx, insertAddressQuery, a); err != nil {
 				return fmt.Errorf("insert address: %w", err)
 			}
+			continue
+		}
+		res, err := tx.NamedExecContext(ctx, updateAddressQuery, a)
+		if err != nil {
+			return fmt.Errorf("update address: %w", err)
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return sql.ErrNoRows
 		}
 	}
 
-	return tx.Commit()
+	return ensurePrimaryAddresses(ctx, tx, customerID)
 }
 
 // SoftDelete marca um cliente como removido.
@@ -138,3 +300,225 @@ func (r *PostgresRepository) SoftDelete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// ListAddresses retorna os enderecos ativos de um cliente.
+func (r *PostgresRepository) ListAddresses(ctx context.Context, customerID string) ([]Address, error) {
+	addresses := []Address{}
+	const q = `SELECT * FROM addresses WHERE customer_id=$1 AND deleted_at IS NULL
+        ORDER BY address_type, is_primary DESC, created_at`
+	if err := r.db.SelectContext(ctx, &addresses, q, customerID); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// AddAddress insere um endereco para um cliente existente.
+func (r *PostgresRepository) AddAddress(ctx context.Context, a *Address) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var exists int
+	if err = tx.GetContext(ctx, &exists, `SELECT 1 FROM customers WHERE id=$1 AND deleted_at IS NULL`, a.CustomerID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.NamedExecContext(ctx, insertAddressQuery, a); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("insert address: %w", err)
+	}
+	if err = ensurePrimaryAddresses(ctx, tx, a.CustomerID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateAddress altera um endereco mantendo seu ID.
+func (r *PostgresRepository) UpdateAddress(ctx context.Context, a *Address) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.NamedExecContext(ctx, updateAddressQuery, a)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affected == 0 {
+		_ = tx.Rollback()
+		return sql.ErrNoRows
+	}
+	if err = ensurePrimaryAddresses(ctx, tx, a.CustomerID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// SoftDeleteAddress marca um endereco como removido.
+func (r *PostgresRepository) SoftDeleteAddress(ctx context.Context, customerID, addressID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	const q = `UPDATE addresses SET deleted_at=now(), is_primary=false, updated_at=now()
+        WHERE id=$1 AND customer_id=$2 AND deleted_at IS NULL`
+	res, err := tx.ExecContext(ctx, q, addressID, customerID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affected == 0 {
+		_ = tx.Rollback()
+		return sql.ErrNoRows
+	}
+	if err = ensurePrimaryAddresses(ctx, tx, customerID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetPrimaryAddress define o endereco principal do seu tipo.
+func (r *PostgresRepository) SetPrimaryAddress(ctx context.Context, customerID, addressID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var addressType string
+	const qt = `SELECT address_type FROM addresses WHERE id=$1 AND customer_id=$2 AND deleted_at IS NULL FOR UPDATE`
+	if err = tx.GetContext(ctx, &addressType, qt, addressID, customerID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	const qr = `UPDATE addresses SET is_primary=false, updated_at=now()
+        WHERE customer_id=$1 AND address_type=$2 AND is_primary AND id<>$3`
+	if _, err = tx.ExecContext(ctx, qr, customerID, addressType, addressID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE addresses SET is_primary=true, updated_at=now() WHERE id=$1`, addressID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ensurePrimaryAddresses garante um endereco principal para cada tipo
+// que ainda possua enderecos ativos, escolhendo o mais antigo.
+func ensurePrimaryAddresses(ctx context.Context, tx *sqlx.Tx, customerID string) error {
+	const q = `UPDATE addresses SET is_primary=true
+        WHERE id IN (
+            SELECT DISTINCT ON (address_type) id FROM addresses a
+             WHERE a.customer_id=$1 AND a.deleted_at IS NULL
+               AND NOT EXISTS (
+                   SELECT 1 FROM addresses p
+                    WHERE p.customer_id=a.customer_id AND p.address_type=a.address_type
+                      AND p.deleted_at IS NULL AND p.is_primary)
+             ORDER BY address_type, created_at, id)`
+	_, err := tx.ExecContext(ctx, q, customerID)
+	return err
+}
+
+// Merge move leads, contratos, enderecos, notas e tags do cliente de origem
+// para o destino e remove logicamente a origem apontando para o sobrevivente.
+// Em dry-run apenas lista o que seria movido.
+func (r *PostgresRepository) Merge(ctx context.Context, targetID, sourceID, actorID string, dryRun bool) (MergeResult, error) {
+	if targetID == sourceID {
+		return MergeResult{}, ErrMergeSameCustomer
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var locked []string
+	const ql = `SELECT id FROM customers WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE`
+	if err = tx.SelectContext(ctx, &locked, ql, pq.Array([]string{targetID, sourceID})); err != nil {
+		return MergeResult{}, err
+	}
+	if len(locked) != 2 {
+		return MergeResult{}, sql.ErrNoRows
+	}
+
+	res := MergeResult{TargetID: targetID, SourceID: sourceID, DryRun: dryRun}
+	selects := []struct {
+		dest *[]string
+		q    string
+	}{
+		{&res.Leads, `SELECT id FROM leads WHERE customer_id=$1 AND deleted_at IS NULL ORDER BY id`},
+		{&res.Contracts, `SELECT id FROM contracts WHERE customer_id=$1 AND deleted_at IS NULL ORDER BY id`},
+		{&res.Addresses, `SELECT id FROM addresses WHERE customer_id=$1 AND deleted_at IS NULL ORDER BY id`},
+		{&res.Notes, `SELECT id FROM notes WHERE entity_name='customers' AND entity_id=$1 ORDER BY id`},
+		{&res.Tags, `SELECT DISTINCT tag_id FROM entity_tags WHERE entity_name='customers' AND entity_id=$1 AND deleted_at IS NULL ORDER BY tag_id`},
+	}
+	for _, s := range selects {
+		*s.dest = []string{}
+		if err = tx.SelectContext(ctx, s.dest, s.q, sourceID); err != nil {
+			return MergeResult{}, err
+		}
+	}
+	if dryRun {
+		return res, nil
+	}
+
+	stmts := []string{
+		`UPDATE leads SET customer_id=$1, updated_at=now() WHERE customer_id=$2 AND deleted_at IS NULL`,
+		`UPDATE contracts SET customer_id=$1, updated_at=now() WHERE customer_id=$2 AND deleted_at IS NULL`,
+		`UPDATE addresses SET customer_id=$1, is_primary=false, updated_at=now() WHERE customer_id=$2 AND deleted_at IS NULL`,
+		`UPDATE notes SET entity_id=$1 WHERE entity_name='customers' AND entity_id=$2`,
+		// tags ja presentes no destino sao descartadas na origem
+		`UPDATE entity_tags s SET deleted_at=now()
+            WHERE s.entity_name='customers' AND s.entity_id=$2 AND s.deleted_at IS NULL
+              AND EXISTS (SELECT 1 FROM entity_tags t WHERE t.entity_name='customers' AND t.entity_id=$1
+                          AND t.tag_id=s.tag_id AND t.deleted_at IS NULL)`,
+		`UPDATE entity_tags SET entity_id=$1 WHERE entity_name='customers' AND entity_id=$2 AND deleted_at IS NULL`,
+		`UPDATE customers SET promoter_id=(SELECT promoter_id FROM customers WHERE id=$2), updated_at=now()
+            WHERE id=$1 AND promoter_id IS NULL`,
+		`UPDATE customers SET merged_into_id=$1, deleted_at=now(), updated_at=now() WHERE id=$2`,
+	}
+	for _, q := range stmts {
+		if _, err = tx.ExecContext(ctx, q, targetID, sourceID); err != nil {
+			return MergeResult{}, err
+		}
+	}
+	if err = ensurePrimaryAddresses(ctx, tx, targetID); err != nil {
+		return MergeResult{}, err
+	}
+
+	diff, err := json.Marshal(res)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	var userID *string
+	if actorID != "" {
+		userID = &actorID
+	}
+	const qa = `INSERT INTO audit_logs (id, user_id, entity_name, entity_id, action, diff)
+        VALUES ($1, $2, 'customers', $3, 'delete', $4)`
+	if _, err = tx.ExecContext(ctx, qa, ulid.Make().String(), userID, sourceID, string(diff)); err != nil {
+		return MergeResult{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return MergeResult{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,131 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Repository calcula os indicadores do dashboard.
+type Repository interface {
+	Summary(ctx context.Context, rg Range) (Dashboard, error)
+}
+
+// Errors especificos
+
+var ErrInvalidRange = errors.New("from and to must be dates (YYYY-MM-DD), from <= to, spanning at most 36 months")
+
+// maxMonths limita o periodo consultado.
+const maxMonths = 36
+
+// DefaultCacheTTL eh o tempo que um resultado fica em cache.
+const DefaultCacheTTL = time.Minute
+
+// maxCacheEntries limita os periodos distintos guardados em cache.
+const maxCacheEntries = 32
+
+// topPromoters eh o tamanho do ranking de promotores.
+const topPromoters = 5
+
+// Range eh o periodo do dashboard, com datas inclusivas.
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// ParseRange le from e to (AAAA-MM-DD). Sem datas, o periodo vai do inicio
+// do mes de onze meses atras ate hoje.
+func ParseRange(q url.Values, now time.Time) (Range, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	rg := Range{To: today}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return Range{}, ErrInvalidRange
+		}
+		rg.To = t
+	}
+	rg.From = time.Date(rg.To.Year(), rg.To.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return Range{}, ErrInvalidRange
+		}
+		rg.From = t
+	}
+	if rg.From.After(rg.To) || rg.From.AddDate(0, maxMonths, 0).Before(rg.To) {
+		return Range{}, ErrInvalidRange
+	}
+	return rg, nil
+}
+
+// CacheTTLFromEnv le DASHBOARD_CACHE_SECONDS, usando DefaultCacheTTL como padrao.
+func CacheTTLFromEnv() time.Duration {
+	if v := os.Getenv("DASHBOARD_CACHE_SECONDS"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+			return time.Duration(s) * time.Second
+		}
+	}
+	return DefaultCacheTTL
+}
+
+type cacheEntry struct {
+	value   Dashboard
+	expires time.Time
+}
+
+// CachedRepository guarda em memoria o resultado de cada periodo por ttl,
+// evitando recalcular os agregados a cada acesso. Guarda no maximo
+// maxCacheEntries periodos; cheio, descarta o que expira primeiro.
+type CachedRepository struct {
+	repo Repository
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[Range]cacheEntry
+}
+
+// NewCachedRepository cria um cache sobre repo. Com ttl zero o cache fica
+// desligado.
+func NewCachedRepository(repo Repository, ttl time.Duration) *CachedRepository {
+	return &CachedRepository{repo: repo, ttl: ttl, now: time.Now, entries: map[Range]cacheEntry{}}
+}
+
+// Summary retorna o resultado em cache ou o recalcula quando expirado.
+func (c *CachedRepository) Summary(ctx context.Context, rg Range) (Dashboard, error) {
+	if c.ttl <= 0 {
+		return c.repo.Summary(ctx, rg)
+	}
+	now := c.now()
+	c.mu.Lock()
+	e, ok := c.entries[rg]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.value, nil
+	}
+
+	d, err := c.repo.Summary(ctx, rg)
+	if err != nil {
+		return Dashboard{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var oldest Range
+	for k, old := range c.entries {
+		if !now.Before(old.expires) {
+			delete(c.entries, k)
+		} else if oldest == (Range{}) || old.expires.Before(c.entries[oldest].expires) {
+			oldest = k
+		}
+	}
+	if _, ok := c.entries[rg]; !ok && len(c.entries) >= maxCacheEntries {
+		delete(c.entries, oldest)
+	}
+	c.entries[rg] = cacheEntry{value: d, expires: now.Add(c.ttl)}
+	return d, nil
+}
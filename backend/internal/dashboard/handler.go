@@ -0,0 +1,43 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes adiciona a rota do dashboard executivo.
+func RegisterRoutes(r chi.Router, repo Repository) {
+	h := handler{repo: repo}
+	r.Get("/dashboard", h.summary)
+}
+
+type handler struct {
+	repo Repository
+}
+
+// @Summary      Indicadores do dashboard executivo
+// @Description  Contratos ativos e MRR, receita prevista x recebida por mes, inadimplencia por faixa de atraso, conversao de leads, ranking de promotores e comissoes pendentes
+// @Tags         dashboard
+// @Security     BearerAuth
+// @Param        from  query  string  false  "Inicio do periodo (AAAA-MM-DD)"
+// @Param        to    query  string  false  "Fim do periodo (AAAA-MM-DD)"
+// @Success      200  {object}  Dashboard
+// @Router       /dashboard [get]
+func (h handler) summary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	rg, err := ParseRange(r.URL.Query(), time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	d, err := h.repo.Summary(r.Context(), rg)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(d)
+}
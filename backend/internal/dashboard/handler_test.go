@@ -0,0 +1,170 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepo struct {
+	calls int
+	last  Range
+}
+
+func (f *fakeRepo) Summary(ctx context.Context, rg Range) (Dashboard, error) {
+	f.calls++
+	f.last = rg
+	return Dashboard{
+		From:      rg.From,
+		To:        rg.To,
+		Contracts: ContractKPI{Active: 3, MRR: 1500},
+		Overdue:   overdue([]AgingBucket{{Bucket: "31-60", Count: 2, Amount: 200.1}, {Bucket: "1-30", Count: 1, Amount: 99.95}}),
+	}, nil
+}
+
+func setupRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo)
+	return r, token
+}
+
+func get(t *testing.T, url, token string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	return resp
+}
+
+func TestDashboardSummary(t *testing.T) {
+	repo := &fakeRepo{}
+	r, token := setupRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := get(t, server.URL+"/dashboard?from=2026-01-01&to=2026-06-30", token)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var d Dashboard
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if d.Contracts.Active != 3 || d.Contracts.MRR != 1500 {
+		t.Fatalf("unexpected contracts kpi: %+v", d.Contracts)
+	}
+	if len(d.Overdue.Aging) != 4 || d.Overdue.Aging[0].Bucket != "1-30" || d.Overdue.Aging[3].Count != 0 {
+		t.Fatalf("unexpected aging: %+v", d.Overdue.Aging)
+	}
+	if d.Overdue.Amount != 300.05 || d.Overdue.Count != 3 {
+		t.Fatalf("unexpected overdue totals: %+v", d.Overdue)
+	}
+	if got := repo.last.From.Format("2006-01-02") + ".." + repo.last.To.Format("2006-01-02"); got != "2026-01-01..2026-06-30" {
+		t.Fatalf("unexpected range %s", got)
+	}
+}
+
+func TestDashboardInvalidRange(t *testing.T) {
+	r, token := setupRouter(&fakeRepo{}, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	for _, q := range []string{"from=2026-07-01&to=2026-01-01", "from=01/01/2026", "from=2020-01-01&to=2026-01-01"} {
+		resp := get(t, server.URL+"/dashboard?"+q, token)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, resp.StatusCode)
+		}
+	}
+}
+
+func TestDashboardRequiresAdminOrFinance(t *testing.T) {
+	r, token := setupRouter(&fakeRepo{}, "promoter")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := get(t, server.URL+"/dashboard", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestParseRangeDefault(t *testing.T) {
+	rg, err := ParseRange(url.Values{}, time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rg.From.Format("2006-01-02") != "2025-11-01" || rg.To.Format("2006-01-02") != "2026-10-19" {
+		t.Fatalf("unexpected default range %v..%v", rg.From, rg.To)
+	}
+}
+
+func TestCachedRepositoryExpires(t *testing.T) {
+	repo := &fakeRepo{}
+	c := NewCachedRepository(repo, time.Minute)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	rg := Range{From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Summary(context.Background(), rg); err != nil {
+			t.Fatalf("summary: %v", err)
+		}
+	}
+	if repo.calls != 1 {
+		t.Fatalf("expected 1 repository call, got %d", repo.calls)
+	}
+	other := Range{From: rg.From, To: rg.To.AddDate(0, 0, 1)}
+	_, _ = c.Summary(context.Background(), other)
+	if repo.calls != 2 {
+		t.Fatalf("expected separate entry per range, got %d calls", repo.calls)
+	}
+	now = now.Add(time.Minute)
+	_, _ = c.Summary(context.Background(), rg)
+	if repo.calls != 3 {
+		t.Fatalf("expected refresh after ttl, got %d calls", repo.calls)
+	}
+}
+
+func TestCachedRepositoryIsBounded(t *testing.T) {
+	repo := &fakeRepo{}
+	c := NewCachedRepository(repo, time.Hour)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	first := Range{From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	for i := 0; i < maxCacheEntries+5; i++ {
+		rg := Range{From: first.From, To: first.To.AddDate(0, 0, i)}
+		if _, err := c.Summary(context.Background(), rg); err != nil {
+			t.Fatalf("summary: %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+	if len(c.entries) != maxCacheEntries {
+		t.Fatalf("expected %d cached ranges, got %d", maxCacheEntries, len(c.entries))
+	}
+	if _, ok := c.entries[first]; ok {
+		t.Fatal("expected the oldest range to be evicted")
+	}
+}
@@ -0,0 +1,69 @@
+package dashboard
+
+import "time"
+
+// Dashboard reune os indicadores executivos de um periodo.
+type Dashboard struct {
+	From               time.Time          `json:"from"`
+	To                 time.Time          `json:"to"`
+	GeneratedAt        time.Time          `json:"generatedAt"`
+	Contracts          ContractKPI        `json:"contracts"`
+	Revenue            []MonthRevenue     `json:"revenue"`
+	Overdue            OverdueKPI         `json:"overdue"`
+	LeadConversion     []StageConversion  `json:"leadConversion"`
+	TopPromoters       []PromoterValue    `json:"topPromoters"`
+	PendingCommissions PendingCommissions `json:"pendingCommissions"`
+}
+
+// ContractKPI resume os contratos ativos. MRR eh a receita mensal das linhas
+// de servicos recorrentes: o valor das linhas de cada contrato dividido pelo
+// numero de meses (parcelas ou vigencia) que ele cobre.
+type ContractKPI struct {
+	Active int     `db:"active" json:"active"`
+	MRR    float64 `db:"mrr" json:"mrr"`
+}
+
+// MonthRevenue compara o valor previsto (vencimentos do mes) com o recebido
+// (pagamentos do mes).
+type MonthRevenue struct {
+	Month    string  `db:"month" json:"month"`
+	Expected float64 `db:"expected" json:"expected"`
+	Received float64 `db:"received" json:"received"`
+}
+
+// OverdueKPI soma as parcelas vencidas ate o fim do periodo.
+type OverdueKPI struct {
+	Amount float64       `json:"amount"`
+	Count  int           `json:"count"`
+	Aging  []AgingBucket `json:"aging"`
+}
+
+// AgingBucket agrupa parcelas vencidas por dias de atraso.
+type AgingBucket struct {
+	Bucket string  `db:"bucket" json:"bucket"`
+	Count  int     `db:"count" json:"count"`
+	Amount float64 `db:"amount" json:"amount"`
+}
+
+// StageConversion indica quantos leads do periodo estao em cada etapa e
+// quantos chegaram a ela ou a uma etapa posterior.
+type StageConversion struct {
+	Stage   string  `db:"stage" json:"stage"`
+	Count   int     `db:"count" json:"count"`
+	Reached int     `db:"reached" json:"reached"`
+	Rate    float64 `db:"rate" json:"rate"`
+}
+
+// PromoterValue soma os contratos fechados por um promotor no periodo.
+type PromoterValue struct {
+	PromoterID string  `db:"promoter_id" json:"promoterID"`
+	Name       string  `db:"full_name" json:"name"`
+	Contracts  int     `db:"contracts" json:"contracts"`
+	Value      float64 `db:"value" json:"value"`
+}
+
+// PendingCommissions resume as comissoes aguardando aprovacao.
+type PendingCommissions struct {
+	Count  int     `db:"count" json:"count"`
+	Amount float64 `db:"amount" json:"amount"`
+}
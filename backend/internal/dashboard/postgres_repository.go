@@ -0,0 +1,148 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// agingBuckets define a ordem das faixas de atraso na resposta.
+var agingBuckets = []string{"1-30", "31-60", "61-90", "90+"}
+
+const (
+	// MRR: as linhas recorrentes de cada contrato divididas pelos meses que
+	// ele cobre (parcelas, ou meses entre inicio e fim arredondados para
+	// cima); sem parcelas nem fim, o valor das linhas eh o mensal
+	qContracts = `SELECT COUNT(*) AS active, COALESCE(ROUND(SUM(t.recurring / t.months), 2), 0) AS mrr
+        FROM (
+            SELECT c.id, COALESCE(SUM(i.line_total) FILTER (WHERE s.billing_type = 'recurring'), 0) AS recurring,
+                GREATEST(1, COALESCE(NULLIF(c.installments, 0), (
+                    SELECT (date_part('year', a.span) * 12 + date_part('month', a.span)
+                        + CASE WHEN date_part('day', a.span) > 0 THEN 1 ELSE 0 END)::int
+                    FROM age(c.end_date + 1, c.start_date) AS a(span)), 1)) AS months
+            FROM contracts c
+            LEFT JOIN contract_items i ON i.contract_id = c.id
+            LEFT JOIN services s ON s.id = i.service_id
+            WHERE c.status = 'active' AND c.deleted_at IS NULL
+            GROUP BY c.id
+        ) t`
+
+	qRevenue = `SELECT to_char(m, 'YYYY-MM') AS month,
+            COALESCE((SELECT SUM(ar.amount) FROM accounts_receivable ar
+                WHERE ar.deleted_at IS NULL AND ar.status <> 'cancelled'
+                  AND ar.due_date BETWEEN GREATEST(m::date, $1) AND LEAST((m + interval '1 month')::date - 1, $2)), 0) AS expected,
+            COALESCE((SELECT SUM(ar.amount) FROM accounts_receivable ar
+                WHERE ar.deleted_at IS NULL AND ar.status = 'paid'
+                  AND ar.paid_at::date BETWEEN GREATEST(m::date, $1) AND LEAST((m + interval '1 month')::date - 1, $2)), 0) AS received
+        FROM generate_series(date_trunc('month', $1::date), date_trunc('month', $2::date), interval '1 month') m
+        ORDER BY m`
+
+	// parcelas em aberto na data final: ainda abertas ou pagas depois dela
+	qAging = `SELECT bucket, COUNT(*) AS count, SUM(amount) AS amount
+        FROM (
+            SELECT amount, CASE
+                WHEN $1::date - due_date <= 30 THEN '1-30'
+                WHEN $1::date - due_date <= 60 THEN '31-60'
+                WHEN $1::date - due_date <= 90 THEN '61-90'
+                ELSE '90+' END AS bucket
+            FROM accounts_receivable
+            WHERE deleted_at IS NULL AND due_date < $1
+              AND (status IN ('open', 'overdue') OR (status = 'paid' AND paid_at::date > $1))
+        ) t
+        GROUP BY bucket`
+
+	qLeads = `SELECT s.stage,
+            COUNT(l.status) FILTER (WHERE l.pos = s.pos) AS count,
+            COUNT(l.status) FILTER (WHERE l.pos >= s.pos) AS reached,
+            COALESCE(ROUND(COUNT(l.status) FILTER (WHERE l.pos >= s.pos)::numeric / NULLIF(COUNT(l.status), 0), 4), 0) AS rate
+        FROM unnest(ARRAY['lead', 'qualified', 'proposal', 'contract']) WITH ORDINALITY AS s(stage, pos)
+        LEFT JOIN (
+            SELECT status, array_position(ARRAY['lead', 'qualified', 'proposal', 'contract'], status) AS pos
+            FROM leads
+            WHERE deleted_at IS NULL AND created_at::date BETWEEN $1 AND $2
+        ) l ON true
+        GROUP BY s.stage, s.pos
+        ORDER BY s.pos`
+
+	qPromoters = `SELECT p.id AS promoter_id, p.full_name, COUNT(*) AS contracts, SUM(c.value_total) AS value
+        FROM contracts c
+        JOIN promoters p ON p.id = c.promoter_id
+        WHERE c.deleted_at IS NULL AND c.status <> 'cancelled' AND c.start_date BETWEEN $1 AND $2
+        GROUP BY p.id, p.full_name
+        ORDER BY value DESC, p.full_name
+        LIMIT $3`
+
+	qCommissions = `SELECT COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount
+        FROM commissions WHERE deleted_at IS NULL AND approved = false`
+)
+
+// Summary calcula os indicadores em uma unica transacao somente leitura,
+// de modo que todos reflitam o mesmo instante.
+func (r *PostgresRepository) Summary(ctx context.Context, rg Range) (Dashboard, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return Dashboard{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	from, to := rg.From.Format("2006-01-02"), rg.To.Format("2006-01-02")
+	d := Dashboard{From: rg.From, To: rg.To, GeneratedAt: time.Now().UTC()}
+	if err = tx.GetContext(ctx, &d.Contracts, qContracts); err != nil {
+		return Dashboard{}, err
+	}
+	d.Revenue = []MonthRevenue{}
+	if err = tx.SelectContext(ctx, &d.Revenue, qRevenue, from, to); err != nil {
+		return Dashboard{}, err
+	}
+	var aging []AgingBucket
+	if err = tx.SelectContext(ctx, &aging, qAging, to); err != nil {
+		return Dashboard{}, err
+	}
+	d.Overdue = overdue(aging)
+	d.LeadConversion = []StageConversion{}
+	if err = tx.SelectContext(ctx, &d.LeadConversion, qLeads, from, to); err != nil {
+		return Dashboard{}, err
+	}
+	d.TopPromoters = []PromoterValue{}
+	if err = tx.SelectContext(ctx, &d.TopPromoters, qPromoters, from, to, topPromoters); err != nil {
+		return Dashboard{}, err
+	}
+	if err = tx.GetContext(ctx, &d.PendingCommissions, qCommissions); err != nil {
+		return Dashboard{}, err
+	}
+	return d, nil
+}
+
+// overdue completa as faixas sem parcelas e soma o total vencido.
+func overdue(rows []AgingBucket) OverdueKPI {
+	byBucket := map[string]AgingBucket{}
+	for _, b := range rows {
+		byBucket[b.Bucket] = b
+	}
+	out := OverdueKPI{Aging: make([]AgingBucket, 0, len(agingBuckets))}
+	var total int64
+	for _, name := range agingBuckets {
+		b, ok := byBucket[name]
+		if !ok {
+			b = AgingBucket{Bucket: name}
+		}
+		out.Aging = append(out.Aging, b)
+		out.Count += b.Count
+		total += int64(math.Round(b.Amount * 100))
+	}
+	out.Amount = float64(total) / 100
+	return out
+}
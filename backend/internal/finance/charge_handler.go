@@ -0,0 +1,192 @@
+package finance
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+)
+
+// ChargeInput define o payload de emissao de cobranca.
+type ChargeInput struct {
+	Method string `json:"method"`
+}
+
+// writeChargeError traduz os erros de cobranca em respostas HTTP.
+func writeChargeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	case errors.Is(err, ErrInvalidMethod), errors.Is(err, ErrPayerDocumentID):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrNotChargeable), errors.Is(err, ErrChargePending), errors.Is(err, ErrChargeNotPending):
+		status = http.StatusConflict
+	default:
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// chargeable verifica se a parcela pode receber uma nova cobranca.
+func chargeable(ar AccountReceivable, payer Payer, method string) error {
+	if method != MethodBoleto && method != MethodPix {
+		return ErrInvalidMethod
+	}
+	if ar.Status != "open" && ar.Status != "overdue" {
+		return ErrNotChargeable
+	}
+	if ar.ChargeStatus != nil && *ar.ChargeStatus == ChargePending {
+		return ErrChargePending
+	}
+	if method == MethodBoleto && payer.DocumentID == "" {
+		return ErrPayerDocumentID
+	}
+	return nil
+}
+
+// @Summary      Emite boleto ou PIX para a parcela
+// @Description  Emite a cobranca no gateway de pagamento com o valor da parcela. Parcelas vencidas sao cobradas com vencimento hoje, acrescidas de multa e juros. So pode haver uma cobranca pendente por parcela.
+// @Tags         finance
+// @Security     BearerAuth
+// @Param        input  body  ChargeInput  true  "method: boleto ou pix"
+// @Success      201  {object}  Charge
+// @Router       /receivables/{id}/charges [post]
+func (h handler) createCharge(w http.ResponseWriter, r *http.Request) {
+	var in ChargeInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	ar, payer, err := h.repo.ChargeTarget(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeChargeError(w, err)
+		return
+	}
+	if err := chargeable(ar, payer, in.Method); err != nil {
+		writeChargeError(w, err)
+		return
+	}
+
+	due := ar.DueDate
+	if t := today(); due.Before(t) {
+		due = t
+	}
+	c := Charge{
+		ID:           ulid.Make().String(),
+		ReceivableID: ar.ID,
+		Provider:     h.gateway.Name(),
+		Method:       in.Method,
+		Status:       ChargePending,
+		Amount:       ar.AmountAt(due).Total,
+		DueDate:      due,
+	}
+	if actor := auth.UserIDFromContext(r.Context()); actor != "" {
+		c.CreatedBy = &actor
+	}
+	issued, err := h.gateway.Issue(r.Context(), ChargeRequest{
+		ID:          c.ID,
+		Method:      c.Method,
+		Amount:      c.Amount,
+		DueDate:     c.DueDate,
+		Description: fmt.Sprintf("Parcela %s do contrato %s", ar.DueDate.Format("02/01/2006"), ar.ContractID),
+		Payer:       payer,
+	})
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	c.ProviderRef = issued.ProviderRef
+	c.DigitableLine = optional(issued.DigitableLine)
+	c.Barcode = optional(issued.Barcode)
+	c.PixPayload = optional(issued.PixPayload)
+	c.PaymentURL = optional(issued.PaymentURL)
+	c.ExpiresAt = issued.ExpiresAt
+
+	if err := h.repo.CreateCharge(r.Context(), &c); err != nil {
+		// a cobranca nao foi gravada (ex.: outra emitida em paralelo): desfaz a emissao no gateway
+		_ = h.gateway.Cancel(r.Context(), c.ProviderRef)
+		writeChargeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/receivables/"+ar.ID+"/charges/"+c.ID)
+	w.Header().Set("X-Entity", fmt.Sprintf("charges:%s", c.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// @Summary      Lista cobrancas da parcela
+// @Tags         finance
+// @Security     BearerAuth
+// @Success      200  {array}  Charge
+// @Router       /receivables/{id}/charges [get]
+func (h handler) listCharges(w http.ResponseWriter, r *http.Request) {
+	charges, err := h.repo.ListCharges(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeChargeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(charges)
+}
+
+// @Summary      Exibe uma cobranca
+// @Tags         finance
+// @Security     BearerAuth
+// @Success      200  {object}  Charge
+// @Router       /receivables/{id}/charges/{chargeID} [get]
+func (h handler) getCharge(w http.ResponseWriter, r *http.Request) {
+	c, err := h.repo.FindCharge(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "chargeID"))
+	if err != nil {
+		writeChargeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// @Summary      Cancela uma cobranca pendente
+// @Tags         finance
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /receivables/{id}/charges/{chargeID}/cancel [put]
+func (h handler) cancelCharge(w http.ResponseWriter, r *http.Request) {
+	c, err := h.repo.FindCharge(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "chargeID"))
+	if err != nil {
+		writeChargeError(w, err)
+		return
+	}
+	if c.Status != ChargePending {
+		writeChargeError(w, ErrChargeNotPending)
+		return
+	}
+	if err := h.gateway.Cancel(r.Context(), c.ProviderRef); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	if err := h.repo.CancelCharge(r.Context(), c.ID); err != nil {
+		writeChargeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("charges:%s", c.ID))
+	w.WriteHeader(http.StatusNoContent)
+}
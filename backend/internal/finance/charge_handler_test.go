@@ -0,0 +1,119 @@
+package finance
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sendCharge(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestCreateAndCancelCharge(t *testing.T) {
+	due := today().AddDate(0, 0, 10)
+	repo := &fakeRepository{
+		receivables: []AccountReceivable{{ID: "r1", ContractID: "c1", DueDate: due, Amount: 150, Status: "open"}},
+		payer:       Payer{Name: "ACME", DocumentID: "12345678909"},
+	}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := sendCharge(t, http.MethodPost, server.URL+"/receivables/r1/charges", token, `{"method":"boleto"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	var c Charge
+	_ = json.NewDecoder(resp.Body).Decode(&c)
+	resp.Body.Close()
+	if c.Status != ChargePending || c.Provider != "fake" || c.ProviderRef != "fake_"+c.ID || c.Amount != 150 || c.DigitableLine == nil || c.PixPayload != nil {
+		t.Fatalf("unexpected charge %+v", c)
+	}
+	if !c.DueDate.Equal(due) || *c.CreatedBy != "u1" {
+		t.Fatalf("unexpected due date or author %+v", c)
+	}
+	if resp.Header.Get("Location") != "/receivables/r1/charges/"+c.ID {
+		t.Fatalf("unexpected location %s", resp.Header.Get("Location"))
+	}
+
+	resp = sendCharge(t, http.MethodPost, server.URL+"/receivables/r1/charges", token, `{"method":"pix"}`)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 for second pending charge, got %d", resp.StatusCode)
+	}
+
+	resp = sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/charges/"+c.ID+"/cancel", token, "")
+	if resp.StatusCode != http.StatusNoContent || repo.charges[0].Status != ChargeCancelled {
+		t.Fatalf("expected 204 and cancelled charge, got %d", resp.StatusCode)
+	}
+	resp = sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/charges/"+c.ID+"/cancel", token, "")
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 cancelling twice, got %d", resp.StatusCode)
+	}
+
+	resp = sendCharge(t, http.MethodPost, server.URL+"/receivables/r1/charges", token, `{"method":"pix"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 after cancel, got %d", resp.StatusCode)
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&c)
+	resp.Body.Close()
+	if c.PixPayload == nil || !strings.HasPrefix(*c.PixPayload, "000201") || c.ExpiresAt == nil {
+		t.Fatalf("unexpected pix charge %+v", c)
+	}
+
+	resp = sendCharge(t, http.MethodGet, server.URL+"/receivables/r1/charges", token, "")
+	var list []Charge
+	_ = json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if len(list) != 2 || list[0].ID != c.ID {
+		t.Fatalf("unexpected charges %+v", list)
+	}
+	resp = sendCharge(t, http.MethodGet, server.URL+"/receivables/r1/charges/"+c.ID, token, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestCreateChargeRules(t *testing.T) {
+	repo := &fakeRepository{
+		receivables: []AccountReceivable{
+			{ID: "open", DueDate: today().AddDate(0, 0, -5), Amount: 10, Status: "open"},
+			{ID: "paid", DueDate: today(), Amount: 10, Status: "paid"},
+		},
+	}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	cases := []struct {
+		id, body string
+		want     int
+	}{
+		{"missing", `{"method":"pix"}`, http.StatusNotFound},
+		{"open", `{"method":"cheque"}`, http.StatusBadRequest},
+		{"open", `{"method":"boleto"}`, http.StatusBadRequest},
+		{"paid", `{"method":"pix"}`, http.StatusConflict},
+		{"open", `{"method":"pix"}`, http.StatusCreated},
+	}
+	for _, c := range cases {
+		resp := sendCharge(t, http.MethodPost, server.URL+"/receivables/"+c.id+"/charges", token, c.body)
+		resp.Body.Close()
+		if resp.StatusCode != c.want {
+			t.Fatalf("%s %s: expected %d, got %d", c.id, c.body, c.want, resp.StatusCode)
+		}
+	}
+	// parcela vencida eh cobrada com vencimento hoje
+	if got := repo.charges[0].DueDate; !got.Equal(today()) {
+		t.Fatalf("expected due date today, got %s", got.Format(time.DateOnly))
+	}
+}
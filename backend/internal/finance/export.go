@@ -0,0 +1,30 @@
+package finance
+
+import "github.com/rgomids/bckoffice/pkg/export"
+
+// Colunas das exportacoes das listagens de contas a receber e comissoes.
+var (
+	receivableTable = export.Table{
+		Sheet:   "Contas a receber",
+		Columns: []string{"ID", "Contrato", "Vencimento", "Valor", "Status", "Pago em", "Valor atualizado", "Valor pago"},
+	}
+	commissionTable = export.Table{
+		Sheet:   "Comissoes",
+		Columns: []string{"ID", "Contrato", "Promotor", "Valor", "Aprovada", "Aprovada em", "Criada em"},
+	}
+)
+
+func receivableRow(ar AccountReceivable) []any {
+	var updated, paid any
+	if ar.UpdatedAmount != nil {
+		updated = ar.UpdatedAmount.Total
+	}
+	if ar.PaidAmount != nil {
+		paid = *ar.PaidAmount
+	}
+	return []any{ar.ID, ar.ContractID, ar.DueDate, ar.Amount, ar.Status, ar.PaidAt, updated, paid}
+}
+
+func commissionRow(c Commission) []any {
+	return []any{c.ID, c.ContractID, c.PromoterID, c.Amount, c.Approved, c.ApprovedAt, c.CreatedAt}
+}
@@ -3,15 +3,38 @@ package finance
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // Repository define operacoes para contas a receber e comissoes.
 type Repository interface {
 	ListReceivables(ctx context.Context, status string) ([]AccountReceivable, error)
-	MarkAsPaid(ctx context.Context, id string) error
+	EachReceivable(ctx context.Context, status string, fn func(AccountReceivable) error) error
+	FindReceivable(ctx context.Context, id string) (AccountReceivable, error)
+	MarkAsPaid(ctx context.Context, id string, p Payment) error
 
 	ListCommissions(ctx context.Context, onlyPending bool) ([]Commission, error)
+	EachCommission(ctx context.Context, onlyPending bool, fn func(Commission) error) error
 	ApproveCommission(ctx context.Context, id string, approverID string) error
+
+	// ChargeTarget retorna a parcela e o cliente a ser cobrado.
+	ChargeTarget(ctx context.Context, receivableID string) (AccountReceivable, Payer, error)
+	CreateCharge(ctx context.Context, c *Charge) error
+	ListCharges(ctx context.Context, receivableID string) ([]Charge, error)
+	FindCharge(ctx context.Context, receivableID, id string) (Charge, error)
+	CancelCharge(ctx context.Context, id string) error
+
+	// SaveWebhookEvent grava o evento recebido. Na reentrega de um evento ja
+	// gravado, conta a tentativa e preenche e com o registro existente.
+	SaveWebhookEvent(ctx context.Context, e *WebhookEvent) error
+	// ProcessWebhookEvent aplica o evento na cobranca e na parcela uma unica
+	// vez e retorna a situacao final (processed ou ignored).
+	ProcessWebhookEvent(ctx context.Context, id string) (string, error)
+	FailWebhookEvent(ctx context.Context, id string, cause error) error
+
+	Aging(ctx context.Context, date time.Time) (AgingReport, error)
+	CashFlow(ctx context.Context, from, to time.Time, period string) (CashFlowReport, error)
+	DSO(ctx context.Context, date time.Time, days int) (DSOReport, error)
 }
 
 // Errors especificos
@@ -19,4 +42,81 @@ type Repository interface {
 var (
 	ErrAlreadyPaid     = errors.New("already paid")
 	ErrAlreadyApproved = errors.New("already approved")
+	ErrInvalidPayment  = errors.New("paid_at must be a date (YYYY-MM-DD) up to today and paid_amount positive")
+
+	ErrInvalidMethod    = errors.New("method must be boleto or pix")
+	ErrNotChargeable    = errors.New("receivable is not open")
+	ErrChargePending    = errors.New("receivable already has a pending charge")
+	ErrChargeNotPending = errors.New("charge is not pending")
+	ErrPayerDocumentID  = errors.New("customer document_id is required for boleto")
 )
+
+// payable verifica se a parcela ainda pode ser baixada. Eh a regra comum a
+// baixa manual (MarkAsPaid) e a baixa pelo webhook do gateway.
+func payable(ar AccountReceivable) error {
+	if ar.Status != "open" && ar.Status != "overdue" {
+		return ErrAlreadyPaid
+	}
+	return nil
+}
+
+// manuallyPayable acrescenta a payable a regra da baixa manual: com uma
+// cobranca pendente o boleto/PIX continuaria pagavel no gateway, entao ela
+// precisa ser cancelada antes.
+func manuallyPayable(ar AccountReceivable) error {
+	if err := payable(ar); err != nil {
+		return err
+	}
+	if ar.ChargeStatus != nil && *ar.ChargeStatus == ChargePending {
+		return ErrChargePending
+	}
+	return nil
+}
+
+// applyEvent aplica o evento do gateway na cobranca e na parcela. Retorna
+// false quando o evento nao muda nada, como um cancelamento que chega depois
+// do pagamento. O cancelamento ou a expiracao da cobranca nao cancela a
+// parcela, que continua em aberto para uma nova cobranca. A nota descreve um
+// pagamento recebido para parcela que nao estava mais pagavel, que precisa
+// ser estornado pelo financeiro.
+func applyEvent(e GatewayEvent, ch *Charge, ar *AccountReceivable) (bool, string) {
+	at := e.OccurredAt
+	switch e.Type {
+	case EventChargePaid:
+		if ch.Status == ChargePaid || ch.Status == ChargeRefunded {
+			return false, ""
+		}
+		ch.Status, ch.PaidAt = ChargePaid, &at
+		// parcela ja baixada (ex.: manualmente) registra apenas a cobranca
+		if payable(*ar) != nil {
+			return true, "receivable already " + ar.Status
+		}
+		// o valor da cobranca foi fixado no seu vencimento
+		date := at
+		if dateOf(at).After(ch.DueDate) {
+			date = ch.DueDate
+		}
+		settle(ar, at, ar.AmountAt(date))
+		return true, ""
+	case EventChargeCancelled, EventChargeExpired:
+		if ch.Status != ChargePending {
+			return false, ""
+		}
+		if e.Type == EventChargeExpired {
+			ch.Status = ChargeExpired
+		} else {
+			ch.Status, ch.CancelledAt = ChargeCancelled, &at
+		}
+		return true, ""
+	case EventChargeRefunded:
+		if ch.Status != ChargePaid {
+			return false, ""
+		}
+		ch.Status, ch.RefundedAt = ChargeRefunded, &at
+		if ar.Status == "paid" {
+			ar.Status = "refunded"
+		}
+		return true, ""
+	}
+	return false, ""
+}
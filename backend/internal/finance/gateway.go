@@ -0,0 +1,319 @@
+package finance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metodos de cobranca.
+const (
+	MethodBoleto = "boleto"
+	MethodPix    = "pix"
+)
+
+// Payer identifica quem paga a cobranca (o cliente do contrato).
+type Payer struct {
+	Name       string `db:"name"`
+	DocumentID string `db:"document_id"`
+	Email      string `db:"email"`
+}
+
+// ChargeRequest eh a cobranca enviada ao gateway. ID eh o ID local da
+// cobranca e serve de chave de idempotencia no provedor.
+type ChargeRequest struct {
+	ID          string
+	Method      string
+	Amount      float64
+	DueDate     time.Time
+	Description string
+	Payer       Payer
+}
+
+// IssuedCharge traz os dados de pagamento devolvidos pelo gateway. Boletos
+// preenchem DigitableLine e Barcode; PIX preenche PixPayload (copia e cola,
+// tambem usado como conteudo do QR code).
+type IssuedCharge struct {
+	ProviderRef   string
+	DigitableLine string
+	Barcode       string
+	PixPayload    string
+	PaymentURL    string
+	ExpiresAt     *time.Time
+}
+
+// Tipos de evento enviados pelo gateway no webhook.
+const (
+	EventChargePaid      = "charge.paid"
+	EventChargeCancelled = "charge.cancelled"
+	EventChargeExpired   = "charge.expired"
+	EventChargeRefunded  = "charge.refunded"
+)
+
+// GatewayEvent eh a notificacao do webhook ja autenticada e decodificada.
+type GatewayEvent struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	ProviderRef string    `json:"provider_ref"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// Gateway emite e cancela cobrancas em um provedor de pagamentos e
+// autentica as notificacoes que ele envia ao webhook.
+type Gateway interface {
+	Name() string
+	Issue(ctx context.Context, req ChargeRequest) (IssuedCharge, error)
+	Cancel(ctx context.Context, providerRef string) error
+	// ParseEvent verifica a assinatura do corpo recebido e decodifica o evento.
+	ParseEvent(header http.Header, body []byte) (GatewayEvent, error)
+}
+
+var (
+	ErrGatewayChargeNotFound = errors.New("charge not found at gateway")
+	ErrInvalidSignature      = errors.New("invalid webhook signature")
+	ErrInvalidEvent          = errors.New("invalid webhook event")
+)
+
+// SignatureHeader carrega a assinatura HMAC-SHA256 do corpo do webhook, no
+// formato "sha256=<hex>".
+const SignatureHeader = "X-Signature"
+
+// FakeGateway simula um provedor para desenvolvimento local e testes. Gera
+// boletos com linha digitavel e codigo de barras validos (banco 999) e PIX
+// no formato BR Code com CRC, sem chamar servicos externos.
+type FakeGateway struct {
+	pixKey   string
+	merchant string
+	city     string
+	secret   []byte
+
+	// cancelled guarda as cobrancas canceladas nesta execucao; a validade da
+	// referencia vem do prefixo, para aceitar cobrancas emitidas antes de um
+	// reinicio
+	mu        sync.Mutex
+	cancelled map[string]bool
+}
+
+// NewFakeGateway cria um FakeGateway que recebe os PIX na chave informada e
+// aceita webhooks assinados com webhookSecret. Sem segredo, todo webhook eh
+// recusado.
+func NewFakeGateway(pixKey, merchant, city, webhookSecret string) *FakeGateway {
+	return &FakeGateway{pixKey: pixKey, merchant: merchant, city: city, secret: []byte(webhookSecret), cancelled: map[string]bool{}}
+}
+
+// GatewayFromEnv le PAYMENT_PIX_KEY, PAYMENT_MERCHANT_NAME,
+// PAYMENT_MERCHANT_CITY e PAYMENT_WEBHOOK_SECRET para o gateway fake.
+func GatewayFromEnv() *FakeGateway {
+	get := func(name, def string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return def
+	}
+	return NewFakeGateway(
+		get("PAYMENT_PIX_KEY", "financeiro@example.com"),
+		get("PAYMENT_MERCHANT_NAME", "RCM Tech"),
+		get("PAYMENT_MERCHANT_CITY", "Sao Paulo"),
+		os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+	)
+}
+
+// fakeRefPrefix identifica as referencias emitidas pelo FakeGateway.
+const fakeRefPrefix = "fake_"
+
+func (g *FakeGateway) Name() string { return "fake" }
+
+func (g *FakeGateway) Issue(_ context.Context, req ChargeRequest) (IssuedCharge, error) {
+	out := IssuedCharge{ProviderRef: fakeRefPrefix + req.ID}
+	switch req.Method {
+	case MethodBoleto:
+		barcode, err := boletoBarcode(req.ID, req.Amount, req.DueDate)
+		if err != nil {
+			return IssuedCharge{}, err
+		}
+		out.Barcode = barcode
+		out.DigitableLine = digitableLine(barcode)
+	case MethodPix:
+		out.PixPayload = pixPayload(g.pixKey, g.merchant, g.city, req.ID, req.Amount)
+		expires := time.Date(req.DueDate.Year(), req.DueDate.Month(), req.DueDate.Day(), 23, 59, 59, 0, time.UTC)
+		out.ExpiresAt = &expires
+	default:
+		return IssuedCharge{}, ErrInvalidMethod
+	}
+	return out, nil
+}
+
+func (g *FakeGateway) Cancel(_ context.Context, providerRef string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !strings.HasPrefix(providerRef, fakeRefPrefix) || g.cancelled[providerRef] {
+		return ErrGatewayChargeNotFound
+	}
+	g.cancelled[providerRef] = true
+	return nil
+}
+
+// Sign gera o valor de SignatureHeader para o corpo, como o provedor faria
+// ao enviar o webhook.
+func (g *FakeGateway) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (g *FakeGateway) ParseEvent(header http.Header, body []byte) (GatewayEvent, error) {
+	if len(g.secret) == 0 || !hmac.Equal([]byte(header.Get(SignatureHeader)), []byte(g.Sign(body))) {
+		return GatewayEvent{}, ErrInvalidSignature
+	}
+	var e GatewayEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return GatewayEvent{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if e.ID == "" || e.ProviderRef == "" || e.OccurredAt.IsZero() {
+		return GatewayEvent{}, fmt.Errorf("%w: id, provider_ref and occurred_at are required", ErrInvalidEvent)
+	}
+	switch e.Type {
+	case EventChargePaid, EventChargeCancelled, EventChargeExpired, EventChargeRefunded:
+	default:
+		return GatewayEvent{}, fmt.Errorf("%w: unknown type %q", ErrInvalidEvent, e.Type)
+	}
+	return e, nil
+}
+
+// boletoBaseDate eh a data base do fator de vencimento (FEBRABAN).
+var boletoBaseDate = time.Date(1997, 10, 7, 0, 0, 0, 0, time.UTC)
+
+// dueFactor calcula o fator de vencimento. Ao passar de 9999 (22/02/2025)
+// o fator recomeca em 1000.
+func dueFactor(due time.Time) (int, error) {
+	days := int(time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC).Sub(boletoBaseDate).Hours() / 24)
+	if days < 1000 {
+		return 0, fmt.Errorf("due date %s out of range", due.Format("2006-01-02"))
+	}
+	if days > 9999 {
+		days = (days-10000)%9000 + 1000
+	}
+	return days, nil
+}
+
+// boletoBarcode monta o codigo de barras de 44 posicoes: banco, moeda, DV,
+// fator de vencimento, valor e campo livre (derivado do ID da cobranca).
+func boletoBarcode(id string, amount float64, due time.Time) (string, error) {
+	cents := int64(math.Round(amount * 100))
+	if cents <= 0 || cents > 9999999999 {
+		return "", fmt.Errorf("amount %.2f out of range", amount)
+	}
+	factor, err := dueFactor(due)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(id))
+	var free strings.Builder
+	for _, b := range sum[:25] {
+		free.WriteByte('0' + b%10)
+	}
+	rest := fmt.Sprintf("%04d%010d%s", factor, cents, free.String())
+	head := "9999"
+	return head + string('0'+byte(mod11(head+rest))) + rest, nil
+}
+
+// digitableLine converte o codigo de barras na linha digitavel formatada.
+func digitableLine(barcode string) string {
+	free := barcode[19:]
+	f1 := barcode[0:4] + free[0:5]
+	f2 := free[5:15]
+	f3 := free[15:25]
+	f1 += string('0' + byte(mod10(f1)))
+	f2 += string('0' + byte(mod10(f2)))
+	f3 += string('0' + byte(mod10(f3)))
+	return fmt.Sprintf("%s.%s %s.%s %s.%s %s %s",
+		f1[:5], f1[5:], f2[:5], f2[5:], f3[:5], f3[5:], barcode[4:5], barcode[5:19])
+}
+
+// mod10 eh o digito verificador dos campos da linha digitavel.
+func mod10(s string) int {
+	sum, weight := 0, 2
+	for i := len(s) - 1; i >= 0; i-- {
+		p := int(s[i]-'0') * weight
+		sum += p/10 + p%10
+		weight = 3 - weight
+	}
+	return (10 - sum%10) % 10
+}
+
+// mod11 eh o digito verificador geral do codigo de barras.
+func mod11(s string) int {
+	sum, weight := 0, 2
+	for i := len(s) - 1; i >= 0; i-- {
+		sum += int(s[i]-'0') * weight
+		if weight++; weight > 9 {
+			weight = 2
+		}
+	}
+	dv := 11 - sum%11
+	if dv == 0 || dv == 10 || dv == 11 {
+		return 1
+	}
+	return dv
+}
+
+// pixPayload monta o BR Code (EMV) do PIX com valor e txid.
+func pixPayload(key, merchant, city, txid string, amount float64) string {
+	account := tlv("00", "br.gov.bcb.pix") + tlv("01", key)
+	p := tlv("00", "01") +
+		tlv("01", "12") +
+		tlv("26", account) +
+		tlv("52", "0000") +
+		tlv("53", "986") +
+		tlv("54", fmt.Sprintf("%.2f", amount)) +
+		tlv("58", "BR") +
+		tlv("59", emvText(merchant, 25)) +
+		tlv("60", emvText(city, 15)) +
+		tlv("62", tlv("05", emvText(txid, 25))) +
+		"6304"
+	return p + fmt.Sprintf("%04X", crc16(p))
+}
+
+func tlv(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// emvText mantem apenas caracteres ASCII imprimiveis e limita o tamanho.
+func emvText(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 32 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+// crc16 calcula o CRC16-CCITT (polinomio 0x1021, inicial 0xFFFF) do BR Code.
+func crc16(s string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,85 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBoletoCheckDigits(t *testing.T) {
+	// boleto de exemplo do Banco do Brasil
+	const barcode = "00193373700000001000500940144816060680935031"
+	if got := mod11(barcode[:4] + barcode[5:]); got != 3 {
+		t.Fatalf("unexpected general check digit %d", got)
+	}
+	if got := digitableLine(barcode); got != "00190.50095 40144.816069 06809.350314 3 37370000000100" {
+		t.Fatalf("unexpected digitable line %s", got)
+	}
+}
+
+func TestDueFactor(t *testing.T) {
+	cases := map[string]int{"2000-07-03": 1000, "2025-02-21": 9999, "2025-02-22": 1000, "2026-10-19": 1604}
+	for date, want := range cases {
+		d, _ := time.Parse("2006-01-02", date)
+		if got, err := dueFactor(d); err != nil || got != want {
+			t.Fatalf("%s: got %d, %v", date, got, err)
+		}
+	}
+}
+
+func TestFakeGatewayBoleto(t *testing.T) {
+	g := NewFakeGateway("chave@example.com", "RCM Tech", "Sao Paulo", "segredo")
+	due := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	c, err := g.Issue(context.Background(), ChargeRequest{ID: "01J0000000000000000000000A", Method: MethodBoleto, Amount: 1234.56, DueDate: due})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if len(c.Barcode) != 44 || !strings.HasPrefix(c.Barcode, "9999") || c.Barcode[5:19] != "16040000123456" {
+		t.Fatalf("unexpected barcode %s", c.Barcode)
+	}
+	if int(c.Barcode[4]-'0') != mod11(c.Barcode[:4]+c.Barcode[5:]) {
+		t.Fatalf("invalid check digit in %s", c.Barcode)
+	}
+	if digits := strings.NewReplacer(".", "", " ", "").Replace(c.DigitableLine); len(digits) != 47 {
+		t.Fatalf("unexpected digitable line %s", c.DigitableLine)
+	}
+	if err := g.Cancel(context.Background(), c.ProviderRef); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := g.Cancel(context.Background(), c.ProviderRef); !errors.Is(err, ErrGatewayChargeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	// cobranca emitida antes de um reinicio (outra instancia)
+	if err := NewFakeGateway("", "", "", "").Cancel(context.Background(), "fake_01J0000000000000000000000B"); err != nil {
+		t.Fatalf("cancel after restart: %v", err)
+	}
+	if err := g.Cancel(context.Background(), "other_01J0000000000000000000000B"); !errors.Is(err, ErrGatewayChargeNotFound) {
+		t.Fatalf("expected not found for foreign ref, got %v", err)
+	}
+}
+
+func TestFakeGatewayPix(t *testing.T) {
+	g := NewFakeGateway("chave@example.com", "RCM Técnologia", "Sao Paulo", "segredo")
+	c, err := g.Issue(context.Background(), ChargeRequest{ID: "01J0000000000000000000000A", Method: MethodPix, Amount: 10, DueDate: time.Now()})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	p := c.PixPayload
+	for _, want := range []string{"000201", "0014br.gov.bcb.pix0117chave@example.com", "540510.00", "5802BR", "5913RCM Tcnologia", "6009Sao Paulo", "6304"} {
+		if !strings.Contains(p, want) {
+			t.Fatalf("payload missing %q: %s", want, p)
+		}
+	}
+	body, crc := p[:len(p)-4], p[len(p)-4:]
+	if crc != strings.ToUpper(crc) || crc16(body) == 0 {
+		t.Fatalf("unexpected crc %s", crc)
+	}
+	if got := crc16("123456789"); got != 0x29B1 {
+		t.Fatalf("crc16 check value: %X", got)
+	}
+	if _, err := g.Issue(context.Background(), ChargeRequest{Method: "cheque"}); !errors.Is(err, ErrInvalidMethod) {
+		t.Fatalf("expected invalid method, got %v", err)
+	}
+}
@@ -5,55 +5,171 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/pkg/export"
 )
 
 // RegisterRoutes adiciona as rotas do modulo Finance.
-func RegisterRoutes(r chi.Router, repo Repository) {
-	h := handler{repo: repo}
+func RegisterRoutes(r chi.Router, repo Repository, gateway Gateway) {
+	h := handler{repo: repo, gateway: gateway}
 	r.Route("/receivables", func(r chi.Router) {
-		r.Use(auth.RequireRole("finance"))
 		r.Get("/", h.listReceivables)
+		r.Get("/{id}/amount", h.receivableAmount)
 		r.Put("/{id}/pay", h.markAsPaid)
+		r.Post("/{id}/charges", h.createCharge)
+		r.Get("/{id}/charges", h.listCharges)
+		r.Get("/{id}/charges/{chargeID}", h.getCharge)
+		r.Put("/{id}/charges/{chargeID}/cancel", h.cancelCharge)
 	})
 	r.Route("/commissions", func(r chi.Router) {
-		r.Use(auth.RequireRole("finance"))
 		r.Get("/", h.listCommissions)
 		r.Put("/{id}/approve", h.approveCommission)
 	})
+	r.Route("/finance/reports", func(r chi.Router) {
+		r.Get("/aging", h.agingReport)
+		r.Get("/cashflow", h.cashFlowReport)
+		r.Get("/dso", h.dsoReport)
+	})
 }
 
 type handler struct {
-	repo Repository
+	repo    Repository
+	gateway Gateway
 }
 
 // @Summary      Lista contas a receber
 // @Tags         finance
 // @Security     BearerAuth
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        status  query  string  false  "Filtra pelo status"
+// @Param        date    query  string  false  "Data do valor atualizado AAAA-MM-DD (padrao: hoje)"
+// @Param        format  query  string  false  "json|csv|xlsx (ou header Accept)"
 // @Success      200  {array}  AccountReceivable
 // @Router       /receivables [get]
 func (h handler) listReceivables(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	format, err := export.Negotiate(r)
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+	date, err := parseDate(r.URL.Query(), "date", today())
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
 	status := r.URL.Query().Get("status")
+	if format != export.JSON {
+		export.Stream(w, format, "contas-a-receber", receivableTable, func(emit func([]any) error) error {
+			return h.repo.EachReceivable(r.Context(), status, func(ar AccountReceivable) error {
+				return emit(receivableRow(withUpdatedAmount(ar, date)))
+			})
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
 	list, err := h.repo.ListReceivables(r.Context(), status)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
+	for i, ar := range list {
+		list[i] = withUpdatedAmount(ar, date)
+	}
 	_ = json.NewEncoder(w).Encode(list)
 }
 
+// withUpdatedAmount preenche o valor atualizado das parcelas em aberto.
+func withUpdatedAmount(ar AccountReceivable, date time.Time) AccountReceivable {
+	if payable(ar) == nil {
+		a := ar.AmountAt(date)
+		ar.UpdatedAmount = &a
+	}
+	return ar
+}
+
+// @Summary      Calcula o valor atualizado da parcela
+// @Description  Principal, multa e juros pro rata devidos na data, pelos encargos do contrato ou do servico.
+// @Tags         finance
+// @Security     BearerAuth
+// @Param        date  query  string  false  "Data do pagamento AAAA-MM-DD (padrao: hoje)"
+// @Success      200  {object}  Amount
+// @Router       /receivables/{id}/amount [get]
+func (h handler) receivableAmount(w http.ResponseWriter, r *http.Request) {
+	date, err := parseDate(r.URL.Query(), "date", today())
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+	ar, err := h.repo.FindReceivable(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if err := payable(ar); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ar.AmountAt(date))
+}
+
+// PaymentInput define o payload opcional da baixa manual. Sem paid_at o
+// pagamento eh registrado agora; sem paid_amount, pelo valor atualizado na
+// data do pagamento.
+type PaymentInput struct {
+	PaidAt     string   `json:"paid_at"` // AAAA-MM-DD
+	PaidAmount *float64 `json:"paid_amount"`
+}
+
+// payment valida a entrada; pagamentos nao podem ser datados no futuro.
+func (in PaymentInput) payment(now time.Time) (Payment, error) {
+	p := Payment{PaidAt: now, PaidAmount: in.PaidAmount}
+	if in.PaidAt != "" {
+		t, err := time.Parse("2006-01-02", in.PaidAt)
+		if err != nil || t.After(dateOf(now)) {
+			return Payment{}, ErrInvalidPayment
+		}
+		p.PaidAt = t
+	}
+	if p.PaidAmount != nil && *p.PaidAmount <= 0 {
+		return Payment{}, ErrInvalidPayment
+	}
+	return p, nil
+}
+
 // @Summary      Marca receivable como pago
+// @Description  Registra o pagamento com multa e juros por atraso apurados em paid_at (padrao: agora). paid_amount informa o valor efetivamente recebido (padrao: o valor atualizado). Com cobranca pendente responde 409: cancele a cobranca antes.
 // @Tags         finance
 // @Security     BearerAuth
+// @Param        input  body  PaymentInput  false  "paid_at (AAAA-MM-DD) e paid_amount opcionais"
 // @Success      204  {null}  nil
 // @Router       /receivables/{id}/pay [put]
 func (h handler) markAsPaid(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	err := h.repo.MarkAsPaid(r.Context(), id)
+	var in PaymentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	p, err := in.payment(time.Now())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	err = h.repo.MarkAsPaid(r.Context(), id, p)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -63,7 +179,7 @@ func (h handler) markAsPaid(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeChargeError(w, err)
 		return
 	}
 	w.Header().Set("X-Entity", fmt.Sprintf("receivables:%s", id))
@@ -73,11 +189,27 @@ func (h handler) markAsPaid(w http.ResponseWriter, r *http.Request) {
 // @Summary      Lista comissoes
 // @Tags         finance
 // @Security     BearerAuth
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        pending  query  bool    false  "Apenas pendentes de aprovacao"
+// @Param        format   query  string  false  "json|csv|xlsx (ou header Accept)"
 // @Success      200  {array}  Commission
 // @Router       /commissions [get]
 func (h handler) listCommissions(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	format, err := export.Negotiate(r)
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
 	pending := r.URL.Query().Get("pending") == "true"
+	if format != export.JSON {
+		export.Stream(w, format, "comissoes", commissionTable, func(emit func([]any) error) error {
+			return h.repo.EachCommission(r.Context(), pending, func(c Commission) error {
+				return emit(commissionRow(c))
+			})
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
 	list, err := h.repo.ListCommissions(r.Context(), pending)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
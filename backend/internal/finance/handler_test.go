@@ -13,11 +13,19 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
 )
 
 type fakeRepository struct {
 	receivables []AccountReceivable
 	commissions []Commission
+	aging       []AgingRow
+	cashflow    []CashFlowRow
+	dso         DSOReport
+	charges     []Charge
+	payer       Payer
+	events      []WebhookEvent
+	processErr  error
 }
 
 func (f *fakeRepository) ListReceivables(ctx context.Context, status string) ([]AccountReceivable, error) {
@@ -30,15 +38,37 @@ func (f *fakeRepository) ListReceivables(ctx context.Context, status string) ([]
 	return out, nil
 }
 
-func (f *fakeRepository) MarkAsPaid(ctx context.Context, id string) error {
+func (f *fakeRepository) EachReceivable(ctx context.Context, status string, fn func(AccountReceivable) error) error {
+	list, _ := f.ListReceivables(ctx, status)
+	for _, ar := range list {
+		if err := fn(ar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) FindReceivable(ctx context.Context, id string) (AccountReceivable, error) {
+	for _, ar := range f.receivables {
+		if ar.ID == id {
+			return ar, nil
+		}
+	}
+	return AccountReceivable{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) MarkAsPaid(ctx context.Context, id string, p Payment) error {
 	for i, ar := range f.receivables {
 		if ar.ID == id {
-			if ar.Status != "open" {
-				return ErrAlreadyPaid
+			for _, c := range f.charges {
+				if c.ReceivableID == id && c.Status == ChargePending {
+					ar.ChargeStatus = &c.Status
+				}
 			}
-			ar.Status = "paid"
-			now := time.Now()
-			ar.PaidAt = &now
+			if err := manuallyPayable(ar); err != nil {
+				return err
+			}
+			settle(&ar, p.PaidAt, p.Amount(ar))
 			f.receivables[i] = ar
 			return nil
 		}
@@ -56,6 +86,16 @@ func (f *fakeRepository) ListCommissions(ctx context.Context, onlyPending bool)
 	return out, nil
 }
 
+func (f *fakeRepository) EachCommission(ctx context.Context, onlyPending bool, fn func(Commission) error) error {
+	list, _ := f.ListCommissions(ctx, onlyPending)
+	for _, c := range list {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *fakeRepository) ApproveCommission(ctx context.Context, id string, approverID string) error {
 	for i, c := range f.commissions {
 		if c.ID == id {
@@ -73,6 +113,141 @@ func (f *fakeRepository) ApproveCommission(ctx context.Context, id string, appro
 	return sql.ErrNoRows
 }
 
+func (f *fakeRepository) Aging(ctx context.Context, date time.Time) (AgingReport, error) {
+	return NewAgingReport(date, f.aging), nil
+}
+
+func (f *fakeRepository) CashFlow(ctx context.Context, from, to time.Time, period string) (CashFlowReport, error) {
+	rows := append([]CashFlowRow{}, f.cashflow...)
+	accumulate(rows)
+	return CashFlowReport{From: from, To: to, Period: period, Periods: rows}, nil
+}
+
+func (f *fakeRepository) DSO(ctx context.Context, date time.Time, days int) (DSOReport, error) {
+	rep := f.dso
+	rep.Date, rep.Days = date, days
+	computeDSO(&rep)
+	return rep, nil
+}
+
+func (f *fakeRepository) ChargeTarget(ctx context.Context, receivableID string) (AccountReceivable, Payer, error) {
+	for _, ar := range f.receivables {
+		if ar.ID == receivableID {
+			for _, c := range f.charges {
+				if c.ReceivableID == ar.ID {
+					id, status := c.ID, c.Status
+					ar.ChargeID, ar.ChargeStatus = &id, &status
+				}
+			}
+			return ar, f.payer, nil
+		}
+	}
+	return AccountReceivable{}, Payer{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) CreateCharge(ctx context.Context, c *Charge) error {
+	for _, cur := range f.charges {
+		if cur.ReceivableID == c.ReceivableID && cur.Status == ChargePending {
+			return ErrChargePending
+		}
+	}
+	c.CreatedAt, c.UpdatedAt = time.Now(), time.Now()
+	f.charges = append(f.charges, *c)
+	return nil
+}
+
+func (f *fakeRepository) ListCharges(ctx context.Context, receivableID string) ([]Charge, error) {
+	out := []Charge{}
+	for i := len(f.charges) - 1; i >= 0; i-- {
+		if f.charges[i].ReceivableID == receivableID {
+			out = append(out, f.charges[i])
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) FindCharge(ctx context.Context, receivableID, id string) (Charge, error) {
+	for _, c := range f.charges {
+		if c.ID == id && c.ReceivableID == receivableID {
+			return c, nil
+		}
+	}
+	return Charge{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) CancelCharge(ctx context.Context, id string) error {
+	for i, c := range f.charges {
+		if c.ID == id {
+			if c.Status != ChargePending {
+				return ErrChargeNotPending
+			}
+			now := time.Now()
+			f.charges[i].Status, f.charges[i].CancelledAt = ChargeCancelled, &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) SaveWebhookEvent(ctx context.Context, e *WebhookEvent) error {
+	for i, ev := range f.events {
+		if ev.Provider == e.Provider && ev.EventID == e.EventID {
+			f.events[i].Attempts++
+			*e = f.events[i]
+			return nil
+		}
+	}
+	e.Status, e.Attempts, e.ReceivedAt = WebhookReceived, 1, time.Now()
+	f.events = append(f.events, *e)
+	return nil
+}
+
+func (f *fakeRepository) ProcessWebhookEvent(ctx context.Context, id string) (string, error) {
+	if err := f.processErr; err != nil {
+		f.processErr = nil
+		return "", err
+	}
+	for i, e := range f.events {
+		if e.ID != id {
+			continue
+		}
+		if e.Status == WebhookProcessed || e.Status == WebhookIgnored {
+			return e.Status, nil
+		}
+		status, reason := WebhookIgnored, (*string)(nil)
+		for c := range f.charges {
+			if f.charges[c].ProviderRef != e.ProviderRef {
+				continue
+			}
+			for a := range f.receivables {
+				if f.receivables[a].ID == f.charges[c].ReceivableID {
+					event := GatewayEvent{ID: e.EventID, Type: e.Type, ProviderRef: e.ProviderRef, OccurredAt: e.OccurredAt}
+					if changed, note := applyEvent(event, &f.charges[c], &f.receivables[a]); changed {
+						status = WebhookProcessed
+						if note != "" {
+							reason = &note
+						}
+					}
+				}
+			}
+		}
+		now := time.Now()
+		f.events[i].Status, f.events[i].Error, f.events[i].ProcessedAt = status, reason, &now
+		return status, nil
+	}
+	return "", sql.ErrNoRows
+}
+
+func (f *fakeRepository) FailWebhookEvent(ctx context.Context, id string, cause error) error {
+	for i, e := range f.events {
+		if e.ID == id {
+			msg := cause.Error()
+			f.events[i].Status, f.events[i].Error = WebhookFailed, &msg
+		}
+	}
+	return nil
+}
+
 func setupRouter(repo Repository) (*chi.Mux, string) {
 	os.Setenv("JWT_SECRET", "testsecret")
 	claims := jwt.MapClaims{"sub": "u1", "role": "finance", "exp": time.Now().Add(time.Hour).Unix()}
@@ -80,7 +255,8 @@ func setupRouter(repo Repository) (*chi.Mux, string) {
 
 	r := chi.NewRouter()
 	r.Use(auth.AuthMiddleware)
-	RegisterRoutes(r, repo)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo, NewFakeGateway("chave@example.com", "RCM Tech", "Sao Paulo", "segredo"))
 	return r, tokenStr
 }
 
@@ -136,6 +312,29 @@ func TestMarkAsPaid(t *testing.T) {
 	}
 }
 
+func TestMarkAsPaidWithPendingCharge(t *testing.T) {
+	repo := &fakeRepository{
+		receivables: []AccountReceivable{{ID: "r1", Status: "open"}},
+		charges:     []Charge{{ID: "ch1", ReceivableID: "r1", Status: ChargePending}},
+	}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/pay", token, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict || repo.receivables[0].Status != "open" {
+		t.Fatalf("expected 409 keeping the receivable open, got %d / %+v", resp.StatusCode, repo.receivables[0])
+	}
+
+	repo.charges[0].Status = ChargeCancelled
+	resp = sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/pay", token, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || repo.receivables[0].Status != "paid" {
+		t.Fatalf("expected 204 after cancelling the charge, got %d", resp.StatusCode)
+	}
+}
+
 func TestApproveCommission(t *testing.T) {
 	repo := &fakeRepository{commissions: []Commission{{ID: "c1"}}}
 	router, token := setupRouter(repo)
@@ -0,0 +1,77 @@
+package finance
+
+import (
+	"math"
+	"time"
+)
+
+// Amount eh o valor de uma parcela em uma data, separado em principal, multa
+// e juros.
+type Amount struct {
+	Date      time.Time `json:"date"`
+	DaysLate  int       `json:"daysLate"`
+	Principal float64   `json:"principal"`
+	Fine      float64   `json:"fine"`
+	Interest  float64   `json:"interest"`
+	Total     float64   `json:"total"`
+}
+
+// AmountAt calcula o valor devido na data. Apos o vencimento incidem a multa
+// (percentual unico sobre o principal) e juros simples ao mes, pro rata die
+// sobre um mes de 30 dias. Os encargos vem do contrato ou, se ele nao os
+// define, do servico.
+func (ar AccountReceivable) AmountAt(date time.Time) Amount {
+	date = dateOf(date)
+	a := Amount{Date: date, Principal: ar.Amount, Total: ar.Amount}
+	days := int(date.Sub(dateOf(ar.DueDate)).Hours() / 24)
+	if days <= 0 {
+		return a
+	}
+	a.DaysLate = days
+	a.Fine = cents(ar.Amount * ar.LateFinePercent / 100)
+	a.Interest = cents(ar.Amount * ar.MonthlyInterestPercent / 100 / 30 * float64(days))
+	a.Total = cents(a.Principal + a.Fine + a.Interest)
+	return a
+}
+
+// Payment eh a baixa manual: data do pagamento e, opcionalmente, o valor
+// efetivamente recebido.
+type Payment struct {
+	PaidAt     time.Time
+	PaidAmount *float64
+}
+
+// Amount calcula a baixa com os encargos devidos em PaidAt. Com PaidAmount,
+// o valor recebido quita primeiro o principal, depois a multa e por ultimo os
+// juros; o que exceder o devido entra apenas no total.
+func (p Payment) Amount(ar AccountReceivable) Amount {
+	a := ar.AmountAt(p.PaidAt)
+	if p.PaidAmount == nil {
+		return a
+	}
+	rest := *p.PaidAmount
+	take := func(v float64) float64 {
+		v = math.Min(v, math.Max(rest, 0))
+		rest = cents(rest - v)
+		return v
+	}
+	a.Principal, a.Fine, a.Interest = take(a.Principal), take(a.Fine), take(a.Interest)
+	a.Total = cents(*p.PaidAmount)
+	return a
+}
+
+// settle baixa a parcela em paidAt com o valor recebido separado em
+// principal, multa e juros.
+func settle(ar *AccountReceivable, paidAt time.Time, a Amount) {
+	ar.Status, ar.PaidAt = "paid", &paidAt
+	ar.PaidAmount, ar.PaidPrincipal = &a.Total, &a.Principal
+	ar.PaidFine, ar.PaidInterest = &a.Fine, &a.Interest
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func cents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
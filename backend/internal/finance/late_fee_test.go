@@ -0,0 +1,173 @@
+package finance
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAmountAt(t *testing.T) {
+	ar := AccountReceivable{
+		Amount:                 1000,
+		DueDate:                time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC),
+		LateFinePercent:        2,
+		MonthlyInterestPercent: 1,
+	}
+	cases := []struct {
+		date                  string
+		days                  int
+		fine, interest, total float64
+	}{
+		{"2026-03-01", 0, 0, 0, 1000},
+		{"2026-03-05", 0, 0, 0, 1000},
+		{"2026-03-06", 1, 20, 0.33, 1020.33},
+		{"2026-04-04", 30, 20, 10, 1030},
+		{"2026-05-04", 60, 20, 20, 1040},
+	}
+	for _, c := range cases {
+		date, _ := time.Parse("2006-01-02", c.date)
+		a := ar.AmountAt(date.Add(15 * time.Hour))
+		if a.DaysLate != c.days || a.Principal != 1000 || a.Fine != c.fine || a.Interest != c.interest || a.Total != c.total {
+			t.Errorf("%s: unexpected amount %+v", c.date, a)
+		}
+	}
+
+	ar.LateFinePercent, ar.MonthlyInterestPercent = 0, 0
+	if a := ar.AmountAt(time.Date(2026, 4, 4, 0, 0, 0, 0, time.UTC)); a.Total != 1000 || a.DaysLate != 30 {
+		t.Fatalf("expected no late fees, got %+v", a)
+	}
+}
+
+func overdueReceivable(id string, days int) AccountReceivable {
+	return AccountReceivable{
+		ID:                     id,
+		Amount:                 1000,
+		DueDate:                today().AddDate(0, 0, -days),
+		Status:                 "overdue",
+		LateFinePercent:        2,
+		MonthlyInterestPercent: 1,
+	}
+}
+
+func TestMarkAsPaidWithLateFees(t *testing.T) {
+	repo := &fakeRepository{receivables: []AccountReceivable{overdueReceivable("r1", 30)}}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/pay", token, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	ar := repo.receivables[0]
+	if ar.Status != "paid" || *ar.PaidAmount != 1030 || *ar.PaidPrincipal != 1000 || *ar.PaidFine != 20 || *ar.PaidInterest != 10 {
+		t.Fatalf("unexpected settlement %+v", ar)
+	}
+
+	resp = sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/pay", token, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 paying twice, got %d", resp.StatusCode)
+	}
+}
+
+func TestMarkAsPaidWithPaymentBody(t *testing.T) {
+	repo := &fakeRepository{receivables: []AccountReceivable{overdueReceivable("r1", 30), overdueReceivable("r2", 30)}}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for body, want := range map[string]int{
+		`{"paid_at":"` + today().AddDate(0, 0, 1).Format("2006-01-02") + `"}`: http.StatusBadRequest,
+		`{"paid_at":"ontem"}`:  http.StatusBadRequest,
+		`{"paid_amount":0}`:    http.StatusBadRequest,
+		`{"paid_amount":"10"}`: http.StatusBadRequest,
+	} {
+		resp := sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/pay", token, body)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: expected %d, got %d", body, want, resp.StatusCode)
+		}
+	}
+	if repo.receivables[0].Status != "overdue" {
+		t.Fatalf("invalid payment must not settle: %+v", repo.receivables[0])
+	}
+
+	// encargos apurados na data informada: 15 dias de atraso
+	paidAt := today().AddDate(0, 0, -15)
+	resp := sendCharge(t, http.MethodPut, server.URL+"/receivables/r1/pay", token, `{"paid_at":"`+paidAt.Format("2006-01-02")+`"}`)
+	resp.Body.Close()
+	ar := repo.receivables[0]
+	if resp.StatusCode != http.StatusNoContent || !ar.PaidAt.Equal(paidAt) || *ar.PaidAmount != 1025 || *ar.PaidFine != 20 || *ar.PaidInterest != 5 {
+		t.Fatalf("unexpected settlement %d %+v", resp.StatusCode, ar)
+	}
+
+	// valor recebido menor que o devido: quita principal e multa, sem juros
+	resp = sendCharge(t, http.MethodPut, server.URL+"/receivables/r2/pay", token, `{"paid_amount":1010}`)
+	resp.Body.Close()
+	ar = repo.receivables[1]
+	if resp.StatusCode != http.StatusNoContent || *ar.PaidAmount != 1010 || *ar.PaidPrincipal != 1000 || *ar.PaidFine != 10 || *ar.PaidInterest != 0 {
+		t.Fatalf("unexpected settlement %d %+v", resp.StatusCode, ar)
+	}
+}
+
+func TestReceivableUpdatedAmount(t *testing.T) {
+	paid := overdueReceivable("r2", 10)
+	paid.Status = "paid"
+	repo := &fakeRepository{receivables: []AccountReceivable{overdueReceivable("r1", 30), paid}}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := sendCharge(t, http.MethodGet, server.URL+"/receivables", token, "")
+	var list []AccountReceivable
+	_ = json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if len(list) != 2 || list[0].UpdatedAmount == nil || list[0].UpdatedAmount.Total != 1030 || list[1].UpdatedAmount != nil {
+		t.Fatalf("unexpected receivables %+v", list)
+	}
+
+	date := today().AddDate(0, 0, 30).Format("2006-01-02")
+	resp = sendCharge(t, http.MethodGet, server.URL+"/receivables/r1/amount?date="+date, token, "")
+	var a Amount
+	_ = json.NewDecoder(resp.Body).Decode(&a)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || a.DaysLate != 60 || a.Interest != 20 || a.Total != 1040 {
+		t.Fatalf("unexpected amount %d %+v", resp.StatusCode, a)
+	}
+
+	for url, want := range map[string]int{
+		"/receivables/r1/amount?date=ontem": http.StatusBadRequest,
+		"/receivables/r2/amount":            http.StatusConflict,
+		"/receivables/r9/amount":            http.StatusNotFound,
+	} {
+		resp = sendCharge(t, http.MethodGet, server.URL+url, token, "")
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: expected %d, got %d", url, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestChargeIncludesLateFees(t *testing.T) {
+	repo := &fakeRepository{receivables: []AccountReceivable{overdueReceivable("r1", 30)}}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := sendCharge(t, http.MethodPost, server.URL+"/receivables/r1/charges", token, `{"method":"pix"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || repo.charges[0].Amount != 1030 {
+		t.Fatalf("expected charge with late fees, got %d %+v", resp.StatusCode, repo.charges)
+	}
+
+	// pago pelo gateway apos o vencimento da cobranca: vale o valor cobrado
+	ch, ar := repo.charges[0], repo.receivables[0]
+	_, _ = applyEvent(GatewayEvent{Type: EventChargePaid, OccurredAt: time.Now().AddDate(0, 0, 3)}, &ch, &ar)
+	if ar.Status != "paid" || *ar.PaidAmount != 1030 || *ar.PaidInterest != 10 {
+		t.Fatalf("unexpected settlement %+v", ar)
+	}
+}
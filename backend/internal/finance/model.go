@@ -12,6 +12,19 @@ type AccountReceivable struct {
 	PaidAt     *time.Time `db:"paid_at" json:"paidAt,omitempty"`
 	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt  time.Time  `db:"updated_at" json:"updatedAt"`
+	// ChargeID e ChargeStatus identificam a cobranca mais recente da parcela.
+	ChargeID     *string `db:"charge_id" json:"chargeID,omitempty"`
+	ChargeStatus *string `db:"charge_status" json:"chargeStatus,omitempty"`
+	// Encargos por atraso vigentes (do contrato ou padrao do servico).
+	LateFinePercent        float64 `db:"late_fine_percent" json:"lateFinePercent"`
+	MonthlyInterestPercent float64 `db:"monthly_interest_percent" json:"monthlyInterestPercent"`
+	// Valor recebido na baixa, separado em principal, multa e juros.
+	PaidAmount    *float64 `db:"paid_amount" json:"paidAmount,omitempty"`
+	PaidPrincipal *float64 `db:"paid_principal" json:"paidPrincipal,omitempty"`
+	PaidFine      *float64 `db:"paid_fine" json:"paidFine,omitempty"`
+	PaidInterest  *float64 `db:"paid_interest" json:"paidInterest,omitempty"`
+	// UpdatedAmount eh o valor devido na data consultada, para parcelas em aberto.
+	UpdatedAmount *Amount `db:"-" json:"updatedAmount,omitempty"`
 }
 
 // Commission representa a comissao de um promotor por contrato.
@@ -26,3 +39,60 @@ type Commission struct {
 	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt  time.Time  `db:"updated_at" json:"updatedAt"`
 }
+
+// Situacoes de uma cobranca.
+const (
+	ChargePending   = "pending"
+	ChargePaid      = "paid"
+	ChargeCancelled = "cancelled"
+	ChargeExpired   = "expired"
+	ChargeRefunded  = "refunded"
+)
+
+// Charge eh um boleto ou PIX emitido no gateway para uma parcela.
+type Charge struct {
+	ID            string     `db:"id" json:"id"`
+	ReceivableID  string     `db:"receivable_id" json:"receivableID"`
+	Provider      string     `db:"provider" json:"provider"`
+	ProviderRef   string     `db:"provider_ref" json:"providerRef"`
+	Method        string     `db:"method" json:"method"`
+	Status        string     `db:"status" json:"status"`
+	Amount        float64    `db:"amount" json:"amount"`
+	DueDate       time.Time  `db:"due_date" json:"dueDate"`
+	DigitableLine *string    `db:"digitable_line" json:"digitableLine,omitempty"`
+	Barcode       *string    `db:"barcode" json:"barcode,omitempty"`
+	PixPayload    *string    `db:"pix_payload" json:"pixPayload,omitempty"`
+	PaymentURL    *string    `db:"payment_url" json:"paymentURL,omitempty"`
+	ExpiresAt     *time.Time `db:"expires_at" json:"expiresAt,omitempty"`
+	CreatedBy     *string    `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updatedAt"`
+	PaidAt        *time.Time `db:"paid_at" json:"paidAt,omitempty"`
+	CancelledAt   *time.Time `db:"cancelled_at" json:"cancelledAt,omitempty"`
+	RefundedAt    *time.Time `db:"refunded_at" json:"refundedAt,omitempty"`
+}
+
+// Situacoes do processamento de um webhook do gateway.
+const (
+	WebhookReceived  = "received"
+	WebhookProcessed = "processed"
+	WebhookIgnored   = "ignored"
+	WebhookFailed    = "failed"
+)
+
+// WebhookEvent eh uma notificacao recebida do gateway, guardada com o corpo
+// original. Provider e EventID identificam o evento entre reentregas.
+type WebhookEvent struct {
+	ID          string     `db:"id" json:"id"`
+	Provider    string     `db:"provider" json:"provider"`
+	EventID     string     `db:"event_id" json:"eventID"`
+	Type        string     `db:"event_type" json:"type"`
+	ProviderRef string     `db:"provider_ref" json:"providerRef"`
+	OccurredAt  time.Time  `db:"occurred_at" json:"occurredAt"`
+	Payload     []byte     `db:"payload" json:"-"`
+	Status      string     `db:"status" json:"status"`
+	Error       *string    `db:"error" json:"error,omitempty"`
+	Attempts    int        `db:"attempts" json:"attempts"`
+	ReceivedAt  time.Time  `db:"received_at" json:"receivedAt"`
+	ProcessedAt *time.Time `db:"processed_at" json:"processedAt,omitempty"`
+}
@@ -3,8 +3,11 @@ package finance
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // PostgresRepository implementa Repository usando PostgreSQL.
@@ -17,10 +20,31 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
+// Colunas selecionadas nas listagens (sem deleted_at, que nao faz parte dos
+// modelos).
+const (
+	receivableColumns = `id, contract_id, due_date, amount, status, paid_at, created_at, updated_at,
+        (SELECT ch.id FROM charges ch WHERE ch.receivable_id = accounts_receivable.id
+          ORDER BY ch.created_at DESC LIMIT 1) AS charge_id,
+        (SELECT ch.status FROM charges ch WHERE ch.receivable_id = accounts_receivable.id
+          ORDER BY ch.created_at DESC LIMIT 1) AS charge_status,
+        COALESCE((SELECT COALESCE(c.late_fine_percent, s.late_fine_percent) FROM contracts c
+          LEFT JOIN services s ON s.id = c.service_id WHERE c.id = accounts_receivable.contract_id), 0) AS late_fine_percent,
+        COALESCE((SELECT COALESCE(c.monthly_interest_percent, s.monthly_interest_percent) FROM contracts c
+          LEFT JOIN services s ON s.id = c.service_id WHERE c.id = accounts_receivable.contract_id), 0) AS monthly_interest_percent,
+        paid_amount, paid_principal, paid_fine, paid_interest`
+	chargeColumns = `id, receivable_id, provider, provider_ref, method, status, amount, due_date, digitable_line,
+        barcode, pix_payload, payment_url, expires_at, created_by, created_at, updated_at, paid_at, cancelled_at, refunded_at`
+	webhookColumns = `id, provider, event_id, event_type, provider_ref, occurred_at, payload, status, error, attempts,
+        received_at, processed_at`
+	commissionColumns = `id, contract_id, promoter_id, amount, approved, COALESCE(approved_by, '') AS approved_by,
+        approved_at, created_at, updated_at`
+)
+
 // ListReceivables retorna as contas a receber opcionamente filtrando por status.
 func (r *PostgresRepository) ListReceivables(ctx context.Context, status string) ([]AccountReceivable, error) {
 	receivables := []AccountReceivable{}
-	q := `SELECT * FROM accounts_receivable WHERE deleted_at IS NULL`
+	q := `SELECT ` + receivableColumns + ` FROM accounts_receivable WHERE deleted_at IS NULL`
 	if status != "" {
 		q += ` AND status=$1`
 		if err := r.db.SelectContext(ctx, &receivables, q, status); err != nil {
@@ -34,35 +58,77 @@ func (r *PostgresRepository) ListReceivables(ctx context.Context, status string)
 	return receivables, nil
 }
 
-// MarkAsPaid marca uma conta como paga.
-func (r *PostgresRepository) MarkAsPaid(ctx context.Context, id string) error {
-	const q = `UPDATE accounts_receivable SET status='paid', paid_at=now() WHERE id=$1 AND status='open'`
-	res, err := r.db.ExecContext(ctx, q, id)
+// EachReceivable percorre as contas de ListReceivables direto do cursor,
+// sem carregar a lista em memoria.
+func (r *PostgresRepository) EachReceivable(ctx context.Context, status string, fn func(AccountReceivable) error) error {
+	q := `SELECT ` + receivableColumns + ` FROM accounts_receivable
+        WHERE deleted_at IS NULL AND ($1 = '' OR status = $1) ORDER BY due_date, id`
+	rows, err := r.db.QueryxContext(ctx, q, status)
 	if err != nil {
 		return err
 	}
-	affected, err := res.RowsAffected()
+	defer rows.Close()
+	for rows.Next() {
+		var ar AccountReceivable
+		if err := rows.StructScan(&ar); err != nil {
+			return err
+		}
+		if err := fn(ar); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FindReceivable busca uma conta a receber.
+func (r *PostgresRepository) FindReceivable(ctx context.Context, id string) (AccountReceivable, error) {
+	var ar AccountReceivable
+	q := `SELECT ` + receivableColumns + ` FROM accounts_receivable WHERE id=$1 AND deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &ar, q, id)
+	return ar, err
+}
+
+// MarkAsPaid marca uma conta como paga na data e pelo valor do pagamento.
+func (r *PostgresRepository) MarkAsPaid(ctx context.Context, id string, p Payment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if affected == 1 {
-		return nil
-	}
-	var status string
-	err = r.db.GetContext(ctx, &status, `SELECT status FROM accounts_receivable WHERE id=$1`, id)
+	defer func() { _ = tx.Rollback() }()
+
+	ar, err := lockReceivable(ctx, tx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return sql.ErrNoRows
-		}
 		return err
 	}
-	return ErrAlreadyPaid
+	if err := manuallyPayable(ar); err != nil {
+		return err
+	}
+	settle(&ar, p.PaidAt, p.Amount(ar))
+	if err := saveReceivableStatus(ctx, tx, ar); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockReceivable le a parcela bloqueando-a ate o fim da transacao.
+func lockReceivable(ctx context.Context, tx *sqlx.Tx, id string) (AccountReceivable, error) {
+	var ar AccountReceivable
+	q := `SELECT ` + receivableColumns + ` FROM accounts_receivable WHERE id=$1 FOR UPDATE`
+	err := tx.GetContext(ctx, &ar, q, id)
+	return ar, err
+}
+
+func saveReceivableStatus(ctx context.Context, tx *sqlx.Tx, ar AccountReceivable) error {
+	const q = `UPDATE accounts_receivable SET status=$2, paid_at=$3, paid_amount=$4, paid_principal=$5,
+        paid_fine=$6, paid_interest=$7, updated_at=now() WHERE id=$1`
+	_, err := tx.ExecContext(ctx, q, ar.ID, ar.Status, ar.PaidAt, ar.PaidAmount, ar.PaidPrincipal, ar.PaidFine, ar.PaidInterest)
+	return err
 }
 
 // ListCommissions retorna as comissoes, opcionalmente apenas pendentes.
 func (r *PostgresRepository) ListCommissions(ctx context.Context, onlyPending bool) ([]Commission, error) {
 	commissions := []Commission{}
-	q := `SELECT * FROM commissions WHERE deleted_at IS NULL`
+	q := `SELECT ` + commissionColumns + ` FROM commissions WHERE deleted_at IS NULL`
 	if onlyPending {
 		q += ` AND approved=false`
 	}
@@ -72,6 +138,28 @@ func (r *PostgresRepository) ListCommissions(ctx context.Context, onlyPending bo
 	return commissions, nil
 }
 
+// EachCommission percorre as comissoes de ListCommissions direto do cursor,
+// sem carregar a lista em memoria.
+func (r *PostgresRepository) EachCommission(ctx context.Context, onlyPending bool, fn func(Commission) error) error {
+	q := `SELECT ` + commissionColumns + ` FROM commissions
+        WHERE deleted_at IS NULL AND (NOT $1 OR approved = false) ORDER BY created_at, id`
+	rows, err := r.db.QueryxContext(ctx, q, onlyPending)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c Commission
+		if err := rows.StructScan(&c); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // ApproveCommission marca uma comissao como aprovada.
 func (r *PostgresRepository) ApproveCommission(ctx context.Context, id string, approverID string) error {
 	const q = `UPDATE commissions SET approved=true, approved_by=$2, approved_at=now() WHERE id=$1 AND approved=false`
@@ -97,4 +185,233 @@ func (r *PostgresRepository) ApproveCommission(ctx context.Context, id string, a
 	return ErrAlreadyApproved
 }
 
+// openAt seleciona as parcelas em aberto na data $1: ainda abertas ou pagas
+// depois dela, desde que ja existissem.
+const openAt = `ar.deleted_at IS NULL AND ar.created_at::date <= $1
+    AND (ar.status IN ('open', 'overdue') OR (ar.status = 'paid' AND ar.paid_at::date > $1))`
+
+// Aging agrupa as parcelas em aberto em date por cliente e faixa de atraso.
+func (r *PostgresRepository) Aging(ctx context.Context, date time.Time) (AgingReport, error) {
+	q := `SELECT cu.id AS customer_id, cu.legal_name AS customer_name,
+            COALESCE(SUM(ar.amount) FILTER (WHERE ar.due_date >= $1), 0) AS current,
+            COALESCE(SUM(ar.amount) FILTER (WHERE $1::date - ar.due_date BETWEEN 1 AND 30), 0) AS d1_30,
+            COALESCE(SUM(ar.amount) FILTER (WHERE $1::date - ar.due_date BETWEEN 31 AND 60), 0) AS d31_60,
+            COALESCE(SUM(ar.amount) FILTER (WHERE $1::date - ar.due_date BETWEEN 61 AND 90), 0) AS d61_90,
+            COALESCE(SUM(ar.amount) FILTER (WHERE $1::date - ar.due_date > 90), 0) AS over_90,
+            SUM(ar.amount) AS total
+        FROM accounts_receivable ar
+        JOIN contracts c ON c.id = ar.contract_id
+        JOIN customers cu ON cu.id = c.customer_id
+        WHERE ` + openAt + `
+        GROUP BY cu.id, cu.legal_name
+        ORDER BY total DESC, cu.legal_name`
+	rows := []AgingRow{}
+	if err := r.db.SelectContext(ctx, &rows, q, date.Format("2006-01-02")); err != nil {
+		return AgingReport{}, err
+	}
+	return NewAgingReport(date, rows), nil
+}
+
+// CashFlow preve as entradas das parcelas em aberto por semana ou mes entre
+// from e to, incluindo periodos sem vencimentos.
+func (r *PostgresRepository) CashFlow(ctx context.Context, from, to time.Time, period string) (CashFlowReport, error) {
+	const q = `SELECT p::date AS period_start, COUNT(ar.id) AS receivables, COALESCE(SUM(ar.amount), 0) AS amount
+        FROM generate_series(date_trunc($3, $1::timestamp), $2::timestamp, ('1 ' || $3)::interval) p
+        LEFT JOIN accounts_receivable ar ON ar.deleted_at IS NULL AND ar.status IN ('open', 'overdue')
+            AND ar.due_date BETWEEN GREATEST(p::date, $1::date) AND LEAST((p + ('1 ' || $3)::interval)::date - 1, $2::date)
+        GROUP BY p
+        ORDER BY p`
+	const qOverdue = `SELECT COALESCE(SUM(amount), 0) FROM accounts_receivable
+        WHERE deleted_at IS NULL AND status IN ('open', 'overdue') AND due_date < $1`
+	rep := CashFlowReport{From: from, To: to, Period: period, Periods: []CashFlowRow{}}
+	f, t := from.Format("2006-01-02"), to.Format("2006-01-02")
+	if err := r.db.SelectContext(ctx, &rep.Periods, q, f, t, period); err != nil {
+		return CashFlowReport{}, err
+	}
+	if err := r.db.GetContext(ctx, &rep.Overdue, qOverdue, f); err != nil {
+		return CashFlowReport{}, err
+	}
+	accumulate(rep.Periods)
+	return rep, nil
+}
+
+// DSO compara o saldo em aberto em date com o faturado (vencimentos nao
+// cancelados) nos days anteriores.
+func (r *PostgresRepository) DSO(ctx context.Context, date time.Time, days int) (DSOReport, error) {
+	q := `SELECT
+            COALESCE(SUM(ar.amount) FILTER (WHERE ` + openAt + `), 0) AS receivables,
+            COALESCE(SUM(ar.amount) FILTER (WHERE ar.status <> 'cancelled'
+                AND ar.due_date > $1::date - $2::int AND ar.due_date <= $1), 0) AS billed
+        FROM accounts_receivable ar
+        WHERE ar.deleted_at IS NULL`
+	rep := DSOReport{Date: date, Days: days}
+	if err := r.db.GetContext(ctx, &rep, q, date.Format("2006-01-02"), days); err != nil {
+		return DSOReport{}, err
+	}
+	computeDSO(&rep)
+	return rep, nil
+}
+
 var _ Repository = (*PostgresRepository)(nil)
+
+// ChargeTarget busca a parcela e os dados do cliente do contrato.
+func (r *PostgresRepository) ChargeTarget(ctx context.Context, receivableID string) (AccountReceivable, Payer, error) {
+	var ar AccountReceivable
+	q := `SELECT ` + receivableColumns + ` FROM accounts_receivable WHERE id=$1 AND deleted_at IS NULL`
+	if err := r.db.GetContext(ctx, &ar, q, receivableID); err != nil {
+		return AccountReceivable{}, Payer{}, err
+	}
+	var p Payer
+	const qp = `SELECT cu.legal_name AS name, COALESCE(cu.document_id, '') AS document_id, COALESCE(cu.email, '') AS email
+        FROM contracts c JOIN customers cu ON cu.id = c.customer_id
+        WHERE c.id=$1`
+	if err := r.db.GetContext(ctx, &p, qp, ar.ContractID); err != nil && err != sql.ErrNoRows {
+		return AccountReceivable{}, Payer{}, err
+	}
+	return ar, p, nil
+}
+
+// CreateCharge grava a cobranca emitida. O indice unico de cobrancas
+// pendentes garante no maximo uma em aberto por parcela.
+func (r *PostgresRepository) CreateCharge(ctx context.Context, c *Charge) error {
+	const q = `INSERT INTO charges (id, receivable_id, provider, provider_ref, method, status, amount, due_date,
+            digitable_line, barcode, pix_payload, payment_url, expires_at, created_by)
+        VALUES (:id, :receivable_id, :provider, :provider_ref, :method, :status, :amount, :due_date,
+            :digitable_line, :barcode, :pix_payload, :payment_url, :expires_at, :created_by)
+        RETURNING created_at, updated_at`
+	rows, err := r.db.NamedQueryContext(ctx, q, c)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "uq_charges_pending" {
+			return ErrChargePending
+		}
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&c.CreatedAt, &c.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListCharges retorna as cobrancas da parcela, da mais recente para a mais antiga.
+func (r *PostgresRepository) ListCharges(ctx context.Context, receivableID string) ([]Charge, error) {
+	charges := []Charge{}
+	q := `SELECT ` + chargeColumns + ` FROM charges WHERE receivable_id=$1 ORDER BY created_at DESC, id DESC`
+	if err := r.db.SelectContext(ctx, &charges, q, receivableID); err != nil {
+		return nil, err
+	}
+	return charges, nil
+}
+
+// FindCharge busca uma cobranca da parcela.
+func (r *PostgresRepository) FindCharge(ctx context.Context, receivableID, id string) (Charge, error) {
+	var c Charge
+	q := `SELECT ` + chargeColumns + ` FROM charges WHERE id=$1 AND receivable_id=$2`
+	err := r.db.GetContext(ctx, &c, q, id, receivableID)
+	return c, err
+}
+
+// CancelCharge cancela uma cobranca pendente.
+func (r *PostgresRepository) CancelCharge(ctx context.Context, id string) error {
+	const q = `UPDATE charges SET status='cancelled', cancelled_at=now(), updated_at=now() WHERE id=$1 AND status='pending'`
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 1 {
+		return nil
+	}
+	var status string
+	if err := r.db.GetContext(ctx, &status, `SELECT status FROM charges WHERE id=$1`, id); err != nil {
+		return err
+	}
+	return ErrChargeNotPending
+}
+
+// SaveWebhookEvent grava o evento; a chave (provider, event_id) faz da
+// reentrega apenas mais uma tentativa do registro original.
+func (r *PostgresRepository) SaveWebhookEvent(ctx context.Context, e *WebhookEvent) error {
+	const q = `INSERT INTO webhook_events (id, provider, event_id, event_type, provider_ref, occurred_at, payload)
+        VALUES (:id, :provider, :event_id, :event_type, :provider_ref, :occurred_at, :payload)
+        ON CONFLICT (provider, event_id) DO UPDATE SET attempts = webhook_events.attempts + 1
+        RETURNING id, status, error, attempts, received_at, processed_at`
+	rows, err := r.db.NamedQueryContext(ctx, q, e)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&e.ID, &e.Status, &e.Error, &e.Attempts, &e.ReceivedAt, &e.ProcessedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ProcessWebhookEvent aplica o evento em uma transacao. O bloqueio do evento
+// serializa entregas simultaneas e o status processed/ignored impede que uma
+// reentrega seja aplicada de novo. Pagamento de parcela que nao estava mais
+// pagavel fica processed com o motivo em error, para o estorno.
+func (r *PostgresRepository) ProcessWebhookEvent(ctx context.Context, id string) (string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var e WebhookEvent
+	if err := tx.GetContext(ctx, &e, `SELECT `+webhookColumns+` FROM webhook_events WHERE id=$1 FOR UPDATE`, id); err != nil {
+		return "", err
+	}
+	if e.Status == WebhookProcessed || e.Status == WebhookIgnored {
+		return e.Status, nil
+	}
+
+	status, reason := WebhookIgnored, (*string)(nil)
+	var ch Charge
+	q := `SELECT ` + chargeColumns + ` FROM charges WHERE provider=$1 AND provider_ref=$2 FOR UPDATE`
+	err = tx.GetContext(ctx, &ch, q, e.Provider, e.ProviderRef)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		msg := "charge not found"
+		reason = &msg
+	case err != nil:
+		return "", err
+	default:
+		ar, err := lockReceivable(ctx, tx, ch.ReceivableID)
+		if err != nil {
+			return "", err
+		}
+		event := GatewayEvent{ID: e.EventID, Type: e.Type, ProviderRef: e.ProviderRef, OccurredAt: e.OccurredAt}
+		if changed, note := applyEvent(event, &ch, &ar); changed {
+			status = WebhookProcessed
+			if note != "" {
+				reason = &note
+			}
+			const qc = `UPDATE charges SET status=$2, paid_at=$3, cancelled_at=$4, refunded_at=$5, updated_at=now() WHERE id=$1`
+			if _, err := tx.ExecContext(ctx, qc, ch.ID, ch.Status, ch.PaidAt, ch.CancelledAt, ch.RefundedAt); err != nil {
+				return "", err
+			}
+			if err := saveReceivableStatus(ctx, tx, ar); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	const qe = `UPDATE webhook_events SET status=$2, error=$3, processed_at=now() WHERE id=$1`
+	if _, err := tx.ExecContext(ctx, qe, id, status, reason); err != nil {
+		return "", err
+	}
+	return status, tx.Commit()
+}
+
+// FailWebhookEvent registra a falha do processamento; o evento volta a ser
+// processado na proxima entrega do gateway.
+func (r *PostgresRepository) FailWebhookEvent(ctx context.Context, id string, cause error) error {
+	const q = `UPDATE webhook_events SET status='failed', error=$2 WHERE id=$1 AND status IN ('received', 'failed')`
+	_, err := r.db.ExecContext(ctx, q, id, cause.Error())
+	return err
+}
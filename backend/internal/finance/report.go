@@ -0,0 +1,201 @@
+package finance
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rgomids/bckoffice/pkg/export"
+)
+
+// Periodos do fluxo de caixa.
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Padroes dos relatorios.
+const (
+	defaultForecastMonths = 3
+	defaultDSODays        = 90
+	maxDSODays            = 365
+	maxForecastMonths     = 24
+)
+
+var ErrInvalidReport = errors.New("invalid report parameters")
+
+// AgingRow soma as parcelas em aberto de um cliente por faixa de atraso.
+type AgingRow struct {
+	CustomerID   string  `db:"customer_id" json:"customerID"`
+	CustomerName string  `db:"customer_name" json:"customerName"`
+	Current      float64 `db:"current" json:"current"`
+	Days1To30    float64 `db:"d1_30" json:"days1To30"`
+	Days31To60   float64 `db:"d31_60" json:"days31To60"`
+	Days61To90   float64 `db:"d61_90" json:"days61To90"`
+	Over90       float64 `db:"over_90" json:"over90"`
+	Total        float64 `db:"total" json:"total"`
+}
+
+// AgingReport eh a posicao de contas a receber por cliente em Date.
+type AgingReport struct {
+	Date      time.Time  `json:"date"`
+	Customers []AgingRow `json:"customers"`
+	Totals    AgingRow   `json:"totals"`
+}
+
+// CashFlowRow soma as parcelas em aberto que vencem no periodo.
+type CashFlowRow struct {
+	PeriodStart time.Time `db:"period_start" json:"periodStart"`
+	Receivables int       `db:"receivables" json:"receivables"`
+	Amount      float64   `db:"amount" json:"amount"`
+	Cumulative  float64   `db:"-" json:"cumulative"`
+}
+
+// CashFlowReport eh a previsao de entradas das parcelas em aberto. Overdue
+// traz o que ja venceu antes de From e segue em aberto.
+type CashFlowReport struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Period  string        `json:"period"`
+	Overdue float64       `json:"overdue"`
+	Periods []CashFlowRow `json:"periods"`
+}
+
+// DSOReport eh o prazo medio de recebimento: saldo em aberto em Date sobre
+// o faturado nos Days anteriores, multiplicado por Days.
+type DSOReport struct {
+	Date        time.Time `json:"date"`
+	Days        int       `json:"days"`
+	Receivables float64   `db:"receivables" json:"receivables"`
+	Billed      float64   `db:"billed" json:"billed"`
+	DSO         float64   `json:"dso"`
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func parseDate(q url.Values, name string, def time.Time) (time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, ErrInvalidReport
+	}
+	return t, nil
+}
+
+// ParseCashFlow le from, to (AAAA-MM-DD) e period (week|month). O padrao eh
+// de hoje ate tres meses a frente, por mes.
+func ParseCashFlow(q url.Values) (from, to time.Time, period string, err error) {
+	if from, err = parseDate(q, "from", today()); err != nil {
+		return
+	}
+	if to, err = parseDate(q, "to", from.AddDate(0, defaultForecastMonths, 0)); err != nil {
+		return
+	}
+	period = q.Get("period")
+	if period == "" {
+		period = PeriodMonth
+	}
+	if (period != PeriodWeek && period != PeriodMonth) || to.Before(from) || from.AddDate(0, maxForecastMonths, 0).Before(to) {
+		err = ErrInvalidReport
+	}
+	return
+}
+
+// ParseDSO le date (AAAA-MM-DD) e days (1 a 365, padrao 90).
+func ParseDSO(q url.Values) (time.Time, int, error) {
+	date, err := parseDate(q, "date", today())
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	days := defaultDSODays
+	if v := q.Get("days"); v != "" {
+		if days, err = strconv.Atoi(v); err != nil || days < 1 || days > maxDSODays {
+			return time.Time{}, 0, ErrInvalidReport
+		}
+	}
+	return date, days, nil
+}
+
+// NewAgingReport soma os totais das linhas por cliente.
+func NewAgingReport(date time.Time, rows []AgingRow) AgingReport {
+	rep := AgingReport{Date: date, Customers: rows, Totals: AgingRow{CustomerName: "Total"}}
+	for _, r := range rows {
+		rep.Totals.Current += r.Current
+		rep.Totals.Days1To30 += r.Days1To30
+		rep.Totals.Days31To60 += r.Days31To60
+		rep.Totals.Days61To90 += r.Days61To90
+		rep.Totals.Over90 += r.Over90
+		rep.Totals.Total += r.Total
+	}
+	t := &rep.Totals
+	for _, v := range []*float64{&t.Current, &t.Days1To30, &t.Days31To60, &t.Days61To90, &t.Over90, &t.Total} {
+		*v = round2(*v)
+	}
+	return rep
+}
+
+// accumulate preenche o acumulado de cada periodo.
+func accumulate(rows []CashFlowRow) {
+	var sum float64
+	for i := range rows {
+		sum = round2(sum + rows[i].Amount)
+		rows[i].Cumulative = sum
+	}
+}
+
+// computeDSO calcula o indicador com uma casa decimal; sem faturamento no
+// periodo o DSO eh zero.
+func computeDSO(r *DSOReport) {
+	if r.Billed <= 0 {
+		r.DSO = 0
+		return
+	}
+	r.DSO = math.Round(r.Receivables/r.Billed*float64(r.Days)*10) / 10
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Table converte o relatorio em planilha, com a linha de totais no fim.
+func (r AgingReport) Table() export.Table {
+	t := export.Table{
+		Sheet:   "Aging",
+		Columns: []string{"Cliente", "A vencer", "1-30", "31-60", "61-90", "90+", "Total"},
+	}
+	rows := make([]AgingRow, 0, len(r.Customers)+1)
+	rows = append(append(rows, r.Customers...), r.Totals)
+	for _, row := range rows {
+		t.Rows = append(t.Rows, []any{row.CustomerName, row.Current, row.Days1To30, row.Days31To60, row.Days61To90, row.Over90, row.Total})
+	}
+	return t
+}
+
+// Table converte a previsao em planilha.
+func (r CashFlowReport) Table() export.Table {
+	t := export.Table{
+		Sheet:   "Fluxo de caixa",
+		Columns: []string{"Periodo", "Parcelas", "Valor", "Acumulado"},
+	}
+	for _, row := range r.Periods {
+		t.Rows = append(t.Rows, []any{row.PeriodStart, row.Receivables, row.Amount, row.Cumulative})
+	}
+	return t
+}
+
+// Table converte o DSO em planilha de uma linha.
+func (r DSOReport) Table() export.Table {
+	return export.Table{
+		Sheet:   "DSO",
+		Columns: []string{"Data", "Dias", "Em aberto", "Faturado", "DSO"},
+		Rows:    [][]any{{r.Date, r.Days, r.Receivables, r.Billed, r.DSO}},
+	}
+}
@@ -0,0 +1,122 @@
+package finance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/rgomids/bckoffice/pkg/export"
+)
+
+// report eh um relatorio que pode ser exportado como planilha.
+type report interface {
+	Table() export.Table
+}
+
+// @Summary      Aging de contas a receber por cliente
+// @Description  Parcelas em aberto na data por faixa: a vencer, 1-30, 31-60, 61-90 e 90+ dias
+// @Tags         finance
+// @Security     BearerAuth
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        date    query  string  false  "Data de referencia (AAAA-MM-DD), padrao hoje"
+// @Param        format  query  string  false  "json|csv|xlsx"
+// @Success      200  {object}  AgingReport
+// @Router       /finance/reports/aging [get]
+func (h handler) agingReport(w http.ResponseWriter, r *http.Request) {
+	format, ok := reportFormat(w, r)
+	if !ok {
+		return
+	}
+	date, err := parseDate(r.URL.Query(), "date", today())
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+	rep, err := h.repo.Aging(r.Context(), date)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writeReport(w, format, fmt.Sprintf("aging-%s", date.Format("2006-01-02")), rep)
+}
+
+// @Summary      Previsao de fluxo de caixa
+// @Description  Parcelas em aberto por semana ou mes de vencimento
+// @Tags         finance
+// @Security     BearerAuth
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        from    query  string  false  "Inicio (AAAA-MM-DD), padrao hoje"
+// @Param        to      query  string  false  "Fim (AAAA-MM-DD), padrao tres meses apos o inicio"
+// @Param        period  query  string  false  "week|month"
+// @Param        format  query  string  false  "json|csv|xlsx"
+// @Success      200  {object}  CashFlowReport
+// @Router       /finance/reports/cashflow [get]
+func (h handler) cashFlowReport(w http.ResponseWriter, r *http.Request) {
+	format, ok := reportFormat(w, r)
+	if !ok {
+		return
+	}
+	from, to, period, err := ParseCashFlow(r.URL.Query())
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+	rep, err := h.repo.CashFlow(r.Context(), from, to, period)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writeReport(w, format, fmt.Sprintf("fluxo-de-caixa-%s", from.Format("2006-01-02")), rep)
+}
+
+// @Summary      Prazo medio de recebimento (DSO)
+// @Tags         finance
+// @Security     BearerAuth
+// @Produce      json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        date    query  string  false  "Data de referencia (AAAA-MM-DD), padrao hoje"
+// @Param        days    query  int     false  "Janela em dias (1-365), padrao 90"
+// @Param        format  query  string  false  "json|csv|xlsx"
+// @Success      200  {object}  DSOReport
+// @Router       /finance/reports/dso [get]
+func (h handler) dsoReport(w http.ResponseWriter, r *http.Request) {
+	format, ok := reportFormat(w, r)
+	if !ok {
+		return
+	}
+	date, days, err := ParseDSO(r.URL.Query())
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+	rep, err := h.repo.DSO(r.Context(), date, days)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writeReport(w, format, fmt.Sprintf("dso-%s", date.Format("2006-01-02")), rep)
+}
+
+func reportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format, err := export.Negotiate(r)
+	if err != nil {
+		writeReportError(w, err)
+		return "", false
+	}
+	return format, true
+}
+
+func writeReportError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// writeReport responde em JSON ou como planilha para download.
+func writeReport(w http.ResponseWriter, format, name string, rep report) {
+	if format == export.JSON {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rep)
+		return
+	}
+	_ = export.Write(w, format, name, rep.Table())
+}
@@ -0,0 +1,151 @@
+package finance
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getReport(t *testing.T, url, token, accept string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	return resp
+}
+
+func TestAgingReportJSONAndCSV(t *testing.T) {
+	repo := &fakeRepository{aging: []AgingRow{
+		{CustomerID: "c1", CustomerName: "Alfa", Current: 100, Days1To30: 50.25, Total: 150.25},
+		{CustomerID: "c2", CustomerName: "Beta", Over90: 30.1, Total: 30.1},
+	}}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := getReport(t, server.URL+"/finance/reports/aging?date=2026-10-19", token, "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var rep AgingReport
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rep.Totals.Total != 180.35 || rep.Totals.Over90 != 30.1 || len(rep.Customers) != 2 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+
+	resp = getReport(t, server.URL+"/finance/reports/aging?date=2026-10-19&format=csv", token, "")
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected content type %s", resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), `filename="aging-2026-10-19.csv"`) {
+		t.Fatalf("unexpected disposition %s", resp.Header.Get("Content-Disposition"))
+	}
+	if !strings.Contains(string(body), "Alfa;100,00;50,25;0,00;0,00;0,00;150,25") || !strings.Contains(string(body), "Total;100,00;50,25;0,00;0,00;30,10;180,35") {
+		t.Fatalf("unexpected csv:\n%s", body)
+	}
+}
+
+func TestCashFlowReportXLSX(t *testing.T) {
+	repo := &fakeRepository{cashflow: []CashFlowRow{
+		{PeriodStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Receivables: 2, Amount: 200},
+		{PeriodStart: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Receivables: 1, Amount: 50.5},
+	}}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := getReport(t, server.URL+"/finance/reports/cashflow?from=2026-10-01&to=2026-11-30", token, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("expected xlsx: %v", err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(data)
+		}
+	}
+	if !strings.Contains(sheet, `<c r="D3" s="1"><v>250.5</v></c>`) {
+		t.Fatalf("expected cumulative in sheet:\n%s", sheet)
+	}
+}
+
+func TestReportValidation(t *testing.T) {
+	router, token := setupRouter(&fakeRepository{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for _, u := range []string{
+		"/finance/reports/cashflow?period=day",
+		"/finance/reports/cashflow?from=2026-10-01&to=2026-09-01",
+		"/finance/reports/dso?days=0",
+		"/finance/reports/aging?date=19/10/2026",
+		"/finance/reports/aging?format=pdf",
+	} {
+		resp := getReport(t, server.URL+u, token, "")
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", u, resp.StatusCode)
+		}
+	}
+}
+
+func TestDSOReport(t *testing.T) {
+	router, token := setupRouter(&fakeRepository{dso: DSOReport{Receivables: 3000, Billed: 9000}})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := getReport(t, server.URL+"/finance/reports/dso?date=2026-10-19&days=90", token, "")
+	defer resp.Body.Close()
+	var rep DSOReport
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rep.DSO != 30 || rep.Days != 90 {
+		t.Fatalf("unexpected dso: %+v", rep)
+	}
+}
+
+func TestExportReceivablesCSV(t *testing.T) {
+	paid := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	repo := &fakeRepository{receivables: []AccountReceivable{
+		{ID: "r1", ContractID: "k1", DueDate: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), Amount: 1500.5, Status: "paid", PaidAt: &paid},
+		{ID: "r2", ContractID: "k1", DueDate: time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC), Amount: 1500.5, Status: "open"},
+	}}
+	router, token := setupRouter(repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp := getReport(t, server.URL+"/receivables?status=paid", token, "text/csv")
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	want := "ID;Contrato;Vencimento;Valor;Status;Pago em;Valor atualizado;Valor pago\nr1;k1;05/03/2026;1500,50;paid;10/03/2026 09:30;;\n"
+	if got := strings.TrimPrefix(string(body), "\ufeff"); got != want {
+		t.Fatalf("unexpected csv:\n%q", got)
+	}
+}
@@ -0,0 +1,76 @@
+package finance
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+// maxWebhookSize limita o corpo aceito no webhook.
+const maxWebhookSize = 1 << 20
+
+// RegisterPublicRoutes adiciona o webhook do gateway de pagamento. A
+// assinatura do corpo eh a unica credencial.
+func RegisterPublicRoutes(r chi.Router, repo Repository, gateway Gateway) {
+	h := handler{repo: repo, gateway: gateway}
+	r.Post("/webhooks/payments", h.paymentWebhook)
+}
+
+// WebhookResult eh a resposta devolvida ao gateway.
+type WebhookResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// @Summary      Recebe eventos do gateway de pagamento
+// @Description  Verifica a assinatura HMAC (header X-Signature), grava o corpo original e aplica o evento (charge.paid, charge.cancelled, charge.expired, charge.refunded) na cobranca e na parcela. Reentregas do mesmo evento nao sao aplicadas de novo; em caso de erro responde 500 para que o gateway reenvie.
+// @Tags         finance
+// @Accept       json
+// @Param        X-Signature  header  string  true  "sha256=<hmac hex do corpo>"
+// @Success      200  {object}  WebhookResult
+// @Router       /webhooks/payments [post]
+func (h handler) paymentWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	e, err := h.gateway.ParseEvent(r.Header, body)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSignature) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	ev := WebhookEvent{
+		ID:          ulid.Make().String(),
+		Provider:    h.gateway.Name(),
+		EventID:     e.ID,
+		Type:        e.Type,
+		ProviderRef: e.ProviderRef,
+		OccurredAt:  e.OccurredAt,
+		Payload:     body,
+		Status:      WebhookReceived,
+	}
+	if err := h.repo.SaveWebhookEvent(r.Context(), &ev); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	status, err := h.repo.ProcessWebhookEvent(r.Context(), ev.ID)
+	if err != nil {
+		_ = h.repo.FailWebhookEvent(r.Context(), ev.ID, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(WebhookResult{ID: ev.ID, Status: status})
+}
@@ -0,0 +1,171 @@
+package finance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupWebhookServer(repo *fakeRepository) (*httptest.Server, *FakeGateway) {
+	g := NewFakeGateway("chave@example.com", "RCM Tech", "Sao Paulo", "segredo")
+	r := chi.NewRouter()
+	RegisterPublicRoutes(r, repo, g)
+	return httptest.NewServer(r), g
+}
+
+func postWebhook(t *testing.T, url string, body []byte, signature string) (int, WebhookResult) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url+"/webhooks/payments", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, signature)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /webhooks/payments: %v", err)
+	}
+	defer resp.Body.Close()
+	var out WebhookResult
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func webhookBody(id, typ, ref string) []byte {
+	b, _ := json.Marshal(GatewayEvent{ID: id, Type: typ, ProviderRef: ref, OccurredAt: time.Date(2026, 10, 19, 14, 30, 0, 0, time.UTC)})
+	return b
+}
+
+func webhookRepo(status string) *fakeRepository {
+	return &fakeRepository{
+		receivables: []AccountReceivable{{ID: "r1", Amount: 150, Status: status}},
+		charges:     []Charge{{ID: "c1", ReceivableID: "r1", Provider: "fake", ProviderRef: "fake_c1", Status: ChargePending}},
+	}
+}
+
+func TestPaymentWebhookPaysReceivableOnce(t *testing.T) {
+	repo := webhookRepo("overdue")
+	server, g := setupWebhookServer(repo)
+	defer server.Close()
+
+	body := webhookBody("evt_1", EventChargePaid, "fake_c1")
+	status, out := postWebhook(t, server.URL, body, g.Sign(body))
+	if status != http.StatusOK || out.Status != WebhookProcessed {
+		t.Fatalf("expected processed, got %d %+v", status, out)
+	}
+	ar, ch := repo.receivables[0], repo.charges[0]
+	if ar.Status != "paid" || ar.PaidAt == nil || !ar.PaidAt.Equal(time.Date(2026, 10, 19, 14, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected receivable %+v", ar)
+	}
+	if ch.Status != ChargePaid || ch.PaidAt == nil {
+		t.Fatalf("unexpected charge %+v", ch)
+	}
+	if !bytes.Equal(repo.events[0].Payload, body) || repo.events[0].Provider != "fake" {
+		t.Fatalf("raw payload not stored: %+v", repo.events[0])
+	}
+
+	// reentrega do mesmo evento: nao aplica de novo, apenas conta a tentativa
+	repo.receivables[0].Status = "open"
+	status, again := postWebhook(t, server.URL, body, g.Sign(body))
+	if status != http.StatusOK || again.ID != out.ID || again.Status != WebhookProcessed {
+		t.Fatalf("expected same processed event, got %d %+v", status, again)
+	}
+	if len(repo.events) != 1 || repo.events[0].Attempts != 2 || repo.receivables[0].Status != "open" {
+		t.Fatalf("redelivery was applied again: %+v", repo.events)
+	}
+}
+
+func TestPaymentWebhookSignature(t *testing.T) {
+	repo := webhookRepo("open")
+	server, g := setupWebhookServer(repo)
+	defer server.Close()
+
+	body := webhookBody("evt_1", EventChargePaid, "fake_c1")
+	for _, sig := range []string{"", "sha256=00", g.Sign([]byte("outro corpo"))} {
+		if status, _ := postWebhook(t, server.URL, body, sig); status != http.StatusUnauthorized {
+			t.Fatalf("signature %q: expected 401, got %d", sig, status)
+		}
+	}
+	if len(repo.events) != 0 || repo.receivables[0].Status != "open" {
+		t.Fatal("unsigned event must not be stored or applied")
+	}
+
+	bad := webhookBody("evt_2", "charge.created", "fake_c1")
+	if status, _ := postWebhook(t, server.URL, bad, g.Sign(bad)); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown type, got %d", status)
+	}
+}
+
+func TestPaymentWebhookRetriesAfterFailure(t *testing.T) {
+	repo := webhookRepo("open")
+	repo.processErr = errors.New("connection reset")
+	server, g := setupWebhookServer(repo)
+	defer server.Close()
+
+	body := webhookBody("evt_1", EventChargePaid, "fake_c1")
+	if status, _ := postWebhook(t, server.URL, body, g.Sign(body)); status != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", status)
+	}
+	if repo.events[0].Status != WebhookFailed || repo.receivables[0].Status != "open" {
+		t.Fatalf("expected failed event, got %+v", repo.events[0])
+	}
+	if status, out := postWebhook(t, server.URL, body, g.Sign(body)); status != http.StatusOK || out.Status != WebhookProcessed {
+		t.Fatalf("expected retry to be processed, got %d %+v", status, out)
+	}
+	if repo.receivables[0].Status != "paid" {
+		t.Fatalf("unexpected receivable %+v", repo.receivables[0])
+	}
+}
+
+func TestPaymentWebhookFlagsUnpayableReceivable(t *testing.T) {
+	repo := webhookRepo("cancelled")
+	server, g := setupWebhookServer(repo)
+	defer server.Close()
+
+	body := webhookBody("evt_1", EventChargePaid, "fake_c1")
+	if status, out := postWebhook(t, server.URL, body, g.Sign(body)); status != http.StatusOK || out.Status != WebhookProcessed {
+		t.Fatalf("expected processed, got %d %+v", status, out)
+	}
+	e := repo.events[0]
+	if e.Error == nil || *e.Error != "receivable already cancelled" {
+		t.Fatalf("expected event flagged for refund, got %+v", e)
+	}
+	if repo.charges[0].Status != ChargePaid || repo.receivables[0].Status != "cancelled" {
+		t.Fatalf("unexpected charge %+v / receivable %+v", repo.charges[0], repo.receivables[0])
+	}
+}
+
+func TestApplyEvent(t *testing.T) {
+	at := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name             string
+		event            string
+		charge, ar       string
+		changed          bool
+		wantCharge, want string
+		note             string
+	}{
+		{"paid", EventChargePaid, ChargePending, "open", true, ChargePaid, "paid", ""},
+		{"paid overdue", EventChargePaid, ChargePending, "overdue", true, ChargePaid, "paid", ""},
+		{"paid after manual payment", EventChargePaid, ChargePending, "paid", true, ChargePaid, "paid", "receivable already paid"},
+		{"paid after receivable cancelled", EventChargePaid, ChargePending, "cancelled", true, ChargePaid, "cancelled", "receivable already cancelled"},
+		{"paid after refund", EventChargePaid, ChargeCancelled, "refunded", true, ChargePaid, "refunded", "receivable already refunded"},
+		{"paid after cancel", EventChargePaid, ChargeCancelled, "open", true, ChargePaid, "paid", ""},
+		{"paid twice", EventChargePaid, ChargePaid, "paid", false, ChargePaid, "paid", ""},
+		{"cancelled", EventChargeCancelled, ChargePending, "open", true, ChargeCancelled, "open", ""},
+		{"expired", EventChargeExpired, ChargePending, "overdue", true, ChargeExpired, "overdue", ""},
+		{"cancelled after paid", EventChargeCancelled, ChargePaid, "paid", false, ChargePaid, "paid", ""},
+		{"refunded", EventChargeRefunded, ChargePaid, "paid", true, ChargeRefunded, "refunded", ""},
+		{"refunded unpaid", EventChargeRefunded, ChargePending, "open", false, ChargePending, "open", ""},
+	}
+	for _, c := range cases {
+		ch := Charge{Status: c.charge}
+		ar := AccountReceivable{Status: c.ar}
+		changed, note := applyEvent(GatewayEvent{Type: c.event, OccurredAt: at}, &ch, &ar)
+		if changed != c.changed || ch.Status != c.wantCharge || ar.Status != c.want || note != c.note {
+			t.Errorf("%s: got %v %s %s %q", c.name, changed, ch.Status, ar.Status, note)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package importer
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/pkg/export"
+)
+
+// RegisterRoutes adiciona as rotas de importacao, com um endpoint de upload
+// por Target (ex.: POST /imports/customers).
+func RegisterRoutes(r chi.Router, repo Repository, targets ...Target) {
+	h := handler{repo: repo}
+	r.Get("/imports", h.list)
+	for _, t := range targets {
+		r.Post("/imports/"+t.Entity, h.upload(t))
+	}
+	r.Get("/imports/{id}", h.get)
+	r.Get("/imports/{id}/errors", h.rowErrors)
+}
+
+type handler struct {
+	repo Repository
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// readUpload le o CSV enviado como multipart (campo "file") ou direto no corpo.
+func readUpload(w http.ResponseWriter, r *http.Request) (string, []byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	name := r.URL.Query().Get("file_name")
+	var body io.Reader = r.Body
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		f, fh, err := r.FormFile("file")
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		name, body = fh.Filename, f
+	}
+	data, err := io.ReadAll(body)
+	return name, data, err
+}
+
+// @Summary      Importa cadastros a partir de CSV
+// @Description  Valida cada linha com as regras da criacao pela API. Com dry_run=true retorna o relatorio sem gravar; sem ele, enfileira um job processado em segundo plano. Linhas com chave (documento ou nome) ja cadastrada sao ignoradas.
+// @Tags         imports
+// @Security     BearerAuth
+// @Accept       text/csv
+// @Param        entity   path   string  true   "customers, services ou promoters"
+// @Param        dry_run  query  bool    false  "Apenas valida o arquivo"
+// @Success      200  {object}  Report
+// @Success      202  {object}  Job
+// @Router       /imports/{entity} [post]
+func (h handler) upload(t Target) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, data, err := readUpload(w, r)
+		if err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		columns, records, err := ParseCSV(t, bytes.NewReader(data))
+		if err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+
+		if dry, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dry {
+			existing, err := h.repo.Existing(r.Context(), t, keys(t, records))
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			rep, _ := check(t, records, existing)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(rep)
+			return
+		}
+
+		j := Job{
+			ID:       ulid.Make().String(),
+			Entity:   t.Entity,
+			FileName: name,
+			Status:   StatusPending,
+			Columns:  columns,
+			Total:    len(records),
+		}
+		if uid := auth.UserIDFromContext(r.Context()); uid != "" {
+			j.CreatedBy = &uid
+		}
+		if err := h.repo.Create(r.Context(), &j, data); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/imports/"+j.ID)
+		w.Header().Set("X-Entity", fmt.Sprintf("import_jobs:%s", j.ID))
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(j.withProgress())
+	}
+}
+
+// @Summary      Lista importacoes recentes
+// @Tags         imports
+// @Security     BearerAuth
+// @Success      200  {array}  Job
+// @Router       /imports [get]
+func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	jobs, err := h.repo.List(r.Context())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	out := make([]Job, len(jobs))
+	for i, j := range jobs {
+		out[i] = j.withProgress()
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// @Summary      Progresso de uma importacao
+// @Tags         imports
+// @Security     BearerAuth
+// @Success      200  {object}  Job
+// @Router       /imports/{id} [get]
+func (h handler) get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j, err := h.repo.FindByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(j.withProgress())
+}
+
+// @Summary      Linhas rejeitadas de uma importacao
+// @Description  Em CSV ou XLSX (format ou Accept), gera o arquivo de erros: as colunas originais com o numero da linha e o motivo. Depois de corrigido, o arquivo pode ser reenviado como esta.
+// @Tags         imports
+// @Security     BearerAuth
+// @Param        format  query  string  false  "json, csv ou xlsx"
+// @Success      200  {array}  RowError
+// @Router       /imports/{id}/errors [get]
+func (h handler) rowErrors(w http.ResponseWriter, r *http.Request) {
+	format, err := export.Negotiate(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	j, err := h.repo.FindByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if format == export.JSON {
+		out := []RowError{}
+		err := h.repo.EachError(r.Context(), j.ID, func(e RowError) error {
+			out = append(out, e)
+			return nil
+		})
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+		return
+	}
+
+	export.Stream(w, format, "importacao-"+j.ID+"-erros", errorTable(j), func(emit func([]any) error) error {
+		return h.repo.EachError(r.Context(), j.ID, func(e RowError) error {
+			return emit(errorRow(j, e))
+		})
+	})
+}
+
+// errorTable eh o cabecalho do arquivo de erros: linha, colunas originais e erro.
+func errorTable(j Job) export.Table {
+	columns := make([]string, 0, len(j.Columns)+2)
+	columns = append(columns, columnLine)
+	for _, c := range j.Columns {
+		if c != "" {
+			columns = append(columns, c)
+		}
+	}
+	return export.Table{Sheet: "Erros", Columns: append(columns, columnError)}
+}
+
+func errorRow(j Job, e RowError) []any {
+	row := make([]any, 0, len(j.Columns)+2)
+	row = append(row, e.Line)
+	for _, c := range j.Columns {
+		if c != "" {
+			row = append(row, e.Values[c])
+		}
+	}
+	return append(row, e.Message)
+}
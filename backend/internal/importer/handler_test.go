@@ -0,0 +1,353 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepo struct {
+	jobs     []Job
+	payloads map[string][]byte
+	errs     map[string][]RowError
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{payloads: map[string][]byte{}, errs: map[string][]RowError{}}
+}
+
+func (f *fakeRepo) Existing(ctx context.Context, t Target, keys []string) (map[string]bool, error) {
+	found := map[string]bool{}
+	for _, k := range keys {
+		if _, ok := store[k]; ok {
+			found[k] = true
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeRepo) Create(ctx context.Context, j *Job, payload []byte) error {
+	j.CreatedAt = time.Now()
+	f.jobs = append(f.jobs, *j)
+	f.payloads[j.ID] = payload
+	return nil
+}
+
+func (f *fakeRepo) List(ctx context.Context) ([]Job, error) { return f.jobs, nil }
+
+func (f *fakeRepo) FindByID(ctx context.Context, id string) (Job, error) {
+	for _, j := range f.jobs {
+		if j.ID == id {
+			return j, nil
+		}
+	}
+	return Job{}, sql.ErrNoRows
+}
+
+func (f *fakeRepo) EachError(ctx context.Context, jobID string, fn func(RowError) error) error {
+	for _, e := range f.errs[jobID] {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) Claim(ctx context.Context, stale time.Duration) (Job, []byte, error) {
+	for i, j := range f.jobs {
+		if j.Status == StatusPending {
+			f.jobs[i].Status = StatusRunning
+			return f.jobs[i], f.payloads[j.ID], nil
+		}
+	}
+	return Job{}, nil, sql.ErrNoRows
+}
+
+func (f *fakeRepo) SaveProgress(ctx context.Context, j *Job, errs []RowError) error {
+	for i := range f.jobs {
+		if f.jobs[i].ID == j.ID {
+			f.jobs[i] = *j
+		}
+	}
+	if j.Status == StatusDone || j.Status == StatusFailed {
+		delete(f.payloads, j.ID)
+	}
+	f.errs[j.ID] = append(f.errs[j.ID], errs...)
+	return nil
+}
+
+// store simula a tabela de destino, indexada pelo documento.
+var store = map[string]string{}
+
+var testTarget = Target{
+	Entity:    "customers",
+	Table:     "customers",
+	KeyColumn: "document_id",
+	Columns:   []string{"name", "document_id"},
+	Key:       func(row Row) string { return strings.ReplaceAll(row.Get("document_id"), ".", "") },
+	Validate: func(row Row) error {
+		if row.Get("name") == "" {
+			return errors.New("Name: required")
+		}
+		if row.Get("document_id") == "" {
+			return errors.New("DocumentID: required")
+		}
+		return nil
+	},
+	Create: func(ctx context.Context, row Row) error {
+		key := strings.ReplaceAll(row.Get("document_id"), ".", "")
+		if row.Get("name") == "falha" {
+			return errors.New("cep lookup failed")
+		}
+		if _, ok := store[key]; ok {
+			return ErrDuplicate
+		}
+		store[key] = row.Get("name")
+		return nil
+	},
+}
+
+const sample = "\ufeffname;document_id\n" +
+	"Ana;111.222\n" +
+	";333\n" +
+	"Bia;444\n" +
+	"Ana de novo;111222\n" +
+	"\n" +
+	"Caio;555\n"
+
+func setupRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo, testTarget)
+	return r, token
+}
+
+func do(r http.Handler, method, url, token, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestParseCSV(t *testing.T) {
+	columns, records, err := ParseCSV(testTarget, strings.NewReader("Document_ID , name,erro\n1,Ana,x\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(columns) != 3 || columns[2] != "" || len(records) != 1 {
+		t.Fatalf("unexpected parse: %v %v", columns, records)
+	}
+	if r := records[0]; r.Line != 2 || r.Values.Get("name") != "Ana" || r.Values.Get("document_id") != "1" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+	if _, ok := records[0].Values["erro"]; ok {
+		t.Fatalf("error column should be ignored")
+	}
+
+	for _, in := range []string{"", "name;cpf\nAna;1\n", "name;document_id\n\n"} {
+		if _, _, err := ParseCSV(testTarget, strings.NewReader(in)); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+func TestParseDecimalAndBool(t *testing.T) {
+	for in, want := range map[string]float64{"": 0, "1234.5": 1234.5, "1234,5": 1234.5, "1.234,56": 1234.56} {
+		if got, err := ParseDecimal(in); err != nil || got != want {
+			t.Fatalf("%q: got %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseDecimal("abc"); err == nil {
+		t.Fatal("expected error")
+	}
+	if v, _ := ParseBool("", true); !v {
+		t.Fatal("empty should use default")
+	}
+	if v, _ := ParseBool("Nao", true); v {
+		t.Fatal("nao should be false")
+	}
+	if _, err := ParseBool("talvez", true); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDryRunReport(t *testing.T) {
+	store = map[string]string{"444": "Bia"}
+	repo := newFakeRepo()
+	r, token := setupRouter(repo, "admin")
+
+	rec := do(r, http.MethodPost, "/imports/customers?dry_run=true", token, "text/csv", []byte(sample))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var rep Report
+	_ = json.NewDecoder(rec.Body).Decode(&rep)
+	if rep.Total != 5 || rep.Valid != 2 || rep.Existing != 1 || rep.Invalid != 2 {
+		t.Fatalf("unexpected report %+v", rep)
+	}
+	if rep.Errors[0].Line != 3 || rep.Errors[0].Message != "Name: required" {
+		t.Fatalf("unexpected first error %+v", rep.Errors[0])
+	}
+	if rep.Errors[1].Line != 5 || rep.Errors[1].Message != "duplicated in line 2" {
+		t.Fatalf("unexpected second error %+v", rep.Errors[1])
+	}
+	if len(repo.jobs) != 0 || len(store) != 1 {
+		t.Fatalf("dry run must not write")
+	}
+}
+
+func TestImportJob(t *testing.T) {
+	store = map[string]string{"444": "Bia"}
+	repo := newFakeRepo()
+	r, token := setupRouter(repo, "finance")
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "clientes.csv")
+	_, _ = fw.Write([]byte(sample + "falha;777\n"))
+	mw.Close()
+
+	rec := do(r, http.MethodPost, "/imports/customers", token, mw.FormDataContentType(), body.Bytes())
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job Job
+	_ = json.NewDecoder(rec.Body).Decode(&job)
+	if job.Status != StatusPending || job.Total != 6 || job.FileName != "clientes.csv" || job.Progress != 0 {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	if rec.Header().Get("Location") != "/imports/"+job.ID || *repo.jobs[0].CreatedBy != "u1" {
+		t.Fatalf("unexpected location %s", rec.Header().Get("Location"))
+	}
+
+	runner := NewRunner(repo, time.Minute, testTarget)
+	if n, err := runner.RunOnce(context.Background()); err != nil || n != 1 {
+		t.Fatalf("run: %d %v", n, err)
+	}
+
+	rec = do(r, http.MethodGet, "/imports/"+job.ID, token, "", nil)
+	_ = json.NewDecoder(rec.Body).Decode(&job)
+	if job.Status != StatusDone || job.Progress != 100 || job.Created != 2 || job.Skipped != 1 || job.Failed != 3 {
+		t.Fatalf("unexpected progress %+v", job)
+	}
+	if _, ok := repo.payloads[job.ID]; ok {
+		t.Fatal("uploaded file must be discarded when the job is done")
+	}
+	keys := make([]string, 0, len(store))
+	for k := range store {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "111222,444,555" {
+		t.Fatalf("unexpected store %v", keys)
+	}
+
+	rec = do(r, http.MethodGet, "/imports/"+job.ID+"/errors?format=csv", token, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	want := "linha;name;document_id;erro\n" +
+		"3;;333;Name: required\n" +
+		"5;Ana de novo;111222;duplicated in line 2\n" +
+		"8;falha;777;cep lookup failed\n"
+	if got := strings.TrimPrefix(rec.Body.String(), bom); got != want {
+		t.Fatalf("unexpected error file:\n%s", got)
+	}
+
+	// o arquivo de erros corrigido pode ser reenviado como esta
+	fixed := strings.Replace(rec.Body.String(), ";;333;", ";Dani;333;", 1)
+	rec = do(r, http.MethodPost, "/imports/customers?dry_run=true", token, "text/csv", []byte(fixed))
+	var rep Report
+	_ = json.NewDecoder(rec.Body).Decode(&rep)
+	if rep.Total != 3 || rep.Valid != 2 || rep.Existing != 1 {
+		t.Fatalf("unexpected report for error file %+v", rep)
+	}
+}
+
+func TestRunnerIsIdempotent(t *testing.T) {
+	store = map[string]string{}
+	repo := newFakeRepo()
+	r, token := setupRouter(repo, "admin")
+	for i := 0; i < 2; i++ {
+		if rec := do(r, http.MethodPost, "/imports/customers", token, "text/csv", []byte(sample)); rec.Code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d", rec.Code)
+		}
+	}
+	if _, err := NewRunner(repo, time.Minute, testTarget).RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	first, second := repo.jobs[0], repo.jobs[1]
+	if first.Created != 3 || second.Created != 0 || second.Skipped != 3 || len(store) != 3 {
+		t.Fatalf("second import should skip everything: %+v %+v", first, second)
+	}
+}
+
+func TestRunnerResumesFromProcessed(t *testing.T) {
+	store = map[string]string{}
+	repo := newFakeRepo()
+	repo.jobs = []Job{{ID: "j1", Entity: "customers", Status: StatusPending, Total: 5, Processed: 3, Created: 1, Failed: 1}}
+	repo.payloads["j1"] = []byte(sample)
+	if _, err := NewRunner(repo, time.Minute, testTarget).RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	j := repo.jobs[0]
+	if j.Processed != 5 || j.Created != 2 || j.Failed != 2 || len(store) != 1 || store["555"] != "Caio" {
+		t.Fatalf("unexpected resume %+v %v", j, store)
+	}
+}
+
+func TestRunnerDiscardsPayloadOfFailedJob(t *testing.T) {
+	repo := newFakeRepo()
+	repo.jobs = []Job{{ID: "j1", Entity: "contracts", Status: StatusPending}}
+	repo.payloads["j1"] = []byte(sample)
+	if _, err := NewRunner(repo, time.Minute, testTarget).RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if repo.jobs[0].Status != StatusFailed || repo.payloads["j1"] != nil {
+		t.Fatalf("failed job must drop the uploaded file: %+v", repo.jobs[0])
+	}
+}
+
+func TestUnknownColumn(t *testing.T) {
+	r, token := setupRouter(newFakeRepo(), "admin")
+	rec := do(r, http.MethodPost, "/imports/customers", token, "text/csv", []byte("nome;document_id\nAna;1\n"))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "unknown column") {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestImportNotFoundAndForbidden(t *testing.T) {
+	r, token := setupRouter(newFakeRepo(), "admin")
+	if rec := do(r, http.MethodGet, "/imports/nope", token, "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	r, token = setupRouter(newFakeRepo(), "promoter")
+	if rec := do(r, http.MethodPost, "/imports/customers", token, "text/csv", []byte(sample)); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
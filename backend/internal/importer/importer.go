@@ -0,0 +1,331 @@
+// Package importer importa cadastros em lote a partir de arquivos CSV.
+//
+// Cada modulo descreve o que importa com um Target (colunas, chave de
+// idempotencia, validacao e criacao). O upload pode ser validado sem gravar
+// nada (dry-run) ou virar um Job, processado em segundo plano pelo Runner.
+// Linhas cuja chave ja esta cadastrada sao ignoradas, entao reenviar o mesmo
+// arquivo, ou retomar um job interrompido, nao duplica registros.
+package importer
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Limites do arquivo enviado.
+const (
+	maxImportSize = 10 << 20
+	maxRows       = 50000
+)
+
+// Colunas acrescentadas ao arquivo de erros. Sao ignoradas no upload, para o
+// arquivo corrigido poder ser reenviado como esta.
+const (
+	columnLine  = "linha"
+	columnError = "erro"
+)
+
+// bom eh o marcador UTF-8 que o Excel grava no inicio do CSV.
+const bom = "\ufeff"
+
+var (
+	ErrDuplicate     = errors.New("already registered")
+	ErrEmptyFile     = errors.New("no rows found")
+	ErrTooManyRows   = fmt.Errorf("file exceeds %d rows", maxRows)
+	ErrUnknownEntity = errors.New("unknown import entity")
+)
+
+// Repository define a fila de jobs de importacao.
+type Repository interface {
+	// Existing retorna quais das chaves ja estao cadastradas em t.Table.
+	Existing(ctx context.Context, t Target, keys []string) (map[string]bool, error)
+	Create(ctx context.Context, j *Job, payload []byte) error
+	List(ctx context.Context) ([]Job, error)
+	FindByID(ctx context.Context, id string) (Job, error)
+	EachError(ctx context.Context, jobID string, fn func(RowError) error) error
+	// Claim reserva o proximo job pendente, ou um em execucao sem progresso
+	// ha mais de stale, e retorna o arquivo enviado. Sem jobs, retorna
+	// sql.ErrNoRows.
+	Claim(ctx context.Context, stale time.Duration) (Job, []byte, error)
+	// SaveProgress grava situacao e contadores do job junto com as novas
+	// linhas rejeitadas. Ao encerrar o job, o arquivo enviado eh descartado.
+	SaveProgress(ctx context.Context, j *Job, errs []RowError) error
+}
+
+// Row eh uma linha do CSV indexada pelo nome da coluna.
+type Row map[string]string
+
+// Get retorna o valor da coluna sem espacos nas pontas.
+func (r Row) Get(column string) string {
+	return strings.TrimSpace(r[column])
+}
+
+// Value grava a linha como JSONB.
+func (r Row) Value() (driver.Value, error) {
+	if r == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan le a linha gravada como JSONB.
+func (r *Row) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return fmt.Errorf("unsupported row type %T", src)
+}
+
+// Record eh uma linha do arquivo com o numero da linha no CSV.
+type Record struct {
+	Line   int
+	Values Row
+}
+
+// Target descreve um tipo de cadastro importavel.
+//
+// Key extrai a chave de idempotencia da linha, comparada com KeyColumn em
+// Table. Validate aplica as mesmas regras da criacao pela API e Create grava a
+// linha ja validada, retornando ErrDuplicate quando a chave ja existe.
+type Target struct {
+	Entity    string
+	Table     string
+	KeyColumn string
+	Columns   []string
+	Key       func(row Row) string
+	Validate  func(row Row) error
+	Create    func(ctx context.Context, row Row) error
+}
+
+// ParseCSV le o arquivo enviado. O separador (';' ou ',') eh detectado pelo
+// cabecalho, que deve usar os nomes de t.Columns em qualquer ordem.
+func ParseCSV(t Target, r io.Reader) ([]string, []Record, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, err
+	}
+	if strings.HasPrefix(string(first), bom) {
+		_, _ = br.Discard(len(bom))
+		first = first[len(bom):]
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header := strings.SplitN(string(first), "\n", 2)[0]
+	if strings.Count(header, ";") >= strings.Count(header, ",") && strings.Contains(header, ";") {
+		cr.Comma = ';'
+	}
+
+	head, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("line 1: %w", err)
+	}
+	columns, err := parseHeader(t, head)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []Record
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if blank(rec) {
+			continue
+		}
+		if len(records) == maxRows {
+			return nil, nil, ErrTooManyRows
+		}
+		line, _ := cr.FieldPos(0)
+		row := make(Row, len(columns))
+		for i, c := range columns {
+			if c != "" && i < len(rec) {
+				row[c] = rec[i]
+			}
+		}
+		records = append(records, Record{Line: line, Values: row})
+	}
+	if len(records) == 0 {
+		return nil, nil, ErrEmptyFile
+	}
+	return columns, records, nil
+}
+
+// parseHeader normaliza os nomes das colunas. As colunas do arquivo de erros
+// viram "" e sao descartadas na leitura das linhas.
+func parseHeader(t Target, head []string) ([]string, error) {
+	known := make(map[string]bool, len(t.Columns))
+	for _, c := range t.Columns {
+		known[c] = true
+	}
+	columns := make([]string, len(head))
+	seen := map[string]bool{}
+	for i, h := range head {
+		c := strings.ToLower(strings.TrimSpace(h))
+		switch {
+		case c == columnLine || c == columnError:
+			continue
+		case !known[c]:
+			return nil, fmt.Errorf("unknown column %q", h)
+		case seen[c]:
+			return nil, fmt.Errorf("duplicated column %q", h)
+		}
+		seen[c] = true
+		columns[i] = c
+	}
+	return columns, nil
+}
+
+func blank(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Report eh o resultado da validacao do arquivo. Valid conta as linhas que
+// serao criadas e Existing as que serao ignoradas por ja estarem cadastradas.
+type Report struct {
+	Entity   string     `json:"entity"`
+	Total    int        `json:"total"`
+	Valid    int        `json:"valid"`
+	Existing int        `json:"existing"`
+	Invalid  int        `json:"invalid"`
+	Errors   []RowError `json:"errors"`
+}
+
+// verdict eh o resultado da validacao de uma linha.
+type verdict struct {
+	key  string
+	skip bool
+	err  error
+}
+
+// check valida cada linha e marca as chaves ja cadastradas. Uma chave
+// repetida no proprio arquivo vale so na primeira ocorrencia.
+func check(t Target, records []Record, existing map[string]bool) (Report, []verdict) {
+	rep := Report{Entity: t.Entity, Total: len(records), Errors: []RowError{}}
+	verdicts := make([]verdict, len(records))
+	firstLine := map[string]int{}
+	for i, rec := range records {
+		v := verdict{key: t.Key(rec.Values)}
+		if err := t.Validate(rec.Values); err != nil {
+			v.err = err
+		} else if line, ok := firstLine[v.key]; ok {
+			v.err = fmt.Errorf("duplicated in line %d", line)
+		} else {
+			firstLine[v.key] = rec.Line
+			v.skip = existing[v.key]
+		}
+		switch {
+		case v.err != nil:
+			rep.Invalid++
+			rep.Errors = append(rep.Errors, newRowError(rec, v.key, v.err))
+		case v.skip:
+			rep.Existing++
+		default:
+			rep.Valid++
+		}
+		verdicts[i] = v
+	}
+	return rep, verdicts
+}
+
+func keys(t Target, records []Record) []string {
+	out := make([]string, 0, len(records))
+	for _, rec := range records {
+		if k := t.Key(rec.Values); k != "" {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func newRowError(rec Record, key string, err error) RowError {
+	return RowError{Line: rec.Line, Key: key, Message: Message(err), Values: rec.Values}
+}
+
+// Message resume erros do validator como "Campo: regra", um por campo.
+func Message(err error) string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err.Error()
+	}
+	parts := make([]string, len(verrs))
+	for i, fe := range verrs {
+		field := fe.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+		parts[i] = field + ": " + fe.Tag()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ParseDecimal aceita valores como "1234.56", "1234,56" e "1.234,56".
+func ParseDecimal(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// ParseBool aceita sim/nao, true/false e 1/0; vazio retorna def.
+func ParseBool(s string, def bool) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return def, nil
+	case "sim", "s", "true", "1":
+		return true, nil
+	case "nao", "não", "n", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// IntervalFromEnv le IMPORT_INTERVAL_SECONDS, usando cinco segundos como padrao.
+func IntervalFromEnv() time.Duration {
+	if v := os.Getenv("IMPORT_INTERVAL_SECONDS"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil && s > 0 {
+			return time.Duration(s) * time.Second
+		}
+	}
+	return 5 * time.Second
+}
@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Situacoes de um job de importacao.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Job eh uma importacao confirmada. Processed avanca conforme o Runner grava
+// as linhas e Progress eh o percentual correspondente.
+type Job struct {
+	ID         string         `db:"id" json:"id"`
+	Entity     string         `db:"entity" json:"entity"`
+	FileName   string         `db:"file_name" json:"fileName"`
+	Status     string         `db:"status" json:"status"`
+	Columns    pq.StringArray `db:"columns" json:"columns"`
+	Total      int            `db:"total" json:"total"`
+	Processed  int            `db:"processed" json:"processed"`
+	Created    int            `db:"created" json:"created"`
+	Skipped    int            `db:"skipped" json:"skipped"`
+	Failed     int            `db:"failed" json:"failed"`
+	Progress   int            `db:"-" json:"progress"`
+	Error      string         `db:"error" json:"error,omitempty"`
+	CreatedBy  *string        `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updatedAt"`
+	StartedAt  *time.Time     `db:"started_at" json:"startedAt,omitempty"`
+	FinishedAt *time.Time     `db:"finished_at" json:"finishedAt,omitempty"`
+}
+
+// withProgress preenche o percentual processado.
+func (j Job) withProgress() Job {
+	j.Progress = 100
+	if j.Total > 0 {
+		j.Progress = j.Processed * 100 / j.Total
+	}
+	return j
+}
+
+// RowError eh uma linha rejeitada, com os valores originais para o arquivo
+// de erros.
+type RowError struct {
+	Line    int    `db:"line" json:"line"`
+	Key     string `db:"key" json:"key,omitempty"`
+	Message string `db:"message" json:"message"`
+	Values  Row    `db:"values" json:"-"`
+}
@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// jobColumns sao as colunas de import_jobs lidas em Job (sem o payload).
+const jobColumns = `id, entity, file_name, status, columns, total, processed, created, skipped, failed,
+        error, created_by, created_at, updated_at, started_at, finished_at`
+
+// Existing consulta as chaves em lotes. Registros removidos logicamente
+// tambem contam, porque continuam ocupando a chave unica.
+func (r *PostgresRepository) Existing(ctx context.Context, t Target, keys []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	q := fmt.Sprintf(`SELECT %[1]s FROM %[2]s WHERE %[1]s = ANY($1)`, t.KeyColumn, t.Table)
+	const batch = 1000
+	for start := 0; start < len(keys); start += batch {
+		end := min(start+batch, len(keys))
+		var rows []string
+		if err := r.db.SelectContext(ctx, &rows, q, pq.Array(keys[start:end])); err != nil {
+			return nil, err
+		}
+		for _, k := range rows {
+			found[k] = true
+		}
+	}
+	return found, nil
+}
+
+// Create enfileira o job com o arquivo enviado.
+func (r *PostgresRepository) Create(ctx context.Context, j *Job, payload []byte) error {
+	const q = `INSERT INTO import_jobs (id, entity, file_name, status, columns, total, created_by, payload)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING created_at, updated_at`
+	return r.db.QueryRowxContext(ctx, q, j.ID, j.Entity, j.FileName, j.Status, j.Columns, j.Total, j.CreatedBy, payload).
+		Scan(&j.CreatedAt, &j.UpdatedAt)
+}
+
+// List retorna os jobs mais recentes.
+func (r *PostgresRepository) List(ctx context.Context) ([]Job, error) {
+	var jobs []Job
+	q := `SELECT ` + jobColumns + ` FROM import_jobs ORDER BY created_at DESC LIMIT 100`
+	if err := r.db.SelectContext(ctx, &jobs, q); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// FindByID busca um job pelo ID.
+func (r *PostgresRepository) FindByID(ctx context.Context, id string) (Job, error) {
+	var j Job
+	err := r.db.GetContext(ctx, &j, `SELECT `+jobColumns+` FROM import_jobs WHERE id=$1`, id)
+	return j, err
+}
+
+// EachError percorre as linhas rejeitadas na ordem do arquivo.
+func (r *PostgresRepository) EachError(ctx context.Context, jobID string, fn func(RowError) error) error {
+	const q = `SELECT line, key, message, "values" FROM import_job_errors WHERE job_id=$1 ORDER BY line`
+	rows, err := r.db.QueryxContext(ctx, q, jobID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e RowError
+		if err := rows.StructScan(&e); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Claim marca o job mais antigo da fila como em execucao. SKIP LOCKED evita
+// que duas instancias peguem o mesmo job.
+func (r *PostgresRepository) Claim(ctx context.Context, stale time.Duration) (Job, []byte, error) {
+	q := `UPDATE import_jobs SET status='running', started_at=COALESCE(started_at, now()), updated_at=now()
+        WHERE id = (
+            SELECT id FROM import_jobs
+            WHERE status='pending' OR (status='running' AND updated_at < now() - make_interval(secs => $1))
+            ORDER BY created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED)
+        RETURNING ` + jobColumns + `, payload`
+	var row struct {
+		Job
+		Payload []byte `db:"payload"`
+	}
+	if err := r.db.GetContext(ctx, &row, q, stale.Seconds()); err != nil {
+		return Job{}, nil, err
+	}
+	return row.Job, row.Payload, nil
+}
+
+// SaveProgress atualiza o job e grava as linhas rejeitadas na mesma
+// transacao. Linhas ja gravadas numa execucao anterior sao mantidas. Jobs
+// encerrados (done/failed) descartam o arquivo enviado, que contem dados
+// pessoais e nao eh mais relido.
+func (r *PostgresRepository) SaveProgress(ctx context.Context, j *Job, errs []RowError) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const qe = `INSERT INTO import_job_errors (job_id, line, key, message, "values")
+        VALUES ($1, $2, $3, $4, $5) ON CONFLICT (job_id, line) DO NOTHING`
+	for _, e := range errs {
+		if _, err = tx.ExecContext(ctx, qe, j.ID, e.Line, e.Key, e.Message, e.Values); err != nil {
+			return err
+		}
+	}
+	const qj = `UPDATE import_jobs SET status=$2, processed=$3, created=$4, skipped=$5, failed=$6,
+            error=$7, finished_at=$8, updated_at=now(),
+            payload=CASE WHEN $2 IN ('done', 'failed') THEN ''::bytea ELSE payload END
+        WHERE id=$1
+        RETURNING updated_at`
+	if err = tx.QueryRowxContext(ctx, qj, j.ID, j.Status, j.Processed, j.Created, j.Skipped, j.Failed, j.Error, j.FinishedAt).
+		Scan(&j.UpdatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// Parametros do processamento em segundo plano.
+const (
+	// progressEvery eh a quantidade de linhas entre gravacoes de progresso.
+	progressEvery = 50
+	// staleAfter eh o tempo sem progresso apos o qual um job em execucao eh
+	// considerado abandonado (ex.: servidor reiniciado) e retomado.
+	staleAfter = 10 * time.Minute
+)
+
+// Runner processa a fila de importacoes.
+type Runner struct {
+	repo     Repository
+	targets  map[string]Target
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewRunner cria um Runner que consulta a fila a cada interval.
+func NewRunner(repo Repository, interval time.Duration, targets ...Target) *Runner {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &Runner{repo: repo, targets: byEntity(targets), interval: interval, now: time.Now}
+}
+
+func byEntity(targets []Target) map[string]Target {
+	m := make(map[string]Target, len(targets))
+	for _, t := range targets {
+		m[t.Entity] = t
+	}
+	return m
+}
+
+// Run esvazia a fila imediatamente e depois a cada intervalo ate ctx ser cancelado.
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if n, err := r.RunOnce(ctx); err != nil {
+			log.Printf("import runner: %v", err)
+		} else if n > 0 {
+			log.Printf("import runner: %d jobs processed", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce processa os jobs da fila, um de cada vez, e retorna quantos
+// foram concluidos.
+func (r *Runner) RunOnce(ctx context.Context) (int, error) {
+	n := 0
+	for {
+		job, payload, err := r.repo.Claim(ctx, staleAfter)
+		if errors.Is(err, sql.ErrNoRows) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if err := r.process(ctx, &job, payload); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// process cria as linhas validas a partir de job.Processed. Como as chaves ja
+// cadastradas sao ignoradas, repetir linhas de uma execucao interrompida nao
+// duplica registros.
+func (r *Runner) process(ctx context.Context, job *Job, payload []byte) error {
+	t, ok := r.targets[job.Entity]
+	if !ok {
+		return r.fail(ctx, job, ErrUnknownEntity)
+	}
+	_, records, err := ParseCSV(t, bytes.NewReader(payload))
+	if err != nil {
+		return r.fail(ctx, job, err)
+	}
+	existing, err := r.repo.Existing(ctx, t, keys(t, records))
+	if err != nil {
+		return err
+	}
+	_, verdicts := check(t, records, existing)
+
+	var errs []RowError
+	for i := job.Processed; i < len(records); i++ {
+		rec, v := records[i], verdicts[i]
+		switch {
+		case v.err != nil:
+			job.Failed++
+			errs = append(errs, newRowError(rec, v.key, v.err))
+		case v.skip:
+			job.Skipped++
+		default:
+			err := t.Create(ctx, rec.Values)
+			switch {
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case errors.Is(err, ErrDuplicate):
+				job.Skipped++
+			case err != nil:
+				job.Failed++
+				errs = append(errs, newRowError(rec, v.key, err))
+			default:
+				job.Created++
+			}
+		}
+		job.Processed = i + 1
+		if job.Processed%progressEvery == 0 {
+			if err := r.repo.SaveProgress(ctx, job, errs); err != nil {
+				return err
+			}
+			errs = nil
+		}
+	}
+	job.Status = StatusDone
+	finished := r.now()
+	job.FinishedAt = &finished
+	return r.repo.SaveProgress(ctx, job, errs)
+}
+
+// fail encerra o job quando o arquivo nao pode ser processado.
+func (r *Runner) fail(ctx context.Context, job *Job, cause error) error {
+	job.Status = StatusFailed
+	job.Error = cause.Error()
+	finished := r.now()
+	job.FinishedAt = &finished
+	return r.repo.SaveProgress(ctx, job, nil)
+}
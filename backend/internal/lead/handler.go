@@ -1,6 +1,7 @@
 package lead
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -13,19 +14,17 @@ import (
 	"github.com/oklog/ulid/v2"
 
 	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+	"github.com/rgomids/bckoffice/internal/tag"
 )
 
 // RegisterRoutes adiciona as rotas do modulo Lead.
 func RegisterRoutes(r chi.Router, repo Repository) {
 	h := handler{repo: repo, validate: validator.New()}
 
-	r.Group(func(gr chi.Router) {
-		gr.Use(auth.RequireRole("admin", "promoter"))
-		gr.Get("/leads", h.list)
-	})
-
+	r.Get("/leads", h.list)
 	r.Post("/leads", h.create)
-	r.With(auth.RequireRole("finance", "admin")).Put("/leads/{id}/status", h.updateStatus)
+	r.Put("/leads/{id}/status", h.updateStatus)
 	r.Put("/leads/{id}", h.update)
 	r.Delete("/leads/{id}", h.remove)
 }
@@ -55,12 +54,13 @@ type statusInput struct {
 // @Summary      Lista leads
 // @Tags         leads
 // @Security     BearerAuth
+// @Param        tags  query  string  false  "Tags separadas por virgula (todas obrigatorias)"
 // @Success      200  {array}  Lead
 // @Router       /leads [get]
 func (h handler) list(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	status := r.URL.Query().Get("status")
-	leads, err := h.repo.List(r.Context(), status)
+	leads, err := h.repo.List(r.Context(), status, tag.ParseFilter(r.URL.Query()))
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -99,6 +99,11 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.repo.Create(r.Context(), &l); err != nil {
+		if errors.Is(err, ErrInactiveService) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -146,6 +151,7 @@ func (h handler) updateStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary      Atualiza lead
+// @Description  Promotores so alteram os proprios leads e nao podem repassa-los a outro promotor (403).
 // @Tags         leads
 // @Security     BearerAuth
 // @Success      204  {null}  nil
@@ -165,6 +171,22 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	promoterID, err := h.ownPromoter(r.Context(), id, in.PromoterID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrNotOwner) {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	in.PromoterID = promoterID
+
 	l := Lead{
 		ID:         id,
 		ServiceID:  in.ServiceID,
@@ -174,6 +196,11 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.repo.Update(r.Context(), &l); err != nil {
+		if errors.Is(err, ErrInactiveService) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 			return
@@ -186,6 +213,27 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// ownPromoter restringe promotores aos proprios leads: o lead precisa ser do
+// promotor vinculado ao usuario e continua com ele. Para as demais roles o
+// promoter_id informado eh mantido.
+func (h handler) ownPromoter(ctx context.Context, id string, promoterID *string) (*string, error) {
+	if auth.RoleFromContext(ctx) != permission.Promoter {
+		return promoterID, nil
+	}
+	own, err := h.repo.PromoterOfUser(ctx, auth.UserIDFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	l, err := h.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if own == "" || l.PromoterID == nil || *l.PromoterID != own || (promoterID != nil && *promoterID != own) {
+		return nil, ErrNotOwner
+	}
+	return &own, nil
+}
+
 // @Summary      Remove lead
 // @Tags         leads
 // @Security     BearerAuth
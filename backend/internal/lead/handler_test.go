@@ -15,13 +15,17 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
 )
 
 type fakeRepository struct {
-	leads []Lead
+	leads    []Lead
+	inactive map[string]bool
+	// promoters vincula usuarios a promotores
+	promoters map[string]string
 }
 
-func (f *fakeRepository) List(ctx context.Context, status string) ([]Lead, error) {
+func (f *fakeRepository) List(ctx context.Context, status string, tags []string) ([]Lead, error) {
 	out := make([]Lead, 0)
 	for _, l := range f.leads {
 		if l.DeletedAt == nil && (status == "" || l.Status == status) {
@@ -32,6 +36,9 @@ func (f *fakeRepository) List(ctx context.Context, status string) ([]Lead, error
 }
 
 func (f *fakeRepository) Create(ctx context.Context, l *Lead) error {
+	if f.inactive[l.ServiceID] {
+		return ErrInactiveService
+	}
 	f.leads = append(f.leads, *l)
 	return nil
 }
@@ -53,6 +60,9 @@ func (f *fakeRepository) UpdateStatus(ctx context.Context, id string, newStatus
 func (f *fakeRepository) Update(ctx context.Context, l *Lead) error {
 	for i, lead := range f.leads {
 		if lead.ID == l.ID {
+			if lead.ServiceID != l.ServiceID && f.inactive[l.ServiceID] {
+				return ErrInactiveService
+			}
 			lead.ServiceID = l.ServiceID
 			lead.PromoterID = l.PromoterID
 			lead.Notes = l.Notes
@@ -78,15 +88,32 @@ func (f *fakeRepository) SoftDelete(ctx context.Context, id string) error {
 	return sql.ErrNoRows
 }
 
+func (f *fakeRepository) FindByID(ctx context.Context, id string) (Lead, error) {
+	for _, l := range f.leads {
+		if l.ID == id && l.DeletedAt == nil {
+			return l, nil
+		}
+	}
+	return Lead{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) PromoterOfUser(ctx context.Context, userID string) (string, error) {
+	return f.promoters[userID], nil
+}
+
 func setupRouter(repo Repository, role string) (*chi.Mux, string) {
 	os.Setenv("JWT_SECRET", "testsecret")
-	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
-	tokenStr, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
-
 	r := chi.NewRouter()
 	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
 	RegisterRoutes(r, repo)
-	return r, tokenStr
+	return r, tokenFor("u1", role)
+}
+
+func tokenFor(sub, role string) string {
+	claims := jwt.MapClaims{"sub": sub, "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	tokenStr, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+	return tokenStr
 }
 
 func TestCreateLead(t *testing.T) {
@@ -137,3 +164,77 @@ func TestStatusTransitionInvalid(t *testing.T) {
 		t.Fatalf("expected 400, got %d", resp.StatusCode)
 	}
 }
+
+func TestLeadRejectsInactiveService(t *testing.T) {
+	repo := &fakeRepository{
+		leads:    []Lead{{ID: "l1", CustomerID: "c1", ServiceID: "old", Status: "lead"}},
+		inactive: map[string]bool{"old": true, "off": true},
+	}
+	router := chi.NewRouter()
+	RegisterRoutes(router, repo)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/leads", "application/json", strings.NewReader(`{"customer_id":"c1","service_id":"off"}`))
+	if err != nil {
+		t.Fatalf("POST /leads error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for inactive service, got %d", resp.StatusCode)
+	}
+
+	for body, want := range map[string]int{
+		`{"service_id":"off"}`:                http.StatusBadRequest,
+		`{"service_id":"old","notes":"keep"}`: http.StatusNoContent,
+	} {
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/leads/l1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PUT /leads/l1 error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: expected status %d, got %d", body, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestPromoterUpdatesOnlyOwnLeads(t *testing.T) {
+	pa := "pA"
+	repo := &fakeRepository{
+		leads:     []Lead{{ID: "l1", CustomerID: "c1", ServiceID: "s1", PromoterID: &pa, Status: "lead"}},
+		promoters: map[string]string{"uA": "pA", "uB": "pB"},
+	}
+	router, _ := setupRouter(repo, "promoter")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	put := func(sub, body string) int {
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/leads/l1", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tokenFor(sub, "promoter"))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PUT /leads/l1 error: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := put("uB", `{"service_id":"s1","notes":"meu"}`); got != http.StatusForbidden {
+		t.Fatalf("expected 403 for another promoter, got %d", got)
+	}
+	if got := put("uA", `{"service_id":"s1","promoter_id":"pB"}`); got != http.StatusForbidden {
+		t.Fatalf("expected 403 handing the lead over, got %d", got)
+	}
+	if repo.leads[0].Notes != "" || *repo.leads[0].PromoterID != "pA" {
+		t.Fatalf("lead changed: %+v", repo.leads[0])
+	}
+	if got := put("uA", `{"service_id":"s1","notes":"retornar"}`); got != http.StatusNoContent {
+		t.Fatalf("expected 204 for the owner, got %d", got)
+	}
+	if repo.leads[0].Notes != "retornar" || repo.leads[0].PromoterID == nil || *repo.leads[0].PromoterID != "pA" {
+		t.Fatalf("unexpected lead %+v", repo.leads[0])
+	}
+}
@@ -1,12 +1,25 @@
 package lead
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrInactiveService indica servico inexistente ou inativo, que nao pode
+// ser usado em novos leads.
+var ErrInactiveService = errors.New("service not found or inactive")
+
+// ErrNotOwner indica promotor alterando lead de outro promotor.
+var ErrNotOwner = errors.New("lead belongs to another promoter")
 
 // Repository define operacoes para gerenciar leads de vendas.
 type Repository interface {
-	List(ctx context.Context, statusFilter string) ([]Lead, error)
+	List(ctx context.Context, statusFilter string, tags []string) ([]Lead, error)
 	Create(ctx context.Context, l *Lead) error
 	UpdateStatus(ctx context.Context, id string, newStatus string) error
 	Update(ctx context.Context, l *Lead) error
 	SoftDelete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (Lead, error)
+	// PromoterOfUser retorna o promotor vinculado ao usuario ("" se nenhum).
+	PromoterOfUser(ctx context.Context, userID string) (string, error)
 }
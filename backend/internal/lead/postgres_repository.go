@@ -4,9 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/tag"
 )
 
 // PostgresRepository implementa Repository usando PostgreSQL.
@@ -19,25 +23,40 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-// List retorna todos os leads filtrando opcionalmente por status.
-func (r *PostgresRepository) List(ctx context.Context, status string) ([]Lead, error) {
+// List retorna todos os leads filtrando opcionalmente por status e tags.
+func (r *PostgresRepository) List(ctx context.Context, status string, tags []string) ([]Lead, error) {
 	leads := []Lead{}
 	q := `SELECT * FROM leads WHERE deleted_at IS NULL`
+	args := []interface{}{}
 	if status != "" {
-		q += ` AND status = $1`
-		if err := r.db.SelectContext(ctx, &leads, q, status); err != nil {
-			return nil, err
-		}
-	} else {
-		if err := r.db.SelectContext(ctx, &leads, q); err != nil {
-			return nil, err
-		}
+		args = append(args, status)
+		q += fmt.Sprintf(` AND status = $%d`, len(args))
+	}
+	if len(tags) > 0 {
+		args = append(args, pq.Array(tags))
+		q += ` AND ` + tag.FilterClause("leads", "id", fmt.Sprintf("$%d", len(args)))
+	}
+	if err := r.db.SelectContext(ctx, &leads, q, args...); err != nil {
+		return nil, err
 	}
 	return leads, nil
 }
 
-// Create insere um novo lead gerando ULID.
+// checkService garante que o servico existe e esta ativo.
+func checkService(ctx context.Context, q sqlx.QueryerContext, serviceID string) error {
+	var active bool
+	err := sqlx.GetContext(ctx, q, &active, `SELECT is_active FROM services WHERE id=$1 AND deleted_at IS NULL`, serviceID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !active) {
+		return ErrInactiveService
+	}
+	return err
+}
+
+// Create insere um novo lead gerando ULID. O servico deve estar ativo.
 func (r *PostgresRepository) Create(ctx context.Context, l *Lead) error {
+	if err := checkService(ctx, r.db, l.ServiceID); err != nil {
+		return err
+	}
 	l.ID = ulid.Make().String()
 	const q = `INSERT INTO leads (id, customer_id, promoter_id, service_id, status, notes)
         VALUES (:id, :customer_id, :promoter_id, :service_id, :status, :notes)`
@@ -74,8 +93,18 @@ func (r *PostgresRepository) UpdateStatus(ctx context.Context, id string, newSta
 	return nil
 }
 
-// Update altera dados de um lead.
+// Update altera dados de um lead. A troca de servico exige servico ativo;
+// manter o servico atual continua permitido mesmo se ele foi desativado.
 func (r *PostgresRepository) Update(ctx context.Context, l *Lead) error {
+	var current string
+	if err := r.db.GetContext(ctx, &current, `SELECT service_id FROM leads WHERE id=$1 AND deleted_at IS NULL`, l.ID); err != nil {
+		return err
+	}
+	if current != l.ServiceID {
+		if err := checkService(ctx, r.db, l.ServiceID); err != nil {
+			return err
+		}
+	}
 	const q = `UPDATE leads SET promoter_id=:promoter_id, service_id=:service_id, notes=:notes, updated_at=now()
         WHERE id=:id AND deleted_at IS NULL`
 	res, err := r.db.NamedExecContext(ctx, q, l)
@@ -88,6 +117,23 @@ func (r *PostgresRepository) Update(ctx context.Context, l *Lead) error {
 	return nil
 }
 
+// FindByID retorna um lead nao removido.
+func (r *PostgresRepository) FindByID(ctx context.Context, id string) (Lead, error) {
+	var l Lead
+	err := r.db.GetContext(ctx, &l, `SELECT * FROM leads WHERE id=$1 AND deleted_at IS NULL`, id)
+	return l, err
+}
+
+// PromoterOfUser retorna o promotor vinculado ao usuario em users.promoter_id.
+func (r *PostgresRepository) PromoterOfUser(ctx context.Context, userID string) (string, error) {
+	var id sql.NullString
+	err := r.db.GetContext(ctx, &id, `SELECT promoter_id FROM users WHERE id=$1 AND deleted_at IS NULL`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return id.String, err
+}
+
 // SoftDelete marca um lead como removido.
 func (r *PostgresRepository) SoftDelete(ctx context.Context, id string) error {
 	res, err := r.db.ExecContext(ctx, `UPDATE leads SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`, id)
@@ -0,0 +1,211 @@
+package note
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+// RegisterRoutes adiciona as rotas do modulo Note. Notas nao podem ser
+// removidas; edicoes ficam registradas em /notes/{id}/revisions.
+func RegisterRoutes(r chi.Router, repo Repository) {
+	h := handler{repo: repo, validate: validator.New()}
+	r.Get("/notes", h.list)
+	r.Post("/notes", h.create)
+	r.Put("/notes/{id}", h.update)
+	r.Get("/notes/{id}/revisions", h.revisions)
+}
+
+type handler struct {
+	repo     Repository
+	validate *validator.Validate
+}
+
+// CreateNoteInput define o payload para criacao de notas.
+type CreateNoteInput struct {
+	EntityName string `json:"entity_name" validate:"required"`
+	EntityID   string `json:"entity_id" validate:"required"`
+	Text       string `json:"text" validate:"required"`
+}
+
+// UpdateNoteInput define o payload para edicao de notas.
+type UpdateNoteInput struct {
+	Text string `json:"text" validate:"required"`
+}
+
+// @Summary      Lista notas de uma entidade
+// @Tags         notes
+// @Security     BearerAuth
+// @Param        entity     query  string  true  "customers, contracts, leads, promoters ou services"
+// @Param        entity_id  query  string  true  "ID da entidade"
+// @Success      200  {array}  Note
+// @Router       /notes [get]
+func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	entity := r.URL.Query().Get("entity")
+	entityID := r.URL.Query().Get("entity_id")
+	if entity == "" || entityID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "entity and entity_id are required"})
+		return
+	}
+	if !canView(r, entity) {
+		writeError(w, ErrForbidden)
+		return
+	}
+	notes, err := h.repo.List(r.Context(), entity, entityID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(notes)
+}
+
+// @Summary      Cria nota
+// @Tags         notes
+// @Security     BearerAuth
+// @Success      201  {object}  Note
+// @Router       /notes [post]
+func (h handler) create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var in CreateNoteInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	in.Text = strings.TrimSpace(in.Text)
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if !canView(r, in.EntityName) {
+		writeError(w, ErrForbidden)
+		return
+	}
+
+	n := Note{
+		ID:         ulid.Make().String(),
+		EntityName: in.EntityName,
+		EntityID:   in.EntityID,
+		AuthorID:   authorFromRequest(r),
+		Text:       in.Text,
+	}
+	if err := h.repo.Create(r.Context(), &n); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/notes/"+n.ID)
+	w.Header().Set("X-Entity", fmt.Sprintf("notes:%s", n.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(n)
+}
+
+// @Summary      Edita nota (somente o autor)
+// @Tags         notes
+// @Security     BearerAuth
+// @Success      200  {object}  Note
+// @Router       /notes/{id} [put]
+func (h handler) update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in UpdateNoteInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	in.Text = strings.TrimSpace(in.Text)
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if !h.visible(w, r, id) {
+		return
+	}
+
+	n := Note{ID: id, AuthorID: authorFromRequest(r), Text: in.Text}
+	if err := h.repo.Update(r.Context(), &n); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("notes:%s", id))
+	_ = json.NewEncoder(w).Encode(n)
+}
+
+// @Summary      Lista o historico de edicoes da nota
+// @Tags         notes
+// @Security     BearerAuth
+// @Success      200  {array}  Revision
+// @Router       /notes/{id}/revisions [get]
+func (h handler) revisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id := chi.URLParam(r, "id")
+	if !h.visible(w, r, id) {
+		return
+	}
+	revisions, err := h.repo.Revisions(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(revisions)
+}
+
+// canView informa se o usuario pode ver a entidade dona das notas, pela
+// politica de listagem da entidade. Entidades desconhecidas seguem para o
+// repositorio, que responde ErrUnknownEntity.
+func canView(r *http.Request, entity string) bool {
+	return !entities[entity] || permission.Routes.CanView(entity, auth.RoleFromContext(r.Context()))
+}
+
+// visible carrega a nota e responde 404/403 quando ela nao existe ou sua
+// entidade nao eh visivel para o usuario.
+func (h handler) visible(w http.ResponseWriter, r *http.Request, id string) bool {
+	n, err := h.repo.Find(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return false
+	}
+	if !canView(r, n.EntityName) {
+		writeError(w, ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// authorFromRequest retorna o usuario autenticado ou nil quando ausente.
+func authorFromRequest(r *http.Request) *string {
+	if id := auth.UserIDFromContext(r.Context()); id != "" {
+		return &id
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownEntity), errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, ErrNotAuthor), errors.Is(err, ErrForbidden):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,218 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepository struct {
+	notes     []Note
+	revisions []Revision
+}
+
+func (f *fakeRepository) List(ctx context.Context, entity, entityID string) ([]Note, error) {
+	if !entities[entity] {
+		return nil, ErrUnknownEntity
+	}
+	out := []Note{}
+	for _, n := range f.notes {
+		if n.EntityName == entity && n.EntityID == entityID {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) Find(ctx context.Context, id string) (Note, error) {
+	for _, n := range f.notes {
+		if n.ID == id {
+			return n, nil
+		}
+	}
+	return Note{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) Create(ctx context.Context, n *Note) error {
+	if !entities[n.EntityName] {
+		return ErrUnknownEntity
+	}
+	n.CreatedAt = time.Now()
+	f.notes = append(f.notes, *n)
+	return nil
+}
+
+func (f *fakeRepository) Update(ctx context.Context, n *Note) error {
+	for i, cur := range f.notes {
+		if cur.ID != n.ID {
+			continue
+		}
+		if cur.AuthorID == nil || n.AuthorID == nil || *cur.AuthorID != *n.AuthorID {
+			return ErrNotAuthor
+		}
+		f.revisions = append(f.revisions, Revision{ID: "r", NoteID: cur.ID, Text: cur.Text, EditedBy: n.AuthorID})
+		now := time.Now()
+		f.notes[i].Text = n.Text
+		f.notes[i].EditedAt = &now
+		*n = f.notes[i]
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	out := []Revision{}
+	for _, r := range f.revisions {
+		if r.NoteID == id {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func tokenFor(userID string) string {
+	return tokenAs(userID, permission.Admin)
+}
+
+func tokenAs(userID, role string) string {
+	claims := jwt.MapClaims{"sub": userID, "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+	return token
+}
+
+func setupRouter(repo Repository) *chi.Mux {
+	os.Setenv("JWT_SECRET", "testsecret")
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo)
+	return r
+}
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestCreateAndListNotes(t *testing.T) {
+	repo := &fakeRepository{}
+	server := httptest.NewServer(setupRouter(repo))
+	defer server.Close()
+
+	resp := do(t, http.MethodPost, server.URL+"/notes", tokenFor("u1"),
+		`{"entity_name":"customers","entity_id":"c1","text":"retornar ligacao"}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var created Note
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.AuthorID == nil || *created.AuthorID != "u1" {
+		t.Fatalf("author not taken from token: %+v", created)
+	}
+
+	resp2 := do(t, http.MethodGet, server.URL+"/notes?entity=customers&entity_id=c1", tokenFor("u2"), "")
+	defer resp2.Body.Close()
+	var list []Note
+	if err := json.NewDecoder(resp2.Body).Decode(&list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list) != 1 || list[0].Text != "retornar ligacao" {
+		t.Fatalf("unexpected timeline: %+v", list)
+	}
+
+	resp3 := do(t, http.MethodGet, server.URL+"/notes?entity=customers", tokenFor("u2"), "")
+	resp3.Body.Close()
+	if resp3.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp3.StatusCode)
+	}
+
+	resp4 := do(t, http.MethodPost, server.URL+"/notes", tokenFor("u1"),
+		`{"entity_name":"users","entity_id":"u1","text":"x"}`)
+	resp4.Body.Close()
+	if resp4.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp4.StatusCode)
+	}
+}
+
+func TestEditNoteKeepsHistory(t *testing.T) {
+	author := "u1"
+	repo := &fakeRepository{notes: []Note{{ID: "n1", EntityName: "leads", EntityID: "l1", AuthorID: &author, Text: "v1"}}}
+	server := httptest.NewServer(setupRouter(repo))
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/notes/n1", tokenFor("u2"), `{"text":"invasao"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", resp.StatusCode)
+	}
+
+	resp2 := do(t, http.MethodPut, server.URL+"/notes/n1", tokenFor("u1"), `{"text":"v2"}`)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp2.StatusCode)
+	}
+
+	resp3 := do(t, http.MethodGet, server.URL+"/notes/n1/revisions", tokenFor("u2"), "")
+	defer resp3.Body.Close()
+	var revs []Revision
+	if err := json.NewDecoder(resp3.Body).Decode(&revs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(revs) != 1 || revs[0].Text != "v1" || repo.notes[0].Text != "v2" {
+		t.Fatalf("unexpected history: %+v / %+v", revs, repo.notes[0])
+	}
+}
+
+func TestNotesFollowEntityVisibility(t *testing.T) {
+	author := "u1"
+	repo := &fakeRepository{notes: []Note{{ID: "n1", EntityName: "customers", EntityID: "c1", AuthorID: &author, Text: "v1"}}}
+	server := httptest.NewServer(setupRouter(repo))
+	defer server.Close()
+
+	promoter := tokenAs("u1", permission.Promoter)
+	cases := []struct {
+		method, url, body string
+	}{
+		{http.MethodGet, "/notes?entity=customers&entity_id=c1", ""},
+		{http.MethodPost, "/notes", `{"entity_name":"customers","entity_id":"c1","text":"x"}`},
+		{http.MethodPut, "/notes/n1", `{"text":"v2"}`},
+		{http.MethodGet, "/notes/n1/revisions", ""},
+	}
+	for _, c := range cases {
+		resp := do(t, c.method, server.URL+c.url, promoter, c.body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("%s %s: expected status 403, got %d", c.method, c.url, resp.StatusCode)
+		}
+	}
+	if len(repo.notes) != 1 || repo.notes[0].Text != "v1" {
+		t.Fatalf("notes changed: %+v", repo.notes)
+	}
+
+	resp := do(t, http.MethodPost, server.URL+"/notes", promoter, `{"entity_name":"leads","entity_id":"l1","text":"ok"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+}
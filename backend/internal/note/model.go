@@ -0,0 +1,26 @@
+package note
+
+import "time"
+
+// Note representa uma anotacao interna vinculada a uma entidade.
+type Note struct {
+	ID         string     `db:"id" json:"id"`
+	EntityName string     `db:"entity_name" json:"entityName"`
+	EntityID   string     `db:"entity_id" json:"entityID"`
+	AuthorID   *string    `db:"author_id" json:"authorID,omitempty"`
+	AuthorName string     `db:"author_name" json:"authorName"`
+	Text       string     `db:"text" json:"text"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	EditedAt   *time.Time `db:"edited_at" json:"editedAt,omitempty"`
+	// Mentions contem os IDs dos usuarios notificados na ultima gravacao.
+	Mentions []string `db:"-" json:"mentions,omitempty"`
+}
+
+// Revision guarda o texto de uma nota antes de cada edicao.
+type Revision struct {
+	ID        string    `db:"id" json:"id"`
+	NoteID    string    `db:"note_id" json:"noteID"`
+	Text      string    `db:"text" json:"text"`
+	EditedBy  *string   `db:"edited_by" json:"editedBy,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,50 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Repository define operacoes sobre a linha do tempo de notas.
+type Repository interface {
+	List(ctx context.Context, entity, entityID string) ([]Note, error)
+	Find(ctx context.Context, id string) (Note, error)
+	Create(ctx context.Context, n *Note) error
+	Update(ctx context.Context, n *Note) error
+	Revisions(ctx context.Context, id string) ([]Revision, error)
+}
+
+// Errors especificos
+
+var (
+	ErrUnknownEntity = errors.New("unknown entity")
+	ErrNotAuthor     = errors.New("only the author can edit a note")
+	ErrForbidden     = errors.New("entity not visible for this role")
+)
+
+// entities lista as tabelas que aceitam notas; o nome da entidade eh o da tabela.
+var entities = map[string]bool{
+	"customers": true,
+	"contracts": true,
+	"leads":     true,
+	"promoters": true,
+	"services":  true,
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.%+-]+@[\w-]+(?:\.[\w-]+)*\.[A-Za-z]{2,})`)
+
+// ParseMentions extrai os e-mails mencionados como @email, em minusculas e sem repeticao.
+func ParseMentions(text string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		email := strings.ToLower(m[1])
+		if !seen[email] {
+			seen[email] = true
+			out = append(out, email)
+		}
+	}
+	return out
+}
@@ -0,0 +1,18 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	text := "ligar amanha, @Ana@empresa.com.br e @joao@empresa.com. cc @ana@empresa.com.br; contato@cliente.com nao eh mencao"
+	got := ParseMentions(text)
+	want := []string{"ana@empresa.com.br", "joao@empresa.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := ParseMentions("sem mencoes"); got != nil {
+		t.Fatalf("expected nil, got %v", got)
+	}
+}
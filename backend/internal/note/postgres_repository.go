@@ -0,0 +1,186 @@
+package note
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/notification"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// List retorna a linha do tempo de notas da entidade, mais recentes primeiro.
+func (r *PostgresRepository) List(ctx context.Context, entity, entityID string) ([]Note, error) {
+	if !entities[entity] {
+		return nil, ErrUnknownEntity
+	}
+	notes := []Note{}
+	const q = `SELECT n.*, COALESCE(u.full_name,'') AS author_name
+        FROM notes n LEFT JOIN users u ON u.id = n.author_id
+        WHERE n.entity_name=$1 AND n.entity_id=$2
+        ORDER BY n.created_at DESC`
+	if err := r.db.SelectContext(ctx, &notes, q, entity, entityID); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// Find retorna uma nota pelo ID.
+func (r *PostgresRepository) Find(ctx context.Context, id string) (Note, error) {
+	var n Note
+	const q = `SELECT n.*, COALESCE(u.full_name,'') AS author_name
+        FROM notes n LEFT JOIN users u ON u.id = n.author_id
+        WHERE n.id=$1`
+	err := r.db.GetContext(ctx, &n, q, id)
+	return n, err
+}
+
+// Create insere a nota em uma entidade existente e notifica os usuarios mencionados.
+func (r *PostgresRepository) Create(ctx context.Context, n *Note) error {
+	if !entities[n.EntityName] {
+		return ErrUnknownEntity
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	q := fmt.Sprintf(`SELECT 1 FROM %s WHERE id=$1 AND deleted_at IS NULL`, n.EntityName)
+	if err = tx.GetContext(ctx, &exists, q, n.EntityID); err != nil {
+		return err
+	}
+
+	const qi = `INSERT INTO notes (id, entity_name, entity_id, author_id, text)
+        VALUES ($1, $2, $3, $4, $5) RETURNING created_at`
+	if err = tx.GetContext(ctx, &n.CreatedAt, qi, n.ID, n.EntityName, n.EntityID, n.AuthorID, n.Text); err != nil {
+		return err
+	}
+	if n.AuthorID != nil {
+		const qa = `SELECT COALESCE(full_name,'') FROM users WHERE id=$1`
+		if err = tx.GetContext(ctx, &n.AuthorName, qa, *n.AuthorID); err != nil {
+			return err
+		}
+	}
+	if n.Mentions, err = notifyMentions(ctx, tx, n, ParseMentions(n.Text)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update edita o texto de uma nota do proprio autor, guardando o texto
+// anterior em note_revisions. Apenas mencoes novas geram notificacao.
+func (r *PostgresRepository) Update(ctx context.Context, n *Note) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var cur Note
+	const qs = `SELECT n.*, COALESCE(u.full_name,'') AS author_name
+        FROM notes n LEFT JOIN users u ON u.id = n.author_id
+        WHERE n.id=$1 FOR UPDATE OF n`
+	if err = tx.GetContext(ctx, &cur, qs, n.ID); err != nil {
+		return err
+	}
+	if cur.AuthorID == nil || n.AuthorID == nil || *cur.AuthorID != *n.AuthorID {
+		return ErrNotAuthor
+	}
+	text := n.Text
+	*n = cur
+	if cur.Text == text {
+		return nil
+	}
+
+	const qr = `INSERT INTO note_revisions (id, note_id, text, edited_by) VALUES ($1, $2, $3, $4)`
+	if _, err = tx.ExecContext(ctx, qr, ulid.Make().String(), cur.ID, cur.Text, cur.AuthorID); err != nil {
+		return err
+	}
+	const qu = `UPDATE notes SET text=$2, edited_at=now() WHERE id=$1 RETURNING edited_at`
+	var editedAt time.Time
+	if err = tx.GetContext(ctx, &editedAt, qu, cur.ID, text); err != nil {
+		return err
+	}
+	n.Text = text
+	n.EditedAt = &editedAt
+
+	previous := map[string]bool{}
+	for _, email := range ParseMentions(cur.Text) {
+		previous[email] = true
+	}
+	var added []string
+	for _, email := range ParseMentions(text) {
+		if !previous[email] {
+			added = append(added, email)
+		}
+	}
+	if n.Mentions, err = notifyMentions(ctx, tx, n, added); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Revisions retorna os textos anteriores da nota, do mais antigo ao mais recente.
+func (r *PostgresRepository) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM notes WHERE id=$1`, id); err != nil {
+		return nil, err
+	}
+	revisions := []Revision{}
+	const q = `SELECT * FROM note_revisions WHERE note_id=$1 ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &revisions, q, id); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// notifyMentions cria uma notificacao para cada usuario ativo mencionado,
+// exceto o proprio autor, e retorna os IDs notificados.
+func notifyMentions(ctx context.Context, tx *sqlx.Tx, n *Note, emails []string) ([]string, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	var ids []string
+	const q = `SELECT id FROM users
+        WHERE lower(email) = ANY($1) AND deleted_at IS NULL AND id IS DISTINCT FROM $2
+        ORDER BY id`
+	if err := tx.SelectContext(ctx, &ids, q, pq.Array(emails), n.AuthorID); err != nil {
+		return nil, err
+	}
+	author := n.AuthorName
+	if author == "" {
+		author = "Alguem"
+	}
+	for _, id := range ids {
+		entity, entityID := n.EntityName, n.EntityID
+		err := notification.Insert(ctx, tx, &notification.Notification{
+			UserID:     id,
+			Kind:       notification.KindMention,
+			EntityName: &entity,
+			EntityID:   &entityID,
+			Message:    fmt.Sprintf("%s mencionou voce em uma nota", author),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+var _ Repository = (*PostgresRepository)(nil)
@@ -0,0 +1,71 @@
+package notification
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+// RegisterRoutes adiciona as rotas de notificacoes do usuario autenticado.
+func RegisterRoutes(r chi.Router, repo Repository) {
+	h := handler{repo: repo}
+	r.Get("/notifications", h.list)
+	r.Put("/notifications/{id}/read", h.markRead)
+}
+
+type handler struct {
+	repo Repository
+}
+
+// @Summary      Lista notificacoes do usuario
+// @Tags         notifications
+// @Security     BearerAuth
+// @Param        unread  query  bool  false  "Somente nao lidas"
+// @Success      200  {array}  Notification
+// @Router       /notifications [get]
+func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	unread := r.URL.Query().Get("unread") == "true"
+	out, err := h.repo.ListForUser(r.Context(), auth.UserIDFromContext(r.Context()), unread)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(visible(out, auth.RoleFromContext(r.Context())))
+}
+
+// visible descarta as notificacoes de entidades que a role do usuario nao
+// pode ver, como a mencao em nota de cliente recebida por um promotor.
+func visible(in []Notification, role string) []Notification {
+	out := []Notification{}
+	for _, n := range in {
+		if n.EntityName == nil || permission.Routes.CanView(*n.EntityName, role) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// @Summary      Marca notificacao como lida
+// @Tags         notifications
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /notifications/{id}/read [put]
+func (h handler) markRead(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.repo.MarkRead(r.Context(), id, auth.UserIDFromContext(r.Context())); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
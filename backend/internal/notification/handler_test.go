@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepository struct {
+	items []Notification
+}
+
+func (f *fakeRepository) ListForUser(ctx context.Context, userID string, unreadOnly bool) ([]Notification, error) {
+	out := []Notification{}
+	for _, n := range f.items {
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) MarkRead(ctx context.Context, id, userID string) error {
+	for i, n := range f.items {
+		if n.ID == id && n.UserID == userID {
+			now := time.Now()
+			f.items[i].ReadAt = &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func setupRouter(repo Repository) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": "sales", "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo)
+	return r, token
+}
+
+func do(t *testing.T, method, url, token string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestNotificationsForCurrentUser(t *testing.T) {
+	repo := &fakeRepository{items: []Notification{
+		{ID: "n1", UserID: "u1", Kind: KindMention, Message: "mencionado"},
+		{ID: "n2", UserID: "u2", Kind: KindMention, Message: "outro usuario"},
+	}}
+	r, token := setupRouter(repo)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/notifications/n2/read", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's notification, got %d", resp.StatusCode)
+	}
+
+	resp2 := do(t, http.MethodPut, server.URL+"/notifications/n1/read", token)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp2.StatusCode)
+	}
+
+	resp3 := do(t, http.MethodGet, server.URL+"/notifications?unread=true", token)
+	defer resp3.Body.Close()
+	var out []Notification
+	if err := json.NewDecoder(resp3.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("expected no unread notifications, got %+v", out)
+	}
+}
+
+func TestNotificationsFollowEntityVisibility(t *testing.T) {
+	customers, leads := "customers", "leads"
+	repo := &fakeRepository{items: []Notification{
+		{ID: "n1", UserID: "u1", Kind: KindMention, EntityName: &customers, Message: "nota de cliente"},
+		{ID: "n2", UserID: "u1", Kind: KindMention, EntityName: &leads, Message: "nota de lead"},
+	}}
+	r, _ := setupRouter(repo)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	claims := jwt.MapClaims{"sub": "u1", "role": permission.Promoter, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+	resp := do(t, http.MethodGet, server.URL+"/notifications", token)
+	defer resp.Body.Close()
+	var out []Notification
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out) != 1 || out[0].ID != "n2" {
+		t.Fatalf("expected only the lead notification, got %+v", out)
+	}
+}
@@ -0,0 +1,21 @@
+package notification
+
+import "time"
+
+// Notification representa um aviso destinado a um usuario.
+type Notification struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     string     `db:"user_id" json:"userID"`
+	Kind       string     `db:"kind" json:"kind"`
+	EntityName *string    `db:"entity_name" json:"entityName,omitempty"`
+	EntityID   *string    `db:"entity_id" json:"entityID,omitempty"`
+	Message    string     `db:"message" json:"message"`
+	ReadAt     *time.Time `db:"read_at" json:"readAt,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+}
+
+// Tipos de notificacao
+const (
+	KindMention          = "mention"
+	KindContractExpiring = "contract_expiring"
+)
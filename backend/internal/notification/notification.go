@@ -0,0 +1,26 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oklog/ulid/v2"
+)
+
+// Repository define operacoes de leitura das notificacoes de um usuario.
+type Repository interface {
+	ListForUser(ctx context.Context, userID string, unreadOnly bool) ([]Notification, error)
+	MarkRead(ctx context.Context, id, userID string) error
+}
+
+// Insert grava uma notificacao usando a conexao ou transacao informada,
+// permitindo que outros modulos notifiquem dentro de suas transacoes.
+func Insert(ctx context.Context, db sqlx.ExtContext, n *Notification) error {
+	if n.ID == "" {
+		n.ID = ulid.Make().String()
+	}
+	const q = `INSERT INTO notifications (id, user_id, kind, entity_name, entity_id, message)
+        VALUES (:id, :user_id, :kind, :entity_name, :entity_id, :message)`
+	_, err := sqlx.NamedExecContext(ctx, db, q, n)
+	return err
+}
@@ -0,0 +1,51 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// ListForUser retorna as notificacoes do usuario, mais recentes primeiro.
+func (r *PostgresRepository) ListForUser(ctx context.Context, userID string, unreadOnly bool) ([]Notification, error) {
+	out := []Notification{}
+	q := `SELECT * FROM notifications WHERE user_id=$1`
+	if unreadOnly {
+		q += ` AND read_at IS NULL`
+	}
+	q += ` ORDER BY created_at DESC LIMIT 200`
+	if err := r.db.SelectContext(ctx, &out, q, userID); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MarkRead marca a notificacao do usuario como lida. Marcar novamente nao tem efeito.
+func (r *PostgresRepository) MarkRead(ctx context.Context, id, userID string) error {
+	const q = `UPDATE notifications SET read_at=COALESCE(read_at, now()) WHERE id=$1 AND user_id=$2`
+	res, err := r.db.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+var _ Repository = (*PostgresRepository)(nil)
@@ -0,0 +1,154 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+)
+
+// Roles de usuario reconhecidas pelas politicas.
+const (
+	Admin    = "admin"
+	Finance  = "finance"
+	Promoter = "promoter"
+)
+
+// Policy define quem pode acessar uma rota. Rotas publicas dispensam
+// autenticacao; sem roles, qualquer usuario autenticado eh aceito.
+type Policy struct {
+	Public bool
+	Roles  []string
+}
+
+var (
+	// Public libera a rota sem autenticacao.
+	Public = Policy{Public: true}
+	// Authenticated libera a rota para qualquer usuario autenticado.
+	Authenticated = Policy{}
+)
+
+// Roles restringe a rota as roles informadas.
+func Roles(roles ...string) Policy {
+	return Policy{Roles: roles}
+}
+
+func (p Policy) allows(role string) bool {
+	if p.Public || len(p.Roles) == 0 {
+		return true
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Table associa "METODO /padrao" (padrao de rota do chi) a uma politica.
+type Table map[string]Policy
+
+// CanView informa se a role pode ver os registros da entidade (nome da
+// tabela, ex.: "customers"), segundo a politica da listagem GET /<entidade>.
+// Entidades sem listagem na tabela nao sao visiveis.
+func (t Table) CanView(entity, role string) bool {
+	p, ok := t[key(http.MethodGet, "/"+entity)]
+	return ok && p.allows(role)
+}
+
+func key(method, pattern string) string {
+	return method + " " + pattern
+}
+
+// matcher monta um roteador plano com os padroes da tabela. Os padroes
+// registrados pelo chi em sub-roteadores ("/receivables/") tambem atendem o
+// caminho sem a barra final, como no roteador real.
+func (t Table) matcher() (*chi.Mux, map[string]string) {
+	mux := chi.NewRouter()
+	keys := map[string]string{}
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for k := range t {
+		method, pattern, _ := strings.Cut(k, " ")
+		mux.Method(method, pattern, noop)
+		keys[k] = k
+		if len(pattern) > 1 && strings.HasSuffix(pattern, "/") {
+			trimmed := key(method, strings.TrimSuffix(pattern, "/"))
+			if _, ok := t[trimmed]; !ok {
+				mux.Method(method, strings.TrimSuffix(pattern, "/"), noop)
+				keys[trimmed] = k
+			}
+		}
+	}
+	return mux, keys
+}
+
+// Middleware aplica a tabela as requisicoes autenticadas. Deve ser usado
+// apos auth.AuthMiddleware. Caminhos sem politica seguem para o roteador,
+// que responde 404/405; rotas registradas sem politica impedem a subida do
+// servidor (Check).
+func (t Table) Middleware(next http.Handler) http.Handler {
+	mux, keys := t.matcher()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+		rctx := chi.NewRouteContext()
+		if !mux.Match(rctx, r.Method, path) {
+			// sem politica para o caminho: o roteador decide (404/405) e
+			// Check garante que rotas registradas estao na tabela
+			next.ServeHTTP(w, r)
+			return
+		}
+		p := t[keys[key(r.Method, rctx.RoutePattern())]]
+		if !p.allows(auth.RoleFromContext(r.Context())) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Check percorre as rotas registradas e falha se alguma nao possui politica
+// ou se a tabela contem politicas para rotas inexistentes.
+func Check(routes chi.Routes, t Table) error {
+	seen := map[string]bool{}
+	var missing, stale []string
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		k := key(method, route)
+		seen[k] = true
+		if _, ok := t[k]; !ok {
+			missing = append(missing, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for k := range t {
+		if !seen[k] {
+			stale = append(stale, k)
+		}
+	}
+	if len(missing) == 0 && len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	var b strings.Builder
+	if len(missing) > 0 {
+		fmt.Fprintf(&b, "routes without permission policy: %s", strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "policies for unknown routes: %s", strings.Join(stale, ", "))
+	}
+	return errors.New(b.String())
+}
@@ -0,0 +1,113 @@
+package permission
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+)
+
+var testTable = Table{
+	"GET /items/":         Authenticated,
+	"PUT /items/{id}":     Roles(Admin),
+	"GET /items/{id}/log": Roles(Admin, Finance),
+}
+
+func setupRouter(t Table) *chi.Mux {
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(t.Middleware)
+	r.Route("/items", func(r chi.Router) {
+		r.Get("/", ok)
+		r.Put("/{id}", ok)
+		r.Get("/{id}/log", ok)
+	})
+	return r
+}
+
+func token(t *testing.T, role string) string {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return tok
+}
+
+func TestMiddlewareAppliesTable(t *testing.T) {
+	server := httptest.NewServer(setupRouter(testTable))
+	defer server.Close()
+
+	cases := []struct {
+		method, path, role string
+		want               int
+	}{
+		{http.MethodGet, "/items", Promoter, http.StatusOK},
+		{http.MethodGet, "/items/", Promoter, http.StatusOK},
+		{http.MethodPut, "/items/i1", Finance, http.StatusForbidden},
+		{http.MethodPut, "/items/i1", Admin, http.StatusOK},
+		{http.MethodGet, "/items/i1/log", Finance, http.StatusOK},
+		{http.MethodGet, "/items/i1/log", Promoter, http.StatusForbidden},
+		{http.MethodGet, "/missing", Admin, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, server.URL+c.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token(t, c.role))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.method, c.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.want {
+			t.Fatalf("%s %s as %s: expected %d, got %d", c.method, c.path, c.role, c.want, resp.StatusCode)
+		}
+	}
+}
+
+func TestCheckReportsMissingAndStalePolicies(t *testing.T) {
+	if err := Check(setupRouter(testTable), testTable); err != nil {
+		t.Fatalf("expected complete table, got %v", err)
+	}
+
+	partial := Table{
+		"GET /items/":     Authenticated,
+		"PUT /items/{id}": Roles(Admin),
+		"DELETE /gone":    Roles(Admin),
+	}
+	err := Check(setupRouter(testTable), partial)
+	if err == nil {
+		t.Fatal("expected error for incomplete table")
+	}
+	if !strings.Contains(err.Error(), "GET /items/{id}/log") || !strings.Contains(err.Error(), "DELETE /gone") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCanView(t *testing.T) {
+	cases := []struct {
+		entity, role string
+		want         bool
+	}{
+		{"customers", Admin, true},
+		{"customers", Finance, true},
+		{"customers", Promoter, false},
+		{"leads", Promoter, true},
+		{"leads", Finance, false},
+		{"services", Promoter, true},
+		{"users", Admin, false},
+	}
+	for _, c := range cases {
+		if got := Routes.CanView(c.entity, c.role); got != c.want {
+			t.Errorf("CanView(%q, %q) = %v, want %v", c.entity, c.role, got, c.want)
+		}
+	}
+}
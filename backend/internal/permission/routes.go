@@ -0,0 +1,136 @@
+package permission
+
+// Routes eh a tabela de permissoes de todas as rotas da API. Toda rota
+// registrada precisa de uma entrada; a verificacao na inicializacao
+// (Check) impede que o servidor suba com rotas sem politica.
+var Routes = Table{
+	// publicas
+	"GET /docs/*":                    Public,
+	"GET /healthz":                   Public,
+	"POST /login":                    Public,
+	"GET /sign/{token}":              Public,
+	"GET /sign/{token}/document.pdf": Public,
+	"POST /sign/{token}":             Public,
+	"POST /sign/{token}/decline":     Public,
+	"POST /webhooks/payments":        Public,
+
+	// clientes
+	"GET /customers":                                    Roles(Admin, Finance),
+	"POST /customers":                                   Roles(Admin, Finance),
+	"GET /customers/{id}":                               Roles(Admin, Finance),
+	"PUT /customers/{id}":                               Roles(Admin, Finance),
+	"DELETE /customers/{id}":                            Roles(Admin, Finance),
+	"POST /customers/{id}/merge":                        Roles(Admin),
+	"GET /customers/{id}/addresses":                     Roles(Admin, Finance),
+	"POST /customers/{id}/addresses":                    Roles(Admin, Finance),
+	"PUT /customers/{id}/addresses/{addressID}":         Roles(Admin, Finance),
+	"DELETE /customers/{id}/addresses/{addressID}":      Roles(Admin, Finance),
+	"PUT /customers/{id}/addresses/{addressID}/primary": Roles(Admin, Finance),
+	"GET /cep/{cep}":                                    Roles(Admin, Finance),
+
+	// catalogo de servicos
+	"GET /services":                           Authenticated,
+	"POST /services":                          Roles(Admin),
+	"PUT /services/{id}":                      Roles(Admin),
+	"DELETE /services/{id}":                   Roles(Admin),
+	"GET /services/{id}/prices":               Authenticated,
+	"POST /services/{id}/prices":              Roles(Admin, Finance),
+	"GET /services/{id}/price":                Authenticated,
+	"GET /service-categories":                 Authenticated,
+	"POST /service-categories":                Roles(Admin),
+	"PUT /service-categories/{id}":            Roles(Admin),
+	"DELETE /service-categories/{id}":         Roles(Admin),
+	"GET /services/{id}/contract-template":    Authenticated,
+	"PUT /services/{id}/contract-template":    Roles(Admin),
+	"DELETE /services/{id}/contract-template": Roles(Admin),
+
+	// promotores (contem dados bancarios)
+	"GET /promoters":         Roles(Admin, Finance),
+	"POST /promoters":        Roles(Admin),
+	"PUT /promoters/{id}":    Roles(Admin, Finance),
+	"DELETE /promoters/{id}": Roles(Admin),
+
+	// leads
+	"GET /leads":             Roles(Admin, Promoter),
+	"POST /leads":            Roles(Admin, Promoter),
+	"PUT /leads/{id}":        Roles(Admin, Promoter),
+	"DELETE /leads/{id}":     Roles(Admin),
+	"PUT /leads/{id}/status": Roles(Admin, Finance),
+
+	// contratos
+	"GET /contracts":                          Roles(Admin, Finance),
+	"POST /contracts":                         Roles(Admin, Finance),
+	"GET /contracts/expiring":                 Roles(Admin, Finance),
+	"PUT /contracts/{id}":                     Roles(Admin, Finance),
+	"DELETE /contracts/{id}":                  Roles(Admin, Finance),
+	"GET /contracts/{id}/items":               Roles(Admin, Finance),
+	"PUT /contracts/{id}/status":              Roles(Admin, Finance),
+	"GET /contracts/{id}/history":             Roles(Admin, Finance),
+	"POST /contracts/{id}/amendments":         Roles(Admin, Finance),
+	"GET /contracts/{id}/amendments":          Roles(Admin, Finance),
+	"POST /contracts/{id}/renewals":           Roles(Admin, Finance),
+	"PUT /contracts/{id}/readjustment":        Roles(Admin, Finance),
+	"GET /contracts/readjustments/preview":    Roles(Admin, Finance),
+	"POST /contracts/readjustments":           Roles(Admin, Finance),
+	"PUT /contracts/{id}/late-fees":           Roles(Admin, Finance),
+	"GET /contracts/{id}/document.pdf":        Roles(Admin, Finance),
+	"POST /contracts/{id}/signature-requests": Roles(Admin, Finance),
+	"GET /contracts/{id}/signature-requests":  Roles(Admin, Finance),
+
+	// indices de preco
+	"GET /price-indexes":                Authenticated,
+	"GET /price-indexes/{index}/values": Authenticated,
+	"POST /price-indexes/import":        Roles(Admin, Finance),
+
+	// financeiro
+	"GET /receivables/":                               Roles(Finance),
+	"GET /receivables/{id}/amount":                    Roles(Finance),
+	"PUT /receivables/{id}/pay":                       Roles(Finance),
+	"POST /receivables/{id}/charges":                  Roles(Finance),
+	"GET /receivables/{id}/charges":                   Roles(Finance),
+	"GET /receivables/{id}/charges/{chargeID}":        Roles(Finance),
+	"PUT /receivables/{id}/charges/{chargeID}/cancel": Roles(Finance),
+	"GET /commissions/":                               Roles(Finance),
+	"PUT /commissions/{id}/approve":                   Roles(Finance),
+	"GET /finance/reports/aging":                      Roles(Finance),
+	"GET /finance/reports/cashflow":                   Roles(Finance),
+	"GET /finance/reports/dso":                        Roles(Finance),
+
+	// tags, notas e notificacoes; as rotas por entidade tambem exigem que a
+	// role veja a entidade dona (CanView), verificado nos handlers
+	"GET /tags":                               Authenticated,
+	"POST /tags":                              Roles(Admin, Finance),
+	"PUT /tags/{id}":                          Roles(Admin, Finance),
+	"DELETE /tags/{id}":                       Roles(Admin, Finance),
+	"GET /tags/entities/{entity}/{entityID}":  Authenticated,
+	"POST /tags/entities/{entity}/{entityID}": Authenticated,
+	"DELETE /tags/entities/{entity}/{entityID}/{tagID}": Authenticated,
+	"GET /notes":                   Authenticated,
+	"POST /notes":                  Authenticated,
+	"PUT /notes/{id}":              Authenticated,
+	"GET /notes/{id}/revisions":    Authenticated,
+	"GET /notifications":           Authenticated,
+	"PUT /notifications/{id}/read": Authenticated,
+
+	// importacoes em lote
+	"GET /imports":             Roles(Admin, Finance),
+	"POST /imports/customers":  Roles(Admin, Finance),
+	"POST /imports/services":   Roles(Admin),
+	"POST /imports/promoters":  Roles(Admin),
+	"GET /imports/{id}":        Roles(Admin, Finance),
+	"GET /imports/{id}/errors": Roles(Admin, Finance),
+
+	// dashboard executivo
+	"GET /dashboard": Roles(Admin, Finance),
+
+	// administracao
+	"GET /audit-logs/":                       Roles(Admin),
+	"GET /trash/":                            Roles(Admin),
+	"GET /trash/{entity}":                    Roles(Admin),
+	"PUT /trash/{entity}/{id}/restore":       Roles(Admin),
+	"DELETE /trash/{entity}/{id}":            Roles(Admin),
+	"GET /privacy/customers/{id}/export":     Roles(Admin),
+	"POST /privacy/customers/{id}/anonymize": Roles(Admin),
+	"GET /privacy/promoters/{id}/export":     Roles(Admin),
+	"POST /privacy/promoters/{id}/anonymize": Roles(Admin),
+}
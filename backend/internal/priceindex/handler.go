@@ -0,0 +1,77 @@
+package priceindex
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxImportSize limita o tamanho do CSV importado.
+const maxImportSize = 1 << 20
+
+// RegisterRoutes adiciona as rotas do modulo PriceIndex.
+func RegisterRoutes(r chi.Router, repo Repository) {
+	h := handler{repo: repo}
+	r.Get("/price-indexes", h.list)
+	r.Get("/price-indexes/{index}/values", h.values)
+	r.Post("/price-indexes/import", h.importCSV)
+}
+
+type handler struct {
+	repo Repository
+}
+
+// @Summary      Lista indices de preco suportados
+// @Tags         price-indexes
+// @Security     BearerAuth
+// @Success      200  {array}  Index
+// @Router       /price-indexes [get]
+func (h handler) list(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Indexes())
+}
+
+// @Summary      Lista valores mensais de um indice
+// @Tags         price-indexes
+// @Security     BearerAuth
+// @Success      200  {array}  Value
+// @Router       /price-indexes/{index}/values [get]
+func (h handler) values(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idx, ok := Lookup(chi.URLParam(r, "index"))
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	values, err := h.repo.List(r.Context(), idx.Code)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(values)
+}
+
+// @Summary      Importa valores mensais de indices a partir de CSV
+// @Description  Linhas no formato indice;mes;variacao, ex.: ipca;2026-01;0,42
+// @Tags         price-indexes
+// @Security     BearerAuth
+// @Accept       text/csv
+// @Success      200  {object}  map[string]int
+// @Router       /price-indexes/import [post]
+func (h handler) importCSV(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	values, err := ParseCSV(io.LimitReader(r.Body, maxImportSize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	n, err := h.repo.Import(r.Context(), values)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]int{"imported": n})
+}
@@ -0,0 +1,122 @@
+package priceindex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepository struct {
+	values []Value
+}
+
+func (f *fakeRepository) List(ctx context.Context, index string) ([]Value, error) {
+	out := []Value{}
+	for _, v := range f.values {
+		if v.IndexCode == index {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) Import(ctx context.Context, values []Value) (int, error) {
+	f.values = append(f.values, values...)
+	return len(values), nil
+}
+
+func setupRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo)
+	return r, token
+}
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "text/csv")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestImportAndListValues(t *testing.T) {
+	repo := &fakeRepository{}
+	r, token := setupRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	csv := "index;month;rate\nipca;2026-01;0,42\nIGPM;02/2026;-0,10\n"
+	resp := do(t, http.MethodPost, server.URL+"/price-indexes/import", token, csv)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var out map[string]int
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if out["imported"] != 2 {
+		t.Fatalf("expected 2 imported, got %v", out)
+	}
+
+	list := do(t, http.MethodGet, server.URL+"/price-indexes/igpm/values", token, "")
+	defer list.Body.Close()
+	var values []Value
+	if err := json.NewDecoder(list.Body).Decode(&values); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(values) != 1 || values[0].Rate != -0.10 || values[0].Month.Month() != time.February {
+		t.Fatalf("unexpected igpm values: %+v", values)
+	}
+}
+
+func TestImportRejectsInvalidCSV(t *testing.T) {
+	r, token := setupRouter(&fakeRepository{}, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPost, server.URL+"/price-indexes/import", token, "selic;2026-01;1,0\n")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestImportRequiresFinance(t *testing.T) {
+	r, token := setupRouter(&fakeRepository{}, "sales")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPost, server.URL+"/price-indexes/import", token, "ipca;2026-01;0,42\n")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestFactor(t *testing.T) {
+	got := Factor([]float64{1, 1})
+	if got != 1.0201 {
+		t.Fatalf("expected 1.0201, got %v", got)
+	}
+}
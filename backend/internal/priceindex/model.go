@@ -0,0 +1,19 @@
+package priceindex
+
+import "time"
+
+// Index descreve um indice de precos suportado.
+type Index struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Value guarda a variacao mensal (em %) de um indice. Month eh sempre o
+// primeiro dia do mes de referencia.
+type Value struct {
+	IndexCode string    `db:"index_code" json:"index"`
+	Month     time.Time `db:"month" json:"month"`
+	Rate      float64   `db:"rate" json:"rate"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,51 @@
+package priceindex
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// List retorna os valores mensais do indice, do mais recente ao mais antigo.
+func (r *PostgresRepository) List(ctx context.Context, index string) ([]Value, error) {
+	values := []Value{}
+	const q = `SELECT * FROM price_index_values WHERE index_code=$1 ORDER BY month DESC`
+	if err := r.db.SelectContext(ctx, &values, q, index); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Import grava os valores em uma unica transacao; meses ja cadastrados
+// sao sobrescritos, permitindo corrigir valores revisados.
+func (r *PostgresRepository) Import(ctx context.Context, values []Value) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const q = `INSERT INTO price_index_values (index_code, month, rate) VALUES ($1, $2, $3)
+        ON CONFLICT (index_code, month) DO UPDATE SET rate=EXCLUDED.rate, updated_at=now()`
+	for _, v := range values {
+		if _, err = tx.ExecContext(ctx, q, v.IndexCode, v.Month, v.Rate); err != nil {
+			return 0, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(values), nil
+}
+
+var _ Repository = (*PostgresRepository)(nil)
@@ -0,0 +1,124 @@
+package priceindex
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repository define operacoes sobre os valores mensais dos indices.
+type Repository interface {
+	List(ctx context.Context, index string) ([]Value, error)
+	Import(ctx context.Context, values []Value) (int, error)
+}
+
+// Errors especificos
+
+var ErrUnknownIndex = errors.New("unknown price index")
+
+// indexes lista os indices aceitos, pelo codigo usado nas clausulas de reajuste.
+var indexes = []Index{
+	{Code: "ipca", Name: "IPCA"},
+	{Code: "igpm", Name: "IGP-M"},
+}
+
+// Indexes retorna os indices suportados.
+func Indexes() []Index {
+	out := make([]Index, len(indexes))
+	copy(out, indexes)
+	return out
+}
+
+// Lookup retorna o indice pelo codigo, sem diferenciar maiusculas.
+func Lookup(code string) (Index, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	for _, i := range indexes {
+		if i.Code == code {
+			return i, true
+		}
+	}
+	return Index{}, false
+}
+
+// Factor acumula as variacoes mensais (em %) em um fator multiplicativo.
+func Factor(rates []float64) float64 {
+	f := 1.0
+	for _, r := range rates {
+		f *= 1 + r/100
+	}
+	return math.Round(f*1e6) / 1e6
+}
+
+// ParseCSV le valores no formato "indice;mes;variacao", com cabecalho
+// opcional. O mes aceita AAAA-MM ou MM/AAAA e a variacao aceita virgula
+// decimal quando o separador eh ponto e virgula.
+func ParseCSV(r io.Reader) ([]Value, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+	if strings.Contains(strings.SplitN(string(first), "\n", 2)[0], ";") {
+		cr.Comma = ';'
+	}
+
+	var values []Value
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(rec[0]), "index") {
+			continue
+		}
+		v, err := parseRecord(rec)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no values found")
+	}
+	return values, nil
+}
+
+func parseRecord(rec []string) (Value, error) {
+	idx, ok := Lookup(rec[0])
+	if !ok {
+		return Value{}, fmt.Errorf("%w: %q", ErrUnknownIndex, rec[0])
+	}
+	month, err := ParseMonth(rec[1])
+	if err != nil {
+		return Value{}, err
+	}
+	rate, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(rec[2]), ",", ".", 1), 64)
+	if err != nil {
+		return Value{}, fmt.Errorf("invalid rate %q", rec[2])
+	}
+	return Value{IndexCode: idx.Code, Month: month, Rate: rate}, nil
+}
+
+// ParseMonth converte AAAA-MM ou MM/AAAA no primeiro dia do mes (UTC).
+func ParseMonth(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01", "01/2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid month %q", s)
+}
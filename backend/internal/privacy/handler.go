@@ -0,0 +1,80 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+)
+
+// RegisterRoutes adiciona as rotas de atendimento a titulares (restritas a admin).
+func RegisterRoutes(r chi.Router, repo Repository) {
+	h := handler{repo: repo}
+	r.Route("/privacy", func(rt chi.Router) {
+		rt.Use(auth.RequireRole("admin"))
+		rt.Get("/customers/{id}/export", h.export("customers", repo.ExportCustomer))
+		rt.Get("/promoters/{id}/export", h.export("promoters", repo.ExportPromoter))
+		rt.Post("/customers/{id}/anonymize", h.anonymize("customers", repo.AnonymizeCustomer))
+		rt.Post("/promoters/{id}/anonymize", h.anonymize("promoters", repo.AnonymizePromoter))
+	})
+}
+
+type handler struct {
+	repo Repository
+}
+
+// @Summary      Exporta os dados pessoais de um titular
+// @Tags         privacy
+// @Security     BearerAuth
+// @Param        subject  path  string  true  "customers ou promoters"
+// @Success      200  {object}  Export
+// @Router       /privacy/{subject}/{id}/export [get]
+func (h handler) export(subject string, fn func(ctx context.Context, id string) (Export, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		out, err := fn(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.json"`, subject, id))
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
+
+// @Summary      Anonimiza irreversivelmente um titular
+// @Tags         privacy
+// @Security     BearerAuth
+// @Param        subject  path  string  true  "customers ou promoters"
+// @Success      204  {null}  nil
+// @Router       /privacy/{subject}/{id}/anonymize [post]
+func (h handler) anonymize(subject string, fn func(ctx context.Context, id, actorID string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if err := fn(r.Context(), id, auth.UserIDFromContext(r.Context())); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("X-Entity", fmt.Sprintf("%s:%s", subject, id))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, ErrAlreadyAnonymized):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
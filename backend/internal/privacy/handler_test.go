@@ -0,0 +1,150 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepository struct {
+	customers map[string]bool // id -> anonimizado
+	promoters map[string]bool
+	lastActor string
+}
+
+func (f *fakeRepository) exportOf(set map[string]bool, subject, id string) (Export, error) {
+	if _, ok := set[id]; !ok {
+		return Export{}, sql.ErrNoRows
+	}
+	return Export{
+		Subject:     subject,
+		SubjectID:   id,
+		GeneratedAt: time.Now(),
+		Data:        map[string]json.RawMessage{subject: json.RawMessage(`{"id":"` + id + `"}`)},
+	}, nil
+}
+
+func (f *fakeRepository) anonymize(set map[string]bool, id, actorID string) error {
+	done, ok := set[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if done {
+		return ErrAlreadyAnonymized
+	}
+	set[id] = true
+	f.lastActor = actorID
+	return nil
+}
+
+func (f *fakeRepository) ExportCustomer(ctx context.Context, id string) (Export, error) {
+	return f.exportOf(f.customers, "customers", id)
+}
+
+func (f *fakeRepository) ExportPromoter(ctx context.Context, id string) (Export, error) {
+	return f.exportOf(f.promoters, "promoters", id)
+}
+
+func (f *fakeRepository) AnonymizeCustomer(ctx context.Context, id, actorID string) error {
+	return f.anonymize(f.customers, id, actorID)
+}
+
+func (f *fakeRepository) AnonymizePromoter(ctx context.Context, id, actorID string) error {
+	return f.anonymize(f.promoters, id, actorID)
+}
+
+func setupRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo)
+	return r, token
+}
+
+func do(t *testing.T, method, url, token string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestExportCustomer(t *testing.T) {
+	repo := &fakeRepository{customers: map[string]bool{"c1": false}}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodGet, server.URL+"/privacy/customers/c1/export", token)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename="customers-c1.json"` {
+		t.Fatalf("unexpected Content-Disposition: %q", cd)
+	}
+	var out Export
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.SubjectID != "c1" || len(out.Data["customers"]) == 0 {
+		t.Fatalf("unexpected export: %+v", out)
+	}
+
+	resp2 := do(t, http.MethodGet, server.URL+"/privacy/promoters/x/export", token)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp2.StatusCode)
+	}
+}
+
+func TestAnonymizePromoter(t *testing.T) {
+	repo := &fakeRepository{promoters: map[string]bool{"p1": false}}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPost, server.URL+"/privacy/promoters/p1/anonymize", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if !repo.promoters["p1"] || repo.lastActor != "u1" {
+		t.Fatalf("promoter not anonymized: %+v", repo)
+	}
+
+	resp2 := do(t, http.MethodPost, server.URL+"/privacy/promoters/p1/anonymize", token)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", resp2.StatusCode)
+	}
+}
+
+func TestPrivacyRequiresAdmin(t *testing.T) {
+	r, token := setupRouter(&fakeRepository{customers: map[string]bool{"c1": false}}, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPost, server.URL+"/privacy/customers/c1/anonymize", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+}
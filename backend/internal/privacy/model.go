@@ -0,0 +1,23 @@
+package privacy
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Export reune tudo o que esta armazenado sobre um titular. Cada secao
+// contem as linhas das tabelas de origem serializadas em JSON.
+type Export struct {
+	Subject     string                     `json:"subject"`
+	SubjectID   string                     `json:"subjectID"`
+	GeneratedAt time.Time                  `json:"generatedAt"`
+	Data        map[string]json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// AttachmentFile eh um PDF anexado a contrato incluido na exportacao.
+type AttachmentFile struct {
+	ID         string `db:"id" json:"id"`
+	FileName   string `db:"file_name" json:"fileName"`
+	StorageURL string `db:"storage_url" json:"storageURL"`
+	Content    []byte `db:"-" json:"content,omitempty"`
+}
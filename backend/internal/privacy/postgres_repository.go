@@ -0,0 +1,339 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL. Os PDFs dos
+// anexos de contrato sao lidos e removidos por docs.
+type PostgresRepository struct {
+	db   *sqlx.DB
+	docs Documents
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB, docs Documents) *PostgresRepository {
+	return &PostgresRepository{db: db, docs: docs}
+}
+
+type section struct {
+	name string
+	q    string
+}
+
+// auditMentions seleciona logs da entidade ou cujo diff menciona o ID,
+// o documento ou o e-mail do titular ($1, $2 e $3).
+const auditMentions = `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM audit_logs
+         WHERE entity_id=$1
+            OR diff::text LIKE '%' || $1 || '%'
+            OR ($2 <> '' AND diff::text LIKE '%' || $2 || '%')
+            OR ($3 <> '' AND diff::text ILIKE '%' || $3 || '%')) t`
+
+var customerSections = []section{
+	{"addresses", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM addresses WHERE customer_id=$1) t`},
+	{"leads", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM leads WHERE customer_id=$1) t`},
+	{"contracts", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM contracts WHERE customer_id=$1) t`},
+	{"receivables", `SELECT COALESCE(json_agg(t ORDER BY t.due_date), '[]') FROM (
+        SELECT ar.* FROM accounts_receivable ar JOIN contracts c ON c.id = ar.contract_id
+         WHERE c.customer_id=$1) t`},
+	{"signatureRequests", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT sr.id, sr.contract_id, sr.provider, sr.provider_ref, sr.status, sr.document_hash,
+               encode(sr.document, 'base64') AS document, sr.created_at, sr.completed_at
+          FROM signature_requests sr JOIN contracts c ON c.id = sr.contract_id
+         WHERE c.customer_id=$1) t`},
+	{"signatureSigners", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT ss.id, ss.request_id, ss.name, ss.email, ss.role, ss.status, ss.signed_at, ss.ip,
+               ss.user_agent, ss.document_hash, ss.decline_reason, ss.created_at
+          FROM signature_signers ss JOIN signature_requests sr ON sr.id = ss.request_id
+          JOIN contracts c ON c.id = sr.contract_id
+         WHERE c.customer_id=$1 AND ss.role='customer') t`},
+	{"contractAttachments", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT ca.* FROM contract_attachments ca JOIN contracts c ON c.id = ca.contract_id
+         WHERE c.customer_id=$1) t`},
+	{"notes", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM notes WHERE entity_name='customers' AND entity_id=$1) t`},
+	{"noteRevisions", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT nr.* FROM note_revisions nr JOIN notes n ON n.id = nr.note_id
+         WHERE n.entity_name='customers' AND n.entity_id=$1) t`},
+	{"tags", `SELECT COALESCE(json_agg(t ORDER BY t.name), '[]') FROM (
+        SELECT tg.name, et.created_at, et.deleted_at FROM entity_tags et JOIN tags tg ON tg.id = et.tag_id
+         WHERE et.entity_name='customers' AND et.entity_id=$1) t`},
+}
+
+var promoterSections = []section{
+	{"commissionContracts", `SELECT COALESCE(json_agg(t ORDER BY t.starts_at), '[]') FROM (
+        SELECT * FROM commission_contracts WHERE promoter_id=$1) t`},
+	{"commissions", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM commissions WHERE promoter_id=$1) t`},
+	{"leads", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM leads WHERE promoter_id=$1) t`},
+	{"contracts", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM contracts WHERE promoter_id=$1) t`},
+	{"notes", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT * FROM notes WHERE entity_name='promoters' AND entity_id=$1) t`},
+	{"noteRevisions", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+        SELECT nr.* FROM note_revisions nr JOIN notes n ON n.id = nr.note_id
+         WHERE n.entity_name='promoters' AND n.entity_id=$1) t`},
+}
+
+// ExportCustomer exporta todos os dados armazenados sobre um cliente,
+// inclusive registros removidos logicamente e os PDFs anexados aos contratos.
+func (r *PostgresRepository) ExportCustomer(ctx context.Context, id string) (Export, error) {
+	out, err := r.export(ctx, "customers", id, customerSections)
+	if err != nil {
+		return Export{}, err
+	}
+	var files []AttachmentFile
+	const q = `SELECT ca.id, ca.file_name, ca.storage_url FROM contract_attachments ca
+        JOIN contracts c ON c.id = ca.contract_id WHERE c.customer_id=$1 ORDER BY ca.created_at`
+	if err = r.db.SelectContext(ctx, &files, q, id); err != nil {
+		return Export{}, fmt.Errorf("export contractAttachmentFiles: %w", err)
+	}
+	if out.Data["contractAttachmentFiles"], err = readDocuments(ctx, r.docs, files); err != nil {
+		return Export{}, err
+	}
+	return out, nil
+}
+
+// readDocuments le o conteudo dos anexos; arquivos ja removidos ficam sem
+// conteudo.
+func readDocuments(ctx context.Context, docs Documents, files []AttachmentFile) (json.RawMessage, error) {
+	for i := range files {
+		data, err := docs.Get(ctx, files[i].StorageURL)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("export contractAttachmentFiles: %w", err)
+		}
+		files[i].Content = data
+	}
+	if files == nil {
+		files = []AttachmentFile{}
+	}
+	return json.Marshal(files)
+}
+
+// ExportPromoter exporta todos os dados armazenados sobre um promotor.
+func (r *PostgresRepository) ExportPromoter(ctx context.Context, id string) (Export, error) {
+	return r.export(ctx, "promoters", id, promoterSections)
+}
+
+func (r *PostgresRepository) export(ctx context.Context, table, id string, sections []section) (Export, error) {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return Export{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var subject struct {
+		Row        string `db:"row"`
+		DocumentID string `db:"document_id"`
+		Email      string `db:"email"`
+	}
+	q := fmt.Sprintf(`SELECT row_to_json(s)::text AS row, COALESCE(s.document_id,'') AS document_id,
+        COALESCE(s.email,'') AS email FROM %s s WHERE s.id=$1`, table)
+	if err = tx.GetContext(ctx, &subject, q, id); err != nil {
+		return Export{}, err
+	}
+
+	out := Export{
+		Subject:     table,
+		SubjectID:   id,
+		GeneratedAt: time.Now(),
+		Data:        map[string]json.RawMessage{table: json.RawMessage(subject.Row)},
+	}
+	for _, s := range sections {
+		var raw string
+		if err = tx.GetContext(ctx, &raw, s.q, id); err != nil {
+			return Export{}, fmt.Errorf("export %s: %w", s.name, err)
+		}
+		out.Data[s.name] = json.RawMessage(raw)
+	}
+	var audit string
+	if err = tx.GetContext(ctx, &audit, auditMentions, id, subject.DocumentID, subject.Email); err != nil {
+		return Export{}, fmt.Errorf("export auditLogs: %w", err)
+	}
+	out.Data["auditLogs"] = json.RawMessage(audit)
+	return out, nil
+}
+
+// statement eh um comando da anonimizacao com seus argumentos.
+type statement struct {
+	q    string
+	args []interface{}
+}
+
+// AnonymizeCustomer remove irreversivelmente os dados pessoais do cliente e
+// dos cadastros mesclados nele. Contratos, contas a receber e comissoes
+// permanecem intactos para a contabilidade; o documento recebe um valor
+// sentinela unico. Os PDFs do contrato (assinatura e anexos) sao apagados,
+// mantendo apenas os hashes como evidencia.
+func (r *PostgresRepository) AnonymizeCustomer(ctx context.Context, id, actorID string) error {
+	return r.anonymize(ctx, "customers", id, actorID, func(tx *sqlx.Tx) ([]statement, []string, error) {
+		var ids []string
+		if err := tx.SelectContext(ctx, &ids, `SELECT id FROM customers WHERE id=$1 OR merged_into_id=$1`, id); err != nil {
+			return nil, nil, err
+		}
+		mentions, err := subjectMentions(ctx, tx, "customers", ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		var files []string
+		const qf = `SELECT storage_url FROM contract_attachments
+            WHERE contract_id IN (SELECT id FROM contracts WHERE customer_id = ANY($1))`
+		if err = tx.SelectContext(ctx, &files, qf, pq.Array(ids)); err != nil {
+			return nil, nil, err
+		}
+		return customerStatements(ids, mentions), files, nil
+	})
+}
+
+// customerStatements monta a anonimizacao dos clientes ids. Signatarios
+// internos sao usuarios do sistema e nao sao alterados.
+func customerStatements(ids, mentions []string) []statement {
+	all := pq.Array(ids)
+	const contracts = `SELECT id FROM contracts WHERE customer_id = ANY($1)`
+	return []statement{
+		{`UPDATE customers SET legal_name=$2, trade_name='', document_id='ANON' || id, email='', phone='',
+                anonymized_at=now(), updated_at=now() WHERE id = ANY($1)`, []interface{}{all, AnonymizedName}},
+		{`UPDATE addresses SET street='', number='', complement='', district='', postal_code='', updated_at=now()
+                WHERE customer_id = ANY($1)`, []interface{}{all}},
+		{`UPDATE leads SET notes='', updated_at=now() WHERE customer_id = ANY($1)`, []interface{}{all}},
+		{`UPDATE notes SET text='[anonimizado]' WHERE entity_name='customers' AND entity_id = ANY($1)`, []interface{}{all}},
+		{`UPDATE note_revisions SET text='[anonimizado]'
+                WHERE note_id IN (SELECT id FROM notes WHERE entity_name='customers' AND entity_id = ANY($1))`, []interface{}{all}},
+		{`UPDATE signature_signers SET name=$2, email='', ip=NULL, user_agent=NULL, decline_reason=NULL
+                WHERE role='customer' AND request_id IN (
+                  SELECT id FROM signature_requests WHERE contract_id IN (` + contracts + `))`, []interface{}{all, AnonymizedName}},
+		{`UPDATE signature_requests SET document='' WHERE contract_id IN (` + contracts + `)`, []interface{}{all}},
+		{`UPDATE contract_attachments SET deleted_at=COALESCE(deleted_at, now())
+                WHERE contract_id IN (` + contracts + `)`, []interface{}{all}},
+		scrubImportErrors(mentions),
+		scrubAudit(ids, mentions),
+	}
+}
+
+// AnonymizePromoter remove irreversivelmente os dados pessoais do promotor,
+// incluindo dados bancarios. Comissoes permanecem para a contabilidade.
+func (r *PostgresRepository) AnonymizePromoter(ctx context.Context, id, actorID string) error {
+	return r.anonymize(ctx, "promoters", id, actorID, func(tx *sqlx.Tx) ([]statement, []string, error) {
+		mentions, err := subjectMentions(ctx, tx, "promoters", []string{id})
+		if err != nil {
+			return nil, nil, err
+		}
+		return promoterStatements(id, mentions), nil, nil
+	})
+}
+
+// promoterStatements monta a anonimizacao do promotor id.
+func promoterStatements(id string, mentions []string) []statement {
+	return []statement{
+		{`UPDATE promoters SET full_name=$2, email='anon-' || lower(id) || '@anonimizado.invalid', phone='',
+                document_id='ANON' || id, bank_account=NULL, anonymized_at=now(), updated_at=now() WHERE id=$1`,
+			[]interface{}{id, AnonymizedName}},
+		{`UPDATE notes SET text='[anonimizado]' WHERE entity_name='promoters' AND entity_id=$1`, []interface{}{id}},
+		{`UPDATE note_revisions SET text='[anonimizado]'
+                WHERE note_id IN (SELECT id FROM notes WHERE entity_name='promoters' AND entity_id=$1)`, []interface{}{id}},
+		scrubImportErrors(mentions),
+		scrubAudit([]string{id}, mentions),
+	}
+}
+
+// subjectMentions retorna os IDs, documentos e e-mails dos titulares, os
+// mesmos termos que a exportacao procura nos logs de auditoria.
+func subjectMentions(ctx context.Context, tx *sqlx.Tx, table string, ids []string) ([]string, error) {
+	var values []string
+	q := fmt.Sprintf(`SELECT v FROM %s s, LATERAL (VALUES (s.id), (s.document_id), (s.email)) t(v)
+        WHERE s.id = ANY($1) AND COALESCE(v, '') <> ''`, table)
+	if err := tx.SelectContext(ctx, &values, q, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// scrubAudit apaga o diff dos logs da entidade e dos que mencionam o titular.
+func scrubAudit(ids, mentions []string) statement {
+	return statement{
+		`UPDATE audit_logs SET diff=NULL WHERE entity_id = ANY($1) OR diff::text ILIKE ANY($2)`,
+		[]interface{}{pq.Array(ids), pq.Array(likePatterns(mentions))},
+	}
+}
+
+// likePatterns envolve cada termo em % para busca por trecho.
+func likePatterns(mentions []string) []string {
+	patterns := make([]string, len(mentions))
+	for i, m := range mentions {
+		patterns[i] = "%" + m + "%"
+	}
+	return patterns
+}
+
+// scrubImportErrors apaga as linhas rejeitadas em importacoes cuja chave eh
+// o documento do titular ou cujos valores o mencionam.
+func scrubImportErrors(mentions []string) statement {
+	return statement{
+		`UPDATE import_job_errors SET key='', "values"='{}'
+            WHERE key = ANY($1) OR "values"::text ILIKE ANY($2)`,
+		[]interface{}{pq.Array(mentions), pq.Array(likePatterns(mentions))},
+	}
+}
+
+// anonymize executa os comandos de build e remove os arquivos retornados por
+// ele. Os arquivos sao apagados antes do commit: se o commit falhar, os dados
+// restantes continuam sujeitos a uma nova anonimizacao.
+func (r *PostgresRepository) anonymize(ctx context.Context, table, id, actorID string, build func(tx *sqlx.Tx) ([]statement, []string, error)) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var anonymizedAt *time.Time
+	q := fmt.Sprintf(`SELECT anonymized_at FROM %s WHERE id=$1 FOR UPDATE`, table)
+	if err = tx.GetContext(ctx, &anonymizedAt, q, id); err != nil {
+		return err
+	}
+	if anonymizedAt != nil {
+		return ErrAlreadyAnonymized
+	}
+
+	stmts, files, err := build(tx)
+	if err != nil {
+		return err
+	}
+	for _, st := range stmts {
+		if _, err = tx.ExecContext(ctx, st.q, st.args...); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if err = r.docs.Delete(ctx, f); err != nil {
+			return err
+		}
+	}
+
+	var userID *string
+	if actorID != "" {
+		userID = &actorID
+	}
+	const qa = `INSERT INTO audit_logs (id, user_id, entity_name, entity_id, action, diff)
+        VALUES ($1, $2, $3, $4, 'update', '{"anonymized": true}')`
+	if _, err = tx.ExecContext(ctx, qa, ulid.Make().String(), userID, table, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var _ Repository = (*PostgresRepository)(nil)
@@ -0,0 +1,27 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+)
+
+// Repository define operacoes de atendimento a titulares de dados (LGPD).
+type Repository interface {
+	ExportCustomer(ctx context.Context, id string) (Export, error)
+	ExportPromoter(ctx context.Context, id string) (Export, error)
+	AnonymizeCustomer(ctx context.Context, id, actorID string) error
+	AnonymizePromoter(ctx context.Context, id, actorID string) error
+}
+
+// Documents acessa os PDFs de contrato gravados em disco
+// (contract_attachments.storage_url).
+type Documents interface {
+	Get(ctx context.Context, url string) ([]byte, error)
+	Delete(ctx context.Context, url string) error
+}
+
+// ErrAlreadyAnonymized eh retornado quando o titular ja foi anonimizado.
+var ErrAlreadyAnonymized = errors.New("already anonymized")
+
+// AnonymizedName substitui o nome do titular anonimizado.
+const AnonymizedName = "Titular anonimizado"
@@ -0,0 +1,110 @@
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+type fakeDocuments map[string][]byte
+
+func (d fakeDocuments) Get(ctx context.Context, url string) ([]byte, error) {
+	if url == "broken" {
+		return nil, errors.New("permission denied")
+	}
+	data, ok := d[url]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return data, nil
+}
+
+func (d fakeDocuments) Delete(ctx context.Context, url string) error {
+	delete(d, url)
+	return nil
+}
+
+func TestCustomerExportCoversSignaturesAndAttachments(t *testing.T) {
+	names := map[string]bool{}
+	for _, s := range customerSections {
+		names[s.name] = true
+	}
+	for _, want := range []string{"signatureRequests", "signatureSigners", "contractAttachments"} {
+		if !names[want] {
+			t.Errorf("customer export misses %s", want)
+		}
+	}
+}
+
+func TestCustomerStatementsScrubSignaturesAndAttachments(t *testing.T) {
+	stmts := customerStatements([]string{"c1"}, []string{"c1", "12345678900"})
+	scrubbed := map[string]string{}
+	for _, st := range stmts {
+		for _, table := range []string{"signature_signers", "signature_requests", "contract_attachments"} {
+			if strings.HasPrefix(st.q, "UPDATE "+table+" ") {
+				scrubbed[table] = st.q
+			}
+		}
+	}
+	for _, want := range []string{"ip=NULL", "user_agent=NULL", "email=''"} {
+		if !strings.Contains(scrubbed["signature_signers"], want) {
+			t.Errorf("signers not scrubbed (%s): %q", want, scrubbed["signature_signers"])
+		}
+	}
+	if !strings.Contains(scrubbed["signature_requests"], "document=''") {
+		t.Errorf("signature document not scrubbed: %q", scrubbed["signature_requests"])
+	}
+	if !strings.Contains(scrubbed["contract_attachments"], "deleted_at") {
+		t.Errorf("attachments not removed: %q", scrubbed["contract_attachments"])
+	}
+}
+
+func TestReadDocuments(t *testing.T) {
+	docs := fakeDocuments{"file://a1.pdf": []byte("%PDF")}
+	raw, err := readDocuments(context.Background(), docs, []AttachmentFile{
+		{ID: "a1", StorageURL: "file://a1.pdf"},
+		{ID: "a2", StorageURL: "file://a2.pdf"},
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var files []AttachmentFile
+	if err := json.Unmarshal(raw, &files); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(files) != 2 || string(files[0].Content) != "%PDF" || files[1].Content != nil {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+
+	if raw, err := readDocuments(context.Background(), docs, nil); err != nil || string(raw) != "[]" {
+		t.Fatalf("expected empty list, got %s %v", raw, err)
+	}
+	if _, err := readDocuments(context.Background(), docs, []AttachmentFile{{ID: "a3", StorageURL: "broken"}}); err == nil {
+		t.Fatal("expected read error")
+	}
+}
+
+func TestAnonymizeScrubsImportErrors(t *testing.T) {
+	mentions := []string{"c1", "12345678900", "ana@example.com"}
+	for subject, stmts := range map[string][]statement{
+		"customers": customerStatements([]string{"c1"}, mentions),
+		"promoters": promoterStatements("p1", mentions),
+	} {
+		var found *statement
+		for i := range stmts {
+			if strings.HasPrefix(stmts[i].q, "UPDATE import_job_errors ") {
+				found = &stmts[i]
+			}
+		}
+		if found == nil {
+			t.Errorf("%s: import errors not scrubbed", subject)
+			continue
+		}
+		if !strings.Contains(found.q, `"values"='{}'`) || !strings.Contains(found.q, "key = ANY($1)") {
+			t.Errorf("%s: unexpected statement %q", subject, found.q)
+		}
+	}
+}
@@ -11,11 +11,16 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/tag"
+	"github.com/rgomids/bckoffice/pkg/document"
 )
 
 // RegisterRoutes adiciona as rotas do módulo Promoter.
 func RegisterRoutes(r chi.Router, repo Repository) {
-	h := handler{repo: repo, validate: validator.New()}
+	v := validator.New()
+	_ = document.RegisterValidation(v)
+	h := handler{repo: repo, validate: v}
 	r.Get("/promoters", h.list)
 	r.Post("/promoters", h.create)
 	r.Put("/promoters/{id}", h.update)
@@ -31,7 +36,7 @@ type createPromoterInput struct {
 	FullName    string          `json:"full_name" validate:"required"`
 	Email       string          `json:"email" validate:"omitempty,email"`
 	Phone       string          `json:"phone"`
-	DocumentID  string          `json:"document_id"`
+	DocumentID  string          `json:"document_id" validate:"omitempty,cpfcnpj"`
 	BankAccount json.RawMessage `json:"bank_account"`
 }
 
@@ -40,18 +45,19 @@ type UpdatePromoterInput struct {
 	FullName    string          `json:"full_name" validate:"required"`
 	Email       string          `json:"email" validate:"omitempty,email"`
 	Phone       string          `json:"phone"`
-	DocumentID  string          `json:"document_id"`
+	DocumentID  string          `json:"document_id" validate:"omitempty,cpfcnpj"`
 	BankAccount json.RawMessage `json:"bank_account"`
 }
 
 // @Summary      Lista promotores
 // @Tags         promoters
 // @Security     BearerAuth
+// @Param        tags  query  string  false  "Tags separadas por virgula (todas obrigatorias)"
 // @Success      200  {array}  Promoter
 // @Router       /promoters [get]
 func (h handler) list(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	promoters, err := h.repo.FindAll(r.Context())
+	promoters, err := h.repo.FindAll(r.Context(), tag.ParseFilter(r.URL.Query()))
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -78,18 +84,13 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p := Promoter{
-		ID:          ulid.Make().String(),
-		FullName:    in.FullName,
-		Email:       in.Email,
-		Phone:       in.Phone,
-		DocumentID:  in.DocumentID,
-		BankAccount: in.BankAccount,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+	p := newPromoter(in)
 
 	if err := h.repo.Create(r.Context(), &p); err != nil {
+		if errors.Is(err, ErrDuplicateDocumentID) || errors.Is(err, ErrDuplicateEmail) {
+			http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+			return
+		}
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -100,6 +101,20 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(p)
 }
 
+// newPromoter monta o promotor a partir de um payload ja validado.
+func newPromoter(in createPromoterInput) Promoter {
+	return Promoter{
+		ID:          ulid.Make().String(),
+		FullName:    in.FullName,
+		Email:       in.Email,
+		Phone:       in.Phone,
+		DocumentID:  document.Normalize(in.DocumentID),
+		BankAccount: in.BankAccount,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
 // @Summary      Atualiza promotor
 // @Tags         promoters
 // @Security     BearerAuth
@@ -126,7 +141,7 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 		FullName:    in.FullName,
 		Email:       in.Email,
 		Phone:       in.Phone,
-		DocumentID:  in.DocumentID,
+		DocumentID:  document.Normalize(in.DocumentID),
 		BankAccount: in.BankAccount,
 		UpdatedAt:   time.Now(),
 	}
@@ -17,7 +17,7 @@ type fakeRepository struct {
 	promoters []Promoter
 }
 
-func (f *fakeRepository) FindAll(ctx context.Context) ([]Promoter, error) {
+func (f *fakeRepository) FindAll(ctx context.Context, tags []string) ([]Promoter, error) {
 	out := make([]Promoter, 0, len(f.promoters))
 	for _, p := range f.promoters {
 		if p.DeletedAt == nil {
@@ -223,3 +223,19 @@ func TestDeletePromoter(t *testing.T) {
 		t.Fatalf("expected 0 promoters, got %d", len(list))
 	}
 }
+
+func TestCreatePromoterInvalidDocument(t *testing.T) {
+	server := httptest.NewServer(setupRouter())
+	defer server.Close()
+
+	body := strings.NewReader(`{"full_name":"Joao","document_id":"123"}`)
+	resp, err := http.Post(server.URL+"/promoters", "application/json", body)
+	if err != nil {
+		t.Fatalf("POST /promoters error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+}
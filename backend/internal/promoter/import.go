@@ -0,0 +1,55 @@
+package promoter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/rgomids/bckoffice/internal/importer"
+	"github.com/rgomids/bckoffice/pkg/document"
+)
+
+// errDocumentRequired eh retornado na importacao de linhas sem documento,
+// que seria a chave de idempotencia.
+var errDocumentRequired = errors.New("DocumentID: required")
+
+// ImportTarget descreve a importacao de promotores em lote. Os dados
+// bancarios nao entram no CSV e o documento, opcional na API, eh obrigatorio
+// por ser a chave de idempotencia.
+func ImportTarget(repo Repository) importer.Target {
+	v := validator.New()
+	_ = document.RegisterValidation(v)
+	return importer.Target{
+		Entity:    "promoters",
+		Table:     "promoters",
+		KeyColumn: "document_id",
+		Columns:   []string{"full_name", "email", "phone", "document_id"},
+		Key: func(row importer.Row) string {
+			return document.Normalize(row.Get("document_id"))
+		},
+		Validate: func(row importer.Row) error {
+			if row.Get("document_id") == "" {
+				return errDocumentRequired
+			}
+			return v.Struct(importInput(row))
+		},
+		Create: func(ctx context.Context, row importer.Row) error {
+			p := newPromoter(importInput(row))
+			err := repo.Create(ctx, &p)
+			if errors.Is(err, ErrDuplicateDocumentID) {
+				return importer.ErrDuplicate
+			}
+			return err
+		},
+	}
+}
+
+func importInput(row importer.Row) createPromoterInput {
+	return createPromoterInput{
+		FullName:   row.Get("full_name"),
+		Email:      row.Get("email"),
+		Phone:      row.Get("phone"),
+		DocumentID: row.Get("document_id"),
+	}
+}
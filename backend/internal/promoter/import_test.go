@@ -0,0 +1,47 @@
+package promoter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rgomids/bckoffice/internal/importer"
+)
+
+type uniqueRepository struct {
+	fakeRepository
+}
+
+func (u *uniqueRepository) Create(ctx context.Context, p *Promoter) error {
+	for _, cur := range u.promoters {
+		if cur.DocumentID == p.DocumentID {
+			return ErrDuplicateDocumentID
+		}
+	}
+	return u.fakeRepository.Create(ctx, p)
+}
+
+func TestImportTarget(t *testing.T) {
+	repo := &uniqueRepository{}
+	target := ImportTarget(repo)
+
+	if err := target.Validate(importer.Row{"full_name": "Ana"}); err == nil {
+		t.Fatal("document_id should be required on import")
+	}
+	if err := target.Validate(importer.Row{"full_name": "Ana", "document_id": "111"}); err == nil {
+		t.Fatal("invalid document should be rejected")
+	}
+	row := importer.Row{"full_name": "Ana", "document_id": "123.456.789-09", "email": "ana@example.com"}
+	if err := target.Validate(row); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if err := target.Create(context.Background(), row); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if repo.promoters[0].DocumentID != "12345678909" {
+		t.Fatalf("document should be normalized: %+v", repo.promoters[0])
+	}
+	if err := target.Create(context.Background(), row); !errors.Is(err, importer.ErrDuplicate) {
+		t.Fatalf("expected duplicate, got %v", err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"database/sql"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/rgomids/bckoffice/internal/tag"
 )
 
 // PostgresRepository implementa Repository usando PostgreSQL.
@@ -17,11 +20,18 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-// FindAll retorna todos os promotores nao excluidos.
-func (r *PostgresRepository) FindAll(ctx context.Context) ([]Promoter, error) {
+// FindAll retorna todos os promotores nao excluidos,
+// opcionalmente filtrados por tags (todas devem estar presentes).
+func (r *PostgresRepository) FindAll(ctx context.Context, tags []string) ([]Promoter, error) {
 	promoters := []Promoter{}
-	const q = `SELECT * FROM promoters WHERE deleted_at IS NULL ORDER BY full_name`
-	if err := r.db.SelectContext(ctx, &promoters, q); err != nil {
+	q := `SELECT * FROM promoters WHERE deleted_at IS NULL`
+	args := []interface{}{}
+	if len(tags) > 0 {
+		q += ` AND ` + tag.FilterClause("promoters", "id", "$1")
+		args = append(args, pq.Array(tags))
+	}
+	q += ` ORDER BY full_name`
+	if err := r.db.SelectContext(ctx, &promoters, q, args...); err != nil {
 		return nil, err
 	}
 	return promoters, nil
@@ -31,6 +41,14 @@ func (r *PostgresRepository) FindAll(ctx context.Context) ([]Promoter, error) {
 func (r *PostgresRepository) Create(ctx context.Context, p *Promoter) error {
 	const q = `INSERT INTO promoters (id, full_name, email, phone, document_id, bank_account) VALUES (:id, :full_name, :email, :phone, :document_id, :bank_account)`
 	_, err := r.db.NamedExecContext(ctx, q, p)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "promoters_document_id_key":
+			return ErrDuplicateDocumentID
+		case "promoters_email_key":
+			return ErrDuplicateEmail
+		}
+	}
 	return err
 }
 
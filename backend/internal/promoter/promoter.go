@@ -1,10 +1,19 @@
 package promoter
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// Erros de unicidade retornados por Create.
+var (
+	ErrDuplicateDocumentID = errors.New("duplicate document_id")
+	ErrDuplicateEmail      = errors.New("duplicate email")
+)
 
 // Repository define operações para armazenamento de promotores.
 type Repository interface {
-	FindAll(ctx context.Context) ([]Promoter, error)
+	FindAll(ctx context.Context, tags []string) ([]Promoter, error)
 	Create(ctx context.Context, p *Promoter) error
 	Update(ctx context.Context, p *Promoter) error
 	SoftDelete(ctx context.Context, id string) error
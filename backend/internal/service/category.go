@@ -0,0 +1,22 @@
+package service
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownCategory = errors.New("category not found")
+	ErrCategoryInUse   = errors.New("category has services")
+	ErrCategoryExists  = errors.New("category name already exists")
+)
+
+// Category agrupa servicos do catalogo.
+type Category struct {
+	ID          string     `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Description string     `db:"description" json:"description,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+}
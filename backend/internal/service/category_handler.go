@@ -0,0 +1,126 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+// CategoryInput define o payload de criacao e atualizacao de categorias.
+type CategoryInput struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+}
+
+func writeCategoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, ErrCategoryExists), errors.Is(err, ErrCategoryInUse):
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func (h handler) decodeCategory(w http.ResponseWriter, r *http.Request) (CategoryInput, bool) {
+	var in CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return in, false
+	}
+	in.Name = strings.TrimSpace(in.Name)
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return in, false
+	}
+	return in, true
+}
+
+// @Summary      Lista categorias de servico
+// @Tags         services
+// @Security     BearerAuth
+// @Success      200  {array}  Category
+// @Router       /service-categories [get]
+func (h handler) listCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	categories, err := h.repo.ListCategories(r.Context())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(categories)
+}
+
+// @Summary      Cria categoria de servico
+// @Tags         services
+// @Security     BearerAuth
+// @Success      201  {object}  Category
+// @Router       /service-categories [post]
+func (h handler) createCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	in, ok := h.decodeCategory(w, r)
+	if !ok {
+		return
+	}
+	c := Category{
+		ID:          ulid.Make().String(),
+		Name:        in.Name,
+		Description: in.Description,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := h.repo.CreateCategory(r.Context(), &c); err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/service-categories/"+c.ID)
+	w.Header().Set("X-Entity", fmt.Sprintf("service_categories:%s", c.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// @Summary      Atualiza categoria de servico
+// @Tags         services
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /service-categories/{id} [put]
+func (h handler) updateCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id := chi.URLParam(r, "id")
+	in, ok := h.decodeCategory(w, r)
+	if !ok {
+		return
+	}
+	c := Category{ID: id, Name: in.Name, Description: in.Description, UpdatedAt: time.Now()}
+	if err := h.repo.UpdateCategory(r.Context(), &c); err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("service_categories:%s", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Remove categoria de servico
+// @Tags         services
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /service-categories/{id} [delete]
+func (h handler) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id := chi.URLParam(r, "id")
+	if err := h.repo.DeleteCategory(r.Context(), id); err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("service_categories:%s", id))
+	w.WriteHeader(http.StatusNoContent)
+}
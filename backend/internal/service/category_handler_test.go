@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(url.Values{"active": {"true"}, "min_price": {"10"}, "max_price": {"99.5"}, "name": {" site "}, "category": {"cat1"}})
+	if err != nil || f.Active == nil || !*f.Active || *f.MinPrice != 10 || *f.MaxPrice != 99.5 || f.Name != "site" || f.CategoryID != "cat1" {
+		t.Fatalf("unexpected filter %+v (%v)", f, err)
+	}
+	for _, q := range []url.Values{
+		{"active": {"yes"}},
+		{"min_price": {"-1"}},
+		{"min_price": {"50"}, "max_price": {"10"}},
+	} {
+		if _, err := ParseFilter(q); err == nil {
+			t.Fatalf("expected error for %v", q)
+		}
+	}
+}
+
+func TestListServicesFilters(t *testing.T) {
+	cat := "cat1"
+	repo := &fakeRepository{services: []Service{
+		{ID: "s1", Name: "Site Basico", BasePrice: 1500, IsActive: true, CategoryID: &cat},
+		{ID: "s2", Name: "Site Premium", BasePrice: 5000, IsActive: false, CategoryID: &cat},
+		{ID: "s3", Name: "Consultoria", BasePrice: 300, IsActive: true},
+	}}
+	r, token := setupAuthRouter(repo, "sales")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	cases := map[string]int{
+		"active=true":                           2,
+		"active=false":                          1,
+		"category=cat1":                         2,
+		"min_price=1000&max_price=2000":         1,
+		"name=site&active=true":                 1,
+		"category=cat1&name=premium":            1,
+		"max_price=100":                         0,
+		"tags=":                                 3,
+		"active=true&category=cat1&min_price=1": 1,
+	}
+	for q, want := range cases {
+		resp := do(t, http.MethodGet, server.URL+"/services?"+q, token, "")
+		var out []Service
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("%s: decode: %v", q, err)
+		}
+		resp.Body.Close()
+		if len(out) != want {
+			t.Fatalf("%s: expected %d services, got %d", q, want, len(out))
+		}
+	}
+
+	bad := do(t, http.MethodGet, server.URL+"/services?active=maybe", token, "")
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", bad.StatusCode)
+	}
+}
+
+func TestServiceCategoriesCRUD(t *testing.T) {
+	repo := &fakeRepository{}
+	r, token := setupAuthRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPost, server.URL+"/service-categories", token, `{"name":"Sites"}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var c Category
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	dup := do(t, http.MethodPost, server.URL+"/service-categories", token, `{"name":"sites"}`)
+	dup.Body.Close()
+	if dup.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409 for duplicate, got %d", dup.StatusCode)
+	}
+
+	upd := do(t, http.MethodPut, server.URL+"/service-categories/"+c.ID, token, `{"name":"Sites e lojas"}`)
+	upd.Body.Close()
+	if upd.StatusCode != http.StatusNoContent || repo.categories[0].Name != "Sites e lojas" {
+		t.Fatalf("unexpected update: %d %+v", upd.StatusCode, repo.categories)
+	}
+
+	repo.services = []Service{{ID: "s1", Name: "Site", CategoryID: &c.ID}}
+	inUse := do(t, http.MethodDelete, server.URL+"/service-categories/"+c.ID, token, "")
+	inUse.Body.Close()
+	if inUse.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409 for category in use, got %d", inUse.StatusCode)
+	}
+	repo.services = nil
+	del := do(t, http.MethodDelete, server.URL+"/service-categories/"+c.ID, token, "")
+	del.Body.Close()
+	if del.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", del.StatusCode)
+	}
+
+	_, salesToken := setupAuthRouter(repo, "sales")
+	denied := do(t, http.MethodPost, server.URL+"/service-categories", salesToken, `{"name":"Outra"}`)
+	denied.Body.Close()
+	if denied.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403 for sales, got %d", denied.StatusCode)
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rgomids/bckoffice/internal/tag"
+)
+
+// Filter define os criterios de consulta do catalogo de servicos. Campos
+// vazios nao filtram; a faixa de preco considera o preco vigente hoje.
+type Filter struct {
+	Active     *bool
+	CategoryID string
+	MinPrice   *float64
+	MaxPrice   *float64
+	Name       string
+	Tags       []string
+}
+
+// ParseFilter le os parametros active, category, min_price, max_price, name
+// e tags da consulta.
+func ParseFilter(q url.Values) (Filter, error) {
+	f := Filter{
+		CategoryID: strings.TrimSpace(q.Get("category")),
+		Name:       strings.TrimSpace(q.Get("name")),
+		Tags:       tag.ParseFilter(q),
+	}
+	if v := q.Get("active"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid active %q", v)
+		}
+		f.Active = &b
+	}
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{{"min_price", &f.MinPrice}, {"max_price", &f.MaxPrice}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return Filter{}, fmt.Errorf("invalid %s %q", p.name, v)
+		}
+		*p.dst = &n
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return Filter{}, fmt.Errorf("min_price must not exceed max_price")
+	}
+	return f, nil
+}
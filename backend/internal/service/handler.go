@@ -20,6 +20,13 @@ func RegisterRoutes(r chi.Router, repo Repository) {
 	r.Post("/services", h.create)
 	r.Put("/services/{id}", h.update)
 	r.Delete("/services/{id}", h.remove)
+	r.Get("/services/{id}/prices", h.prices)
+	r.Post("/services/{id}/prices", h.addPrice)
+	r.Get("/services/{id}/price", h.resolvePrice)
+	r.Get("/service-categories", h.listCategories)
+	r.Post("/service-categories", h.createCategory)
+	r.Put("/service-categories/{id}", h.updateCategory)
+	r.Delete("/service-categories/{id}", h.deleteCategory)
 }
 
 type handler struct {
@@ -28,28 +35,74 @@ type handler struct {
 }
 
 type createServiceInput struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	BasePrice   float64 `json:"base_price" validate:"gte=0"`
-	IsActive    bool    `json:"is_active"`
+	Name               string  `json:"name" validate:"required"`
+	Description        string  `json:"description"`
+	CategoryID         *string `json:"category_id"`
+	BasePrice          float64 `json:"base_price" validate:"gte=0"`
+	IsActive           bool    `json:"is_active"`
+	BillingType        string  `json:"billing_type" validate:"omitempty,oneof=one_off recurring"`
+	AutoCloseGraceDays int     `json:"auto_close_grace_days" validate:"gte=0"`
+	// LateFinePercent e MonthlyInterestPercent sao os encargos por atraso
+	// padrao dos contratos do servico.
+	LateFinePercent        *float64 `json:"late_fine_percent" validate:"omitempty,gte=0,lte=100"`
+	MonthlyInterestPercent *float64 `json:"monthly_interest_percent" validate:"omitempty,gte=0,lte=100"`
 }
 
 // UpdateServiceInput define o payload para atualizacao de servicos.
 type UpdateServiceInput struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	BasePrice   float64 `json:"base_price" validate:"gte=0"`
-	IsActive    bool    `json:"is_active"`
+	Name               string  `json:"name" validate:"required"`
+	Description        string  `json:"description"`
+	CategoryID         *string `json:"category_id"`
+	BasePrice          float64 `json:"base_price" validate:"gte=0"`
+	IsActive           bool    `json:"is_active"`
+	BillingType        string  `json:"billing_type" validate:"omitempty,oneof=one_off recurring"`
+	AutoCloseGraceDays int     `json:"auto_close_grace_days" validate:"gte=0"`
+	// Encargos por atraso nulos mantem os atuais.
+	LateFinePercent        *float64 `json:"late_fine_percent" validate:"omitempty,gte=0,lte=100"`
+	MonthlyInterestPercent *float64 `json:"monthly_interest_percent" validate:"omitempty,gte=0,lte=100"`
+}
+
+// Encargos por atraso padrao: multa de 2% e juros de 1% ao mes.
+const (
+	DefaultLateFinePercent        = 2.0
+	DefaultMonthlyInterestPercent = 1.0
+)
+
+// percentOrDefault aplica o padrao quando o percentual nao eh informado.
+func percentOrDefault(v *float64, def float64) *float64 {
+	if v == nil {
+		return &def
+	}
+	return v
+}
+
+// billingType aplica o padrao de cobranca unica quando nao informado.
+func billingType(v string) string {
+	if v == "" {
+		return BillingOneOff
+	}
+	return v
 }
 
 // @Summary      Lista servicos
 // @Tags         services
 // @Security     BearerAuth
+// @Param        tags       query  string  false  "Tags separadas por virgula (todas obrigatorias)"
+// @Param        active     query  bool    false  "Somente ativos (true) ou inativos (false)"
+// @Param        category   query  string  false  "ID da categoria"
+// @Param        min_price  query  number  false  "Preco vigente minimo"
+// @Param        max_price  query  number  false  "Preco vigente maximo"
+// @Param        name       query  string  false  "Trecho do nome"
 // @Success      200  {array}  Service
 // @Router       /services [get]
 func (h handler) list(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	services, err := h.repo.FindAll(r.Context())
+	f, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	services, err := h.repo.FindAll(r.Context(), f)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -76,17 +129,17 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s := Service{
-		ID:          ulid.Make().String(),
-		Name:        in.Name,
-		Description: in.Description,
-		BasePrice:   in.BasePrice,
-		IsActive:    in.IsActive,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+	s := newService(in)
 
 	if err := h.repo.Create(r.Context(), &s); err != nil {
+		if errors.Is(err, ErrUnknownCategory) {
+			writeBadRequest(w, err)
+			return
+		}
+		if errors.Is(err, ErrDuplicateName) {
+			http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+			return
+		}
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -97,6 +150,24 @@ func (h handler) create(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(s)
 }
 
+// newService monta o servico a partir de um payload ja validado.
+func newService(in createServiceInput) Service {
+	return Service{
+		ID:                     ulid.Make().String(),
+		Name:                   in.Name,
+		Description:            in.Description,
+		CategoryID:             in.CategoryID,
+		BasePrice:              in.BasePrice,
+		IsActive:               in.IsActive,
+		BillingType:            billingType(in.BillingType),
+		AutoCloseGraceDays:     in.AutoCloseGraceDays,
+		LateFinePercent:        percentOrDefault(in.LateFinePercent, DefaultLateFinePercent),
+		MonthlyInterestPercent: percentOrDefault(in.MonthlyInterestPercent, DefaultMonthlyInterestPercent),
+		CreatedAt:              time.Now(),
+		UpdatedAt:              time.Now(),
+	}
+}
+
 // @Summary      Atualiza servico
 // @Tags         services
 // @Security     BearerAuth
@@ -119,15 +190,24 @@ func (h handler) update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s := Service{
-		ID:          id,
-		Name:        in.Name,
-		Description: in.Description,
-		BasePrice:   in.BasePrice,
-		IsActive:    in.IsActive,
-		UpdatedAt:   time.Now(),
+		ID:                     id,
+		Name:                   in.Name,
+		Description:            in.Description,
+		CategoryID:             in.CategoryID,
+		BasePrice:              in.BasePrice,
+		IsActive:               in.IsActive,
+		BillingType:            in.BillingType,
+		AutoCloseGraceDays:     in.AutoCloseGraceDays,
+		LateFinePercent:        in.LateFinePercent,
+		MonthlyInterestPercent: in.MonthlyInterestPercent,
+		UpdatedAt:              time.Now(),
 	}
 
 	if err := h.repo.Update(r.Context(), &s); err != nil {
+		if errors.Is(err, ErrUnknownCategory) {
+			writeBadRequest(w, err)
+			return
+		}
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 			return
@@ -14,13 +14,22 @@ import (
 )
 
 type fakeRepository struct {
-	services []Service
+	services   []Service
+	prices     []Price
+	categories []Category
 }
 
-func (f *fakeRepository) FindAll(ctx context.Context) ([]Service, error) {
+func (f *fakeRepository) FindAll(ctx context.Context, flt Filter) ([]Service, error) {
 	out := make([]Service, 0, len(f.services))
 	for _, s := range f.services {
-		if s.DeletedAt == nil {
+		switch {
+		case s.DeletedAt != nil:
+		case flt.Active != nil && s.IsActive != *flt.Active:
+		case flt.CategoryID != "" && (s.CategoryID == nil || *s.CategoryID != flt.CategoryID):
+		case flt.MinPrice != nil && s.BasePrice < *flt.MinPrice:
+		case flt.MaxPrice != nil && s.BasePrice > *flt.MaxPrice:
+		case flt.Name != "" && !strings.Contains(strings.ToLower(s.Name), strings.ToLower(flt.Name)):
+		default:
 			out = append(out, s)
 		}
 	}
@@ -39,6 +48,12 @@ func (f *fakeRepository) Update(ctx context.Context, s *Service) error {
 			svc.Description = s.Description
 			svc.BasePrice = s.BasePrice
 			svc.IsActive = s.IsActive
+			if s.LateFinePercent != nil {
+				svc.LateFinePercent = s.LateFinePercent
+			}
+			if s.MonthlyInterestPercent != nil {
+				svc.MonthlyInterestPercent = s.MonthlyInterestPercent
+			}
 			svc.UpdatedAt = s.UpdatedAt
 			f.services[i] = svc
 			return nil
@@ -62,6 +77,84 @@ func (f *fakeRepository) SoftDelete(ctx context.Context, id string) error {
 	return sql.ErrNoRows
 }
 
+func (f *fakeRepository) Prices(ctx context.Context, id string) ([]Price, error) {
+	out := []Price{}
+	for _, p := range f.prices {
+		if p.ServiceID == id {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) AddPrice(ctx context.Context, p *Price) error {
+	for _, s := range f.services {
+		if s.ID == p.ServiceID {
+			f.prices = append(f.prices, *p)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) ResolvePrice(ctx context.Context, id string, date time.Time, quantity float64) (ResolvedPrice, error) {
+	for _, s := range f.services {
+		if s.ID == id {
+			prices, _ := f.Prices(ctx, id)
+			out, err := Resolve(prices, date, quantity)
+			out.BillingType = s.BillingType
+			return out, err
+		}
+	}
+	return ResolvedPrice{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	out := []Category{}
+	for _, c := range f.categories {
+		if c.DeletedAt == nil {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) CreateCategory(ctx context.Context, c *Category) error {
+	for _, cur := range f.categories {
+		if cur.DeletedAt == nil && strings.EqualFold(cur.Name, c.Name) {
+			return ErrCategoryExists
+		}
+	}
+	f.categories = append(f.categories, *c)
+	return nil
+}
+
+func (f *fakeRepository) UpdateCategory(ctx context.Context, c *Category) error {
+	for i, cur := range f.categories {
+		if cur.ID == c.ID && cur.DeletedAt == nil {
+			f.categories[i].Name, f.categories[i].Description = c.Name, c.Description
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) DeleteCategory(ctx context.Context, id string) error {
+	for _, s := range f.services {
+		if s.CategoryID != nil && *s.CategoryID == id && s.DeletedAt == nil {
+			return ErrCategoryInUse
+		}
+	}
+	for i, cur := range f.categories {
+		if cur.ID == id && cur.DeletedAt == nil {
+			now := time.Now()
+			f.categories[i].DeletedAt = &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
 func setupRouter() *chi.Mux {
 	r := chi.NewRouter()
 	repo := &fakeRepository{}
@@ -116,6 +209,9 @@ func TestCreateService(t *testing.T) {
 	if svc.Name != "Site Basico" || svc.BasePrice != 1500 {
 		t.Fatalf("unexpected service data: %+v", svc)
 	}
+	if *svc.LateFinePercent != 2 || *svc.MonthlyInterestPercent != 1 {
+		t.Fatalf("expected default late fees, got %+v", svc)
+	}
 }
 
 func TestUpdateService(t *testing.T) {
@@ -138,7 +234,7 @@ func TestUpdateService(t *testing.T) {
 		t.Fatalf("decode created: %v", err)
 	}
 
-	upd := strings.NewReader(`{"name":"Site Avancado","base_price":2000}`)
+	upd := strings.NewReader(`{"name":"Site Avancado","base_price":2000,"late_fine_percent":10}`)
 	req, err := http.NewRequest(http.MethodPut, server.URL+"/services/"+created.ID, upd)
 	if err != nil {
 		t.Fatalf("new request: %v", err)
@@ -171,6 +267,10 @@ func TestUpdateService(t *testing.T) {
 	if list[0].Name != "Site Avancado" {
 		t.Fatalf("name not updated: %+v", list[0])
 	}
+	// juros omitidos mantem o valor atual
+	if *list[0].LateFinePercent != 10 || *list[0].MonthlyInterestPercent != 1 {
+		t.Fatalf("late fees not updated: %+v", list[0])
+	}
 }
 
 func TestDeleteService(t *testing.T) {
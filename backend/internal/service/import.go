@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/rgomids/bckoffice/internal/importer"
+)
+
+// ImportTarget descreve a importacao de servicos em lote, com o nome como
+// chave de idempotencia. base_price aceita virgula decimal e is_active vazio
+// cadastra o servico como ativo.
+func ImportTarget(repo Repository) importer.Target {
+	v := validator.New()
+	return importer.Target{
+		Entity:    "services",
+		Table:     "services",
+		KeyColumn: "name",
+		Columns: []string{
+			"name", "description", "category_id", "base_price", "is_active", "billing_type", "auto_close_grace_days",
+			"late_fine_percent", "monthly_interest_percent",
+		},
+		Key: func(row importer.Row) string {
+			return row.Get("name")
+		},
+		Validate: func(row importer.Row) error {
+			in, err := importInput(row)
+			if err != nil {
+				return err
+			}
+			return v.Struct(in)
+		},
+		Create: func(ctx context.Context, row importer.Row) error {
+			in, err := importInput(row)
+			if err != nil {
+				return err
+			}
+			s := newService(in)
+			err = repo.Create(ctx, &s)
+			if errors.Is(err, ErrDuplicateName) {
+				return importer.ErrDuplicate
+			}
+			return err
+		},
+	}
+}
+
+func importInput(row importer.Row) (createServiceInput, error) {
+	in := createServiceInput{
+		Name:        row.Get("name"),
+		Description: row.Get("description"),
+		BillingType: row.Get("billing_type"),
+	}
+	if id := row.Get("category_id"); id != "" {
+		in.CategoryID = &id
+	}
+	var err error
+	if in.BasePrice, err = importer.ParseDecimal(row.Get("base_price")); err != nil {
+		return in, err
+	}
+	if in.IsActive, err = importer.ParseBool(row.Get("is_active"), true); err != nil {
+		return in, err
+	}
+	if days := row.Get("auto_close_grace_days"); days != "" {
+		if in.AutoCloseGraceDays, err = strconv.Atoi(days); err != nil {
+			return in, errors.New("invalid auto_close_grace_days " + strconv.Quote(days))
+		}
+	}
+	if in.LateFinePercent, err = importPercent(row, "late_fine_percent"); err != nil {
+		return in, err
+	}
+	if in.MonthlyInterestPercent, err = importPercent(row, "monthly_interest_percent"); err != nil {
+		return in, err
+	}
+	return in, nil
+}
+
+// importPercent le um percentual opcional; vazio assume o padrao do servico.
+func importPercent(row importer.Row, column string) (*float64, error) {
+	v := row.Get(column)
+	if v == "" {
+		return nil, nil
+	}
+	pct, err := importer.ParseDecimal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &pct, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rgomids/bckoffice/internal/importer"
+)
+
+type uniqueRepository struct {
+	fakeRepository
+}
+
+func (u *uniqueRepository) Create(ctx context.Context, s *Service) error {
+	for _, cur := range u.services {
+		if cur.Name == s.Name {
+			return ErrDuplicateName
+		}
+	}
+	return u.fakeRepository.Create(ctx, s)
+}
+
+func TestImportTarget(t *testing.T) {
+	repo := &uniqueRepository{}
+	target := ImportTarget(repo)
+
+	row := importer.Row{"name": " Consultoria ", "base_price": "1.500,00", "billing_type": "recurring", "auto_close_grace_days": "5"}
+	if err := target.Validate(row); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if err := target.Create(context.Background(), row); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	s := repo.services[0]
+	if s.Name != "Consultoria" || s.BasePrice != 1500 || !s.IsActive || s.BillingType != BillingRecurring || s.AutoCloseGraceDays != 5 || s.CategoryID != nil {
+		t.Fatalf("unexpected service %+v", s)
+	}
+	if *s.LateFinePercent != DefaultLateFinePercent || *s.MonthlyInterestPercent != DefaultMonthlyInterestPercent {
+		t.Fatalf("expected default late fees, got %v %v", *s.LateFinePercent, *s.MonthlyInterestPercent)
+	}
+	if err := target.Create(context.Background(), row); !errors.Is(err, importer.ErrDuplicate) {
+		t.Fatalf("expected duplicate, got %v", err)
+	}
+
+	for _, bad := range []importer.Row{
+		{"base_price": "10"},
+		{"name": "X", "base_price": "-1"},
+		{"name": "X", "billing_type": "monthly"},
+		{"name": "X", "is_active": "talvez"},
+		{"name": "X", "auto_close_grace_days": "dois"},
+		{"name": "X", "late_fine_percent": "dois"},
+		{"name": "X", "monthly_interest_percent": "150"},
+	} {
+		if err := target.Validate(bad); err == nil {
+			t.Fatalf("expected validation error for %v", bad)
+		}
+	}
+}
@@ -4,12 +4,19 @@ import "time"
 
 // Service representa um servico comercializado pela aplicacao.
 type Service struct {
-	ID          string     `db:"id" json:"id"`
-	Name        string     `db:"name" json:"name"`
-	Description string     `db:"description" json:"description,omitempty"`
-	BasePrice   float64    `db:"base_price" json:"basePrice"`
-	IsActive    bool       `db:"is_active" json:"isActive"`
-	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt   time.Time  `db:"updated_at" json:"updatedAt"`
-	DeletedAt   *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+	ID                 string  `db:"id" json:"id"`
+	Name               string  `db:"name" json:"name"`
+	Description        string  `db:"description" json:"description,omitempty"`
+	CategoryID         *string `db:"category_id" json:"categoryID,omitempty"`
+	BasePrice          float64 `db:"base_price" json:"basePrice"`
+	IsActive           bool    `db:"is_active" json:"isActive"`
+	BillingType        string  `db:"billing_type" json:"billingType"`
+	AutoCloseGraceDays int     `db:"auto_close_grace_days" json:"autoCloseGraceDays"`
+	// Encargos por atraso padrao dos contratos: multa sobre o valor da parcela
+	// e juros ao mes, cobrados pro rata die.
+	LateFinePercent        *float64   `db:"late_fine_percent" json:"lateFinePercent"`
+	MonthlyInterestPercent *float64   `db:"monthly_interest_percent" json:"monthlyInterestPercent"`
+	CreatedAt              time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt              time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt              *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
 }
@@ -3,8 +3,15 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/tag"
 )
 
 // PostgresRepository implementa Repository usando PostgreSQL.
@@ -17,27 +24,94 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-// FindAll retorna todos os servicos ativos (nao excluidos).
-func (r *PostgresRepository) FindAll(ctx context.Context) ([]Service, error) {
+// selectServices traz base_price da versao de preco vigente hoje, de modo que
+// precos agendados passam a valer sem atualizar o cadastro.
+const selectServices = `SELECT s.id, s.name, COALESCE(s.description, '') AS description, s.category_id, COALESCE(p.base_price, s.base_price) AS base_price,
+        s.is_active, s.billing_type, s.auto_close_grace_days, s.late_fine_percent, s.monthly_interest_percent, s.created_at, s.updated_at, s.deleted_at
+        FROM services s
+        LEFT JOIN LATERAL (
+            SELECT base_price FROM service_prices
+             WHERE service_id = s.id AND effective_from <= current_date
+             ORDER BY effective_from DESC LIMIT 1) p ON true`
+
+// FindAll retorna os servicos nao excluidos que atendem ao filtro. Tags
+// exigem todas as informadas; name busca por trecho sem diferenciar caixa.
+func (r *PostgresRepository) FindAll(ctx context.Context, f Filter) ([]Service, error) {
 	services := []Service{}
-	const q = `SELECT * FROM services WHERE deleted_at IS NULL ORDER BY name`
-	if err := r.db.SelectContext(ctx, &services, q); err != nil {
+	q := selectServices + ` WHERE s.deleted_at IS NULL`
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if f.Active != nil {
+		q += ` AND s.is_active = ` + arg(*f.Active)
+	}
+	if f.CategoryID != "" {
+		q += ` AND s.category_id = ` + arg(f.CategoryID)
+	}
+	if f.MinPrice != nil {
+		q += ` AND COALESCE(p.base_price, s.base_price) >= ` + arg(*f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		q += ` AND COALESCE(p.base_price, s.base_price) <= ` + arg(*f.MaxPrice)
+	}
+	if f.Name != "" {
+		q += ` AND s.name ILIKE '%' || ` + arg(f.Name) + ` || '%'`
+	}
+	if len(f.Tags) > 0 {
+		q += ` AND ` + tag.FilterClause("services", "s.id", arg(pq.Array(f.Tags)))
+	}
+	q += ` ORDER BY s.name`
+	if err := r.db.SelectContext(ctx, &services, q, args...); err != nil {
 		return nil, err
 	}
 	return services, nil
 }
 
-// Create insere um novo servico.
+// Create insere um novo servico com a primeira versao de preco, vigente
+// desde FirstPriceDate.
 func (r *PostgresRepository) Create(ctx context.Context, s *Service) error {
-	const q = `INSERT INTO services (id, name, description, base_price, is_active) VALUES (:id, :name, :description, :base_price, :is_active)`
-	_, err := r.db.NamedExecContext(ctx, q, s)
-	return err
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = checkCategory(ctx, tx, s.CategoryID); err != nil {
+		return err
+	}
+	const q = `INSERT INTO services (id, name, description, category_id, base_price, is_active, billing_type, auto_close_grace_days, late_fine_percent, monthly_interest_percent) VALUES (:id, :name, :description, :category_id, :base_price, :is_active, :billing_type, :auto_close_grace_days, :late_fine_percent, :monthly_interest_percent)`
+	if _, err = tx.NamedExecContext(ctx, q, s); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "services_name_key" {
+			return ErrDuplicateName
+		}
+		return err
+	}
+	const qp = `INSERT INTO service_prices (id, service_id, effective_from, base_price) VALUES ($1, $2, $3, $4)`
+	if _, err = tx.ExecContext(ctx, qp, ulid.Make().String(), s.ID, FirstPriceDate, s.BasePrice); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// Update atualiza um servico existente.
+// Update atualiza um servico existente; billing_type vazio e encargos por
+// atraso nulos mantem os atuais.
+// Se o preco base mudar, uma nova
+// versao de preco passa a valer hoje, preservando o historico.
 func (r *PostgresRepository) Update(ctx context.Context, s *Service) error {
-	const q = `UPDATE services SET name=:name, description=:description, base_price=:base_price, is_active=:is_active, updated_at=now() WHERE id=:id AND deleted_at IS NULL`
-	res, err := r.db.NamedExecContext(ctx, q, s)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = checkCategory(ctx, tx, s.CategoryID); err != nil {
+		return err
+	}
+	const q = `UPDATE services SET name=:name, description=:description, category_id=:category_id, base_price=:base_price, is_active=:is_active, billing_type=COALESCE(NULLIF(:billing_type, ''), billing_type), auto_close_grace_days=:auto_close_grace_days, late_fine_percent=COALESCE(:late_fine_percent, late_fine_percent), monthly_interest_percent=COALESCE(:monthly_interest_percent, monthly_interest_percent), updated_at=now() WHERE id=:id AND deleted_at IS NULL`
+	res, err := tx.NamedExecContext(ctx, q, s)
 	if err != nil {
 		return err
 	}
@@ -48,7 +122,136 @@ func (r *PostgresRepository) Update(ctx context.Context, s *Service) error {
 	if affected == 0 {
 		return sql.ErrNoRows
 	}
-	return nil
+	if err = recordBasePrice(ctx, tx, s.ID, s.BasePrice); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func checkCategory(ctx context.Context, tx *sqlx.Tx, id *string) error {
+	if id == nil {
+		return nil
+	}
+	var exists int
+	err := tx.GetContext(ctx, &exists, `SELECT 1 FROM service_categories WHERE id=$1 AND deleted_at IS NULL`, *id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUnknownCategory
+	}
+	return err
+}
+
+// recordBasePrice grava uma versao de preco vigente hoje quando o preco base
+// difere do vigente. As faixas da versao anterior sao mantidas.
+func recordBasePrice(ctx context.Context, tx *sqlx.Tx, serviceID string, basePrice float64) error {
+	var cur Price
+	const qc = `SELECT * FROM service_prices WHERE service_id=$1 AND effective_from <= current_date
+        ORDER BY effective_from DESC LIMIT 1`
+	err := tx.GetContext(ctx, &cur, qc, serviceID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil && cur.BasePrice == basePrice {
+		return nil
+	}
+	var id string
+	const qi = `INSERT INTO service_prices (id, service_id, effective_from, base_price) VALUES ($1, $2, current_date, $3)
+        ON CONFLICT (service_id, effective_from) DO UPDATE SET base_price=EXCLUDED.base_price RETURNING id`
+	if err = tx.GetContext(ctx, &id, qi, ulid.Make().String(), serviceID, basePrice); err != nil {
+		return err
+	}
+	if cur.ID == "" || cur.ID == id {
+		return nil
+	}
+	const qt = `INSERT INTO service_price_tiers (price_id, min_quantity, unit_price)
+        SELECT $1, min_quantity, unit_price FROM service_price_tiers WHERE price_id=$2`
+	_, err = tx.ExecContext(ctx, qt, id, cur.ID)
+	return err
+}
+
+// Prices retorna o historico de precos do servico, do mais recente ao mais
+// antigo, com as faixas de cada versao.
+func (r *PostgresRepository) Prices(ctx context.Context, id string) ([]Price, error) {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM services WHERE id=$1 AND deleted_at IS NULL`, id); err != nil {
+		return nil, err
+	}
+	return loadPrices(ctx, r.db, id)
+}
+
+func loadPrices(ctx context.Context, q sqlx.QueryerContext, serviceID string) ([]Price, error) {
+	prices := []Price{}
+	if err := sqlx.SelectContext(ctx, q, &prices, `SELECT * FROM service_prices WHERE service_id=$1 ORDER BY effective_from DESC`, serviceID); err != nil {
+		return nil, err
+	}
+	var tiers []Tier
+	const qt = `SELECT t.* FROM service_price_tiers t JOIN service_prices p ON p.id = t.price_id
+        WHERE p.service_id=$1 ORDER BY t.min_quantity`
+	if err := sqlx.SelectContext(ctx, q, &tiers, qt, serviceID); err != nil {
+		return nil, err
+	}
+	for i := range prices {
+		prices[i].Tiers = []Tier{}
+		for _, t := range tiers {
+			if t.PriceID == prices[i].ID {
+				prices[i].Tiers = append(prices[i].Tiers, t)
+			}
+		}
+	}
+	return prices, nil
+}
+
+// AddPrice grava uma versao de preco com suas faixas. Uma versao na mesma
+// data eh substituida; se vigente hoje, o preco base do servico acompanha.
+func (r *PostgresRepository) AddPrice(ctx context.Context, p *Price) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	if err = tx.GetContext(ctx, &exists, `SELECT 1 FROM services WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, p.ServiceID); err != nil {
+		return err
+	}
+	const qi = `INSERT INTO service_prices (id, service_id, effective_from, base_price, created_by) VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (service_id, effective_from) DO UPDATE SET base_price=EXCLUDED.base_price, created_by=EXCLUDED.created_by
+        RETURNING id, created_at`
+	if err = tx.QueryRowxContext(ctx, qi, p.ID, p.ServiceID, p.EffectiveFrom, p.BasePrice, p.CreatedBy).Scan(&p.ID, &p.CreatedAt); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM service_price_tiers WHERE price_id=$1`, p.ID); err != nil {
+		return err
+	}
+	for i := range p.Tiers {
+		p.Tiers[i].PriceID = p.ID
+		const qt = `INSERT INTO service_price_tiers (price_id, min_quantity, unit_price) VALUES (:price_id, :min_quantity, :unit_price)`
+		if _, err = tx.NamedExecContext(ctx, qt, &p.Tiers[i]); err != nil {
+			return err
+		}
+	}
+	const qs = `UPDATE services SET base_price=$2, updated_at=now() WHERE id=$1 AND $3 = current_date`
+	if _, err = tx.ExecContext(ctx, qs, p.ServiceID, p.BasePrice, p.EffectiveFrom); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ResolvePrice calcula o preco do servico para a data e quantidade.
+func (r *PostgresRepository) ResolvePrice(ctx context.Context, id string, date time.Time, quantity float64) (ResolvedPrice, error) {
+	var billing string
+	if err := r.db.GetContext(ctx, &billing, `SELECT billing_type FROM services WHERE id=$1 AND deleted_at IS NULL`, id); err != nil {
+		return ResolvedPrice{}, err
+	}
+	prices, err := loadPrices(ctx, r.db, id)
+	if err != nil {
+		return ResolvedPrice{}, err
+	}
+	out, err := Resolve(prices, date, quantity)
+	if err != nil {
+		return ResolvedPrice{}, err
+	}
+	out.BillingType = billing
+	return out, nil
 }
 
 // SoftDelete marca um servico como removido.
@@ -67,3 +270,62 @@ func (r *PostgresRepository) SoftDelete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// ListCategories retorna as categorias nao excluidas por nome.
+func (r *PostgresRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	categories := []Category{}
+	if err := r.db.SelectContext(ctx, &categories, `SELECT * FROM service_categories WHERE deleted_at IS NULL ORDER BY name`); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// CreateCategory insere uma categoria.
+func (r *PostgresRepository) CreateCategory(ctx context.Context, c *Category) error {
+	const q = `INSERT INTO service_categories (id, name, description) VALUES (:id, :name, :description)`
+	_, err := r.db.NamedExecContext(ctx, q, c)
+	return translateUnique(err)
+}
+
+// UpdateCategory altera nome e descricao da categoria.
+func (r *PostgresRepository) UpdateCategory(ctx context.Context, c *Category) error {
+	const q = `UPDATE service_categories SET name=:name, description=:description, updated_at=now()
+        WHERE id=:id AND deleted_at IS NULL`
+	res, err := r.db.NamedExecContext(ctx, q, c)
+	if err != nil {
+		return translateUnique(err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteCategory remove logicamente uma categoria sem servicos vinculados.
+func (r *PostgresRepository) DeleteCategory(ctx context.Context, id string) error {
+	var inUse bool
+	const qc = `SELECT EXISTS (SELECT 1 FROM services WHERE category_id=$1 AND deleted_at IS NULL)`
+	if err := r.db.GetContext(ctx, &inUse, qc, id); err != nil {
+		return err
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE service_categories SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// translateUnique converte violacoes de unicidade do nome em ErrCategoryExists.
+func translateUnique(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrCategoryExists
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+)
+
+// TierInput define uma faixa de preco por volume.
+type TierInput struct {
+	MinQuantity float64 `json:"min_quantity" validate:"gt=0"`
+	UnitPrice   float64 `json:"unit_price" validate:"gte=0"`
+}
+
+// PriceInput define o payload de uma nova versao de preco.
+type PriceInput struct {
+	EffectiveFrom string      `json:"effective_from" validate:"required"`
+	BasePrice     float64     `json:"base_price" validate:"gte=0"`
+	Tiers         []TierInput `json:"tiers" validate:"omitempty,dive"`
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func today(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// @Summary      Historico de precos do servico
+// @Tags         services
+// @Security     BearerAuth
+// @Success      200  {array}  Price
+// @Router       /services/{id}/prices [get]
+func (h handler) prices(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	prices, err := h.repo.Prices(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(prices)
+}
+
+// @Summary      Registra nova versao de preco do servico
+// @Description  Versoes valem a partir de effective_from (hoje ou futuro) ate a proxima.
+// @Tags         services
+// @Security     BearerAuth
+// @Success      201  {object}  Price
+// @Router       /services/{id}/prices [post]
+func (h handler) addPrice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	var in PriceInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	effective, err := time.Parse("2006-01-02", in.EffectiveFrom)
+	if err != nil {
+		writeBadRequest(w, fmt.Errorf("invalid date %q", in.EffectiveFrom))
+		return
+	}
+	// o historico nao eh reescrito: precos passados ficam como estao
+	if effective.Before(today(time.Now())) {
+		writeBadRequest(w, ErrPastPrice)
+		return
+	}
+
+	p := Price{
+		ID:            ulid.Make().String(),
+		ServiceID:     id,
+		EffectiveFrom: effective,
+		BasePrice:     in.BasePrice,
+		Tiers:         make([]Tier, 0, len(in.Tiers)),
+	}
+	for _, t := range in.Tiers {
+		p.Tiers = append(p.Tiers, Tier{MinQuantity: t.MinQuantity, UnitPrice: t.UnitPrice})
+	}
+	if err := SortTiers(p.Tiers); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if actor := auth.UserIDFromContext(r.Context()); actor != "" {
+		p.CreatedBy = &actor
+	}
+
+	if err := h.repo.AddPrice(r.Context(), &p); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("services:%s", id))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// @Summary      Resolve preco do servico
+// @Tags         services
+// @Security     BearerAuth
+// @Param        date      query  string  false  "Data de referencia (AAAA-MM-DD, padrao hoje)"
+// @Param        quantity  query  number  false  "Quantidade (padrao 1)"
+// @Success      200  {object}  ResolvedPrice
+// @Router       /services/{id}/price [get]
+func (h handler) resolvePrice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	date := today(time.Now())
+	if v := r.URL.Query().Get("date"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			writeBadRequest(w, fmt.Errorf("invalid date %q", v))
+			return
+		}
+		date = t
+	}
+	quantity := 1.0
+	if v := r.URL.Query().Get("quantity"); v != "" {
+		q, err := strconv.ParseFloat(v, 64)
+		if err != nil || q <= 0 {
+			writeBadRequest(w, fmt.Errorf("invalid quantity %q", v))
+			return
+		}
+		quantity = q
+	}
+
+	price, err := h.repo.ResolvePrice(r.Context(), chi.URLParam(r, "id"), date, quantity)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, ErrNoPrice) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(price)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestResolvePrice(t *testing.T) {
+	prices := []Price{
+		{ID: "p1", ServiceID: "s1", EffectiveFrom: day("2025-01-01"), BasePrice: 100},
+		{ID: "p2", ServiceID: "s1", EffectiveFrom: day("2026-01-01"), BasePrice: 120,
+			Tiers: []Tier{{MinQuantity: 10, UnitPrice: 110}, {MinQuantity: 50, UnitPrice: 100}}},
+	}
+	cases := []struct {
+		date     string
+		qty      float64
+		price    string
+		unit     float64
+		total    float64
+		hasTier  bool
+		notFound bool
+	}{
+		{date: "2024-12-31", qty: 1, notFound: true},
+		{date: "2025-06-01", qty: 20, price: "p1", unit: 100, total: 2000},
+		{date: "2026-01-01", qty: 3, price: "p2", unit: 120, total: 360},
+		{date: "2026-03-01", qty: 10, price: "p2", unit: 110, total: 1100, hasTier: true},
+		{date: "2026-03-01", qty: 75, price: "p2", unit: 100, total: 7500, hasTier: true},
+	}
+	for _, c := range cases {
+		got, err := Resolve(prices, day(c.date), c.qty)
+		if c.notFound {
+			if err != ErrNoPrice {
+				t.Fatalf("%s: expected ErrNoPrice, got %v", c.date, err)
+			}
+			continue
+		}
+		if err != nil || got.PriceID != c.price || got.UnitPrice != c.unit || got.Total != c.total || (got.TierMinQuantity != nil) != c.hasTier {
+			t.Fatalf("%s x%v: unexpected %+v (%v)", c.date, c.qty, got, err)
+		}
+	}
+}
+
+func TestSortTiers(t *testing.T) {
+	tiers := []Tier{{MinQuantity: 50}, {MinQuantity: 10}}
+	if err := SortTiers(tiers); err != nil || tiers[0].MinQuantity != 10 {
+		t.Fatalf("unexpected %v %+v", err, tiers)
+	}
+	if err := SortTiers([]Tier{{MinQuantity: 5}, {MinQuantity: 5}}); err != ErrInvalidTiers {
+		t.Fatalf("expected ErrInvalidTiers, got %v", err)
+	}
+}
+
+func setupAuthRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo)
+	return r, token
+}
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestServicePriceEndpoints(t *testing.T) {
+	repo := &fakeRepository{
+		services: []Service{{ID: "s1", Name: "Consultoria", BillingType: BillingRecurring}},
+		prices:   []Price{{ID: "p1", ServiceID: "s1", EffectiveFrom: day("2025-01-01"), BasePrice: 100}},
+	}
+	r, token := setupAuthRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	future := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	body := `{"effective_from":"` + future + `","base_price":150,"tiers":[{"min_quantity":20,"unit_price":120},{"min_quantity":5,"unit_price":140}]}`
+	resp := do(t, http.MethodPost, server.URL+"/services/s1/prices", token, body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var p Price
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(p.Tiers) != 2 || p.Tiers[0].MinQuantity != 5 || p.CreatedBy == nil || *p.CreatedBy != "u1" {
+		t.Fatalf("unexpected price: %+v", p)
+	}
+
+	past := do(t, http.MethodPost, server.URL+"/services/s1/prices", token, `{"effective_from":"2020-01-01","base_price":1}`)
+	past.Body.Close()
+	if past.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for past price, got %d", past.StatusCode)
+	}
+
+	_, salesToken := setupAuthRouter(repo, "sales")
+	denied := do(t, http.MethodPost, server.URL+"/services/s1/prices", salesToken, body)
+	denied.Body.Close()
+	if denied.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403 for sales, got %d", denied.StatusCode)
+	}
+
+	res := do(t, http.MethodGet, server.URL+"/services/s1/price?date="+future+"&quantity=6", token, "")
+	defer res.Body.Close()
+	var rp ResolvedPrice
+	if err := json.NewDecoder(res.Body).Decode(&rp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rp.UnitPrice != 140 || rp.Total != 840 || rp.BillingType != BillingRecurring {
+		t.Fatalf("unexpected resolved price: %+v", rp)
+	}
+
+	old := do(t, http.MethodGet, server.URL+"/services/s1/price?date=2024-01-01", token, "")
+	old.Body.Close()
+	if old.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404 before first price, got %d", old.StatusCode)
+	}
+
+	hist := do(t, http.MethodGet, server.URL+"/services/s1/prices", token, "")
+	defer hist.Body.Close()
+	var prices []Price
+	if err := json.NewDecoder(hist.Body).Decode(&prices); err != nil || len(prices) != 2 {
+		t.Fatalf("unexpected history: %v %+v", err, prices)
+	}
+}
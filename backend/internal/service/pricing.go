@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Tipos de cobranca do servico.
+const (
+	BillingOneOff    = "one_off"
+	BillingRecurring = "recurring"
+)
+
+// FirstPriceDate eh a vigencia da primeira versao de preco de um servico,
+// para que contratos iniciados antes do cadastro tambem tenham preco.
+const FirstPriceDate = "1900-01-01"
+
+var (
+	ErrNoPrice      = errors.New("no price effective at the given date")
+	ErrPastPrice    = errors.New("effective_from must not be in the past")
+	ErrInvalidTiers = errors.New("tier min_quantity must be positive and unique")
+)
+
+// Price eh uma versao do preco do servico valida a partir de EffectiveFrom
+// ate a proxima versao. Faixas (Tiers) definem preco por volume.
+type Price struct {
+	ID            string    `db:"id" json:"id"`
+	ServiceID     string    `db:"service_id" json:"serviceID"`
+	EffectiveFrom time.Time `db:"effective_from" json:"effectiveFrom"`
+	BasePrice     float64   `db:"base_price" json:"basePrice"`
+	Tiers         []Tier    `db:"-" json:"tiers"`
+	CreatedBy     *string   `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+}
+
+// Tier aplica UnitPrice a todas as unidades quando a quantidade contratada
+// atinge MinQuantity.
+type Tier struct {
+	PriceID     string  `db:"price_id" json:"-"`
+	MinQuantity float64 `db:"min_quantity" json:"minQuantity"`
+	UnitPrice   float64 `db:"unit_price" json:"unitPrice"`
+}
+
+// ResolvedPrice eh o preco aplicavel a uma data e quantidade.
+type ResolvedPrice struct {
+	ServiceID       string    `json:"serviceID"`
+	BillingType     string    `json:"billingType"`
+	PriceID         string    `json:"priceID"`
+	EffectiveFrom   time.Time `json:"effectiveFrom"`
+	Date            time.Time `json:"date"`
+	Quantity        float64   `json:"quantity"`
+	UnitPrice       float64   `json:"unitPrice"`
+	Total           float64   `json:"total"`
+	TierMinQuantity *float64  `json:"tierMinQuantity,omitempty"`
+}
+
+// SortTiers ordena as faixas por quantidade minima e rejeita faixas
+// duplicadas ou com quantidade nao positiva.
+func SortTiers(tiers []Tier) error {
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinQuantity < tiers[j].MinQuantity })
+	for i, t := range tiers {
+		if t.MinQuantity <= 0 || (i > 0 && tiers[i-1].MinQuantity == t.MinQuantity) {
+			return ErrInvalidTiers
+		}
+	}
+	return nil
+}
+
+// Resolve escolhe a versao de preco vigente em date (a de maior
+// EffectiveFrom nao posterior a date) e, dentro dela, a maior faixa cuja
+// quantidade minima foi atingida. Sem faixa aplicavel vale o preco base.
+func Resolve(prices []Price, date time.Time, quantity float64) (ResolvedPrice, error) {
+	var cur *Price
+	for i := range prices {
+		p := &prices[i]
+		if p.EffectiveFrom.After(date) {
+			continue
+		}
+		if cur == nil || p.EffectiveFrom.After(cur.EffectiveFrom) {
+			cur = p
+		}
+	}
+	if cur == nil {
+		return ResolvedPrice{}, ErrNoPrice
+	}
+	out := ResolvedPrice{
+		ServiceID:     cur.ServiceID,
+		PriceID:       cur.ID,
+		EffectiveFrom: cur.EffectiveFrom,
+		Date:          date,
+		Quantity:      quantity,
+		UnitPrice:     cur.BasePrice,
+	}
+	for _, t := range cur.Tiers {
+		if quantity >= t.MinQuantity && (out.TierMinQuantity == nil || t.MinQuantity > *out.TierMinQuantity) {
+			min := t.MinQuantity
+			out.TierMinQuantity = &min
+			out.UnitPrice = t.UnitPrice
+		}
+	}
+	out.Total = math.Round(out.UnitPrice*quantity*100) / 100
+	return out, nil
+}
@@ -1,11 +1,25 @@
 package service
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDuplicateName eh retornado por Create quando ja existe servico com o nome.
+var ErrDuplicateName = errors.New("duplicate service name")
 
 // Repository define operacoes de acesso aos servicos.
 type Repository interface {
-	FindAll(ctx context.Context) ([]Service, error)
+	FindAll(ctx context.Context, f Filter) ([]Service, error)
 	Create(ctx context.Context, s *Service) error
 	Update(ctx context.Context, s *Service) error
 	SoftDelete(ctx context.Context, id string) error
+	Prices(ctx context.Context, id string) ([]Price, error)
+	AddPrice(ctx context.Context, p *Price) error
+	ResolvePrice(ctx context.Context, id string, date time.Time, quantity float64) (ResolvedPrice, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	CreateCategory(ctx context.Context, c *Category) error
+	UpdateCategory(ctx context.Context, c *Category) error
+	DeleteCategory(ctx context.Context, id string) error
 }
@@ -0,0 +1,259 @@
+package signature
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/contractdoc"
+)
+
+// RegisterRoutes adiciona as rotas autenticadas de solicitacao de assinatura.
+func RegisterRoutes(r chi.Router, repo Repository, docs contractdoc.Repository, provider Provider) {
+	h := handler{repo: repo, docs: docs, provider: provider, validate: validator.New(), now: time.Now}
+	r.Post("/contracts/{id}/signature-requests", h.create)
+	r.Get("/contracts/{id}/signature-requests", h.list)
+}
+
+// RegisterPublicRoutes adiciona as rotas de assinatura acessadas pelo link
+// enviado ao signatario; o token do link eh a unica credencial.
+func RegisterPublicRoutes(r chi.Router, repo Repository) {
+	h := handler{repo: repo, validate: validator.New(), now: time.Now}
+	r.Get("/sign/{token}", h.view)
+	r.Get("/sign/{token}/document.pdf", h.document)
+	r.Post("/sign/{token}", h.sign)
+	r.Post("/sign/{token}/decline", h.decline)
+}
+
+type handler struct {
+	repo     Repository
+	docs     contractdoc.Repository
+	provider Provider
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+// SignerInput define um signatario da solicitacao.
+type SignerInput struct {
+	Name   string `json:"name" validate:"required"`
+	Email  string `json:"email" validate:"required,email"`
+	Role   string `json:"role" validate:"required,oneof=customer internal"`
+	UserID string `json:"user_id" validate:"required_if=Role internal"`
+}
+
+// RequestInput define o payload de uma solicitacao de assinatura.
+type RequestInput struct {
+	Signers []SignerInput `json:"signers" validate:"required,min=1,dive"`
+}
+
+// SignInput confirma a assinatura.
+type SignInput struct {
+	Accept bool `json:"accept" validate:"required"`
+}
+
+// DeclineInput define o motivo da recusa.
+type DeclineInput struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// @Summary      Envia o contrato para assinatura
+// @Tags         signatures
+// @Security     BearerAuth
+// @Success      201  {object}  Request
+// @Router       /contracts/{id}/signature-requests [post]
+func (h handler) create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	contractID := chi.URLParam(r, "id")
+
+	var in RequestInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	doc, _, err := contractdoc.Generate(r.Context(), h.docs, contractID, h.now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	req := Request{
+		ID:           ulid.Make().String(),
+		ContractID:   contractID,
+		Provider:     h.provider.Name(),
+		Status:       StatusPending,
+		DocumentHash: HashDocument(doc),
+		Document:     doc,
+	}
+	if actor := auth.UserIDFromContext(r.Context()); actor != "" {
+		req.CreatedBy = &actor
+	}
+	for _, s := range in.Signers {
+		signer := Signer{
+			ID:     ulid.Make().String(),
+			Name:   strings.TrimSpace(s.Name),
+			Email:  strings.ToLower(strings.TrimSpace(s.Email)),
+			Role:   s.Role,
+			Status: StatusPending,
+		}
+		if s.UserID != "" {
+			userID := s.UserID
+			signer.UserID = &userID
+		}
+		req.Signers = append(req.Signers, signer)
+	}
+
+	env := Envelope{Request: &req, Signers: req.Signers, Document: doc}
+	if err := h.provider.Send(r.Context(), &env); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	if err := h.repo.CreateRequest(r.Context(), &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("signature_requests:%s", req.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(req)
+}
+
+// @Summary      Lista solicitacoes de assinatura do contrato
+// @Tags         signatures
+// @Security     BearerAuth
+// @Success      200  {array}  Request
+// @Router       /contracts/{id}/signature-requests [get]
+func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	requests, err := h.repo.ListRequests(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(requests)
+}
+
+// @Summary      Exibe a solicitacao de assinatura do link
+// @Tags         signatures
+// @Success      200  {object}  SigningView
+// @Router       /sign/{token} [get]
+func (h handler) view(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	v, err := h.repo.FindByToken(r.Context(), HashToken(chi.URLParam(r, "token")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// @Summary      Retorna o documento enviado para assinatura
+// @Tags         signatures
+// @Produce      application/pdf
+// @Success      200  {file}  file
+// @Router       /sign/{token}/document.pdf [get]
+func (h handler) document(w http.ResponseWriter, r *http.Request) {
+	doc, err := h.repo.Document(r.Context(), HashToken(chi.URLParam(r, "token")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="contrato.pdf"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(doc)))
+	_, _ = w.Write(doc)
+}
+
+// @Summary      Assina o documento
+// @Tags         signatures
+// @Success      200  {object}  SignResult
+// @Router       /sign/{token} [post]
+func (h handler) sign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var in SignInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	res, err := h.repo.Sign(r.Context(), HashToken(chi.URLParam(r, "token")), h.evidence(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// @Summary      Recusa a assinatura
+// @Tags         signatures
+// @Success      204  {null}  nil
+// @Router       /sign/{token}/decline [post]
+func (h handler) decline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var in DeclineInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	in.Reason = strings.TrimSpace(in.Reason)
+	if err := h.validate.Struct(in); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	if err := h.repo.Decline(r.Context(), HashToken(chi.URLParam(r, "token")), in.Reason, h.evidence(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// evidence captura IP, user agent e horario da requisicao. O IP segue a
+// mesma regra do middleware de auditoria.
+func (h handler) evidence(r *http.Request) Evidence {
+	ip := r.Header.Get("X-Forwarded-For")
+	if ip == "" {
+		ip = strings.Split(r.RemoteAddr, ":")[0]
+	}
+	return Evidence{IP: ip, UserAgent: r.UserAgent(), At: h.now()}
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, ErrUnknownUser):
+		writeBadRequest(w, err)
+	case errors.Is(err, ErrNotPendingSignature), errors.Is(err, ErrRequestClosed), errors.Is(err, ErrAlreadySigned):
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
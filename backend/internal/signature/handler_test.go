@@ -0,0 +1,256 @@
+package signature
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/contractdoc"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeDocs struct{}
+
+func (fakeDocs) TemplateFor(ctx context.Context, serviceID string) (contractdoc.Template, error) {
+	return contractdoc.Template{Body: contractdoc.DefaultTemplate}, nil
+}
+func (fakeDocs) SaveTemplate(ctx context.Context, t *contractdoc.Template) error { return nil }
+func (fakeDocs) DeleteTemplate(ctx context.Context, serviceID string) error      { return nil }
+func (fakeDocs) AddAttachment(ctx context.Context, a *contractdoc.Attachment) error {
+	return nil
+}
+func (fakeDocs) LoadData(ctx context.Context, contractID string) (contractdoc.Data, error) {
+	if contractID != "k1" {
+		return contractdoc.Data{}, sql.ErrNoRows
+	}
+	return contractdoc.SampleData(), nil
+}
+
+type fakeRepository struct {
+	contractStatus string
+	requests       []Request
+	// users lista os usuarios ativos aceitos como signatarios internos
+	users map[string]bool
+}
+
+func (f *fakeRepository) CreateRequest(ctx context.Context, req *Request) error {
+	if f.contractStatus != "pending_signature" {
+		return ErrNotPendingSignature
+	}
+	for _, s := range req.Signers {
+		if s.UserID != nil && !f.users[*s.UserID] {
+			return ErrUnknownUser
+		}
+	}
+	f.requests = append(f.requests, *req)
+	return nil
+}
+
+func (f *fakeRepository) ListRequests(ctx context.Context, contractID string) ([]Request, error) {
+	return f.requests, nil
+}
+
+func (f *fakeRepository) find(tokenHash string) (*Request, *Signer) {
+	for i := range f.requests {
+		for j := range f.requests[i].Signers {
+			if f.requests[i].Signers[j].TokenHash == tokenHash {
+				return &f.requests[i], &f.requests[i].Signers[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) FindByToken(ctx context.Context, tokenHash string) (SigningView, error) {
+	req, s := f.find(tokenHash)
+	if s == nil {
+		return SigningView{}, sql.ErrNoRows
+	}
+	return SigningView{SignerName: s.Name, SignerStatus: s.Status, RequestStatus: req.Status, ContractID: req.ContractID, DocumentHash: req.DocumentHash}, nil
+}
+
+func (f *fakeRepository) Document(ctx context.Context, tokenHash string) ([]byte, error) {
+	req, _ := f.find(tokenHash)
+	if req == nil {
+		return nil, sql.ErrNoRows
+	}
+	return req.Document, nil
+}
+
+func (f *fakeRepository) Sign(ctx context.Context, tokenHash string, ev Evidence) (SignResult, error) {
+	req, s := f.find(tokenHash)
+	if s == nil {
+		return SignResult{}, sql.ErrNoRows
+	}
+	if s.Status != StatusPending {
+		return SignResult{}, ErrAlreadySigned
+	}
+	s.Status, s.SignedAt, s.IP, s.UserAgent, s.DocumentHash = StatusSigned, &ev.At, &ev.IP, &ev.UserAgent, &req.DocumentHash
+	completed := true
+	for _, o := range req.Signers {
+		if o.Status == StatusPending {
+			completed = false
+		}
+	}
+	if completed {
+		req.Status = StatusCompleted
+		f.contractStatus = "active"
+	}
+	return SignResult{Signer: *s, Completed: completed}, nil
+}
+
+func (f *fakeRepository) Decline(ctx context.Context, tokenHash, reason string, ev Evidence) error {
+	req, s := f.find(tokenHash)
+	if s == nil {
+		return sql.ErrNoRows
+	}
+	s.Status, req.Status = StatusDeclined, StatusDeclined
+	return nil
+}
+
+func setupRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	RegisterPublicRoutes(r, repo)
+	r.Group(func(pr chi.Router) {
+		pr.Use(auth.AuthMiddleware)
+		pr.Use(permission.Routes.Middleware)
+		RegisterRoutes(pr, repo, fakeDocs{}, NewLocalProvider("https://app.example/"))
+	})
+	return r, token
+}
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "test-agent")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestSignatureFlow(t *testing.T) {
+	repo := &fakeRepository{contractStatus: "pending_signature", users: map[string]bool{"u9": true}}
+	r, token := setupRouter(repo, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	body := `{"signers":[{"name":"Cliente","email":"Cliente@Example.com","role":"customer"},
+        {"name":"Diretor","email":"diretor@example.com","role":"internal","user_id":"u9"}]}`
+	resp := do(t, http.MethodPost, server.URL+"/contracts/k1/signature-requests", token, body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var req Request
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	resp.Body.Close()
+	if len(req.Signers) != 2 || req.DocumentHash == "" || req.Provider != "local" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	var tokens []string
+	for _, s := range req.Signers {
+		if !strings.HasPrefix(s.SigningURL, "https://app.example/sign/") {
+			t.Fatalf("unexpected signing url %q", s.SigningURL)
+		}
+		tokens = append(tokens, strings.TrimPrefix(s.SigningURL, "https://app.example/sign/"))
+	}
+	if strings.Contains(repo.requests[0].Signers[0].TokenHash, tokens[0]) {
+		t.Fatal("token must be stored hashed")
+	}
+
+	resp = do(t, http.MethodGet, server.URL+"/sign/"+tokens[0], "", "")
+	var view SigningView
+	_ = json.NewDecoder(resp.Body).Decode(&view)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || view.SignerName != "Cliente" || view.DocumentHash != req.DocumentHash {
+		t.Fatalf("unexpected view %d %+v", resp.StatusCode, view)
+	}
+
+	resp = do(t, http.MethodPost, server.URL+"/sign/"+tokens[0], "", `{"accept":true}`)
+	var res SignResult
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+	if res.Completed || res.Signer.UserAgent == nil || *res.Signer.UserAgent != "test-agent" || res.Signer.IP == nil {
+		t.Fatalf("expected evidence and pending request, got %+v", res)
+	}
+
+	resp = do(t, http.MethodPost, server.URL+"/sign/"+tokens[0], "", `{"accept":true}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409 on second signature, got %d", resp.StatusCode)
+	}
+
+	resp = do(t, http.MethodPost, server.URL+"/sign/"+tokens[1], "", `{"accept":true}`)
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+	if !res.Completed || repo.contractStatus != "active" {
+		t.Fatalf("expected completed request and active contract, got %+v %s", res, repo.contractStatus)
+	}
+}
+
+func TestSignatureRequestRequiresPendingContract(t *testing.T) {
+	repo := &fakeRepository{contractStatus: "active"}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPost, server.URL+"/contracts/k1/signature-requests", token,
+		`{"signers":[{"name":"Cliente","email":"c@example.com","role":"customer"}]}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", resp.StatusCode)
+	}
+}
+
+func TestSignatureRequestValidatesInternalSigner(t *testing.T) {
+	repo := &fakeRepository{contractStatus: "pending_signature", users: map[string]bool{"u9": true}}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	for _, signer := range []string{
+		`{"name":"Diretor","email":"d@example.com","role":"internal"}`,
+		`{"name":"Diretor","email":"d@example.com","role":"internal","user_id":"missing"}`,
+	} {
+		resp := do(t, http.MethodPost, server.URL+"/contracts/k1/signature-requests", token, `{"signers":[`+signer+`]}`)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", signer, resp.StatusCode)
+		}
+	}
+	if len(repo.requests) != 0 {
+		t.Fatalf("no request should be stored: %+v", repo.requests)
+	}
+}
+
+func TestSignUnknownToken(t *testing.T) {
+	r, _ := setupRouter(&fakeRepository{}, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodGet, server.URL+"/sign/nope", "", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+}
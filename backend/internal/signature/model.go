@@ -0,0 +1,63 @@
+package signature
+
+import "time"
+
+// Request agrupa os signatarios de uma versao do documento do contrato.
+type Request struct {
+	ID           string     `db:"id" json:"id"`
+	ContractID   string     `db:"contract_id" json:"contractID"`
+	Provider     string     `db:"provider" json:"provider"`
+	ProviderRef  *string    `db:"provider_ref" json:"providerRef,omitempty"`
+	Status       string     `db:"status" json:"status"`
+	DocumentHash string     `db:"document_hash" json:"documentHash"`
+	Document     []byte     `db:"document" json:"-"`
+	CreatedBy    *string    `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+	CompletedAt  *time.Time `db:"completed_at" json:"completedAt,omitempty"`
+	Signers      []Signer   `db:"-" json:"signers"`
+}
+
+// Signer eh um signatario da solicitacao. Os campos de evidencia sao
+// preenchidos no momento da assinatura.
+type Signer struct {
+	ID            string     `db:"id" json:"id"`
+	RequestID     string     `db:"request_id" json:"requestID"`
+	Name          string     `db:"name" json:"name"`
+	Email         string     `db:"email" json:"email"`
+	Role          string     `db:"role" json:"role"`
+	UserID        *string    `db:"user_id" json:"userID,omitempty"`
+	TokenHash     string     `db:"token_hash" json:"-"`
+	Status        string     `db:"status" json:"status"`
+	SignedAt      *time.Time `db:"signed_at" json:"signedAt,omitempty"`
+	IP            *string    `db:"ip" json:"ip,omitempty"`
+	UserAgent     *string    `db:"user_agent" json:"userAgent,omitempty"`
+	DocumentHash  *string    `db:"document_hash" json:"documentHash,omitempty"`
+	DeclineReason *string    `db:"decline_reason" json:"declineReason,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	SigningURL    string     `db:"-" json:"signingURL,omitempty"`
+}
+
+// Evidence registra as circunstancias de uma assinatura ou recusa.
+type Evidence struct {
+	IP        string
+	UserAgent string
+	At        time.Time
+}
+
+// SigningView eh o que o signatario ve ao abrir o link de assinatura.
+type SigningView struct {
+	SignerName    string  `db:"signer_name" json:"signerName"`
+	SignerStatus  string  `db:"signer_status" json:"signerStatus"`
+	RequestStatus string  `db:"request_status" json:"requestStatus"`
+	ContractID    string  `db:"contract_id" json:"contractID"`
+	CustomerName  string  `db:"customer_name" json:"customerName"`
+	ServiceName   string  `db:"service_name" json:"serviceName"`
+	ValueTotal    float64 `db:"value_total" json:"valueTotal"`
+	DocumentHash  string  `db:"document_hash" json:"documentHash"`
+}
+
+// SignResult informa a assinatura registrada e se ela concluiu a solicitacao.
+type SignResult struct {
+	Signer    Signer `json:"signer"`
+	Completed bool   `json:"completed"`
+}
@@ -0,0 +1,242 @@
+package signature
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/contract"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// requestColumns omite o documento, lido apenas por Document.
+const requestColumns = `id, contract_id, provider, provider_ref, status, document_hash, created_by, created_at, completed_at`
+
+// CreateRequest grava a solicitacao e seus signatarios. Solicitacoes
+// pendentes anteriores do mesmo contrato sao canceladas. Signatarios com
+// user_id precisam referenciar usuarios ativos.
+func (r *PostgresRepository) CreateRequest(ctx context.Context, req *Request) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var status string
+	const qc = `SELECT status FROM contracts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	if err = tx.GetContext(ctx, &status, qc, req.ContractID); err != nil {
+		return err
+	}
+	if status != "pending_signature" {
+		return ErrNotPendingSignature
+	}
+	if err = checkUsers(ctx, tx, req.Signers); err != nil {
+		return err
+	}
+	const qx = `UPDATE signature_requests SET status=$2 WHERE contract_id=$1 AND status=$3`
+	if _, err = tx.ExecContext(ctx, qx, req.ContractID, StatusCancelled, StatusPending); err != nil {
+		return err
+	}
+
+	const qr = `INSERT INTO signature_requests (id, contract_id, provider, provider_ref, status, document_hash, document, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at`
+	if err = tx.GetContext(ctx, &req.CreatedAt, qr, req.ID, req.ContractID, req.Provider, req.ProviderRef,
+		req.Status, req.DocumentHash, req.Document, req.CreatedBy); err != nil {
+		return err
+	}
+	const qs = `INSERT INTO signature_signers (id, request_id, name, email, role, user_id, token_hash, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at`
+	for i := range req.Signers {
+		s := &req.Signers[i]
+		s.RequestID = req.ID
+		if err = tx.GetContext(ctx, &s.CreatedAt, qs, s.ID, s.RequestID, s.Name, s.Email, s.Role, s.UserID, s.TokenHash, s.Status); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// checkUsers garante que os usuarios vinculados aos signatarios existem e
+// nao foram removidos.
+func checkUsers(ctx context.Context, tx *sqlx.Tx, signers []Signer) error {
+	var ids []string
+	seen := map[string]bool{}
+	for _, s := range signers {
+		if s.UserID != nil && !seen[*s.UserID] {
+			seen[*s.UserID] = true
+			ids = append(ids, *s.UserID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var found int
+	const q = `SELECT count(*) FROM users WHERE id = ANY($1) AND deleted_at IS NULL`
+	if err := tx.GetContext(ctx, &found, q, pq.Array(ids)); err != nil {
+		return err
+	}
+	if found != len(ids) {
+		return ErrUnknownUser
+	}
+	return nil
+}
+
+// ListRequests retorna as solicitacoes do contrato com seus signatarios,
+// mais recentes primeiro.
+func (r *PostgresRepository) ListRequests(ctx context.Context, contractID string) ([]Request, error) {
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM contracts WHERE id=$1`, contractID); err != nil {
+		return nil, err
+	}
+	requests := []Request{}
+	q := `SELECT ` + requestColumns + ` FROM signature_requests WHERE contract_id=$1 ORDER BY created_at DESC, id DESC`
+	if err := r.db.SelectContext(ctx, &requests, q, contractID); err != nil {
+		return nil, err
+	}
+	for i := range requests {
+		requests[i].Signers = []Signer{}
+		const qs = `SELECT * FROM signature_signers WHERE request_id=$1 ORDER BY created_at, id`
+		if err := r.db.SelectContext(ctx, &requests[i].Signers, qs, requests[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+// FindByToken retorna o resumo exibido ao signatario.
+func (r *PostgresRepository) FindByToken(ctx context.Context, tokenHash string) (SigningView, error) {
+	var v SigningView
+	const q = `SELECT s.name AS signer_name, s.status AS signer_status, sr.status AS request_status,
+            c.id AS contract_id, cu.legal_name AS customer_name, COALESCE(sv.name,'') AS service_name,
+            c.value_total, sr.document_hash
+        FROM signature_signers s
+        JOIN signature_requests sr ON sr.id = s.request_id
+        JOIN contracts c ON c.id = sr.contract_id
+        JOIN customers cu ON cu.id = c.customer_id
+        LEFT JOIN services sv ON sv.id = c.service_id
+        WHERE s.token_hash=$1`
+	err := r.db.GetContext(ctx, &v, q, tokenHash)
+	return v, err
+}
+
+// Document retorna o PDF exatamente como enviado para assinatura.
+func (r *PostgresRepository) Document(ctx context.Context, tokenHash string) ([]byte, error) {
+	var doc []byte
+	const q = `SELECT sr.document FROM signature_requests sr
+        JOIN signature_signers s ON s.request_id = sr.id WHERE s.token_hash=$1`
+	err := r.db.GetContext(ctx, &doc, q, tokenHash)
+	return doc, err
+}
+
+// lockSigner trava o signatario, a solicitacao e o contrato e verifica se
+// ainda aceitam resposta.
+func lockSigner(ctx context.Context, tx *sqlx.Tx, tokenHash string) (Signer, Request, error) {
+	var s Signer
+	if err := tx.GetContext(ctx, &s, `SELECT * FROM signature_signers WHERE token_hash=$1 FOR UPDATE`, tokenHash); err != nil {
+		return Signer{}, Request{}, err
+	}
+	var req Request
+	q := `SELECT ` + requestColumns + ` FROM signature_requests WHERE id=$1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &req, q, s.RequestID); err != nil {
+		return Signer{}, Request{}, err
+	}
+	var contractStatus string
+	const qc = `SELECT status FROM contracts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.GetContext(ctx, &contractStatus, qc, req.ContractID); err != nil {
+		return Signer{}, Request{}, err
+	}
+	if req.Status != StatusPending || contractStatus != "pending_signature" {
+		return Signer{}, Request{}, ErrRequestClosed
+	}
+	if s.Status != StatusPending {
+		return Signer{}, Request{}, ErrAlreadySigned
+	}
+	return s, req, nil
+}
+
+// Sign registra a assinatura com a evidencia informada. Quando todos os
+// signatarios assinam, a solicitacao eh concluida e o contrato ativado, o
+// que gera suas contas a receber e a comissao.
+func (r *PostgresRepository) Sign(ctx context.Context, tokenHash string, ev Evidence) (SignResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return SignResult{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	s, req, err := lockSigner(ctx, tx, tokenHash)
+	if err != nil {
+		return SignResult{}, err
+	}
+	s.Status = StatusSigned
+	s.SignedAt, s.IP, s.UserAgent, s.DocumentHash = &ev.At, &ev.IP, &ev.UserAgent, &req.DocumentHash
+	const qs = `UPDATE signature_signers SET status=$2, signed_at=$3, ip=$4, user_agent=$5, document_hash=$6 WHERE id=$1`
+	if _, err = tx.ExecContext(ctx, qs, s.ID, s.Status, s.SignedAt, s.IP, s.UserAgent, s.DocumentHash); err != nil {
+		return SignResult{}, err
+	}
+
+	var pending int
+	const qp = `SELECT count(*) FROM signature_signers WHERE request_id=$1 AND status=$2`
+	if err = tx.GetContext(ctx, &pending, qp, req.ID, StatusPending); err != nil {
+		return SignResult{}, err
+	}
+	res := SignResult{Signer: s, Completed: pending == 0}
+	if res.Completed {
+		const qr = `UPDATE signature_requests SET status=$2, completed_at=$3 WHERE id=$1`
+		if _, err = tx.ExecContext(ctx, qr, req.ID, StatusCompleted, ev.At); err != nil {
+			return SignResult{}, err
+		}
+		const qc = `UPDATE contracts SET status='active', updated_at=now() WHERE id=$1`
+		if _, err = tx.ExecContext(ctx, qc, req.ContractID); err != nil {
+			return SignResult{}, err
+		}
+		const qh = `INSERT INTO contract_status_history (id, contract_id, from_status, to_status, reason)
+            VALUES ($1, $2, 'pending_signature', 'active', $3)`
+		if _, err = tx.ExecContext(ctx, qh, ulid.Make().String(), req.ContractID, "assinatura concluida ("+req.ID+")"); err != nil {
+			return SignResult{}, err
+		}
+		if err = contract.GenerateBilling(ctx, tx, req.ContractID); err != nil {
+			return SignResult{}, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return SignResult{}, err
+	}
+	return res, nil
+}
+
+// Decline registra a recusa do signatario e encerra a solicitacao. O
+// contrato continua aguardando assinatura ate uma nova solicitacao.
+func (r *PostgresRepository) Decline(ctx context.Context, tokenHash, reason string, ev Evidence) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	s, req, err := lockSigner(ctx, tx, tokenHash)
+	if err != nil {
+		return err
+	}
+	const qs = `UPDATE signature_signers SET status=$2, signed_at=$3, ip=$4, user_agent=$5, decline_reason=$6 WHERE id=$1`
+	if _, err = tx.ExecContext(ctx, qs, s.ID, StatusDeclined, ev.At, ev.IP, ev.UserAgent, reason); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE signature_requests SET status=$2 WHERE id=$1`, req.ID, StatusDeclined); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var _ Repository = (*PostgresRepository)(nil)
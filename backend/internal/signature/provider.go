@@ -0,0 +1,55 @@
+package signature
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// Envelope reune o documento e os signatarios enviados ao provedor.
+type Envelope struct {
+	Request  *Request
+	Signers  []Signer
+	Document []byte
+}
+
+// Provider envia o envelope para assinatura. Implementacoes devem preencher
+// TokenHash e SigningURL de cada signatario e, quando houver, ProviderRef.
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, env *Envelope) error
+}
+
+// LocalProvider assina pelo proprio backoffice, gerando links com token
+// para a rota publica /sign/{token}.
+type LocalProvider struct {
+	baseURL string
+}
+
+// NewLocalProvider cria um LocalProvider cujos links apontam para baseURL.
+func NewLocalProvider(baseURL string) *LocalProvider {
+	return &LocalProvider{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// LocalProviderFromEnv le SIGNATURE_BASE_URL, usando http://localhost:3000 como padrao.
+func LocalProviderFromEnv() *LocalProvider {
+	base := os.Getenv("SIGNATURE_BASE_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return NewLocalProvider(base)
+}
+
+func (p *LocalProvider) Name() string { return "local" }
+
+func (p *LocalProvider) Send(_ context.Context, env *Envelope) error {
+	for i := range env.Signers {
+		token, err := NewToken()
+		if err != nil {
+			return err
+		}
+		env.Signers[i].TokenHash = HashToken(token)
+		env.Signers[i].SigningURL = p.baseURL + "/sign/" + token
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package signature
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// Repository define operacoes sobre solicitacoes de assinatura.
+type Repository interface {
+	CreateRequest(ctx context.Context, req *Request) error
+	ListRequests(ctx context.Context, contractID string) ([]Request, error)
+	FindByToken(ctx context.Context, tokenHash string) (SigningView, error)
+	Document(ctx context.Context, tokenHash string) ([]byte, error)
+	Sign(ctx context.Context, tokenHash string, ev Evidence) (SignResult, error)
+	Decline(ctx context.Context, tokenHash, reason string, ev Evidence) error
+}
+
+// Status de solicitacao e de signatario
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusDeclined  = "declined"
+	StatusCancelled = "cancelled"
+	StatusSigned    = "signed"
+)
+
+// Papeis de signatario
+const (
+	RoleCustomer = "customer"
+	RoleInternal = "internal"
+)
+
+// Errors especificos
+
+var (
+	ErrNotPendingSignature = errors.New("contract is not pending signature")
+	ErrRequestClosed       = errors.New("signature request is no longer pending")
+	ErrAlreadySigned       = errors.New("signer has already answered")
+	ErrUnknownUser         = errors.New("signer user_id does not match an active user")
+)
+
+// NewToken gera um token aleatorio para o link de assinatura.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken retorna o hash armazenado do token; o token em si nunca eh gravado.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashDocument retorna o SHA-256 do documento assinado.
+func HashDocument(doc []byte) string {
+	sum := sha256.Sum256(doc)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,244 @@
+package tag
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+// RegisterRoutes adiciona as rotas do modulo Tag.
+func RegisterRoutes(r chi.Router, repo Repository) {
+	h := handler{repo: repo, validate: validator.New()}
+	r.Get("/tags", h.list)
+	r.Post("/tags", h.create)
+	r.Put("/tags/{id}", h.update)
+	r.Delete("/tags/{id}", h.remove)
+	r.Get("/tags/entities/{entity}/{entityID}", h.listFor)
+	r.Post("/tags/entities/{entity}/{entityID}", h.attach)
+	r.Delete("/tags/entities/{entity}/{entityID}/{tagID}", h.detach)
+}
+
+type handler struct {
+	repo     Repository
+	validate *validator.Validate
+}
+
+// TagInput define o payload para criacao e renomeacao de tags.
+type TagInput struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// AttachInput define o payload para associar uma tag a uma entidade.
+type AttachInput struct {
+	TagID string `json:"tag_id" validate:"required"`
+}
+
+// @Summary      Lista tags
+// @Tags         tags
+// @Security     BearerAuth
+// @Success      200  {array}  Tag
+// @Router       /tags [get]
+func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	tags, err := h.repo.FindAll(r.Context())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(tags)
+}
+
+// @Summary      Cria tag
+// @Tags         tags
+// @Security     BearerAuth
+// @Success      201  {object}  Tag
+// @Router       /tags [post]
+func (h handler) create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	in, ok := h.decodeInput(w, r)
+	if !ok {
+		return
+	}
+
+	t := Tag{
+		ID:        ulid.Make().String(),
+		Name:      in.Name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := h.repo.Create(r.Context(), &t); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/tags/"+t.ID)
+	w.Header().Set("X-Entity", fmt.Sprintf("tags:%s", t.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// @Summary      Renomeia tag
+// @Tags         tags
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /tags/{id} [put]
+func (h handler) update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+	in, ok := h.decodeInput(w, r)
+	if !ok {
+		return
+	}
+
+	t := Tag{ID: id, Name: in.Name, UpdatedAt: time.Now()}
+	if err := h.repo.Update(r.Context(), &t); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Entity", fmt.Sprintf("tags:%s", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Remove tag
+// @Tags         tags
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /tags/{id} [delete]
+func (h handler) remove(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.repo.SoftDelete(r.Context(), id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("tags:%s", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Lista tags de uma entidade
+// @Tags         tags
+// @Security     BearerAuth
+// @Param        entity  path  string  true  "customers, contracts, leads, promoters ou services"
+// @Success      200  {array}  Tag
+// @Router       /tags/entities/{entity}/{entityID} [get]
+func (h handler) listFor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	entity := chi.URLParam(r, "entity")
+	if !canView(r, entity) {
+		writeError(w, ErrForbidden)
+		return
+	}
+	tags, err := h.repo.ListFor(r.Context(), entity, chi.URLParam(r, "entityID"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(tags)
+}
+
+// @Summary      Associa tag a uma entidade
+// @Tags         tags
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /tags/entities/{entity}/{entityID} [post]
+func (h handler) attach(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	entity := chi.URLParam(r, "entity")
+	entityID := chi.URLParam(r, "entityID")
+	if !canView(r, entity) {
+		writeError(w, ErrForbidden)
+		return
+	}
+
+	var in AttachInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.Attach(r.Context(), entity, entityID, in.TagID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("%s:%s", entity, entityID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Desassocia tag de uma entidade
+// @Tags         tags
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /tags/entities/{entity}/{entityID}/{tagID} [delete]
+func (h handler) detach(w http.ResponseWriter, r *http.Request) {
+	entity := chi.URLParam(r, "entity")
+	entityID := chi.URLParam(r, "entityID")
+	if !canView(r, entity) {
+		writeError(w, ErrForbidden)
+		return
+	}
+	if err := h.repo.Detach(r.Context(), entity, entityID, chi.URLParam(r, "tagID")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("%s:%s", entity, entityID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeInput le e valida o payload de tag; o nome eh armazenado sem espacos nas pontas.
+func (h handler) decodeInput(w http.ResponseWriter, r *http.Request) (TagInput, bool) {
+	var in TagInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return TagInput{}, false
+	}
+	in.Name = strings.TrimSpace(in.Name)
+	if err := h.validate.Struct(in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return TagInput{}, false
+	}
+	return in, true
+}
+
+// canView informa se o usuario pode ver a entidade, pela politica de
+// listagem da entidade. Entidades desconhecidas seguem para o repositorio,
+// que responde ErrUnknownEntity.
+func canView(r *http.Request, entity string) bool {
+	return !entities[entity] || permission.Routes.CanView(entity, auth.RoleFromContext(r.Context()))
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownEntity), errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, ErrForbidden):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrDuplicateName):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
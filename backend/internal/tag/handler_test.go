@@ -0,0 +1,282 @@
+package tag
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepository struct {
+	tags []Tag
+	// links mapeia "entidade:id" para os IDs das tags associadas
+	links map[string][]string
+	// existing lista "entidade:id" que existem
+	existing map[string]bool
+}
+
+func (f *fakeRepository) FindAll(ctx context.Context) ([]Tag, error) {
+	out := make([]Tag, 0, len(f.tags))
+	for _, t := range f.tags {
+		if t.DeletedAt == nil {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) Create(ctx context.Context, t *Tag) error {
+	for _, ex := range f.tags {
+		if ex.Name == t.Name {
+			return ErrDuplicateName
+		}
+	}
+	f.tags = append(f.tags, *t)
+	return nil
+}
+
+func (f *fakeRepository) Update(ctx context.Context, t *Tag) error {
+	for i, ex := range f.tags {
+		if ex.ID == t.ID && ex.DeletedAt == nil {
+			f.tags[i].Name = t.Name
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) SoftDelete(ctx context.Context, id string) error {
+	for i, ex := range f.tags {
+		if ex.ID == id && ex.DeletedAt == nil {
+			now := time.Now()
+			f.tags[i].DeletedAt = &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeRepository) ensure(entity, entityID string) error {
+	if !entities[entity] {
+		return ErrUnknownEntity
+	}
+	if !f.existing[entity+":"+entityID] {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (f *fakeRepository) ListFor(ctx context.Context, entity, entityID string) ([]Tag, error) {
+	if err := f.ensure(entity, entityID); err != nil {
+		return nil, err
+	}
+	out := []Tag{}
+	for _, id := range f.links[entity+":"+entityID] {
+		for _, t := range f.tags {
+			if t.ID == id {
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepository) Attach(ctx context.Context, entity, entityID, tagID string) error {
+	if err := f.ensure(entity, entityID); err != nil {
+		return err
+	}
+	key := entity + ":" + entityID
+	for _, id := range f.links[key] {
+		if id == tagID {
+			return nil
+		}
+	}
+	f.links[key] = append(f.links[key], tagID)
+	return nil
+}
+
+func (f *fakeRepository) Detach(ctx context.Context, entity, entityID, tagID string) error {
+	key := entity + ":" + entityID
+	for i, id := range f.links[key] {
+		if id == tagID {
+			f.links[key] = append(f.links[key][:i], f.links[key][i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func setupRouter(repo *fakeRepository) *chi.Mux {
+	return setupRouterAs(repo, permission.Admin)
+}
+
+// setupRouterAs autentica todas as requisicoes com a role informada.
+func setupRouterAs(repo *fakeRepository, role string) *chi.Mux {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Use(auth.AuthMiddleware)
+	RegisterRoutes(r, repo)
+	return r
+}
+
+func TestCreateTagDuplicate(t *testing.T) {
+	repo := &fakeRepository{}
+	server := httptest.NewServer(setupRouter(repo))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/tags", "application/json", strings.NewReader(`{"name":"  vip "}`))
+	if err != nil {
+		t.Fatalf("POST /tags error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	var created Tag
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.Name != "vip" {
+		t.Fatalf("name not trimmed: %q", created.Name)
+	}
+
+	resp2, err := http.Post(server.URL+"/tags", "application/json", strings.NewReader(`{"name":"vip"}`))
+	if err != nil {
+		t.Fatalf("POST /tags error: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", resp2.StatusCode)
+	}
+}
+
+func TestAttachAndDetachTag(t *testing.T) {
+	repo := &fakeRepository{
+		tags:     []Tag{{ID: "t1", Name: "vip"}},
+		links:    map[string][]string{},
+		existing: map[string]bool{"customers:c1": true},
+	}
+	server := httptest.NewServer(setupRouter(repo))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Post(server.URL+"/tags/entities/customers/c1", "application/json", strings.NewReader(`{"tag_id":"t1"}`))
+		if err != nil {
+			t.Fatalf("POST attach error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("expected status 204, got %d", resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/tags/entities/customers/c1")
+	if err != nil {
+		t.Fatalf("GET entity tags error: %v", err)
+	}
+	defer resp.Body.Close()
+	var list []Tag
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "vip" {
+		t.Fatalf("unexpected tags: %+v", list)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/tags/entities/customers/c1/t1", nil)
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE detach error: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp2.StatusCode)
+	}
+}
+
+func TestAttachTagValidatesEntity(t *testing.T) {
+	repo := &fakeRepository{links: map[string][]string{}, existing: map[string]bool{}}
+	server := httptest.NewServer(setupRouter(repo))
+	defer server.Close()
+
+	for _, path := range []string{"/tags/entities/customers/missing", "/tags/entities/users/u1"} {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(`{"tag_id":"t1"}`))
+		if err != nil {
+			t.Fatalf("POST %s error: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("%s: expected status 404, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestEntityTagsFollowEntityVisibility(t *testing.T) {
+	repo := &fakeRepository{
+		tags:     []Tag{{ID: "t1", Name: "vip"}},
+		links:    map[string][]string{"customers:c1": {"t1"}},
+		existing: map[string]bool{"customers:c1": true, "leads:l1": true},
+	}
+	server := httptest.NewServer(setupRouterAs(repo, permission.Promoter))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/tags/entities/customers/c1")
+	if err != nil {
+		t.Fatalf("GET entity tags error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/tags/entities/customers/c1/t1", nil)
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE detach error: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusForbidden || len(repo.links["customers:c1"]) != 1 {
+		t.Fatalf("expected status 403 keeping the tag, got %d / %v", resp2.StatusCode, repo.links)
+	}
+
+	resp3, err := http.Post(server.URL+"/tags/entities/leads/l1", "application/json", strings.NewReader(`{"tag_id":"t1"}`))
+	if err != nil {
+		t.Fatalf("POST attach error: %v", err)
+	}
+	resp3.Body.Close()
+	if resp3.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp3.StatusCode)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	q := url.Values{"tags": {"vip, inadimplente,,vip"}}
+	if got := ParseFilter(q); !reflect.DeepEqual(got, []string{"vip", "inadimplente"}) {
+		t.Fatalf("unexpected filter: %v", got)
+	}
+	if got := ParseFilter(url.Values{}); got != nil {
+		t.Fatalf("expected nil filter, got %v", got)
+	}
+}
@@ -0,0 +1,12 @@
+package tag
+
+import "time"
+
+// Tag representa um rotulo que pode ser associado a qualquer entidade suportada.
+type Tag struct {
+	ID        string     `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+}
@@ -0,0 +1,146 @@
+package tag
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// FindAll retorna todas as tags ativas ordenadas por nome.
+func (r *PostgresRepository) FindAll(ctx context.Context) ([]Tag, error) {
+	tags := []Tag{}
+	const q = `SELECT * FROM tags WHERE deleted_at IS NULL ORDER BY name`
+	if err := r.db.SelectContext(ctx, &tags, q); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Create insere uma nova tag.
+func (r *PostgresRepository) Create(ctx context.Context, t *Tag) error {
+	const q = `INSERT INTO tags (id, name) VALUES (:id, :name)`
+	_, err := r.db.NamedExecContext(ctx, q, t)
+	return translateUnique(err)
+}
+
+// Update renomeia uma tag existente.
+func (r *PostgresRepository) Update(ctx context.Context, t *Tag) error {
+	const q = `UPDATE tags SET name=:name, updated_at=now() WHERE id=:id AND deleted_at IS NULL`
+	res, err := r.db.NamedExecContext(ctx, q, t)
+	if err != nil {
+		return translateUnique(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SoftDelete marca uma tag como removida. As associacoes sao mantidas para
+// que a tag possa ser restaurada pela lixeira.
+func (r *PostgresRepository) SoftDelete(ctx context.Context, id string) error {
+	const q = `UPDATE tags SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListFor retorna as tags ativas associadas a uma entidade existente.
+func (r *PostgresRepository) ListFor(ctx context.Context, entity, entityID string) ([]Tag, error) {
+	if err := r.ensureEntity(ctx, entity, entityID); err != nil {
+		return nil, err
+	}
+	tags := []Tag{}
+	const q = `SELECT t.* FROM tags t JOIN entity_tags et ON et.tag_id = t.id
+        WHERE et.entity_name=$1 AND et.entity_id=$2 AND et.deleted_at IS NULL AND t.deleted_at IS NULL
+        ORDER BY t.name`
+	if err := r.db.SelectContext(ctx, &tags, q, entity, entityID); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Attach associa a tag a entidade. Associar uma tag ja presente nao tem efeito.
+func (r *PostgresRepository) Attach(ctx context.Context, entity, entityID, tagID string) error {
+	if err := r.ensureEntity(ctx, entity, entityID); err != nil {
+		return err
+	}
+	var exists int
+	if err := r.db.GetContext(ctx, &exists, `SELECT 1 FROM tags WHERE id=$1 AND deleted_at IS NULL`, tagID); err != nil {
+		return err
+	}
+	const q = `INSERT INTO entity_tags (id, entity_name, entity_id, tag_id) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (entity_name, entity_id, tag_id) WHERE deleted_at IS NULL DO NOTHING`
+	_, err := r.db.ExecContext(ctx, q, ulid.Make().String(), entity, entityID, tagID)
+	return err
+}
+
+// Detach remove logicamente a associacao entre a tag e a entidade.
+func (r *PostgresRepository) Detach(ctx context.Context, entity, entityID, tagID string) error {
+	if !entities[entity] {
+		return ErrUnknownEntity
+	}
+	const q = `UPDATE entity_tags SET deleted_at=now()
+        WHERE entity_name=$1 AND entity_id=$2 AND tag_id=$3 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, entity, entityID, tagID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ensureEntity valida o nome da entidade e a existencia do registro.
+func (r *PostgresRepository) ensureEntity(ctx context.Context, entity, entityID string) error {
+	if !entities[entity] {
+		return ErrUnknownEntity
+	}
+	var exists int
+	q := fmt.Sprintf(`SELECT 1 FROM %s WHERE id=$1 AND deleted_at IS NULL`, entity)
+	return r.db.GetContext(ctx, &exists, q, entityID)
+}
+
+// translateUnique converte violacoes de unicidade em ErrDuplicateName.
+func translateUnique(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateName
+	}
+	return err
+}
+
+var _ Repository = (*PostgresRepository)(nil)
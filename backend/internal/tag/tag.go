@@ -0,0 +1,78 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Repository define operacoes sobre tags e suas associacoes.
+type Repository interface {
+	FindAll(ctx context.Context) ([]Tag, error)
+	Create(ctx context.Context, t *Tag) error
+	Update(ctx context.Context, t *Tag) error
+	SoftDelete(ctx context.Context, id string) error
+	ListFor(ctx context.Context, entity, entityID string) ([]Tag, error)
+	Attach(ctx context.Context, entity, entityID, tagID string) error
+	Detach(ctx context.Context, entity, entityID, tagID string) error
+}
+
+// Errors especificos
+
+var (
+	ErrUnknownEntity = errors.New("unknown entity")
+	ErrDuplicateName = errors.New("tag name already exists")
+	ErrForbidden     = errors.New("entity not visible for this role")
+)
+
+// entities lista as tabelas que aceitam tags; o nome da entidade eh o da tabela.
+var entities = map[string]bool{
+	"customers": true,
+	"contracts": true,
+	"leads":     true,
+	"promoters": true,
+	"services":  true,
+}
+
+// Entities retorna os nomes das entidades que aceitam tags.
+func Entities() []string {
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFilter le o parametro tags (lista separada por virgula) de uma
+// listagem, sem repeticoes.
+func ParseFilter(q url.Values) []string {
+	raw := q.Get("tags")
+	if raw == "" {
+		return nil
+	}
+	var names []string
+	seen := map[string]bool{}
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" && !seen[s] {
+			seen[s] = true
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// FilterClause retorna uma condicao SQL que exige todas as tags do filtro na
+// entidade. idColumn eh a coluna de ID da consulta e arg o placeholder que
+// recebe pq.Array(tags). Tags repetidas no filtro contam uma vez.
+func FilterClause(entity, idColumn, arg string) string {
+	return fmt.Sprintf(`%[1]s IN (
+        SELECT et.entity_id FROM entity_tags et JOIN tags t ON t.id = et.tag_id
+         WHERE et.entity_name='%[2]s' AND et.deleted_at IS NULL AND t.deleted_at IS NULL
+           AND t.name = ANY(%[3]s)
+         GROUP BY et.entity_id
+        HAVING count(DISTINCT t.name) = (SELECT count(DISTINCT f) FROM unnest(%[3]s::text[]) f))`, idColumn, entity, arg)
+}
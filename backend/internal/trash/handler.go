@@ -0,0 +1,106 @@
+package trash
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+)
+
+// RegisterRoutes adiciona as rotas da lixeira (restrita a admin).
+func RegisterRoutes(r chi.Router, repo Repository, retention time.Duration) {
+	h := handler{repo: repo, retention: retention}
+	r.Route("/trash", func(rt chi.Router) {
+		rt.Get("/", h.entities)
+		rt.Get("/{entity}", h.list)
+		rt.Put("/{entity}/{id}/restore", h.restore)
+		rt.Delete("/{entity}/{id}", h.purge)
+	})
+}
+
+type handler struct {
+	repo      Repository
+	retention time.Duration
+}
+
+// @Summary      Lista entidades suportadas pela lixeira
+// @Tags         trash
+// @Security     BearerAuth
+// @Success      200  {array}  string
+// @Router       /trash [get]
+func (h handler) entities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Entities())
+}
+
+// @Summary      Lista registros removidos
+// @Tags         trash
+// @Security     BearerAuth
+// @Param        limit  query  int  false  "Quantidade maxima"
+// @Success      200  {array}  Item
+// @Router       /trash/{entity} [get]
+func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	items, err := h.repo.List(r.Context(), chi.URLParam(r, "entity"), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for i := range items {
+		items[i].PurgeableAt = items[i].DeletedAt.Add(h.retention)
+	}
+	_ = json.NewEncoder(w).Encode(items)
+}
+
+// @Summary      Restaura registro removido
+// @Tags         trash
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /trash/{entity}/{id}/restore [put]
+func (h handler) restore(w http.ResponseWriter, r *http.Request) {
+	entity := chi.URLParam(r, "entity")
+	id := chi.URLParam(r, "id")
+	if err := h.repo.Restore(r.Context(), entity, id, auth.UserIDFromContext(r.Context())); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("%s:%s", entity, id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Exclui definitivamente registro removido
+// @Tags         trash
+// @Security     BearerAuth
+// @Success      204  {null}  nil
+// @Router       /trash/{entity}/{id} [delete]
+func (h handler) purge(w http.ResponseWriter, r *http.Request) {
+	entity := chi.URLParam(r, "entity")
+	id := chi.URLParam(r, "id")
+	if err := h.repo.Purge(r.Context(), entity, id, auth.UserIDFromContext(r.Context()), h.retention); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Entity", fmt.Sprintf("%s:%s", entity, id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownEntity), errors.Is(err, sql.ErrNoRows):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, ErrUniqueConflict), errors.Is(err, ErrMerged),
+		errors.Is(err, ErrRetention), errors.Is(err, ErrHasDependents):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
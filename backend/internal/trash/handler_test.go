@@ -0,0 +1,179 @@
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rgomids/bckoffice/internal/auth"
+	"github.com/rgomids/bckoffice/internal/permission"
+)
+
+type fakeRepository struct {
+	items    map[string][]Item
+	restored []string
+	purged   []string
+}
+
+func (f *fakeRepository) List(ctx context.Context, entity string, limit int) ([]Item, error) {
+	if _, ok := entities[entity]; !ok {
+		return nil, ErrUnknownEntity
+	}
+	out := make([]Item, 0)
+	out = append(out, f.items[entity]...)
+	return out, nil
+}
+
+func (f *fakeRepository) find(entity, id string) (Item, error) {
+	if _, ok := entities[entity]; !ok {
+		return Item{}, ErrUnknownEntity
+	}
+	for _, it := range f.items[entity] {
+		if it.ID == id {
+			return it, nil
+		}
+	}
+	return Item{}, sql.ErrNoRows
+}
+
+func (f *fakeRepository) Restore(ctx context.Context, entity, id, actorID string) error {
+	if _, err := f.find(entity, id); err != nil {
+		return err
+	}
+	if id == "dup" {
+		return ErrUniqueConflict
+	}
+	f.restored = append(f.restored, id)
+	return nil
+}
+
+func (f *fakeRepository) Purge(ctx context.Context, entity, id, actorID string, retention time.Duration) error {
+	it, err := f.find(entity, id)
+	if err != nil {
+		return err
+	}
+	if time.Since(it.DeletedAt) < retention {
+		return ErrRetention
+	}
+	f.purged = append(f.purged, id)
+	return nil
+}
+
+func setupRouter(repo Repository, role string) (*chi.Mux, string) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	claims := jwt.MapClaims{"sub": "u1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("testsecret"))
+
+	r := chi.NewRouter()
+	r.Use(auth.AuthMiddleware)
+	r.Use(permission.Routes.Middleware)
+	RegisterRoutes(r, repo, DefaultRetention)
+	return r, token
+}
+
+func do(t *testing.T, method, url, token string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestListTrash(t *testing.T) {
+	deleted := time.Now().Add(-time.Hour)
+	repo := &fakeRepository{items: map[string][]Item{
+		"customers": {{ID: "c1", Label: "ACME", DeletedAt: deleted}},
+	}}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodGet, server.URL+"/trash/customers", token)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var out []Item
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out) != 1 || !out[0].PurgeableAt.Equal(out[0].DeletedAt.Add(DefaultRetention)) {
+		t.Fatalf("unexpected result: %+v", out)
+	}
+
+	resp2 := do(t, http.MethodGet, server.URL+"/trash/unknown", token)
+	defer resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp2.StatusCode)
+	}
+}
+
+func TestRestoreTrash(t *testing.T) {
+	repo := &fakeRepository{items: map[string][]Item{
+		"customers": {{ID: "c1", DeletedAt: time.Now()}, {ID: "dup", DeletedAt: time.Now()}},
+	}}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodPut, server.URL+"/trash/customers/c1/restore", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if len(repo.restored) != 1 {
+		t.Fatalf("record not restored")
+	}
+
+	resp2 := do(t, http.MethodPut, server.URL+"/trash/customers/dup/restore", token)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", resp2.StatusCode)
+	}
+}
+
+func TestPurgeTrashRetention(t *testing.T) {
+	repo := &fakeRepository{items: map[string][]Item{
+		"customers": {
+			{ID: "recent", DeletedAt: time.Now()},
+			{ID: "old", DeletedAt: time.Now().Add(-DefaultRetention - time.Hour)},
+		},
+	}}
+	r, token := setupRouter(repo, "admin")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodDelete, server.URL+"/trash/customers/recent", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", resp.StatusCode)
+	}
+
+	resp2 := do(t, http.MethodDelete, server.URL+"/trash/customers/old", token)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp2.StatusCode)
+	}
+}
+
+func TestTrashRequiresAdmin(t *testing.T) {
+	r, token := setupRouter(&fakeRepository{}, "finance")
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp := do(t, http.MethodGet, server.URL+"/trash/customers", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+}
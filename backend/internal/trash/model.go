@@ -0,0 +1,11 @@
+package trash
+
+import "time"
+
+// Item representa um registro removido logicamente.
+type Item struct {
+	ID          string    `db:"id" json:"id"`
+	Label       string    `db:"label" json:"label"`
+	DeletedAt   time.Time `db:"deleted_at" json:"deletedAt"`
+	PurgeableAt time.Time `db:"-" json:"purgeableAt"`
+}
@@ -0,0 +1,168 @@
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/oklog/ulid/v2"
+)
+
+// PostgresRepository implementa Repository usando PostgreSQL.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository cria uma instancia de PostgresRepository.
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// List retorna os registros removidos de uma entidade, mais recentes primeiro.
+func (r *PostgresRepository) List(ctx context.Context, name string, limit int) ([]Item, error) {
+	e, ok := entities[name]
+	if !ok {
+		return nil, ErrUnknownEntity
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	items := []Item{}
+	q := fmt.Sprintf(`SELECT id, COALESCE(%s, '') AS label, deleted_at FROM %s
+        WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT $1`, e.label, e.table)
+	if err := r.db.SelectContext(ctx, &items, q, limit); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Restore desfaz a remocao logica apos verificar as restricoes de unicidade.
+func (r *PostgresRepository) Restore(ctx context.Context, name, id, actorID string) error {
+	e, ok := entities[name]
+	if !ok {
+		return ErrUnknownEntity
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	guard := "false"
+	if e.restoreGuard != "" {
+		guard = e.restoreGuard
+	}
+	var blocked bool
+	q := fmt.Sprintf(`SELECT %s FROM %s WHERE id=$1 AND deleted_at IS NOT NULL FOR UPDATE`, guard, e.table)
+	if err = tx.GetContext(ctx, &blocked, q, id); err != nil {
+		return err
+	}
+	if blocked {
+		return ErrMerged
+	}
+
+	for _, u := range e.uniques {
+		var conflict bool
+		q := fmt.Sprintf(`SELECT EXISTS (
+            SELECT 1 FROM %[1]s a, %[1]s d
+             WHERE d.id=$1 AND a.id<>d.id AND a.deleted_at IS NULL
+               AND d.%[2]s IS NOT NULL AND d.%[2]s <> ''
+               AND %[3]s = %[4]s)`,
+			e.table, u.column, fmt.Sprintf(u.expr, "a"), fmt.Sprintf(u.expr, "d"))
+		if err = tx.GetContext(ctx, &conflict, q, id); err != nil {
+			return err
+		}
+		if conflict {
+			return fmt.Errorf("%w: %s", ErrUniqueConflict, u.column)
+		}
+	}
+
+	q = fmt.Sprintf(`UPDATE %s SET deleted_at=NULL WHERE id=$1`, e.table)
+	if _, err = tx.ExecContext(ctx, q, id); err != nil {
+		return err
+	}
+	if err = insertAudit(ctx, tx, actorID, e.table, id, "update", map[string]bool{"restored": true}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Purge exclui definitivamente um registro removido ha mais tempo que a
+// retencao, junto com seus filhos, tags e notas.
+func (r *PostgresRepository) Purge(ctx context.Context, name, id, actorID string, retention time.Duration) error {
+	e, ok := entities[name]
+	if !ok {
+		return ErrUnknownEntity
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var deletedAt time.Time
+	q := fmt.Sprintf(`SELECT deleted_at FROM %s WHERE id=$1 AND deleted_at IS NOT NULL FOR UPDATE`, e.table)
+	if err = tx.GetContext(ctx, &deletedAt, q, id); err != nil {
+		return err
+	}
+	if time.Since(deletedAt) < retention {
+		return ErrRetention
+	}
+
+	for _, child := range e.children {
+		parts := strings.SplitN(child, ".", 2)
+		q := fmt.Sprintf(`DELETE FROM %s WHERE %s=$1`, parts[0], parts[1])
+		if _, err = tx.ExecContext(ctx, q, id); err != nil {
+			return translateFK(err)
+		}
+	}
+	for _, q := range []string{
+		`DELETE FROM entity_tags WHERE entity_name=$1 AND entity_id=$2`,
+		`DELETE FROM notes WHERE entity_name=$1 AND entity_id=$2`,
+	} {
+		if _, err = tx.ExecContext(ctx, q, e.table, id); err != nil {
+			return err
+		}
+	}
+	q = fmt.Sprintf(`DELETE FROM %s WHERE id=$1`, e.table)
+	if _, err = tx.ExecContext(ctx, q, id); err != nil {
+		return translateFK(err)
+	}
+	if err = insertAudit(ctx, tx, actorID, e.table, id, "delete", map[string]bool{"purged": true}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// translateFK converte violacoes de chave estrangeira em ErrHasDependents.
+func translateFK(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrHasDependents
+	}
+	return err
+}
+
+func insertAudit(ctx context.Context, tx *sqlx.Tx, actorID, entityName, entityID, action string, diff interface{}) error {
+	b, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+	var userID *string
+	if actorID != "" {
+		userID = &actorID
+	}
+	const q = `INSERT INTO audit_logs (id, user_id, entity_name, entity_id, action, diff)
+        VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, q, ulid.Make().String(), userID, entityName, entityID, action, string(b))
+	return err
+}
+
+var _ Repository = (*PostgresRepository)(nil)
@@ -0,0 +1,111 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Repository define operacoes sobre registros removidos logicamente.
+type Repository interface {
+	List(ctx context.Context, entity string, limit int) ([]Item, error)
+	Restore(ctx context.Context, entity, id, actorID string) error
+	Purge(ctx context.Context, entity, id, actorID string, retention time.Duration) error
+}
+
+// Errors especificos
+
+var (
+	ErrUnknownEntity  = errors.New("unknown entity")
+	ErrUniqueConflict = errors.New("an active record with the same unique value exists")
+	ErrMerged         = errors.New("record was merged into another and cannot be restored")
+	ErrRetention      = errors.New("retention period has not elapsed")
+	ErrHasDependents  = errors.New("record has dependent records")
+)
+
+// DefaultRetention eh o prazo minimo entre a remocao logica e a exclusao definitiva.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// RetentionFromEnv le TRASH_RETENTION_DAYS, usando DefaultRetention como padrao.
+func RetentionFromEnv() time.Duration {
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days >= 0 {
+			return time.Duration(days) * 24 * time.Hour
+		}
+	}
+	return DefaultRetention
+}
+
+// uniqueCheck compara uma expressao SQL entre o registro restaurado e os ativos.
+type uniqueCheck struct {
+	column string
+	expr   string
+}
+
+// entity descreve como listar, restaurar e excluir uma tabela.
+type entity struct {
+	table string
+	// label eh a expressao SQL exibida na listagem
+	label string
+	// uniques sao verificados antes de restaurar
+	uniques []uniqueCheck
+	// restoreGuard eh uma condicao SQL que bloqueia a restauracao quando verdadeira
+	restoreGuard string
+	// children sao tabelas "tabela.coluna" excluidas junto com o registro
+	children []string
+}
+
+const normalizedDocument = `regexp_replace(upper(%s.document_id), '[^0-9A-Z]', '', 'g')`
+
+var entities = map[string]entity{
+	"customers": {
+		table:        "customers",
+		label:        "legal_name",
+		uniques:      []uniqueCheck{{column: "document_id", expr: normalizedDocument}},
+		restoreGuard: "merged_into_id IS NOT NULL",
+		children:     []string{"addresses.customer_id"},
+	},
+	"promoters": {
+		table: "promoters",
+		label: "full_name",
+		uniques: []uniqueCheck{
+			{column: "document_id", expr: normalizedDocument},
+			{column: "email", expr: "lower(%s.email)"},
+		},
+		children: []string{"commission_contracts.promoter_id"},
+	},
+	"services": {
+		table:    "services",
+		label:    "name",
+		uniques:  []uniqueCheck{{column: "name", expr: "lower(%s.name)"}},
+		children: []string{"contract_templates.service_id"},
+	},
+	"leads": {
+		table: "leads",
+		label: "status",
+	},
+	"tags": {
+		table:    "tags",
+		label:    "name",
+		uniques:  []uniqueCheck{{column: "name", expr: "lower(%s.name)"}},
+		children: []string{"entity_tags.tag_id"},
+	},
+	"contracts": {
+		table:    "contracts",
+		label:    "status || ' - ' || value_total::text",
+		children: []string{"contract_attachments.contract_id"},
+	},
+}
+
+// Entities retorna os nomes das entidades suportadas pela lixeira.
+func Entities() []string {
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
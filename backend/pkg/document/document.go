@@ -0,0 +1,143 @@
+// Package document valida e normaliza documentos brasileiros (CPF e CNPJ).
+//
+// O CNPJ segue o formato alfanumerico definido pela Receita Federal: as 12
+// primeiras posicoes aceitam [0-9A-Z] e os dois digitos verificadores
+// continuam numericos. CNPJs exclusivamente numericos seguem validos.
+package document
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PersonType classifica o titular do documento.
+type PersonType string
+
+const (
+	// PersonNatural identifica pessoa fisica (CPF).
+	PersonNatural PersonType = "PF"
+	// PersonLegal identifica pessoa juridica (CNPJ).
+	PersonLegal PersonType = "PJ"
+)
+
+// ValidationTag eh o nome da tag registrada no validator.
+const ValidationTag = "cpfcnpj"
+
+// ErrInvalid eh retornado quando o documento nao eh um CPF ou CNPJ valido.
+var ErrInvalid = errors.New("invalid document")
+
+// Normalize remove pontuacao e espacos, mantendo apenas [0-9A-Z].
+// Letras sao convertidas para maiusculas para suportar o CNPJ alfanumerico.
+func Normalize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToUpper(s) {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Validate normaliza o documento e retorna o tipo de pessoa correspondente.
+func Validate(s string) (PersonType, error) {
+	n := Normalize(s)
+	switch {
+	case IsCPF(n):
+		return PersonNatural, nil
+	case IsCNPJ(n):
+		return PersonLegal, nil
+	}
+	return "", ErrInvalid
+}
+
+// Type retorna o tipo de pessoa do documento ou vazio se for invalido.
+func Type(s string) PersonType {
+	t, _ := Validate(s)
+	return t
+}
+
+// IsCPF verifica os digitos de um CPF ja normalizado.
+func IsCPF(s string) bool {
+	if len(s) != 11 || repeated(s) {
+		return false
+	}
+	d := make([]int, 11)
+	for i := 0; i < 11; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+		d[i] = int(s[i] - '0')
+	}
+	for n := 9; n <= 10; n++ {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += d[i] * (n + 1 - i)
+		}
+		dv := sum * 10 % 11
+		if dv == 10 {
+			dv = 0
+		}
+		if dv != d[n] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	cnpjWeights1 = []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	cnpjWeights2 = []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+)
+
+// IsCNPJ verifica os digitos de um CNPJ (numerico ou alfanumerico) ja normalizado.
+func IsCNPJ(s string) bool {
+	if len(s) != 14 || repeated(s) {
+		return false
+	}
+	v := make([]int, 14)
+	for i := 0; i < 14; i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'A' && c <= 'Z' && i < 12:
+		default:
+			return false
+		}
+		// valor ASCII - 48, conforme especificacao do CNPJ alfanumerico
+		v[i] = int(c) - '0'
+	}
+	return cnpjDigit(v[:12], cnpjWeights1) == v[12] &&
+		cnpjDigit(v[:13], cnpjWeights2) == v[13]
+}
+
+func cnpjDigit(v []int, weights []int) int {
+	sum := 0
+	for i, w := range weights {
+		sum += v[i] * w
+	}
+	r := sum % 11
+	if r < 2 {
+		return 0
+	}
+	return 11 - r
+}
+
+func repeated(s string) bool {
+	for i := 1; i < len(s); i++ {
+		if s[i] != s[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// RegisterValidation registra a tag "cpfcnpj" no validator informado.
+func RegisterValidation(v *validator.Validate) error {
+	return v.RegisterValidation(ValidationTag, func(fl validator.FieldLevel) bool {
+		_, err := Validate(fl.Field().String())
+		return err == nil
+	})
+}
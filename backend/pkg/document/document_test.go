@@ -0,0 +1,67 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"123.456.789-09":     "12345678909",
+		"11.222.333/0001-81": "11222333000181",
+		"12.abc.345/01de-35": "12ABC34501DE35",
+		" 529 982 247 25 ":   "52998224725",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Fatalf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		in   string
+		want PersonType
+		ok   bool
+	}{
+		{"123.456.789-09", PersonNatural, true},
+		{"52998224725", PersonNatural, true},
+		{"11.222.333/0001-81", PersonLegal, true},
+		{"12.ABC.345/01DE-35", PersonLegal, true},
+		{"123.456.789-00", "", false},
+		{"111.111.111-11", "", false},
+		{"11.222.333/0001-82", "", false},
+		{"12.ABC.345/01DE-3A", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		got, err := Validate(c.in)
+		if c.ok && err != nil {
+			t.Fatalf("Validate(%q) unexpected error: %v", c.in, err)
+		}
+		if !c.ok && err == nil {
+			t.Fatalf("Validate(%q) expected error", c.in)
+		}
+		if got != c.want {
+			t.Fatalf("Validate(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	v := validator.New()
+	if err := RegisterValidation(v); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	type input struct {
+		DocumentID string `validate:"required,cpfcnpj"`
+	}
+	if err := v.Struct(input{DocumentID: "123.456.789-09"}); err != nil {
+		t.Fatalf("expected valid document, got %v", err)
+	}
+	if err := v.Struct(input{DocumentID: "1"}); err == nil {
+		t.Fatalf("expected validation error")
+	}
+}
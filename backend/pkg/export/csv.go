@@ -0,0 +1,118 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bom identifica o arquivo como UTF-8 para o Excel.
+const bom = "\ufeff"
+
+// WriteCSV grava t separado por ';' com numeros e datas no formato pt-BR.
+func WriteCSV(w io.Writer, t Table) error {
+	return writeAll(NewCSVWriter(w, t.Columns), t.Rows)
+}
+
+type csvWriter struct {
+	w       io.Writer
+	cw      *csv.Writer
+	columns []string
+	rec     []string
+	started bool
+}
+
+// NewCSVWriter cria um RowWriter CSV. O BOM e o cabecalho sao gravados
+// junto com a primeira linha (ou no Close).
+func NewCSVWriter(w io.Writer, columns []string) RowWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	return &csvWriter{w: w, cw: cw, columns: columns, rec: make([]string, len(columns))}
+}
+
+func (c *csvWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	if _, err := io.WriteString(c.w, bom); err != nil {
+		return err
+	}
+	return c.cw.Write(c.columns)
+}
+
+func (c *csvWriter) WriteRow(row []any) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	for i := range c.rec {
+		c.rec[i] = ""
+		if i < len(row) {
+			c.rec[i] = FormatValue(row[i])
+		}
+	}
+	return c.cw.Write(c.rec)
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// FormatValue formata uma celula no padrao brasileiro: numeros com virgula
+// decimal e duas casas, datas como dd/mm/aaaa (com hora quando houver).
+// Textos que a planilha interpretaria como formula recebem o prefixo '.
+func FormatValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(x)
+	case int:
+		return strconv.Itoa(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strings.Replace(strconv.FormatFloat(x, 'f', 2, 64), ".", ",", 1)
+	case bool:
+		if x {
+			return "sim"
+		}
+		return "nao"
+	case time.Time:
+		if isDate(x) {
+			return x.Format("02/01/2006")
+		}
+		return x.Format("02/01/2006 15:04")
+	case *time.Time:
+		if x == nil {
+			return ""
+		}
+		return FormatValue(*x)
+	case *string:
+		if x == nil {
+			return ""
+		}
+		return escapeFormula(*x)
+	}
+	return fmt.Sprint(v)
+}
+
+// escapeFormula evita injecao de formulas (=, +, -, @, tab ou CR no inicio)
+// ao abrir o CSV em planilhas.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func isDate(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
@@ -0,0 +1,131 @@
+// Package export gera planilhas CSV e XLSX a partir de tabelas simples, sem
+// dependencias externas.
+//
+// Os arquivos seguem o formato brasileiro: o CSV usa ';' como separador,
+// virgula decimal e datas dd/mm/aaaa; o XLSX grava numeros e datas como
+// valores nativos com formatos de exibicao equivalentes.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Formatos suportados.
+const (
+	JSON = "json"
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// Tipos de conteudo de cada formato.
+const (
+	ContentTypeCSV  = "text/csv; charset=utf-8"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var ErrUnknownFormat = errors.New("format must be json, csv or xlsx")
+
+// Table eh uma planilha com cabecalho. Cada celula pode ser string, int,
+// int64, float64, time.Time, *time.Time ou nil.
+type Table struct {
+	Sheet   string
+	Columns []string
+	Rows    [][]any
+}
+
+// RowWriter grava uma planilha linha a linha. Close finaliza o arquivo e
+// deve ser chamado mesmo sem linhas.
+type RowWriter interface {
+	WriteRow(row []any) error
+	Close() error
+}
+
+func writeAll(rw RowWriter, rows [][]any) error {
+	for _, row := range rows {
+		if err := rw.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return rw.Close()
+}
+
+// Negotiate escolhe o formato pelo parametro ?format= ou, na falta dele,
+// pelo header Accept. O padrao eh JSON.
+func Negotiate(r *http.Request) (string, error) {
+	if f := strings.ToLower(r.URL.Query().Get("format")); f != "" {
+		switch f {
+		case JSON, CSV, XLSX:
+			return f, nil
+		}
+		return "", ErrUnknownFormat
+	}
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return CSV, nil
+	case strings.Contains(accept, "spreadsheetml"):
+		return XLSX, nil
+	}
+	return JSON, nil
+}
+
+// Stream responde com a planilha no formato informado gravando as linhas a
+// medida que rows as emite, sem acumular o resultado em memoria. Um erro
+// antes da primeira linha vira 500; depois dela a resposta ja comecou e a
+// conexao eh abortada, para o cliente nao tomar o arquivo truncado por
+// completo.
+func Stream(w http.ResponseWriter, format, name string, header Table, rows func(emit func(row []any) error) error) {
+	var rw RowWriter
+	open := func() {
+		setHeaders(w, format, name)
+		if format == XLSX {
+			rw = NewXLSXWriter(w, header.Sheet, header.Columns)
+		} else {
+			rw = NewCSVWriter(w, header.Columns)
+		}
+	}
+	err := rows(func(row []any) error {
+		if rw == nil {
+			open()
+		}
+		return rw.WriteRow(row)
+	})
+	if err == nil {
+		if rw == nil {
+			open()
+		}
+		err = rw.Close()
+	}
+	if err != nil {
+		if rw == nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func setHeaders(w http.ResponseWriter, format, name string) {
+	if format == XLSX {
+		w.Header().Set("Content-Type", ContentTypeXLSX)
+	} else {
+		w.Header().Set("Content-Type", ContentTypeCSV)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+}
+
+// Write grava t no formato informado como anexo name.csv ou name.xlsx.
+func Write(w http.ResponseWriter, format, name string, t Table) error {
+	switch format {
+	case CSV:
+		setHeaders(w, format, name)
+		return WriteCSV(w, t)
+	case XLSX:
+		setHeaders(w, format, name)
+		return WriteXLSX(w, t)
+	}
+	return ErrUnknownFormat
+}
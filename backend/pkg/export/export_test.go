@@ -0,0 +1,153 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sample = Table{
+	Sheet:   "Aging: clientes",
+	Columns: []string{"Cliente", "Vencimento", "Valor"},
+	Rows: [][]any{
+		{"Açaí & Cia; Ltda", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), 1234.5},
+		{"Outro", nil, 10},
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCSV(&b, sample); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := strings.TrimPrefix(b.String(), bom)
+	want := "Cliente;Vencimento;Valor\n\"Açaí & Cia; Ltda\";05/03/2026;1234,50\nOutro;;10\n"
+	if out != want {
+		t.Fatalf("unexpected csv:\n%q\nwant\n%q", out, want)
+	}
+}
+
+func TestFormatValueEscapesFormulas(t *testing.T) {
+	cmd := "@SUM(A1)"
+	cases := []struct {
+		in   any
+		want string
+	}{
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+5511999990000", "'+5511999990000"},
+		{"-2", "'-2"},
+		{&cmd, "'@SUM(A1)"},
+		{"\tx", "'\tx"},
+		{"\rx", "'\rx"},
+		{"a=b", "a=b"},
+		{-2.5, "-2,50"},
+	}
+	for _, c := range cases {
+		if got := FormatValue(c.in); got != c.want {
+			t.Errorf("FormatValue(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteXLSX(&b, sample); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("missing part %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Aging clientes"`) {
+		t.Fatalf("unexpected sheet name: %s", parts["xl/workbook.xml"])
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">Açaí &amp; Cia; Ltda</t></is></c>`,
+		`<c r="B2" s="2"><v>46086</v></c>`,
+		`<c r="C2" s="1"><v>1234.5</v></c>`,
+		`<c r="C3"><v>10</v></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Fatalf("sheet missing %s:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		url, accept, want string
+		err               bool
+	}{
+		{"/r", "", JSON, false},
+		{"/r?format=CSV", "", CSV, false},
+		{"/r", "text/csv", CSV, false},
+		{"/r", ContentTypeXLSX, XLSX, false},
+		{"/r?format=json", "text/csv", JSON, false},
+		{"/r?format=pdf", "", "", true},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", c.url, nil)
+		req.Header.Set("Accept", c.accept)
+		got, err := Negotiate(req)
+		if (err != nil) != c.err || got != c.want {
+			t.Fatalf("%s accept=%q: got %q, %v", c.url, c.accept, got, err)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Fatalf("column %d: got %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	header := Table{Sheet: "Itens", Columns: []string{"ID", "Valor"}}
+
+	rec := httptest.NewRecorder()
+	Stream(rec, CSV, "itens", header, func(emit func([]any) error) error {
+		for i := 1; i <= 2; i++ {
+			if err := emit([]any{i, float64(i) / 4}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if got := strings.TrimPrefix(rec.Body.String(), bom); got != "ID;Valor\n1;0,25\n2;0,50\n" {
+		t.Fatalf("unexpected csv %q", got)
+	}
+	if rec.Header().Get("Content-Disposition") != `attachment; filename="itens.csv"` {
+		t.Fatalf("unexpected disposition %s", rec.Header().Get("Content-Disposition"))
+	}
+
+	rec = httptest.NewRecorder()
+	Stream(rec, XLSX, "itens", header, func(func([]any) error) error { return nil })
+	if _, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len())); err != nil {
+		t.Fatalf("empty export should still be a valid xlsx: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	Stream(rec, CSV, "itens", header, func(func([]any) error) error { return io.ErrUnexpectedEOF })
+	if rec.Code != 500 || rec.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("expected 500 before the first row, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,236 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estilos definidos em stylesXML, pela posicao em cellXfs.
+const (
+	styleDefault = iota
+	styleNumber
+	styleDate
+	styleDateTime
+	styleHeader
+)
+
+const contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const stylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="2"><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/><numFmt numFmtId="165" formatCode="dd/mm/yyyy hh:mm"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+</cellXfs>
+</styleSheet>`
+
+// excelEpoch eh a data base dos numeros seriais de data do Excel.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// WriteXLSX grava t como uma pasta de trabalho com uma unica planilha.
+func WriteXLSX(w io.Writer, t Table) error {
+	return writeAll(NewXLSXWriter(w, t.Sheet, t.Columns), t.Rows)
+}
+
+type xlsxWriter struct {
+	w       io.Writer
+	sheet   string
+	columns []string
+	zw      *zip.Writer
+	sw      *bufio.Writer
+	n       int
+}
+
+// NewXLSXWriter cria um RowWriter XLSX. O arquivo eh gravado em streaming:
+// as partes fixas e o cabecalho saem com a primeira linha (ou no Close) e a
+// planilha eh a ultima entrada do zip.
+func NewXLSXWriter(w io.Writer, sheet string, columns []string) RowWriter {
+	return &xlsxWriter{w: w, sheet: sheet, columns: columns}
+}
+
+func (x *xlsxWriter) start() error {
+	if x.zw != nil {
+		return nil
+	}
+	x.zw = zip.NewWriter(x.w)
+	files := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXML, escape(sheetName(x.sheet)))},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, f := range files {
+		fw, err := x.zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+	fw, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sw = bufio.NewWriter(fw)
+	x.sw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	x.sw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]any, len(x.columns))
+	for i, c := range x.columns {
+		header[i] = c
+	}
+	x.n = 1
+	writeRow(x.sw, x.n, header, styleHeader)
+	return nil
+}
+
+func (x *xlsxWriter) WriteRow(row []any) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	x.n++
+	writeRow(x.sw, x.n, row, styleDefault)
+	if x.sw.Buffered() > 32<<10 {
+		return x.sw.Flush()
+	}
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	x.sw.WriteString(`</sheetData></worksheet>`)
+	if err := x.sw.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func writeRow(b *bufio.Writer, n int, row []any, textStyle int) {
+	fmt.Fprintf(b, `<row r="%d">`, n)
+	for i, v := range row {
+		ref := columnName(i) + strconv.Itoa(n)
+		if p, ok := v.(*time.Time); ok {
+			if p == nil {
+				continue
+			}
+			v = *p
+		}
+		if p, ok := v.(*string); ok {
+			if p == nil {
+				continue
+			}
+			v = *p
+		}
+		switch x := v.(type) {
+		case nil:
+			continue
+		case int:
+			fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, x)
+		case int64:
+			fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, x)
+		case float64:
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleNumber, strconv.FormatFloat(x, 'f', -1, 64))
+		case time.Time:
+			style := styleDateTime
+			if isDate(x) {
+				style = styleDate
+			}
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(serial(x), 'f', -1, 64))
+		default:
+			style := ""
+			if textStyle != styleDefault {
+				style = fmt.Sprintf(` s="%d"`, textStyle)
+			}
+			// string inline nao eh avaliada como formula: o texto vai sem o
+			// prefixo de protecao do CSV
+			text, ok := x.(string)
+			if !ok {
+				text = FormatValue(x)
+			}
+			fmt.Fprintf(b, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(text))
+		}
+	}
+	b.WriteString(`</row>`)
+}
+
+// serial converte a data (no horario local dela) para o numero serial do Excel.
+func serial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return wall.Sub(excelEpoch).Seconds() / 86400
+}
+
+// columnName converte o indice (0 = A) na letra da coluna.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// sheetName remove caracteres proibidos e limita o nome a 31 caracteres.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, s)
+	if s == "" {
+		s = "Planilha1"
+	}
+	if r := []rune(s); len(r) > 31 {
+		s = string(r[:31])
+	}
+	return s
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,221 @@
+// Package pdf gera documentos PDF simples de texto sem dependencias externas.
+//
+// Os documentos usam as fontes padrao Helvetica e Helvetica-Bold com a
+// codificacao WinAnsi, suficiente para textos em portugues. Caracteres fora
+// do Latin-1 sao substituidos por '?'.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Dimensoes de uma pagina A4 em pontos.
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+	margin     = 56.0
+)
+
+type font int
+
+const (
+	regular font = iota
+	bold
+)
+
+type line struct {
+	text string
+	font font
+	size float64
+	y    float64
+}
+
+// Document acumula blocos de texto e os distribui em paginas A4.
+type Document struct {
+	pages [][]line
+	y     float64
+}
+
+// New cria um documento vazio.
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, nil)
+	d.y = PageHeight - margin
+}
+
+// Heading adiciona um titulo em negrito.
+func (d *Document) Heading(text string) {
+	d.block(text, bold, 14, 6)
+}
+
+// Paragraph adiciona um paragrafo com quebra automatica de linhas.
+func (d *Document) Paragraph(text string) {
+	d.block(text, regular, 11, 6)
+}
+
+// Space adiciona um espaco vertical em pontos.
+func (d *Document) Space(pt float64) {
+	d.y -= pt
+	if d.y < margin {
+		d.newPage()
+	}
+}
+
+func (d *Document) block(text string, f font, size, after float64) {
+	leading := size * 1.35
+	for _, l := range wrap(text, f, size, PageWidth-2*margin) {
+		if d.y-leading < margin {
+			d.newPage()
+		}
+		d.y -= leading
+		p := len(d.pages) - 1
+		d.pages[p] = append(d.pages[p], line{text: l, font: f, size: size, y: d.y})
+	}
+	d.Space(after)
+}
+
+// PageCount retorna o numero de paginas do documento.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Bytes serializa o documento no formato PDF 1.4.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalogo, 2 arvore de paginas, 3 e 4 fontes; cada pagina usa dois
+	// objetos a partir do 5 (pagina e conteudo).
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", PageWidth, PageHeight, 6+2*i))
+		var content bytes.Buffer
+		for _, l := range lines {
+			name := "F1"
+			if l.font == bold {
+				name = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %g Tf %g %.2f Td (%s) Tj ET\n", name, l.size, margin, l.y, escape(l.text))
+		}
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// escape converte o texto para WinAnsi e escapa os delimitadores de string.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 32:
+		case r < 128:
+			b.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// wrap quebra o texto em linhas que cabem na largura informada. Quebras de
+// linha explicitas sao preservadas.
+func wrap(text string, f font, size, width float64) []string {
+	var out []string
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			out = append(out, "")
+			continue
+		}
+		cur := words[0]
+		for _, w := range words[1:] {
+			if textWidth(cur+" "+w, f, size) > width {
+				out = append(out, cur)
+				cur = w
+				continue
+			}
+			cur += " " + w
+		}
+		out = append(out, cur)
+	}
+	return out
+}
+
+// textWidth estima a largura do texto em pontos pelas metricas da Helvetica.
+func textWidth(s string, f font, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		total += glyphWidth(r, f)
+	}
+	return float64(total) * size / 1000
+}
+
+func glyphWidth(r rune, f font) int {
+	base := unaccent(r)
+	w := 556
+	if base >= 32 && base < 127 {
+		w = helveticaWidths[base-32]
+	}
+	if f == bold {
+		// a Helvetica-Bold eh cerca de 5% mais larga
+		w = w * 105 / 100
+	}
+	return w
+}
+
+// unaccent mapeia letras acentuadas do Latin-1 para a letra base, usada
+// apenas para estimar a largura.
+func unaccent(r rune) rune {
+	const from = "ÀÁÂÃÄÅÇÈÉÊËÌÍÎÏÑÒÓÔÕÖÙÚÛÜÝàáâãäåçèéêëìíîïñòóôõöùúûüýÿ"
+	const to = "AAAAAACEEEEIIIINOOOOOUUUUYaaaaaaceeeeiiiinooooouuuuyy"
+	if i := strings.IndexRune(from, r); i >= 0 {
+		return []rune(to)[len([]rune(from[:i]))]
+	}
+	return r
+}
+
+// helveticaWidths contem as larguras (1/1000 em) dos caracteres 32 a 126.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
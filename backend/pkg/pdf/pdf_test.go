@@ -0,0 +1,37 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBytesStructure(t *testing.T) {
+	d := New()
+	d.Heading("Contrato de Prestação de Serviços")
+	d.Paragraph("Cláusula (1): valor R$ 1.000,00")
+	out := d.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if !bytes.Contains(out, []byte(`Cl\341usula \(1\)`)) {
+		t.Fatal("expected WinAnsi octal escape and escaped parentheses")
+	}
+	if !bytes.Contains(out, []byte("/Count 1")) {
+		t.Fatal("expected a single page")
+	}
+}
+
+func TestLongTextBreaksPages(t *testing.T) {
+	d := New()
+	d.Paragraph(strings.Repeat("palavra ", 5000))
+	if d.PageCount() < 2 {
+		t.Fatalf("expected multiple pages, got %d", d.PageCount())
+	}
+	for _, l := range wrap(strings.Repeat("palavra ", 200), regular, 11, PageWidth-2*margin) {
+		if textWidth(l, regular, 11) > PageWidth-2*margin {
+			t.Fatalf("line exceeds page width: %q", l)
+		}
+	}
+}
@@ -37,6 +37,8 @@ export default function ContractForm({ contract, onClose, onSuccess }: ContractF
   const [startDate, setStartDate] = useState(contract?.start_date || "");
   const [endDate, setEndDate] = useState(contract?.end_date || "");
   const [status, setStatus] = useState(contract?.status || "active");
+  const [reason, setReason] = useState("");
+  const [requireSignature, setRequireSignature] = useState(false);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState("");
 
@@ -59,6 +61,8 @@ export default function ContractForm({ contract, onClose, onSuccess }: ContractF
     if (valueTotal < 0) return "Valor inválido";
     if (startDate && endDate && new Date(startDate) > new Date(endDate))
       return "Datas incoerentes";
+    if (contract && status !== contract.status && ["suspended", "cancelled"].includes(status) && !reason.trim())
+      return "Motivo obrigatório";
     return "";
   };
 
@@ -78,7 +82,6 @@ export default function ContractForm({ contract, onClose, onSuccess }: ContractF
       value_total: valueTotal,
       start_date: startDate,
       end_date: endDate,
-      status,
     };
     try {
       if (contract) {
@@ -86,8 +89,17 @@ export default function ContractForm({ contract, onClose, onSuccess }: ContractF
           method: "PUT",
           body: JSON.stringify(payload),
         });
+        if (status !== contract.status) {
+          await api(`/contracts/${contract.id}/status`, {
+            method: "PUT",
+            body: JSON.stringify({ status, reason }),
+          });
+        }
       } else {
-        await api("/contracts", { method: "POST", body: JSON.stringify(payload) });
+        await api("/contracts", {
+          method: "POST",
+          body: JSON.stringify({ ...payload, require_signature: requireSignature }),
+        });
       }
       onSuccess();
     } catch {
@@ -165,16 +177,40 @@ export default function ContractForm({ contract, onClose, onSuccess }: ContractF
           value={endDate}
           onChange={(e) => setEndDate(e.target.value)}
         />
-        <select
-          className="border p-2 w-full"
-          value={status}
-          onChange={(e) => setStatus(e.target.value)}
-        >
-          <option value="active">active</option>
-          <option value="suspended">suspended</option>
-          <option value="closed">closed</option>
-          <option value="cancelled">cancelled</option>
-        </select>
+        {contract && (
+          <select
+            className="border p-2 w-full"
+            value={status}
+            onChange={(e) => setStatus(e.target.value)}
+          >
+            {contract.status === "pending_signature" && (
+              <option value="pending_signature">pending_signature</option>
+            )}
+            <option value="active">active</option>
+            <option value="suspended">suspended</option>
+            <option value="closed">closed</option>
+            <option value="cancelled">cancelled</option>
+          </select>
+        )}
+        {!contract && (
+          <label className="flex items-center gap-2">
+            <input
+              type="checkbox"
+              checked={requireSignature}
+              onChange={(e) => setRequireSignature(e.target.checked)}
+            />
+            Exigir assinatura antes de ativar
+          </label>
+        )}
+        {contract && status !== contract.status && ["suspended", "cancelled"].includes(status) && (
+          <input
+            className="border p-2 w-full"
+            placeholder="Motivo"
+            value={reason}
+            onChange={(e) => setReason(e.target.value)}
+            required
+          />
+        )}
       </div>
       {contract && <Attachments contractId={contract.id} />}
       {error && <p className="text-red-500 text-sm">{error}</p>}
@@ -1,7 +1,22 @@
 "use client";
+import useSWR from "swr";
 import ProtectedRoute from "@/components/ProtectedRoute";
 import Link from "next/link";
 import { getToken } from "@/hooks/useAuth";
+import { api } from "@/util/api";
+
+interface DashboardData {
+  contracts: { active: number; mrr: number };
+  revenue: { month: string; expected: number; received: number }[];
+  overdue: {
+    amount: number;
+    count: number;
+    aging: { bucket: string; count: number; amount: number }[];
+  };
+  leadConversion: { stage: string; count: number; reached: number; rate: number }[];
+  topPromoters: { promoterID: string; name: string; contracts: number; value: number }[];
+  pendingCommissions: { count: number; amount: number };
+}
 
 function getRole(): string | null {
   const token = getToken();
@@ -14,12 +29,83 @@ function getRole(): string | null {
   }
 }
 
+const money = (v: number) =>
+  v.toLocaleString("pt-BR", { style: "currency", currency: "BRL" });
+
+const fetcher = (url: string) => api<DashboardData>(url);
+
+function Kpis() {
+  const { data } = useSWR("/dashboard", fetcher);
+  if (!data) return <div>Carregando...</div>;
+  return (
+    <div className="space-y-4">
+      <div className="grid grid-cols-2 md:grid-cols-4 gap-2">
+        <div className="border rounded p-2">
+          <div className="text-sm text-gray-500">Contratos ativos</div>
+          <div className="text-lg font-bold">{data.contracts.active}</div>
+        </div>
+        <div className="border rounded p-2">
+          <div className="text-sm text-gray-500">MRR</div>
+          <div className="text-lg font-bold">{money(data.contracts.mrr)}</div>
+        </div>
+        <div className="border rounded p-2">
+          <div className="text-sm text-gray-500">Em atraso</div>
+          <div className="text-lg font-bold">{money(data.overdue.amount)}</div>
+        </div>
+        <div className="border rounded p-2">
+          <div className="text-sm text-gray-500">Comissoes pendentes</div>
+          <div className="text-lg font-bold">
+            {money(data.pendingCommissions.amount)} ({data.pendingCommissions.count})
+          </div>
+        </div>
+      </div>
+      <table className="text-sm">
+        <thead>
+          <tr>
+            <th className="text-left pr-4">Mes</th>
+            <th className="text-right pr-4">Previsto</th>
+            <th className="text-right">Recebido</th>
+          </tr>
+        </thead>
+        <tbody>
+          {data.revenue.map((m) => (
+            <tr key={m.month}>
+              <td className="pr-4">{m.month}</td>
+              <td className="text-right pr-4">{money(m.expected)}</td>
+              <td className="text-right">{money(m.received)}</td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+      <div className="text-sm">
+        Atraso:{" "}
+        {data.overdue.aging.map((b) => `${b.bucket} dias: ${money(b.amount)}`).join(" | ")}
+      </div>
+      <div className="text-sm">
+        Conversao:{" "}
+        {data.leadConversion
+          .map((s) => `${s.stage}: ${(s.rate * 100).toFixed(1)}%`)
+          .join(" | ")}
+      </div>
+      <div className="text-sm">
+        <div className="font-bold">Top promotores</div>
+        {data.topPromoters.map((p) => (
+          <div key={p.promoterID}>
+            {p.name}: {money(p.value)} ({p.contracts})
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+}
+
 export default function DashboardPage() {
   const role = getRole();
   return (
     <ProtectedRoute>
       <div className="p-4 space-y-2">
         <div>Dashboard</div>
+        {(role === "admin" || role === "finance") && <Kpis />}
         {role === "admin" && (
           <Link href="/audit-logs" className="text-blue-600 hover:underline">
             Audit Logs
@@ -23,7 +23,7 @@ export default function NewLeadModal({ isOpen, onClose, onSuccess }: ModalProps)
   useEffect(() => {
     (async () => {
       setCustomers(await api<Customer[]>("/customers"));
-      setServices(await api<Service[]>("/services"));
+      setServices(await api<Service[]>("/services?active=true"));
     })();
   }, []);
 
@@ -9,6 +9,8 @@ interface Service {
   description?: string;
   basePrice: number;
   isActive: boolean;
+  billingType?: string;
+  autoCloseGraceDays?: number;
 }
 
 interface ServiceFormProps {
@@ -21,13 +23,16 @@ export default function ServiceForm({ service, onClose, onSuccess }: ServiceForm
   const [name, setName] = useState(service?.name || "");
   const [description, setDescription] = useState(service?.description || "");
   const [price, setPrice] = useState(service?.basePrice ?? 0);
+  const [graceDays, setGraceDays] = useState(service?.autoCloseGraceDays ?? 0);
   const [active, setActive] = useState(service?.isActive ?? true);
+  const [billingType, setBillingType] = useState(service?.billingType || "one_off");
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
 
   const validate = () => {
     if (!name) return "Nome é obrigatório";
     if (price < 0) return "Preço deve ser maior ou igual a 0";
+    if (graceDays < 0) return "Carência deve ser maior ou igual a 0";
     return "";
   };
 
@@ -45,6 +50,8 @@ export default function ServiceForm({ service, onClose, onSuccess }: ServiceForm
       description,
       base_price: price,
       is_active: active,
+      billing_type: billingType,
+      auto_close_grace_days: graceDays,
     };
     try {
       if (service) {
@@ -91,6 +98,24 @@ export default function ServiceForm({ service, onClose, onSuccess }: ServiceForm
         onChange={(e) => setPrice(parseFloat(e.target.value))}
         required
       />
+      <label className="flex flex-col gap-1 text-sm">
+        Tipo de cobrança
+        <select className="border p-2" value={billingType} onChange={(e) => setBillingType(e.target.value)}>
+          <option value="one_off">Única</option>
+          <option value="recurring">Recorrente</option>
+        </select>
+      </label>
+      <label className="flex flex-col gap-1 text-sm">
+        Dias de carência para encerrar contratos vencidos
+        <input
+          type="number"
+          min="0"
+          step="1"
+          className="border p-2"
+          value={graceDays}
+          onChange={(e) => setGraceDays(parseInt(e.target.value, 10) || 0)}
+        />
+      </label>
       <label className="flex items-center gap-2">
         <input type="checkbox" checked={active} onChange={(e) => setActive(e.target.checked)} />
         Ativo
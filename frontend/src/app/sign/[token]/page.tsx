@@ -0,0 +1,108 @@
+"use client";
+import { use, useEffect, useState } from "react";
+
+const API_BASE = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080";
+
+interface SigningView {
+  signerName: string;
+  signerStatus: string;
+  requestStatus: string;
+  contractID: string;
+  customerName: string;
+  serviceName: string;
+  valueTotal: number;
+  documentHash: string;
+}
+
+export default function SignPage({ params }: { params: Promise<{ token: string }> }) {
+  const { token } = use(params);
+  const [view, setView] = useState<SigningView | null>(null);
+  const [error, setError] = useState("");
+  const [reason, setReason] = useState("");
+  const [loading, setLoading] = useState(false);
+
+  const load = async () => {
+    const res = await fetch(`${API_BASE}/sign/${token}`);
+    if (!res.ok) {
+      setError("Link de assinatura inválido");
+      return;
+    }
+    setView(await res.json());
+  };
+
+  useEffect(() => {
+    load();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [token]);
+
+  const answer = async (path: string, body: object) => {
+    setLoading(true);
+    setError("");
+    try {
+      const res = await fetch(`${API_BASE}/sign/${token}${path}`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(body),
+      });
+      if (!res.ok) throw new Error(await res.text());
+      await load();
+    } catch {
+      setError("Não foi possível registrar a resposta");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  if (error && !view) return <p className="p-8 text-red-500">{error}</p>;
+  if (!view) return <p className="p-8">Carregando...</p>;
+
+  const open = view.signerStatus === "pending" && view.requestStatus === "pending";
+
+  return (
+    <main className="max-w-xl mx-auto p-8 flex flex-col gap-4">
+      <h1 className="text-xl font-bold">Assinatura de contrato</h1>
+      <p>
+        {view.signerName}, você foi convidado(a) a assinar o contrato de{" "}
+        <strong>{view.serviceName}</strong> para <strong>{view.customerName}</strong>.
+      </p>
+      <a
+        className="text-blue-600 underline"
+        href={`${API_BASE}/sign/${token}/document.pdf`}
+        target="_blank"
+        rel="noreferrer"
+      >
+        Ler o documento (PDF)
+      </a>
+      <p className="text-xs text-gray-500 break-all">SHA-256: {view.documentHash}</p>
+      {open ? (
+        <div className="flex flex-col gap-2">
+          <button
+            className="bg-blue-500 text-white px-4 py-2"
+            disabled={loading}
+            onClick={() => answer("", { accept: true })}
+          >
+            Li e aceito, assinar
+          </button>
+          <input
+            className="border p-2"
+            placeholder="Motivo da recusa"
+            value={reason}
+            onChange={(e) => setReason(e.target.value)}
+          />
+          <button
+            className="border px-4 py-2"
+            disabled={loading || !reason.trim()}
+            onClick={() => answer("/decline", { reason })}
+          >
+            Recusar
+          </button>
+        </div>
+      ) : (
+        <p className="font-semibold">
+          {view.signerStatus === "signed" ? "Assinatura registrada." : "Esta solicitação não aceita mais respostas."}
+        </p>
+      )}
+      {error && <p className="text-red-500 text-sm">{error}</p>}
+    </main>
+  );
+}
@@ -0,0 +1,37 @@
+-------------------------------------------------
+-- customers.person_type (PF | PJ)
+-------------------------------------------------
+ALTER TABLE customers
+  ADD COLUMN person_type CHAR(2) CHECK (person_type IN ('PF', 'PJ'));
+
+-------------------------------------------------
+-- normaliza document_id para [0-9A-Z]
+-- linhas que colidiriam com outro registro ficam como estao
+-- e devem ser tratadas pelo merge de clientes
+-------------------------------------------------
+UPDATE customers c
+   SET document_id = regexp_replace(upper(c.document_id), '[^0-9A-Z]', '', 'g')
+ WHERE c.document_id IS NOT NULL
+   AND NOT EXISTS (
+     SELECT 1 FROM customers o
+      WHERE o.id <> c.id
+        AND regexp_replace(upper(o.document_id), '[^0-9A-Z]', '', 'g')
+          = regexp_replace(upper(c.document_id), '[^0-9A-Z]', '', 'g')
+   );
+
+-- pelo documento normalizado: linhas que nao foram normalizadas acima
+-- (colisoes) ainda estao formatadas; outros tamanhos ficam sem tipo
+UPDATE customers
+   SET person_type = CASE length(regexp_replace(upper(document_id), '[^0-9A-Z]', '', 'g'))
+                       WHEN 11 THEN 'PF' WHEN 14 THEN 'PJ' END
+ WHERE document_id IS NOT NULL;
+
+UPDATE promoters p
+   SET document_id = regexp_replace(upper(p.document_id), '[^0-9A-Z]', '', 'g')
+ WHERE p.document_id IS NOT NULL
+   AND NOT EXISTS (
+     SELECT 1 FROM promoters o
+      WHERE o.id <> p.id
+        AND regexp_replace(upper(o.document_id), '[^0-9A-Z]', '', 'g')
+          = regexp_replace(upper(p.document_id), '[^0-9A-Z]', '', 'g')
+   );
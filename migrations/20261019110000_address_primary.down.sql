@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_addresses_primary;
+ALTER TABLE addresses DROP COLUMN IF EXISTS is_primary;
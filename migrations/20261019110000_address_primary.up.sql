@@ -0,0 +1,17 @@
+-------------------------------------------------
+-- addresses.is_primary (um principal por tipo)
+-------------------------------------------------
+ALTER TABLE addresses
+  ADD COLUMN is_primary BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE addresses SET is_primary = true
+ WHERE id IN (
+   SELECT DISTINCT ON (customer_id, address_type) id
+     FROM addresses
+    WHERE deleted_at IS NULL
+    ORDER BY customer_id, address_type, created_at, id
+ );
+
+CREATE UNIQUE INDEX idx_addresses_primary
+  ON addresses (customer_id, address_type)
+  WHERE is_primary AND deleted_at IS NULL;
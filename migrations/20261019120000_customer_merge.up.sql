@@ -0,0 +1,5 @@
+-------------------------------------------------
+-- customers.merged_into_id (sobrevivente do merge)
+-------------------------------------------------
+ALTER TABLE customers
+  ADD COLUMN merged_into_id CHAR(26) REFERENCES customers(id);
@@ -0,0 +1,2 @@
+ALTER TABLE promoters DROP COLUMN IF EXISTS anonymized_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS anonymized_at;
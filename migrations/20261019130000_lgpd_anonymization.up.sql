@@ -0,0 +1,8 @@
+-------------------------------------------------
+-- anonymized_at (LGPD: titular anonimizado)
+-------------------------------------------------
+ALTER TABLE customers
+  ADD COLUMN anonymized_at TIMESTAMPTZ;
+
+ALTER TABLE promoters
+  ADD COLUMN anonymized_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_entity_tags_active;
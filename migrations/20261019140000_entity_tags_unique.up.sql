@@ -0,0 +1,13 @@
+-------------------------------------------------
+-- entity_tags: uma associacao ativa por entidade/tag
+-------------------------------------------------
+UPDATE entity_tags et SET deleted_at = now()
+ WHERE et.deleted_at IS NULL
+   AND EXISTS (
+     SELECT 1 FROM entity_tags o
+      WHERE o.entity_name = et.entity_name AND o.entity_id = et.entity_id
+        AND o.tag_id = et.tag_id AND o.deleted_at IS NULL AND o.id < et.id);
+
+CREATE UNIQUE INDEX idx_entity_tags_active
+  ON entity_tags (entity_name, entity_id, tag_id)
+  WHERE deleted_at IS NULL;
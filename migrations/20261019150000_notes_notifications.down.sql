@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS note_revisions;
+DROP INDEX IF EXISTS idx_notes_entity;
+ALTER TABLE notes DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,31 @@
+-------------------------------------------------
+-- notes.edited_at + note_revisions (historico de edicoes)
+-------------------------------------------------
+ALTER TABLE notes
+  ADD COLUMN edited_at TIMESTAMPTZ;
+
+CREATE INDEX idx_notes_entity ON notes (entity_name, entity_id, created_at);
+
+CREATE TABLE note_revisions (
+  id           CHAR(26) PRIMARY KEY,    -- ULID
+  note_id      CHAR(26) NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+  text         TEXT NOT NULL,           -- texto anterior a edicao
+  edited_by    CHAR(26) REFERENCES users(id),
+  created_at   TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+-------------------------------------------------
+-- notifications
+-------------------------------------------------
+CREATE TABLE notifications (
+  id           CHAR(26) PRIMARY KEY,    -- ULID
+  user_id      CHAR(26) NOT NULL REFERENCES users(id),
+  kind         TEXT NOT NULL,           -- ex.: 'mention'
+  entity_name  TEXT,
+  entity_id    CHAR(26),
+  message      TEXT NOT NULL,
+  read_at      TIMESTAMPTZ,
+  created_at   TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+CREATE INDEX idx_notifications_user ON notifications (user_id, created_at DESC);
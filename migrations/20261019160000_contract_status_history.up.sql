@@ -0,0 +1,18 @@
+-------------------------------------------------
+-- contract_status_history
+-------------------------------------------------
+CREATE TABLE contract_status_history (
+  id            CHAR(26) PRIMARY KEY,             -- ULID
+  contract_id   CHAR(26) NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+  from_status   TEXT,                             -- NULL na criacao
+  to_status     TEXT NOT NULL,
+  reason        TEXT NOT NULL DEFAULT '',
+  changed_by    CHAR(26) REFERENCES users(id),
+  created_at    TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+CREATE INDEX idx_contract_status_history_contract ON contract_status_history (contract_id, created_at);
+
+-- status atual dos contratos existentes como ponto de partida do historico
+INSERT INTO contract_status_history (id, contract_id, from_status, to_status, created_at)
+SELECT id, id, NULL, status, created_at FROM contracts;
@@ -0,0 +1,4 @@
+ALTER TABLE contracts
+  DROP COLUMN IF EXISTS renewed_from_id,
+  DROP COLUMN IF EXISTS current_version;
+DROP TABLE IF EXISTS contract_versions;
@@ -0,0 +1,24 @@
+-------------------------------------------------
+-- contract_versions (termos acordados de cada aditivo)
+-------------------------------------------------
+CREATE TABLE contract_versions (
+  id             CHAR(26) PRIMARY KEY,            -- ULID
+  contract_id    CHAR(26) NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+  version        INT NOT NULL,
+  value_total    NUMERIC(12,2) NOT NULL,
+  start_date     DATE NOT NULL,
+  end_date       DATE,
+  reason         TEXT NOT NULL DEFAULT '',
+  effective_date DATE NOT NULL,
+  created_by     CHAR(26) REFERENCES users(id),
+  created_at     TIMESTAMPTZ DEFAULT now() NOT NULL,
+  UNIQUE (contract_id, version)
+);
+
+ALTER TABLE contracts
+  ADD COLUMN current_version INT NOT NULL DEFAULT 1,
+  ADD COLUMN renewed_from_id CHAR(26) REFERENCES contracts(id);
+
+-- versao 1 com os termos atuais dos contratos existentes
+INSERT INTO contract_versions (id, contract_id, version, value_total, start_date, end_date, reason, effective_date, created_at)
+SELECT id, id, 1, value_total, start_date, end_date, '', start_date, created_at FROM contracts;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_contracts_active_end_date;
+DROP TABLE IF EXISTS contract_expiry_notices;
+ALTER TABLE services DROP COLUMN IF EXISTS auto_close_grace_days;
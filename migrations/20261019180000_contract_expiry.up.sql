@@ -0,0 +1,19 @@
+-------------------------------------------------
+-- services.auto_close_grace_days (dias apos end_date ate encerrar o contrato)
+-------------------------------------------------
+ALTER TABLE services
+  ADD COLUMN auto_close_grace_days INT NOT NULL DEFAULT 0 CHECK (auto_close_grace_days >= 0);
+
+-------------------------------------------------
+-- contract_expiry_notices (avisos de vencimento ja enviados)
+-------------------------------------------------
+CREATE TABLE contract_expiry_notices (
+  contract_id   CHAR(26) NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+  days_before   INT NOT NULL,
+  end_date      DATE NOT NULL,                    -- aditivos que mudam end_date geram novos avisos
+  sent_at       TIMESTAMPTZ DEFAULT now() NOT NULL,
+  PRIMARY KEY (contract_id, days_before, end_date)
+);
+
+CREATE INDEX idx_contracts_active_end_date ON contracts (end_date)
+  WHERE status = 'active' AND deleted_at IS NULL;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_contracts_anniversary;
+DROP TABLE IF EXISTS contract_readjustments;
+ALTER TABLE contracts
+  DROP CONSTRAINT IF EXISTS contracts_readjustment_clause_chk,
+  DROP COLUMN IF EXISTS anniversary_month,
+  DROP COLUMN IF EXISTS readjustment_index;
+DROP TABLE IF EXISTS price_index_values;
@@ -0,0 +1,43 @@
+-------------------------------------------------
+-- price_index_values (variacao mensal dos indices, em %)
+-------------------------------------------------
+CREATE TABLE price_index_values (
+  index_code  VARCHAR(10) NOT NULL,               -- ipca | igpm
+  month       DATE NOT NULL,                      -- primeiro dia do mes
+  rate        NUMERIC(8,4) NOT NULL,
+  created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+  updated_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+  PRIMARY KEY (index_code, month),
+  CHECK (month = date_trunc('month', month)::date)
+);
+
+-------------------------------------------------
+-- clausula de reajuste anual dos contratos
+-------------------------------------------------
+ALTER TABLE contracts
+  ADD COLUMN readjustment_index VARCHAR(10),
+  ADD COLUMN anniversary_month  SMALLINT CHECK (anniversary_month BETWEEN 1 AND 12),
+  ADD CONSTRAINT contracts_readjustment_clause_chk
+    CHECK ((readjustment_index IS NULL) = (anniversary_month IS NULL));
+
+-------------------------------------------------
+-- contract_readjustments (reajustes aplicados)
+-------------------------------------------------
+CREATE TABLE contract_readjustments (
+  id              CHAR(26) PRIMARY KEY,           -- ULID
+  contract_id     CHAR(26) NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+  index_code      VARCHAR(10) NOT NULL,
+  reference_month DATE NOT NULL,
+  period_start    DATE NOT NULL,
+  period_end      DATE NOT NULL,
+  factor          NUMERIC(12,6) NOT NULL,
+  previous_value  NUMERIC(12,2) NOT NULL,
+  new_value       NUMERIC(12,2) NOT NULL,
+  version         INT NOT NULL,                   -- versao do contrato gerada
+  created_by      CHAR(26) REFERENCES users(id),
+  created_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+  UNIQUE (contract_id, reference_month)
+);
+
+CREATE INDEX idx_contracts_anniversary ON contracts (anniversary_month)
+  WHERE readjustment_index IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,15 @@
+-------------------------------------------------
+-- contract_templates (modelo de contrato por servico)
+-------------------------------------------------
+CREATE TABLE contract_templates (
+  id          CHAR(26) PRIMARY KEY,               -- ULID
+  service_id  CHAR(26) NOT NULL REFERENCES services(id),
+  name        TEXT NOT NULL,
+  body        TEXT NOT NULL,                      -- sintaxe text/template
+  created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+  updated_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+  deleted_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_contract_templates_service ON contract_templates (service_id)
+  WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS signature_signers;
+DROP TABLE IF EXISTS signature_requests;
+UPDATE contracts SET status = 'cancelled' WHERE status = 'pending_signature';
+ALTER TABLE contracts DROP CONSTRAINT IF EXISTS contracts_status_check;
+ALTER TABLE contracts ADD CONSTRAINT contracts_status_check CHECK (status IN (
+  'active', 'suspended', 'closed', 'cancelled'
+));
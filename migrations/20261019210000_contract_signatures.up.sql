@@ -0,0 +1,47 @@
+-------------------------------------------------
+-- contracts.status: etapa de assinatura
+-------------------------------------------------
+ALTER TABLE contracts DROP CONSTRAINT IF EXISTS contracts_status_check;
+ALTER TABLE contracts ADD CONSTRAINT contracts_status_check CHECK (status IN (
+  'pending_signature', 'active', 'suspended', 'closed', 'cancelled'
+));
+
+-------------------------------------------------
+-- signature_requests (documento enviado para assinatura)
+-------------------------------------------------
+CREATE TABLE signature_requests (
+  id             CHAR(26) PRIMARY KEY,            -- ULID
+  contract_id    CHAR(26) NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+  provider       TEXT NOT NULL,                   -- local | provedor externo
+  provider_ref   TEXT,
+  status         TEXT NOT NULL DEFAULT 'pending' CHECK (status IN (
+                   'pending', 'completed', 'declined', 'cancelled'
+                 )),
+  document_hash  CHAR(64) NOT NULL,               -- SHA-256 do PDF
+  document       BYTEA NOT NULL,
+  created_by     CHAR(26) REFERENCES users(id),
+  created_at     TIMESTAMPTZ DEFAULT now() NOT NULL,
+  completed_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_signature_requests_contract ON signature_requests (contract_id, created_at);
+
+-------------------------------------------------
+-- signature_signers (signatarios e evidencias)
+-------------------------------------------------
+CREATE TABLE signature_signers (
+  id             CHAR(26) PRIMARY KEY,            -- ULID
+  request_id     CHAR(26) NOT NULL REFERENCES signature_requests(id) ON DELETE CASCADE,
+  name           TEXT NOT NULL,
+  email          TEXT NOT NULL,
+  role           TEXT NOT NULL CHECK (role IN ('customer', 'internal')),
+  user_id        CHAR(26) REFERENCES users(id),
+  token_hash     CHAR(64) NOT NULL UNIQUE,        -- SHA-256 do token do link
+  status         TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'signed', 'declined')),
+  signed_at      TIMESTAMPTZ,
+  ip             TEXT,
+  user_agent     TEXT,
+  document_hash  CHAR(64),
+  decline_reason TEXT,
+  created_at     TIMESTAMPTZ DEFAULT now() NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_contract_items_service;
+DROP TABLE IF EXISTS contract_items;
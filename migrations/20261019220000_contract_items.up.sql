@@ -0,0 +1,24 @@
+-------------------------------------------------
+-- contract_items (linhas de servico do contrato)
+-------------------------------------------------
+CREATE TABLE contract_items (
+  id           CHAR(26) PRIMARY KEY,              -- ULID
+  contract_id  CHAR(26) NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+  service_id   CHAR(26) NOT NULL REFERENCES services(id),
+  quantity     NUMERIC(12,3) NOT NULL CHECK (quantity > 0),
+  unit_price   NUMERIC(14,4) NOT NULL CHECK (unit_price >= 0),
+  discount     NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+  line_total   NUMERIC(12,2) NOT NULL CHECK (line_total >= 0),
+  position     INT NOT NULL,
+  created_at   TIMESTAMPTZ DEFAULT now() NOT NULL,
+  updated_at   TIMESTAMPTZ DEFAULT now() NOT NULL,
+  UNIQUE (contract_id, position)
+);
+
+CREATE INDEX idx_contract_items_service ON contract_items (service_id);
+
+-- contratos existentes viram uma linha unica com o servico e o valor atuais
+INSERT INTO contract_items (id, contract_id, service_id, quantity, unit_price, line_total, position)
+SELECT c.id, c.id, c.service_id, 1, c.value_total, c.value_total, 1
+  FROM contracts c
+ WHERE c.service_id IS NOT NULL;
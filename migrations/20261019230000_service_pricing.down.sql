@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS service_price_tiers;
+DROP TABLE IF EXISTS service_prices;
+ALTER TABLE services DROP COLUMN IF EXISTS billing_type;
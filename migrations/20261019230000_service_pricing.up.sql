@@ -0,0 +1,35 @@
+-------------------------------------------------
+-- services.billing_type (cobranca unica ou recorrente)
+-------------------------------------------------
+ALTER TABLE services
+  ADD COLUMN billing_type VARCHAR(10) NOT NULL DEFAULT 'one_off'
+    CHECK (billing_type IN ('one_off', 'recurring'));
+
+-------------------------------------------------
+-- service_prices (historico de precos por vigencia)
+-------------------------------------------------
+CREATE TABLE service_prices (
+  id              CHAR(26) PRIMARY KEY,           -- ULID
+  service_id      CHAR(26) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+  effective_from  DATE NOT NULL,
+  base_price      NUMERIC(12,2) NOT NULL CHECK (base_price >= 0),
+  created_by      CHAR(26) REFERENCES users(id),
+  created_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+  UNIQUE (service_id, effective_from)
+);
+
+-------------------------------------------------
+-- service_price_tiers (preco por volume)
+-------------------------------------------------
+CREATE TABLE service_price_tiers (
+  price_id      CHAR(26) NOT NULL REFERENCES service_prices(id) ON DELETE CASCADE,
+  min_quantity  NUMERIC(12,3) NOT NULL CHECK (min_quantity > 0),
+  unit_price    NUMERIC(12,2) NOT NULL CHECK (unit_price >= 0),
+  PRIMARY KEY (price_id, min_quantity)
+);
+
+-- o preco atual de cada servico vira a primeira versao, vigente desde
+-- sempre para cobrir contratos iniciados antes do cadastro do servico
+INSERT INTO service_prices (id, service_id, effective_from, base_price)
+SELECT s.id, s.id, DATE '1900-01-01', s.base_price
+  FROM services s;
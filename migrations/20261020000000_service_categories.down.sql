@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_services_category;
+ALTER TABLE services DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS service_categories;
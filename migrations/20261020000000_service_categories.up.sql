@@ -0,0 +1,19 @@
+-------------------------------------------------
+-- service_categories
+-------------------------------------------------
+CREATE TABLE service_categories (
+  id           CHAR(26) PRIMARY KEY,          -- ULID
+  name         TEXT NOT NULL,
+  description  TEXT NOT NULL DEFAULT '',
+  created_at   TIMESTAMPTZ DEFAULT now() NOT NULL,
+  updated_at   TIMESTAMPTZ DEFAULT now() NOT NULL,
+  deleted_at   TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX uq_service_categories_name ON service_categories (lower(name))
+  WHERE deleted_at IS NULL;
+
+ALTER TABLE services
+  ADD COLUMN category_id CHAR(26) REFERENCES service_categories(id);
+
+CREATE INDEX idx_services_category ON services (category_id) WHERE deleted_at IS NULL;
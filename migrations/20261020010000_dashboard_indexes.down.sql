@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_commissions_pending;
+DROP INDEX IF EXISTS idx_leads_created_at;
+DROP INDEX IF EXISTS idx_contracts_promoter_start;
+DROP INDEX IF EXISTS idx_receivables_paid_at;
+DROP INDEX IF EXISTS idx_receivables_due_date;
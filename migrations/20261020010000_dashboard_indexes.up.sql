@@ -0,0 +1,17 @@
+-------------------------------------------------
+-- indices para os agregados do dashboard
+-------------------------------------------------
+CREATE INDEX idx_receivables_due_date ON accounts_receivable (due_date)
+  WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_receivables_paid_at ON accounts_receivable (paid_at)
+  WHERE status = 'paid' AND deleted_at IS NULL;
+
+CREATE INDEX idx_contracts_promoter_start ON contracts (promoter_id, start_date)
+  WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_leads_created_at ON leads (created_at)
+  WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_commissions_pending ON commissions (created_at)
+  WHERE approved = false AND deleted_at IS NULL;
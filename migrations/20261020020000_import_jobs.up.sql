@@ -0,0 +1,38 @@
+-------------------------------------------------
+-- import_jobs (importacoes em lote de CSV)
+-------------------------------------------------
+CREATE TABLE import_jobs (
+  id           CHAR(26) PRIMARY KEY,
+  entity       TEXT NOT NULL,                     -- customers | services | promoters
+  file_name    TEXT NOT NULL DEFAULT '',
+  status       TEXT NOT NULL DEFAULT 'pending'
+               CHECK (status IN ('pending','running','done','failed')),
+  columns      TEXT[] NOT NULL,                   -- cabecalho do arquivo enviado
+  payload      BYTEA NOT NULL,                    -- CSV original, relido pelo job
+  total        INT NOT NULL DEFAULT 0,
+  processed    INT NOT NULL DEFAULT 0,            -- linhas ja tratadas; retomada continua daqui
+  created      INT NOT NULL DEFAULT 0,
+  skipped      INT NOT NULL DEFAULT 0,            -- ja cadastrados (idempotencia pela chave)
+  failed       INT NOT NULL DEFAULT 0,
+  error        TEXT NOT NULL DEFAULT '',
+  created_by   CHAR(26) REFERENCES users(id),
+  created_at   TIMESTAMPTZ DEFAULT now() NOT NULL,
+  updated_at   TIMESTAMPTZ DEFAULT now() NOT NULL,
+  started_at   TIMESTAMPTZ,
+  finished_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_import_jobs_queue ON import_jobs (created_at)
+  WHERE status IN ('pending','running');
+
+-------------------------------------------------
+-- import_job_errors (linhas rejeitadas, para o arquivo de erros)
+-------------------------------------------------
+CREATE TABLE import_job_errors (
+  job_id   CHAR(26) NOT NULL REFERENCES import_jobs(id) ON DELETE CASCADE,
+  line     INT NOT NULL,
+  key      TEXT NOT NULL DEFAULT '',
+  message  TEXT NOT NULL,
+  "values" JSONB NOT NULL DEFAULT '{}',
+  PRIMARY KEY (job_id, line)
+);
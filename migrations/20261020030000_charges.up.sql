@@ -0,0 +1,32 @@
+-------------------------------------------------
+-- charges (cobrancas boleto/PIX emitidas no gateway de pagamento)
+-------------------------------------------------
+CREATE TABLE charges (
+  id              CHAR(26) PRIMARY KEY,            -- ULID
+  receivable_id   CHAR(26) NOT NULL REFERENCES accounts_receivable(id),
+  provider        TEXT NOT NULL,                   -- fake | gateway externo
+  provider_ref    TEXT NOT NULL,                   -- id da cobranca no gateway
+  method          TEXT NOT NULL CHECK (method IN ('boleto', 'pix')),
+  status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN (
+                    'pending', 'paid', 'cancelled', 'expired'
+                  )),
+  amount          NUMERIC(12,2) NOT NULL,
+  due_date        DATE NOT NULL,
+  digitable_line  TEXT,                            -- linha digitavel do boleto
+  barcode         TEXT,
+  pix_payload     TEXT,                            -- PIX copia e cola (conteudo do QR code)
+  payment_url     TEXT,
+  expires_at      TIMESTAMPTZ,
+  created_by      CHAR(26) REFERENCES users(id),
+  created_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+  updated_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+  paid_at         TIMESTAMPTZ,
+  cancelled_at    TIMESTAMPTZ,
+  UNIQUE (provider, provider_ref)
+);
+
+CREATE INDEX idx_charges_receivable ON charges (receivable_id, created_at);
+
+-- no maximo uma cobranca em aberto por parcela
+CREATE UNIQUE INDEX uq_charges_pending ON charges (receivable_id)
+  WHERE status = 'pending';
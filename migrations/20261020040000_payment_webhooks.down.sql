@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS webhook_events;
+UPDATE charges SET status = 'paid' WHERE status = 'refunded';
+ALTER TABLE charges DROP COLUMN IF EXISTS refunded_at;
+ALTER TABLE charges DROP CONSTRAINT IF EXISTS charges_status_check;
+ALTER TABLE charges ADD CONSTRAINT charges_status_check CHECK (status IN (
+  'pending', 'paid', 'cancelled', 'expired'
+));
+UPDATE accounts_receivable SET status = 'cancelled' WHERE status = 'refunded';
+ALTER TABLE accounts_receivable DROP CONSTRAINT IF EXISTS accounts_receivable_status_check;
+ALTER TABLE accounts_receivable ADD CONSTRAINT accounts_receivable_status_check CHECK (status IN (
+  'open', 'paid', 'overdue', 'cancelled'
+));
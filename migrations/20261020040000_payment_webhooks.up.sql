@@ -0,0 +1,39 @@
+-------------------------------------------------
+-- accounts_receivable.status: estorno pelo gateway
+-------------------------------------------------
+ALTER TABLE accounts_receivable DROP CONSTRAINT IF EXISTS accounts_receivable_status_check;
+ALTER TABLE accounts_receivable ADD CONSTRAINT accounts_receivable_status_check CHECK (status IN (
+  'open', 'paid', 'overdue', 'cancelled', 'refunded'
+));
+
+-------------------------------------------------
+-- charges.status: estorno pelo gateway
+-------------------------------------------------
+ALTER TABLE charges DROP CONSTRAINT IF EXISTS charges_status_check;
+ALTER TABLE charges ADD CONSTRAINT charges_status_check CHECK (status IN (
+  'pending', 'paid', 'cancelled', 'expired', 'refunded'
+));
+ALTER TABLE charges ADD COLUMN refunded_at TIMESTAMPTZ;
+
+-------------------------------------------------
+-- webhook_events (notificacoes recebidas do gateway de pagamento)
+-------------------------------------------------
+CREATE TABLE webhook_events (
+  id             CHAR(26) PRIMARY KEY,            -- ULID
+  provider       TEXT NOT NULL,
+  event_id       TEXT NOT NULL,                   -- id do evento no gateway
+  event_type     TEXT NOT NULL,                   -- charge.paid | charge.cancelled | ...
+  provider_ref   TEXT NOT NULL,                   -- cobranca afetada
+  occurred_at    TIMESTAMPTZ NOT NULL,
+  payload        BYTEA NOT NULL,                  -- corpo original, como recebido
+  status         TEXT NOT NULL DEFAULT 'received' CHECK (status IN (
+                   'received', 'processed', 'ignored', 'failed'
+                 )),
+  error          TEXT,
+  attempts       INT NOT NULL DEFAULT 1,          -- entregas recebidas do mesmo evento
+  received_at    TIMESTAMPTZ DEFAULT now() NOT NULL,
+  processed_at   TIMESTAMPTZ,
+  UNIQUE (provider, event_id)
+);
+
+CREATE INDEX idx_webhook_events_ref ON webhook_events (provider, provider_ref);
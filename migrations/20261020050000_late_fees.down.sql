@@ -0,0 +1,11 @@
+ALTER TABLE accounts_receivable
+  DROP COLUMN IF EXISTS paid_amount,
+  DROP COLUMN IF EXISTS paid_principal,
+  DROP COLUMN IF EXISTS paid_fine,
+  DROP COLUMN IF EXISTS paid_interest;
+ALTER TABLE contracts
+  DROP COLUMN IF EXISTS late_fine_percent,
+  DROP COLUMN IF EXISTS monthly_interest_percent;
+ALTER TABLE services
+  DROP COLUMN IF EXISTS late_fine_percent,
+  DROP COLUMN IF EXISTS monthly_interest_percent;
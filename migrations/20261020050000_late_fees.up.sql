@@ -0,0 +1,22 @@
+-------------------------------------------------
+-- services: encargos por atraso padrao dos contratos do servico
+-------------------------------------------------
+ALTER TABLE services
+  ADD COLUMN late_fine_percent        NUMERIC(5,2) NOT NULL DEFAULT 2.00 CHECK (late_fine_percent BETWEEN 0 AND 100),
+  ADD COLUMN monthly_interest_percent NUMERIC(5,2) NOT NULL DEFAULT 1.00 CHECK (monthly_interest_percent BETWEEN 0 AND 100);
+
+-------------------------------------------------
+-- contracts: encargos por atraso negociados (NULL usa o padrao do servico)
+-------------------------------------------------
+ALTER TABLE contracts
+  ADD COLUMN late_fine_percent        NUMERIC(5,2) CHECK (late_fine_percent BETWEEN 0 AND 100),
+  ADD COLUMN monthly_interest_percent NUMERIC(5,2) CHECK (monthly_interest_percent BETWEEN 0 AND 100);
+
+-------------------------------------------------
+-- accounts_receivable: valor recebido na baixa (principal + multa + juros)
+-------------------------------------------------
+ALTER TABLE accounts_receivable
+  ADD COLUMN paid_amount    NUMERIC(12,2),
+  ADD COLUMN paid_principal NUMERIC(12,2),
+  ADD COLUMN paid_fine      NUMERIC(12,2),
+  ADD COLUMN paid_interest  NUMERIC(12,2);
@@ -0,0 +1,2 @@
+ALTER TABLE contracts
+  DROP COLUMN IF EXISTS installments;
@@ -0,0 +1,6 @@
+-------------------------------------------------
+-- contracts: parcelas a gerar na ativacao (na criacao ou na conclusao da
+-- assinatura)
+-------------------------------------------------
+ALTER TABLE contracts
+  ADD COLUMN installments INT NOT NULL DEFAULT 0 CHECK (installments BETWEEN 0 AND 120);
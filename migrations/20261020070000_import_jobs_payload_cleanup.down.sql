@@ -0,0 +1,2 @@
+-- os arquivos descartados nao podem ser restaurados
+SELECT 1;
@@ -0,0 +1,6 @@
+-------------------------------------------------
+-- import_jobs.payload: o CSV enviado so eh relido enquanto o job esta na
+-- fila; jobs encerrados descartam o arquivo (dados pessoais)
+-------------------------------------------------
+UPDATE import_jobs SET payload = ''::bytea
+ WHERE status IN ('done', 'failed') AND length(payload) > 0;
@@ -0,0 +1,6 @@
+-------------------------------------------------
+-- users.promoter_id: cadastro de promotor do usuario com role promoter;
+-- restringe a edicao de leads aos do proprio promotor
+-------------------------------------------------
+ALTER TABLE users
+  ADD COLUMN promoter_id CHAR(26) REFERENCES promoters(id);